@@ -2,7 +2,11 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"log/slog"
 	"net/http"
@@ -10,13 +14,20 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	gormLogger "gorm.io/gorm/logger"
 
+	"hitalent-test/internal/blob"
 	"hitalent-test/internal/config"
+	"hitalent-test/internal/contentfilter"
+	"hitalent-test/internal/domain"
 	"hitalent-test/internal/handler"
+	"hitalent-test/internal/mailer"
+	"hitalent-test/internal/middleware"
+	"hitalent-test/internal/ratelimit"
 	"hitalent-test/internal/repository"
 	"hitalent-test/internal/server"
 	"hitalent-test/internal/service"
@@ -24,12 +35,25 @@ import (
 )
 
 func main() {
-	cfg, err := config.Load()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "config":
+			os.Exit(runConfigCommand(os.Args[2:]))
+		case "user":
+			os.Exit(runUserCommand(os.Args[2:]))
+		case "data":
+			os.Exit(runDataCommand(os.Args[2:]))
+		}
+	}
+
+	cfg, err := config.Load(os.Args[1:])
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
-	appLogger := logger.New(cfg.Logger.Level, cfg.Logger.Format)
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(logger.ParseLevel(cfg.Logger.Level))
+	appLogger := logger.New(logLevel, cfg.Logger.Format)
 
 	db, err := setupDatabase(cfg.Database, appLogger)
 	if err != nil {
@@ -43,23 +67,142 @@ func main() {
 	userRepo := repository.NewUserRepository(db)
 	questionRepo := repository.NewQuestionRepository(db)
 	answerRepo := repository.NewAnswerRepository(db)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(db)
+	userTokenRepo := repository.NewUserTokenRepository(db)
+	recoveryCodeRepo := repository.NewRecoveryCodeRepository(db)
+	patRepo := repository.NewPersonalAccessTokenRepository(db)
+	identityRepo := repository.NewUserIdentityRepository(db)
+	exportRepo := repository.NewDataExportRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	flagRepo := repository.NewFlagRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	watchRepo := repository.NewWatchRepository(db)
+	mentionRepo := repository.NewMentionRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	transferRepo := repository.NewTransferRepository(db)
+
+	appMailer, err := mailer.New(cfg.Mail, appLogger)
+	if err != nil {
+		appLogger.Error("Failed to set up mailer", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	blobStore, err := blob.New(cfg.Storage)
+	if err != nil {
+		appLogger.Error("Failed to set up attachment storage", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
 
+	auditService := service.NewAuditService(auditRepo, appLogger)
 	tokenService := service.NewTokenService(&cfg.JWT)
 	refreshTokenStore := service.NewRefreshTokenStore()
 
-	authService := service.NewAuthService(userRepo, tokenService, refreshTokenStore)
-	questionService := service.NewQuestionService(questionRepo)
-	answerService := service.NewAnswerService(answerRepo, questionRepo)
+	loginThrottle := service.NewLoginThrottle(loginAttemptRepo, cfg.Login, appLogger)
+
+	mfaService := service.NewMFAService(userRepo, recoveryCodeRepo, &cfg.MFA)
+	authService := service.NewAuthService(userRepo, tokenService, refreshTokenStore, loginThrottle, mfaService, auditService, &cfg.Validation)
+	authService.SetRegistrationEnabled(cfg.Features.Registration)
+	notificationService := service.NewNotificationService(notificationRepo, appLogger)
+	watchService := service.NewWatchService(watchRepo, questionRepo, userRepo, appMailer, &cfg.Mail, appLogger)
+	mentionService := service.NewMentionService(mentionRepo, userRepo, notificationService, &cfg.Validation, appLogger)
+	digestService := service.NewDigestService(watchRepo, userRepo, appMailer, &cfg.Mail, cfg.Digest, appLogger)
+	moderationService := service.NewModerationService(
+		flagRepo, questionRepo, answerRepo, userRepo, appMailer, auditService, notificationService, &cfg.Moderation, appLogger)
+	contentPipeline := contentfilter.New(cfg.Filter, repository.NewPostHistoryRepository(db))
+	contentFilter := service.NewContentFilterService(contentPipeline, moderationService, appLogger)
+	postDeps := service.PostDeps{
+		Filter:        contentFilter,
+		Audit:         auditService,
+		Notifications: notificationService,
+		Watches:       watchService,
+		Mentions:      mentionService,
+	}
+	questionService := service.NewQuestionService(questionRepo, userRepo, postDeps, &cfg.Validation)
+	questionService.SetSimilarity(cfg.Similarity)
+	answerService := service.NewAnswerService(answerRepo, questionRepo, userRepo, postDeps, &cfg.Validation)
+	answerService.SetRequireVerifiedEmail(cfg.Features.RequireEmailVerification)
+	verificationService := service.NewEmailVerificationService(
+		userRepo, userTokenRepo, tokenService, appMailer, &cfg.Mail, &cfg.Tokens)
+	patService := service.NewPersonalAccessTokenService(patRepo, userRepo, auditService, appLogger)
+	oidcService := service.NewOIDCService(&cfg.OIDC, &http.Client{Timeout: 10 * time.Second},
+		userRepo, identityRepo, tokenService, authService, auditService)
+	userService := service.NewUserService(userRepo, &cfg.Validation)
+	accountService := service.NewAccountService(userRepo, questionRepo, answerRepo, identityRepo, patRepo, exportRepo,
+		refreshTokenStore, mfaService, auditService, &cfg.Account, appLogger)
+	attachmentService := service.NewAttachmentService(attachmentRepo, questionRepo, answerRepo, blobStore, cfg.Attachments, appLogger)
+	transferService := service.NewTransferService(transferRepo, userRepo, auditService)
+	feedService := service.NewFeedService(questionRepo, userRepo, &cfg.Mail)
+	passwordService := service.NewPasswordService(
+		userRepo, userTokenRepo, refreshTokenStore, appMailer, &cfg.Mail, &cfg.Tokens, auditService, &cfg.Validation)
 
 	questionHandler := handler.NewQuestionHandler(questionService, appLogger)
 	answerHandler := handler.NewAnswerHandler(answerService, appLogger)
-	authHandler := handler.NewAuthHandler(authService, appLogger)
+	authHandler := handler.NewAuthHandler(authService, verificationService, appLogger)
+	passwordHandler := handler.NewPasswordHandler(passwordService, appLogger)
+	mfaHandler := handler.NewMFAHandler(mfaService, appLogger)
+	tokenHandler := handler.NewTokenHandler(patService, appLogger)
+	oidcHandler := handler.NewOIDCHandler(oidcService, appLogger)
+	userHandler := handler.NewUserHandler(userService, appLogger)
+	accountHandler := handler.NewAccountHandler(accountService, appLogger)
+	moderationHandler := handler.NewModerationHandler(moderationService, appLogger)
+	notificationHandler := handler.NewNotificationHandler(notificationService, appLogger)
+	watchHandler := handler.NewWatchHandler(watchService, appLogger)
+	attachmentHandler := handler.NewAttachmentHandler(attachmentService, appLogger)
+	transferHandler := handler.NewTransferHandler(transferService, appLogger)
+	feedHandler := handler.NewFeedHandler(feedService, appLogger)
+
+	cors := middleware.NewCORS(cfg.CORS.AllowedOrigins)
+	clientIP := middleware.NewClientIP(cfg.Server.TrustedProxies)
+
+	var rateStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimit.Store == "postgres" {
+		rateStore = ratelimit.NewPostgresStore(db)
+	}
+	limiter := ratelimit.NewLimiter(rateStore, cfg.RateLimit)
+
+	reloader := config.NewReloader(cfg, os.Args[1:], appLogger)
+	reloader.OnReload(func(c *config.Config) {
+		logLevel.Set(logger.ParseLevel(c.Logger.Level))
+		cors.SetAllowedOrigins(c.CORS.AllowedOrigins)
+		authService.SetRegistrationEnabled(c.Features.Registration)
+		answerService.SetRequireVerifiedEmail(c.Features.RequireEmailVerification)
+		tokenService.SetVerificationSecrets(c.JWT.VerificationSecrets)
+		clientIP.SetTrustedProxies(c.Server.TrustedProxies)
+		limiter.SetConfig(c.RateLimit)
+		loginThrottle.SetConfig(c.Login)
+		moderationService.SetHideThreshold(c.Moderation.HideThreshold)
+		contentPipeline.SetConfig(c.Filter)
+		questionService.SetSimilarity(c.Similarity)
+		digestService.SetConfig(c.Digest)
+		attachmentService.SetConfig(c.Attachments)
+	})
+	adminHandler := handler.NewAdminHandler(reloader, appLogger)
+	auditHandler := handler.NewAuditHandler(auditService, appLogger)
 
 	router := server.NewRouter(
 		questionHandler,
 		answerHandler,
 		authHandler,
+		passwordHandler,
+		mfaHandler,
+		tokenHandler,
+		oidcHandler,
+		userHandler,
+		accountHandler,
+		moderationHandler,
+		notificationHandler,
+		watchHandler,
+		attachmentHandler,
+		transferHandler,
+		feedHandler,
+		adminHandler,
+		auditHandler,
 		tokenService,
+		patService,
+		limiter,
+		clientIP,
+		cors,
+		&cfg.Server,
 		appLogger,
 	)
 
@@ -68,15 +211,44 @@ func main() {
 		defer ticker.Stop()
 		for range ticker.C {
 			refreshTokenStore.CleanupExpired()
+			if err := limiter.Cleanup(time.Now().Add(-time.Hour)); err != nil {
+				appLogger.Warn("Failed to clean up rate limit buckets", slog.String("error", err.Error()))
+			}
+			if err := loginThrottle.Cleanup(); err != nil {
+				appLogger.Warn("Failed to clean up login failures", slog.String("error", err.Error()))
+			}
+			if err := verificationService.Cleanup(); err != nil {
+				appLogger.Warn("Failed to clean up user tokens", slog.String("error", err.Error()))
+			}
+			if err := accountService.CleanupExports(); err != nil {
+				appLogger.Warn("Failed to clean up data exports", slog.String("error", err.Error()))
+			}
+			if _, err := accountService.PurgeDue(); err != nil {
+				appLogger.Warn("Failed to erase accounts due for deletion", slog.String("error", err.Error()))
+			}
+			if _, err := attachmentService.CleanupBlobs(context.Background()); err != nil {
+				appLogger.Warn("Failed to delete orphaned attachment blobs", slog.String("error", err.Error()))
+			}
+		}
+	}()
+
+	go func() {
+		ticker := time.NewTicker(cfg.Digest.Interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := digestService.SendDue(); err != nil {
+				appLogger.Warn("Failed to send digests", slog.String("error", err.Error()))
+			}
 		}
 	}()
 
 	srv := &http.Server{
-		Addr:         fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
-		Handler:      router,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Addr:              fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
+		Handler:           router,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
 	}
 
 	go func() {
@@ -90,13 +262,24 @@ func main() {
 		}
 	}()
 
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			appLogger.Info("Received SIGHUP, reloading configuration")
+			if _, err := reloader.Reload(); err != nil {
+				appLogger.Error("Failed to reload configuration", slog.String("error", err.Error()))
+			}
+		}
+	}()
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	appLogger.Info("Shutting down server...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
@@ -121,11 +304,217 @@ func setupDatabase(cfg config.DatabaseConfig, logger *slog.Logger) (*gorm.DB, er
 		return nil, fmt.Errorf("failed to get database instance: %w", err)
 	}
 
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
-	sqlDB.SetConnMaxLifetime(time.Hour)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 
 	logger.Info("Database connection established")
 
 	return db, nil
 }
+
+func runConfigCommand(args []string) int {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprintln(os.Stderr, "usage: app config print [-config file] [-<setting> value ...]")
+		return 2
+	}
+
+	cfg, err := config.Load(args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if err := cfg.Print(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+const userUsage = "usage: app user set-role [-config file] [-<setting> value ...] <email> <user|moderator|admin>"
+
+func runUserCommand(args []string) int {
+	if len(args) == 0 || args[0] != "set-role" {
+		fmt.Fprintln(os.Stderr, userUsage)
+		return 2
+	}
+
+	flags := flag.NewFlagSet("user set-role", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	cfg, err := config.LoadFlags(flags, args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if flags.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, userUsage)
+		return 2
+	}
+
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(logger.ParseLevel(cfg.Logger.Level))
+	appLogger := logger.New(logLevel, cfg.Logger.Format)
+
+	db, err := setupDatabase(cfg.Database, appLogger)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	userRepo := repository.NewUserRepository(db)
+	authService := service.NewAuthService(
+		userRepo,
+		service.NewTokenService(&cfg.JWT),
+		service.NewRefreshTokenStore(),
+		service.NewLoginThrottle(repository.NewLoginAttemptRepository(db), cfg.Login, appLogger),
+		service.NewMFAService(userRepo, repository.NewRecoveryCodeRepository(db), &cfg.MFA),
+		service.NewAuditService(repository.NewAuditRepository(db), appLogger),
+		&cfg.Validation,
+	)
+
+	user, err := authService.SetRole(flags.Arg(0), flags.Arg(1), domain.SystemMeta("cli"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Printf("%s is now %s\n", user.Email, user.Role)
+	return 0
+}
+
+const dataUsage = `usage: app data export [-format jsonl|csv] [-o file]
+       app data import [-format jsonl|csv] [-source name] [-dry-run] [file]
+       app data import-stackexchange [-source name] <dump directory>
+
+Each command also takes [-config file] [-<setting> value ...].`
+
+// runDataCommand exports or imports all questions with their answers. The
+// export goes to stdout and the import is read from stdin unless a file is
+// given; logs go to stderr so as not to mix with the data. StackExchange
+// imports stop at the end of a batch on SIGINT or SIGTERM and resume when
+// run again.
+func runDataCommand(args []string) int {
+	if len(args) == 0 || (args[0] != "export" && args[0] != "import" && args[0] != "import-stackexchange") {
+		fmt.Fprintln(os.Stderr, dataUsage)
+		return 2
+	}
+
+	flags := flag.NewFlagSet("data "+args[0], flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.Usage = func() { fmt.Fprintln(os.Stderr, dataUsage) }
+	format := flags.String("format", domain.TransferJSONL, "file format")
+	output := flags.String("o", "", "export to this file instead of stdout")
+	source := flags.String("source", "", "name of the imported data")
+	dryRun := flags.Bool("dry-run", false, "validate the import without saving")
+	cfg, err := config.LoadFlags(flags, args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if flags.NArg() > 1 || args[0] == "import-stackexchange" && flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(logger.ParseLevel(cfg.Logger.Level))
+	appLogger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel}))
+
+	db, err := setupDatabase(cfg.Database, appLogger)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	transferService := service.NewTransferService(
+		repository.NewTransferRepository(db),
+		repository.NewUserRepository(db),
+		service.NewAuditService(repository.NewAuditRepository(db), appLogger),
+	)
+
+	if args[0] == "import-stackexchange" {
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		importer := service.NewStackExchangeImportService(
+			repository.NewTransferRepository(db),
+			repository.NewUserRepository(db),
+			service.NewAuditService(repository.NewAuditRepository(db), appLogger),
+			appLogger,
+		)
+		report, err := importer.Import(ctx, os.DirFS(flags.Arg(0)), *source, domain.SystemMeta("cli"))
+		if errors.Is(err, context.Canceled) {
+			fmt.Fprintln(os.Stderr, "import interrupted; run the same command again to resume")
+			return 1
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return printImportReport(report)
+	}
+
+	if args[0] == "export" {
+		var w io.Writer = os.Stdout
+		if *output != "" {
+			f, err := os.Create(*output)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+			defer f.Close()
+			w = f
+		}
+		if err := transferService.Export(w, *format); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	var r io.Reader = os.Stdin
+	if name := flags.Arg(0); name != "" && name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		r = f
+	}
+	report, err := transferService.Import(r, domain.ImportOptions{
+		Format: *format,
+		Source: *source,
+		DryRun: *dryRun,
+	}, domain.SystemMeta("cli"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return printImportReport(report)
+}
+
+// printImportReport writes the report to stdout and fails if any record
+// was skipped.
+func printImportReport(report *domain.ImportReport) int {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if len(report.Errors) > 0 {
+		return 1
+	}
+	return 0
+}
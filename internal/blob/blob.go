@@ -0,0 +1,27 @@
+// Package blob stores the contents of uploaded files. Blobs are written
+// once under a key and never modified; callers derive keys from the
+// content's checksum, so equal files share a blob.
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned for keys that have no blob.
+var ErrNotFound = errors.New("blob not found")
+
+// Store is a place to keep blobs: a local directory or an S3-compatible
+// bucket.
+type Store interface {
+	// Put stores size bytes read from r under key, replacing any blob
+	// already there.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open returns a reader for the blob under key, whose size the caller
+	// already knows. The reader supports seeking so that byte ranges can be
+	// served.
+	Open(ctx context.Context, key string, size int64) (io.ReadSeekCloser, error)
+	Exists(ctx context.Context, key string) (bool, error)
+	Delete(ctx context.Context, key string) error
+}
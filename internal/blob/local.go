@@ -0,0 +1,95 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStore keeps blobs as files under a directory, creating
+// subdirectories for keys that contain slashes.
+type LocalStore struct {
+	dir string
+}
+
+func NewLocalStore(dir string) *LocalStore {
+	return &LocalStore{dir: dir}
+}
+
+func (s *LocalStore) path(key string) (string, error) {
+	clean := filepath.FromSlash(key)
+	if key == "" || !filepath.IsLocal(clean) || strings.ContainsRune(key, '\\') {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(s.dir, clean), nil
+}
+
+// Put writes to a temporary file first, so readers never see a partly
+// written blob.
+func (s *LocalStore) Put(_ context.Context, key string, r io.Reader, size int64, _ string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return fmt.Errorf("blob %q: wrote %d bytes, expected %d", key, n, size)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *LocalStore) Open(_ context.Context, key string, _ int64) (io.ReadSeekCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (s *LocalStore) Exists(_ context.Context, key string) (bool, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *LocalStore) Delete(_ context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package blob
+
+import (
+	"fmt"
+
+	"hitalent-test/internal/config"
+)
+
+// New builds the store selected by cfg.Driver.
+func New(cfg config.StorageConfig) (Store, error) {
+	switch cfg.Driver {
+	case "local":
+		return NewLocalStore(cfg.Dir), nil
+	case "s3":
+		return NewS3Store(S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PathStyle: cfg.S3PathStyle,
+		}, nil)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}
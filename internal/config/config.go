@@ -2,88 +2,431 @@ package config
 
 import (
 	"fmt"
-	"os"
-	"strconv"
+	"slices"
 	"time"
 )
 
+// Config is the effective application configuration. Every leaf field is a
+// setting addressable by its dotted key (derived from the yaml tags), by the
+// environment variable in its env tag and by a command-line flag named after
+// the key. Fields tagged secret:"true" are redacted when printed and may be
+// read from a file via the <ENV>_FILE variable. Fields tagged reload:"true"
+// are applied on SIGHUP without a restart.
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Logger   LoggerConfig
-	JWT      JWTConfig
+	Server      ServerConfig      `yaml:"server"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Logger      LoggerConfig      `yaml:"log"`
+	JWT         JWTConfig         `yaml:"jwt"`
+	Validation  ValidationConfig  `yaml:"validation"`
+	CORS        CORSConfig        `yaml:"cors"`
+	Features    FeaturesConfig    `yaml:"features"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit"`
+	Login       LoginConfig       `yaml:"login"`
+	Mail        MailConfig        `yaml:"mail"`
+	Tokens      TokensConfig      `yaml:"tokens"`
+	MFA         MFAConfig         `yaml:"mfa"`
+	OIDC        OIDCConfig        `yaml:"oidc"`
+	Account     AccountConfig     `yaml:"account"`
+	Moderation  ModerationConfig  `yaml:"moderation"`
+	Filter      FilterConfig      `yaml:"filter"`
+	Similarity  SimilarityConfig  `yaml:"similarity"`
+	Digest      DigestConfig      `yaml:"digest"`
+	Storage     StorageConfig     `yaml:"storage"`
+	Attachments AttachmentsConfig `yaml:"attachments"`
 }
 
 type ServerConfig struct {
-	Host string
-	Port int
+	Host              string        `yaml:"host" env:"SERVER_HOST"`
+	Port              int           `yaml:"port" env:"SERVER_PORT"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+	MaxBodyBytes      int64         `yaml:"max_body_bytes" env:"SERVER_MAX_BODY_BYTES"`
+	// TrustedProxies lists IPs or CIDRs whose X-Forwarded-For header is
+	// honoured when determining the client IP.
+	TrustedProxies []string `yaml:"trusted_proxies" env:"SERVER_TRUSTED_PROXIES" reload:"true"`
 }
 
 type DatabaseConfig struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+	Host            string        `yaml:"host" env:"DB_HOST"`
+	Port            int           `yaml:"port" env:"DB_PORT"`
+	User            string        `yaml:"user" env:"DB_USER"`
+	Password        string        `yaml:"password" env:"DB_PASSWORD" secret:"true"`
+	DBName          string        `yaml:"name" env:"DB_NAME"`
+	SSLMode         string        `yaml:"sslmode" env:"DB_SSLMODE"`
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
 }
 
 type LoggerConfig struct {
-	Level  string
-	Format string
+	Level  string `yaml:"level" env:"LOG_LEVEL" reload:"true"`
+	Format string `yaml:"format" env:"LOG_FORMAT"`
 }
 
 type JWTConfig struct {
-	Secret             string
-	AccessTokenExpiry  time.Duration
-	RefreshTokenExpiry time.Duration
+	Secret             string        `yaml:"secret" env:"JWT_SECRET" secret:"true"`
+	AccessTokenExpiry  time.Duration `yaml:"access_token_expiry" env:"JWT_ACCESS_TOKEN_EXPIRY"`
+	RefreshTokenExpiry time.Duration `yaml:"refresh_token_expiry" env:"JWT_REFRESH_TOKEN_EXPIRY"`
+	// VerificationSecrets are accepted in addition to Secret when verifying
+	// tokens, so the signing secret can be rotated without logging users out.
+	VerificationSecrets []string `yaml:"verification_secrets" env:"JWT_VERIFICATION_SECRETS" secret:"true" reload:"true"`
 }
 
-func Load() (*Config, error) {
-	port, _ := strconv.Atoi(getEnv("SERVER_PORT", "8080"))
-	dbPort, _ := strconv.Atoi(getEnv("DB_PORT", "5432"))
+type ValidationConfig struct {
+	QuestionMinLength    int `yaml:"question_min_length" env:"VALIDATION_QUESTION_MIN_LENGTH"`
+	QuestionMaxLength    int `yaml:"question_max_length" env:"VALIDATION_QUESTION_MAX_LENGTH"`
+	AnswerMinLength      int `yaml:"answer_min_length" env:"VALIDATION_ANSWER_MIN_LENGTH"`
+	AnswerMaxLength      int `yaml:"answer_max_length" env:"VALIDATION_ANSWER_MAX_LENGTH"`
+	PasswordMinLength    int `yaml:"password_min_length" env:"VALIDATION_PASSWORD_MIN_LENGTH"`
+	DisplayNameMaxLength int `yaml:"display_name_max_length" env:"VALIDATION_DISPLAY_NAME_MAX_LENGTH"`
+	BioMaxLength         int `yaml:"bio_max_length" env:"VALIDATION_BIO_MAX_LENGTH"`
+	// MaxMentions is how many users a single question or answer may
+	// mention.
+	MaxMentions int `yaml:"max_mentions" env:"VALIDATION_MAX_MENTIONS"`
+	// SourceLengthFactor caps the Markdown source of a post at this many
+	// times its maximum length. The length limits count the text without
+	// markup, which leaves link targets and markup that renders to nothing
+	// unbounded otherwise.
+	SourceLengthFactor int `yaml:"source_length_factor" env:"VALIDATION_SOURCE_LENGTH_FACTOR"`
+}
 
-	accessTokenExpiry, _ := time.ParseDuration(getEnv("JWT_ACCESS_TOKEN_EXPIRY", "15m"))
-	refreshTokenExpiry, _ := time.ParseDuration(getEnv("JWT_REFRESH_TOKEN_EXPIRY", "168h"))
+type CORSConfig struct {
+	AllowedOrigins []string `yaml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS" reload:"true"`
+}
 
-	jwtSecret := getEnv("JWT_SECRET", "")
-	if jwtSecret == "" {
-		return nil, fmt.Errorf("JWT_SECRET environment variable is required")
-	}
+type FeaturesConfig struct {
+	Registration bool `yaml:"registration" env:"FEATURE_REGISTRATION" reload:"true"`
+	// RequireEmailVerification forbids unverified users from posting answers.
+	RequireEmailVerification bool `yaml:"require_email_verification" env:"FEATURE_REQUIRE_EMAIL_VERIFICATION" reload:"true"`
+}
+
+type MailConfig struct {
+	// Driver is "file" (.eml files in Dir), "smtp" or "log" (only the
+	// recipient and subject are logged; nothing is delivered).
+	Driver       string `yaml:"driver" env:"MAIL_DRIVER"`
+	From         string `yaml:"from" env:"MAIL_FROM"`
+	Dir          string `yaml:"dir" env:"MAIL_DIR"`
+	SMTPHost     string `yaml:"smtp_host" env:"MAIL_SMTP_HOST"`
+	SMTPPort     int    `yaml:"smtp_port" env:"MAIL_SMTP_PORT"`
+	SMTPUsername string `yaml:"smtp_username" env:"MAIL_SMTP_USERNAME"`
+	SMTPPassword string `yaml:"smtp_password" env:"MAIL_SMTP_PASSWORD" secret:"true"`
+	// LinkBaseURL is prepended to links sent by email.
+	LinkBaseURL string `yaml:"link_base_url" env:"MAIL_LINK_BASE_URL"`
+}
+
+// TokensConfig holds lifetimes of single-use tokens sent to users.
+type TokensConfig struct {
+	EmailVerificationTTL time.Duration `yaml:"email_verification_ttl" env:"TOKENS_EMAIL_VERIFICATION_TTL"`
+	PasswordResetTTL     time.Duration `yaml:"password_reset_ttl" env:"TOKENS_PASSWORD_RESET_TTL"`
+}
 
+// MFAConfig configures TOTP two-factor authentication.
+type MFAConfig struct {
+	// Issuer is the account issuer shown by authenticator apps.
+	Issuer string `yaml:"issuer" env:"MFA_ISSUER"`
+	// ChallengeTTL is how long the second login step may take.
+	ChallengeTTL  time.Duration `yaml:"challenge_ttl" env:"MFA_CHALLENGE_TTL"`
+	RecoveryCodes int           `yaml:"recovery_codes" env:"MFA_RECOVERY_CODES"`
+}
+
+// OIDCConfig registers OpenID Connect providers for single sign-on. Sign-in
+// is off while no provider is configured.
+type OIDCConfig struct {
+	// Providers are keyed by the name used in URLs and linked identities.
+	// In the environment, OIDC_PROVIDERS lists the names and each
+	// provider's settings follow as OIDC_PROVIDERS_<NAME>_ISSUER and so on.
+	Providers map[string]*OIDCProviderConfig `yaml:"providers" env:"OIDC_PROVIDERS"`
+	// AutoProvision creates accounts for unknown users with a verified
+	// email; otherwise only existing accounts can sign in.
+	AutoProvision bool `yaml:"auto_provision" env:"OIDC_AUTO_PROVISION"`
+	// FlowTTL is how long the user may take to sign in at the provider.
+	FlowTTL time.Duration `yaml:"flow_ttl" env:"OIDC_FLOW_TTL"`
+}
+
+// OIDCProviderConfig is the client registration at one provider.
+type OIDCProviderConfig struct {
+	Issuer       string   `yaml:"issuer" env:"ISSUER"`
+	ClientID     string   `yaml:"client_id" env:"CLIENT_ID"`
+	ClientSecret string   `yaml:"client_secret" env:"CLIENT_SECRET" secret:"true"`
+	RedirectURL  string   `yaml:"redirect_url" env:"REDIRECT_URL"`
+	Scopes       []string `yaml:"scopes" env:"SCOPES"`
+}
+
+// applyDefaults fills in a provider that the loader has just found.
+func (p *OIDCProviderConfig) applyDefaults() {
+	p.Scopes = []string{"openid", "email", "profile"}
+}
+
+// AccountConfig controls account deletion and personal data export.
+type AccountConfig struct {
+	// DeletionGracePeriod is how long a deletion request may be cancelled
+	// before the account is erased; zero erases it at once.
+	DeletionGracePeriod time.Duration `yaml:"deletion_grace_period" env:"ACCOUNT_DELETION_GRACE_PERIOD"`
+	// ContentPolicy is "anonymize" to keep the user's questions and answers
+	// under a "deleted user" placeholder, or "delete" to remove them.
+	ContentPolicy string `yaml:"content_policy" env:"ACCOUNT_CONTENT_POLICY"`
+	// ExportTTL is how long a generated data export stays downloadable.
+	ExportTTL time.Duration `yaml:"export_ttl" env:"ACCOUNT_EXPORT_TTL"`
+	// ReauthWindow is how recent a sign-in through a linked identity
+	// provider must be to stand in for the password when deleting the
+	// account; zero always requires the password.
+	ReauthWindow time.Duration `yaml:"reauth_window" env:"ACCOUNT_REAUTH_WINDOW"`
+}
+
+// ModerationConfig controls content flagging.
+type ModerationConfig struct {
+	// HideThreshold is the number of open flags after which a question or
+	// answer is hidden until a moderator decides; zero never hides content.
+	HideThreshold int `yaml:"hide_threshold" env:"MODERATION_HIDE_THRESHOLD" reload:"true"`
+}
+
+// SimilarityConfig controls the search for near-duplicate questions.
+type SimilarityConfig struct {
+	// Threshold is the minimal trigram similarity, between 0 and 1, for a
+	// question to count as similar.
+	Threshold float64 `yaml:"threshold" env:"SIMILARITY_THRESHOLD" reload:"true"`
+	// Limit caps the candidates returned on creation and by the search;
+	// zero disables the duplicate check on creation.
+	Limit int `yaml:"limit" env:"SIMILARITY_LIMIT" reload:"true"`
+	// Related caps the related questions shown with a question; zero hides
+	// them.
+	Related int `yaml:"related" env:"SIMILARITY_RELATED" reload:"true"`
+}
+
+// DigestConfig controls the emails about activity on watched questions.
+type DigestConfig struct {
+	// Enabled turns the daily and weekly digests on; instant emails are
+	// sent regardless.
+	Enabled bool `yaml:"enabled" env:"DIGEST_ENABLED" reload:"true"`
+	// Hour is the hour of the day, in each user's time zone, when digests
+	// are sent; weekly digests go out on Weekday.
+	Hour    int    `yaml:"hour" env:"DIGEST_HOUR" reload:"true"`
+	Weekday string `yaml:"weekday" env:"DIGEST_WEEKDAY" reload:"true"`
+	// Interval is how often the job looks for digests that are due.
+	Interval time.Duration `yaml:"interval" env:"DIGEST_INTERVAL"`
+}
+
+// WeekdayValue returns Weekday as a time.Weekday; Weekday must be valid.
+func (c DigestConfig) WeekdayValue() time.Weekday {
+	return time.Weekday(slices.Index(weekdays, c.Weekday))
+}
+
+// StorageConfig selects where the contents of attachments are kept.
+type StorageConfig struct {
+	// Driver is "local" (files under Dir) or "s3" (an S3-compatible
+	// bucket).
+	Driver      string `yaml:"driver" env:"STORAGE_DRIVER"`
+	Dir         string `yaml:"dir" env:"STORAGE_DIR"`
+	S3Endpoint  string `yaml:"s3_endpoint" env:"STORAGE_S3_ENDPOINT"`
+	S3Region    string `yaml:"s3_region" env:"STORAGE_S3_REGION"`
+	S3Bucket    string `yaml:"s3_bucket" env:"STORAGE_S3_BUCKET"`
+	S3AccessKey string `yaml:"s3_access_key" env:"STORAGE_S3_ACCESS_KEY"`
+	S3SecretKey string `yaml:"s3_secret_key" env:"STORAGE_S3_SECRET_KEY" secret:"true"`
+	// S3PathStyle addresses the bucket as a path of the endpoint rather
+	// than as its subdomain, as MinIO and most self-hosted services need.
+	S3PathStyle bool `yaml:"s3_path_style" env:"STORAGE_S3_PATH_STYLE"`
+}
+
+// AttachmentsConfig limits the files attached to questions and answers.
+type AttachmentsConfig struct {
+	// MaxSize is the largest file accepted, in bytes.
+	MaxSize int64 `yaml:"max_size" env:"ATTACHMENTS_MAX_SIZE" reload:"true"`
+	// MaxPerPost caps the files attached to one question or answer; zero
+	// disables uploads.
+	MaxPerPost int `yaml:"max_per_post" env:"ATTACHMENTS_MAX_PER_POST" reload:"true"`
+	// AllowedTypes lists the accepted media types, as detected from the
+	// content rather than taken from the client.
+	AllowedTypes []string `yaml:"allowed_types" env:"ATTACHMENTS_ALLOWED_TYPES" reload:"true"`
+	// Images larger than MaxImageWidth x MaxImageHeight pixels are
+	// rejected.
+	MaxImageWidth  int `yaml:"max_image_width" env:"ATTACHMENTS_MAX_IMAGE_WIDTH" reload:"true"`
+	MaxImageHeight int `yaml:"max_image_height" env:"ATTACHMENTS_MAX_IMAGE_HEIGHT" reload:"true"`
+}
+
+// FilterConfig controls the checks run on new questions and answers. Each
+// check either lets the text through, holds it for moderation or rejects it.
+type FilterConfig struct {
+	Enabled bool `yaml:"enabled" env:"FILTER_ENABLED" reload:"true"`
+	// BannedWords reject a text containing any of them; HoldWords send it to
+	// the moderation queue. Words match case-insensitively as whole words.
+	BannedWords []string `yaml:"banned_words" env:"FILTER_BANNED_WORDS" reload:"true"`
+	HoldWords   []string `yaml:"hold_words" env:"FILTER_HOLD_WORDS" reload:"true"`
+	// MaxLinks is how many links a text may contain before it is held;
+	// zero disables the check.
+	MaxLinks int `yaml:"max_links" env:"FILTER_MAX_LINKS" reload:"true"`
+	// DuplicateWindow is how long the same user may not post the same text
+	// again; zero disables the check.
+	DuplicateWindow time.Duration `yaml:"duplicate_window" env:"FILTER_DUPLICATE_WINDOW" reload:"true"`
+	// Texts whose heuristic spam score, between 0 and 1, reaches SpamHold
+	// are held, and those reaching SpamReject are rejected.
+	SpamHold   float64 `yaml:"spam_hold" env:"FILTER_SPAM_HOLD" reload:"true"`
+	SpamReject float64 `yaml:"spam_reject" env:"FILTER_SPAM_REJECT" reload:"true"`
+}
+
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled" env:"RATE_LIMIT_ENABLED" reload:"true"`
+	// Store is "memory" for a single instance or "postgres" to share buckets
+	// between replicas.
+	Store  string          `yaml:"store" env:"RATE_LIMIT_STORE"`
+	Auth   RateLimitPolicy `yaml:"auth" env:"RATE_LIMIT_AUTH"`
+	Writes RateLimitPolicy `yaml:"writes" env:"RATE_LIMIT_WRITES"`
+	Reads  RateLimitPolicy `yaml:"reads" env:"RATE_LIMIT_READS"`
+}
+
+// LoginConfig controls brute-force protection of POST /auth/login. Failed
+// attempts are counted per account and per client IP within FailureWindow.
+// Each account failure delays the next attempt by DelayBase doubled per
+// failure (capped at MaxDelay); reaching a Max*Failures threshold locks the
+// account or IP for LockoutDuration.
+type LoginConfig struct {
+	MaxAccountFailures int           `yaml:"max_account_failures" env:"LOGIN_MAX_ACCOUNT_FAILURES" reload:"true"`
+	MaxIPFailures      int           `yaml:"max_ip_failures" env:"LOGIN_MAX_IP_FAILURES" reload:"true"`
+	FailureWindow      time.Duration `yaml:"failure_window" env:"LOGIN_FAILURE_WINDOW" reload:"true"`
+	LockoutDuration    time.Duration `yaml:"lockout_duration" env:"LOGIN_LOCKOUT_DURATION" reload:"true"`
+	DelayBase          time.Duration `yaml:"delay_base" env:"LOGIN_DELAY_BASE" reload:"true"`
+	MaxDelay           time.Duration `yaml:"max_delay" env:"LOGIN_MAX_DELAY" reload:"true"`
+}
+
+// RateLimitPolicy is a token bucket holding up to Burst tokens and refilled
+// with Requests tokens every Period. Requests of zero disables the limit.
+type RateLimitPolicy struct {
+	Requests int           `yaml:"requests" env:"REQUESTS" reload:"true"`
+	Period   time.Duration `yaml:"period" env:"PERIOD" reload:"true"`
+	Burst    int           `yaml:"burst" env:"BURST" reload:"true"`
+}
+
+// Default returns the configuration used when no file, environment variable
+// or flag overrides a setting.
+func Default() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Host: getEnv("SERVER_HOST", "0.0.0.0"),
-			Port: port,
+			Host:              "0.0.0.0",
+			Port:              8080,
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      15 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			ShutdownTimeout:   30 * time.Second,
+			MaxBodyBytes:      1 << 20,
 		},
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     dbPort,
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", "postgres"),
-			DBName:   getEnv("DB_NAME", "qaservice"),
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			Host:            "localhost",
+			Port:            5432,
+			User:            "postgres",
+			Password:        "postgres",
+			DBName:          "qaservice",
+			SSLMode:         "disable",
+			MaxOpenConns:    100,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: time.Hour,
 		},
 		Logger: LoggerConfig{
-			Level:  getEnv("LOG_LEVEL", "info"),
-			Format: getEnv("LOG_FORMAT", "json"),
+			Level:  "info",
+			Format: "json",
 		},
 		JWT: JWTConfig{
-			Secret:             jwtSecret,
-			AccessTokenExpiry:  accessTokenExpiry,
-			RefreshTokenExpiry: refreshTokenExpiry,
+			AccessTokenExpiry:  15 * time.Minute,
+			RefreshTokenExpiry: 168 * time.Hour,
+		},
+		Validation: ValidationConfig{
+			QuestionMinLength:    10,
+			QuestionMaxLength:    1000,
+			AnswerMinLength:      5,
+			AnswerMaxLength:      1000,
+			PasswordMinLength:    8,
+			DisplayNameMaxLength: 64,
+			BioMaxLength:         1000,
+			MaxMentions:          10,
+			SourceLengthFactor:   4,
+		},
+		Features: FeaturesConfig{
+			Registration: true,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Store:   "memory",
+			Auth:    RateLimitPolicy{Requests: 10, Period: time.Minute, Burst: 5},
+			Writes:  RateLimitPolicy{Requests: 60, Period: time.Minute, Burst: 20},
+			Reads:   RateLimitPolicy{Requests: 600, Period: time.Minute, Burst: 100},
+		},
+		Login: LoginConfig{
+			MaxAccountFailures: 5,
+			MaxIPFailures:      50,
+			FailureWindow:      15 * time.Minute,
+			LockoutDuration:    15 * time.Minute,
+			DelayBase:          time.Second,
+			MaxDelay:           30 * time.Second,
+		},
+		Mail: MailConfig{
+			Driver:      "file",
+			From:        "Q&A Service <no-reply@localhost>",
+			Dir:         "mail",
+			SMTPPort:    587,
+			LinkBaseURL: "http://localhost:8080",
 		},
-	}, nil
+		Tokens: TokensConfig{
+			EmailVerificationTTL: 24 * time.Hour,
+			PasswordResetTTL:     time.Hour,
+		},
+		MFA: MFAConfig{
+			Issuer:        "Q&A Service",
+			ChallengeTTL:  5 * time.Minute,
+			RecoveryCodes: 10,
+		},
+		OIDC: OIDCConfig{
+			AutoProvision: true,
+			FlowTTL:       10 * time.Minute,
+		},
+		Account: AccountConfig{
+			DeletionGracePeriod: 30 * 24 * time.Hour,
+			ContentPolicy:       "anonymize",
+			ExportTTL:           24 * time.Hour,
+			ReauthWindow:        5 * time.Minute,
+		},
+		Moderation: ModerationConfig{
+			HideThreshold: 3,
+		},
+		Filter: FilterConfig{
+			Enabled:         true,
+			MaxLinks:        3,
+			DuplicateWindow: 10 * time.Minute,
+			SpamHold:        0.5,
+			SpamReject:      0.9,
+		},
+		Similarity: SimilarityConfig{
+			Threshold: 0.45,
+			Limit:     5,
+			Related:   5,
+		},
+		Digest: DigestConfig{
+			Enabled:  true,
+			Hour:     8,
+			Weekday:  "monday",
+			Interval: 15 * time.Minute,
+		},
+		Storage: StorageConfig{
+			Driver:   "local",
+			Dir:      "data/attachments",
+			S3Region: "us-east-1",
+		},
+		Attachments: AttachmentsConfig{
+			MaxSize:    10 << 20,
+			MaxPerPost: 5,
+			AllowedTypes: []string{
+				"image/png", "image/jpeg", "image/gif", "image/webp",
+				"text/plain", "application/pdf", "application/zip",
+			},
+			MaxImageWidth:  8000,
+			MaxImageHeight: 8000,
+		},
+	}
 }
 
 func (c *DatabaseConfig) DSN() string {
 	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		c.Host, c.Port, c.User, c.Password, c.DBName, c.SSLMode)
 }
-
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
@@ -0,0 +1,388 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	configFileEnv = "CONFIG_FILE"
+	redactedValue = "******"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setting is a single leaf of Config together with the names it can be
+// addressed by.
+type setting struct {
+	key    string
+	env    string
+	secret bool
+	reload bool
+	value  reflect.Value
+}
+
+// Load builds the configuration from defaults, an optional YAML or TOML file,
+// environment variables and command-line flags, in increasing order of
+// precedence. The file is taken from the -config flag or CONFIG_FILE. All
+// parse and validation problems are reported together in a single error.
+//
+// Named entries, such as identity providers, come from the file and the
+// environment only: there are no flags for them.
+func Load(args []string) (*Config, error) {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return LoadFlags(fs, args)
+}
+
+// LoadFlags is Load for commands with flags of their own: it adds the
+// configuration flags to fs before parsing args, and leaves the remaining
+// arguments in fs.Args().
+func LoadFlags(fs *flag.FlagSet, args []string) (*Config, error) {
+	cfg := Default()
+	settings := settingsOf(cfg)
+
+	configFile := fs.String("config", os.Getenv(configFileEnv), "path to a YAML or TOML config file")
+	settingKeys := make(map[string]struct{}, len(settings))
+	for _, s := range settings {
+		fs.String(s.key, "", fmt.Sprintf("overrides %s", s.env))
+		settingKeys[s.key] = struct{}{}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("invalid flags: %w", err)
+	}
+
+	var errs []error
+
+	var fileValues map[string]string
+	if *configFile != "" {
+		var err error
+		if fileValues, err = readFile(*configFile); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	addEntries(cfg, fileValues)
+	settings = settingsOf(cfg)
+
+	if *configFile != "" {
+		errs = append(errs, apply(settings, fileValues, "file "+*configFile, func(s setting) string { return s.key })...)
+	}
+
+	errs = append(errs, applyEnv(settings)...)
+
+	flagValues := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		if _, ok := settingKeys[f.Name]; ok {
+			flagValues[f.Name] = f.Value.String()
+		}
+	})
+	errs = append(errs, apply(settings, flagValues, "flag", func(s setting) string { return "-" + s.key })...)
+	errs = append(errs, cfg.validate()...)
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+
+	return cfg, nil
+}
+
+// Redacted returns a copy of the configuration with secret settings masked,
+// suitable for printing or logging.
+func (c *Config) Redacted() *Config {
+	clone := c.clone()
+	for _, s := range settingsOf(clone) {
+		if !s.secret || s.value.IsZero() {
+			continue
+		}
+		if s.value.Kind() == reflect.Slice {
+			masked := make([]string, s.value.Len())
+			for i := range masked {
+				masked[i] = redactedValue
+			}
+			s.value.Set(reflect.ValueOf(masked))
+			continue
+		}
+		s.value.SetString(redactedValue)
+	}
+	return clone
+}
+
+// clone copies the configuration, including the entries of named settings,
+// so that setting values on the copy leaves c alone.
+func (c *Config) clone() *Config {
+	clone := *c
+	clone.OIDC.Providers = make(map[string]*OIDCProviderConfig, len(c.OIDC.Providers))
+	for name, p := range c.OIDC.Providers {
+		copied := *p
+		clone.OIDC.Providers[name] = &copied
+	}
+	return &clone
+}
+
+// Print writes the configuration as YAML with secrets redacted.
+func (c *Config) Print(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func settingsOf(cfg *Config) []setting {
+	var settings []setting
+	var walk func(v reflect.Value, prefix, envPrefix string)
+	walk = func(v reflect.Value, prefix, envPrefix string) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			key := strings.Split(f.Tag.Get("yaml"), ",")[0]
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			env := f.Tag.Get("env")
+			if envPrefix != "" && env != "" {
+				env = envPrefix + "_" + env
+			}
+			if f.Type.Kind() == reflect.Struct && f.Type != durationType {
+				// A nested struct may carry an env tag that prefixes the
+				// variables of its fields, e.g. RATE_LIMIT_AUTH + REQUESTS.
+				walk(v.Field(i), key, env)
+				continue
+			}
+			if f.Type.Kind() == reflect.Map {
+				// A map holds named entries; their keys and variables
+				// include the name, e.g. OIDC_PROVIDERS + CORP + ISSUER.
+				entries := v.Field(i)
+				names := entries.MapKeys()
+				sort.Slice(names, func(a, b int) bool { return names[a].String() < names[b].String() })
+				for _, name := range names {
+					walk(entries.MapIndex(name).Elem(), key+"."+name.String(), env+"_"+envName(name.String()))
+				}
+				continue
+			}
+			settings = append(settings, setting{
+				key:    key,
+				env:    env,
+				secret: f.Tag.Get("secret") == "true",
+				reload: f.Tag.Get("reload") == "true",
+				value:  v.Field(i),
+			})
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem(), "", "")
+	return settings
+}
+
+// addEntries creates the named entries that the file or the environment
+// mention: file keys such as oidc.providers.corp.issuer, and the
+// comma-separated names in the map's own variable, such as OIDC_PROVIDERS.
+// New entries start from their defaults.
+func addEntries(cfg *Config, fileValues map[string]string) {
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			key := strings.Split(f.Tag.Get("yaml"), ",")[0]
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			switch {
+			case f.Type.Kind() == reflect.Struct && f.Type != durationType:
+				walk(v.Field(i), key)
+			case f.Type.Kind() == reflect.Map:
+				var names []string
+				for fileKey := range fileValues {
+					if rest, ok := strings.CutPrefix(fileKey, key+"."); ok {
+						names = append(names, strings.Split(rest, ".")[0])
+					}
+				}
+				for _, name := range strings.Split(os.Getenv(f.Tag.Get("env")), ",") {
+					names = append(names, strings.TrimSpace(name))
+				}
+
+				entries := v.Field(i)
+				if entries.IsNil() {
+					entries.Set(reflect.MakeMap(f.Type))
+				}
+				for _, name := range names {
+					if name == "" || entries.MapIndex(reflect.ValueOf(name)).IsValid() {
+						continue
+					}
+					entry := reflect.New(f.Type.Elem().Elem())
+					if d, ok := entry.Interface().(interface{ applyDefaults() }); ok {
+						d.applyDefaults()
+					}
+					entries.SetMapIndex(reflect.ValueOf(name), entry)
+				}
+			}
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem(), "")
+}
+
+// envName turns an entry name into its part of a variable name.
+func envName(name string) string {
+	return strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+func apply(settings []setting, values map[string]string, source string, name func(setting) string) []error {
+	var errs []error
+	known := make(map[string]bool, len(settings))
+	for _, s := range settings {
+		known[s.key] = true
+		raw, ok := values[s.key]
+		if !ok {
+			continue
+		}
+		if err := setValue(s.value, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s: %w", source, name(s), err))
+		}
+	}
+
+	var unknown []string
+	for key := range values {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		errs = append(errs, fmt.Errorf("%s: unknown setting %q", source, key))
+	}
+	return errs
+}
+
+func applyEnv(settings []setting) []error {
+	var errs []error
+	for _, s := range settings {
+		value, set := os.LookupEnv(s.env)
+		if s.secret {
+			if path, ok := os.LookupEnv(s.env + "_FILE"); ok && path != "" {
+				if set && value != "" {
+					errs = append(errs, fmt.Errorf("env: %s and %s_FILE are mutually exclusive", s.env, s.env))
+					continue
+				}
+				data, err := os.ReadFile(path)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("env: %s_FILE: %w", s.env, err))
+					continue
+				}
+				value, set = strings.TrimRight(string(data), "\r\n"), true
+			}
+		}
+		if !set || value == "" {
+			continue
+		}
+		if err := setValue(s.value, value); err != nil {
+			errs = append(errs, fmt.Errorf("env: %s: %w", s.env, err))
+		}
+	}
+	return errs
+}
+
+func setValue(v reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Int, v.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(n)
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// readFile parses a YAML or TOML file into a flat map of dotted keys.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("file %s: %w", path, err)
+	}
+
+	tree := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&tree); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("file %s: %w", path, err)
+		}
+	case ".toml":
+		if _, err := toml.Decode(string(data), &tree); err != nil {
+			return nil, fmt.Errorf("file %s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("file %s: unsupported format, use .yaml, .yml or .toml", path)
+	}
+
+	values := make(map[string]string)
+	flatten(tree, "", values)
+	return values, nil
+}
+
+func flatten(tree map[string]interface{}, prefix string, out map[string]string) {
+	for k, v := range tree {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch val := v.(type) {
+		case map[string]interface{}:
+			flatten(val, key, out)
+		case []interface{}:
+			items := make([]string, 0, len(val))
+			for _, item := range val {
+				items = append(items, fmt.Sprint(item))
+			}
+			out[key] = strings.Join(items, ",")
+		case nil:
+			out[key] = ""
+		default:
+			out[key] = fmt.Sprint(val)
+		}
+	}
+}
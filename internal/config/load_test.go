@@ -0,0 +1,164 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+server:
+  port: 9000
+  write_timeout: 30s
+log:
+  level: warn
+`)
+	t.Setenv("JWT_SECRET", "secret")
+	t.Setenv("SERVER_PORT", "9100")
+
+	cfg, err := Load([]string{"-config", path, "-log.level", "debug"})
+
+	require.NoError(t, err)
+	assert.Equal(t, 9100, cfg.Server.Port)
+	assert.Equal(t, 30*time.Second, cfg.Server.WriteTimeout)
+	assert.Equal(t, "debug", cfg.Logger.Level)
+	assert.Equal(t, 15*time.Second, cfg.Server.ReadTimeout)
+}
+
+func TestLoadFlags_KeepsCommandFlags(t *testing.T) {
+	path := writeFile(t, "config.yaml", "log:\n  level: warn\n")
+	t.Setenv("JWT_SECRET", "secret")
+
+	fs := flag.NewFlagSet("data export", flag.ContinueOnError)
+	format := fs.String("format", "jsonl", "file format")
+	cfg, err := LoadFlags(fs, []string{"-config", path, "-format", "csv", "-server.port", "9200", "out.csv"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "warn", cfg.Logger.Level)
+	assert.Equal(t, 9200, cfg.Server.Port)
+	assert.Equal(t, "csv", *format)
+	assert.Equal(t, []string{"out.csv"}, fs.Args())
+}
+
+func TestLoad_TOMLFile(t *testing.T) {
+	path := writeFile(t, "config.toml", `
+[database]
+max_open_conns = 20
+max_idle_conns = 5
+`)
+	t.Setenv("JWT_SECRET", "secret")
+
+	cfg, err := Load([]string{"-config", path})
+
+	require.NoError(t, err)
+	assert.Equal(t, 20, cfg.Database.MaxOpenConns)
+	assert.Equal(t, 5, cfg.Database.MaxIdleConns)
+}
+
+func TestLoad_SecretFromFile(t *testing.T) {
+	path := writeFile(t, "jwt_secret", "from-file\n")
+	t.Setenv("JWT_SECRET_FILE", path)
+
+	cfg, err := Load(nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", cfg.JWT.Secret)
+}
+
+func TestLoad_AggregatesErrors(t *testing.T) {
+	path := writeFile(t, "config.yaml", "server:\n  prot: 1\n")
+	t.Setenv("SERVER_PORT", "80a")
+	t.Setenv("JWT_ACCESS_TOKEN_EXPIRY", "soon")
+
+	_, err := Load([]string{"-config", path, "-log.format", "xml"})
+
+	require.Error(t, err)
+	msg := err.Error()
+	assert.Contains(t, msg, `unknown setting "server.prot"`)
+	assert.Contains(t, msg, "SERVER_PORT")
+	assert.Contains(t, msg, "JWT_ACCESS_TOKEN_EXPIRY")
+	assert.Contains(t, msg, "log.format")
+	assert.Contains(t, msg, "jwt.secret")
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := Default()
+	cfg.JWT.Secret = "secret"
+
+	redacted := cfg.Redacted()
+
+	assert.Equal(t, "******", redacted.JWT.Secret)
+	assert.Equal(t, "******", redacted.Database.Password)
+	assert.Equal(t, "secret", cfg.JWT.Secret)
+}
+
+func TestLoad_OIDCProviders(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+oidc:
+  providers:
+    corp:
+      issuer: https://login.corp.example
+      client_id: qa-service
+      redirect_url: https://qa.example.com/auth/oidc/corp/callback
+    google:
+      issuer: https://accounts.google.com
+      client_id: from-file
+      client_secret: google-secret
+      redirect_url: https://qa.example.com/auth/oidc/google/callback
+      scopes: [openid, email]
+`)
+	t.Setenv("JWT_SECRET", "secret")
+	t.Setenv("OIDC_PROVIDERS", "google, gitlab-ce")
+	t.Setenv("OIDC_PROVIDERS_GOOGLE_CLIENT_ID", "from-env")
+	t.Setenv("OIDC_PROVIDERS_GITLAB_CE_ISSUER", "https://gitlab.example.com")
+	t.Setenv("OIDC_PROVIDERS_GITLAB_CE_CLIENT_ID", "qa")
+	t.Setenv("OIDC_PROVIDERS_GITLAB_CE_REDIRECT_URL", "https://qa.example.com/auth/oidc/gitlab-ce/callback")
+
+	cfg, err := Load([]string{"-config", path})
+
+	require.NoError(t, err)
+	require.Len(t, cfg.OIDC.Providers, 3)
+	assert.Equal(t, "https://login.corp.example", cfg.OIDC.Providers["corp"].Issuer)
+	assert.Equal(t, []string{"openid", "email", "profile"}, cfg.OIDC.Providers["corp"].Scopes)
+	assert.Equal(t, "from-env", cfg.OIDC.Providers["google"].ClientID)
+	assert.Equal(t, []string{"openid", "email"}, cfg.OIDC.Providers["google"].Scopes)
+	assert.Equal(t, "https://gitlab.example.com", cfg.OIDC.Providers["gitlab-ce"].Issuer)
+
+	redacted := cfg.Redacted()
+	assert.Equal(t, "******", redacted.OIDC.Providers["google"].ClientSecret)
+	assert.Equal(t, "google-secret", cfg.OIDC.Providers["google"].ClientSecret)
+}
+
+func TestLoad_OIDCProviderValidation(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+oidc:
+  providers:
+    Corp:
+      issuer: login.corp.example
+      scopes: [email]
+`)
+	t.Setenv("JWT_SECRET", "secret")
+
+	_, err := Load([]string{"-config", path})
+
+	require.Error(t, err)
+	msg := err.Error()
+	assert.Contains(t, msg, "oidc.providers.Corp: name must be")
+	assert.Contains(t, msg, "oidc.providers.Corp.issuer")
+	assert.Contains(t, msg, "oidc.providers.Corp.client_id")
+	assert.Contains(t, msg, "oidc.providers.Corp.redirect_url")
+	assert.Contains(t, msg, "oidc.providers.Corp.scopes")
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sync"
+)
+
+// Change describes a setting whose value differs between two configurations.
+// Values of secret settings are redacted.
+type Change struct {
+	Key        string `json:"key"`
+	Old        string `json:"old"`
+	New        string `json:"new"`
+	Reloadable bool   `json:"reloadable"`
+}
+
+// ReloadResult reports what a reload applied and what still needs a restart.
+type ReloadResult struct {
+	Applied         []Change `json:"applied"`
+	RestartRequired []Change `json:"restart_required"`
+}
+
+// Diff lists the settings that differ between old and new. Settings of
+// named entries that only one side has are listed with an empty value on
+// the other; adding or removing an entry always needs a restart.
+func Diff(old, new *Config) []Change {
+	oldSettings := settingsByKey(old.Redacted())
+	newSettings := settingsByKey(new.Redacted())
+	rawOld := settingsOf(old)
+	rawNew := settingsOf(new)
+	rawNewByKey := settingsByKey(new)
+
+	var changes []Change
+	for _, s := range rawOld {
+		n, ok := rawNewByKey[s.key]
+		if ok && reflect.DeepEqual(s.value.Interface(), n.value.Interface()) {
+			continue
+		}
+		change := Change{Key: s.key, Old: fmt.Sprint(oldSettings[s.key].value.Interface())}
+		if ok {
+			change.New = fmt.Sprint(newSettings[s.key].value.Interface())
+			change.Reloadable = s.reload
+		}
+		changes = append(changes, change)
+	}
+	for _, s := range rawNew {
+		if _, ok := oldSettings[s.key]; !ok {
+			changes = append(changes, Change{Key: s.key, New: fmt.Sprint(newSettings[s.key].value.Interface())})
+		}
+	}
+	return changes
+}
+
+func settingsByKey(cfg *Config) map[string]setting {
+	settings := make(map[string]setting)
+	for _, s := range settingsOf(cfg) {
+		settings[s.key] = s
+	}
+	return settings
+}
+
+// Reloader re-reads the configuration from the same sources it was first
+// loaded from and hands the reloadable part of it to registered subscribers.
+type Reloader struct {
+	mu          sync.Mutex
+	args        []string
+	current     *Config
+	subscribers []func(*Config)
+	logger      *slog.Logger
+}
+
+func NewReloader(cfg *Config, args []string, logger *slog.Logger) *Reloader {
+	return &Reloader{
+		args:    args,
+		current: cfg,
+		logger:  logger,
+	}
+}
+
+// OnReload registers fn to be called with the effective configuration after
+// every successful reload. Subscribers must only read settings tagged
+// reload:"true"; everything else keeps its startup value.
+func (r *Reloader) OnReload(fn func(*Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers = append(r.subscribers, fn)
+}
+
+// Current returns the configuration currently in effect.
+func (r *Reloader) Current() *Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Reload loads a fresh configuration. If it is invalid the running one is
+// kept and the error returned; otherwise reloadable settings are applied and
+// the rest are reported as requiring a restart.
+func (r *Reloader) Reload() (*ReloadResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loaded, err := Load(r.args)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReloadResult{}
+	next := r.current.clone()
+	nextSettings := settingsByKey(next)
+	loadedSettings := settingsByKey(loaded)
+	for _, change := range Diff(r.current, loaded) {
+		if !change.Reloadable {
+			result.RestartRequired = append(result.RestartRequired, change)
+			continue
+		}
+		result.Applied = append(result.Applied, change)
+		nextSettings[change.Key].value.Set(loadedSettings[change.Key].value)
+	}
+
+	r.current = next
+	for _, fn := range r.subscribers {
+		fn(r.current)
+	}
+
+	for _, change := range result.Applied {
+		r.logger.Info("config setting reloaded",
+			slog.String("key", change.Key),
+			slog.String("old", change.Old),
+			slog.String("new", change.New),
+		)
+	}
+	for _, change := range result.RestartRequired {
+		r.logger.Warn("config setting changed but requires restart",
+			slog.String("key", change.Key),
+			slog.String("old", change.Old),
+			slog.String("new", change.New),
+		)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloader_AppliesOnlyReloadableSettings(t *testing.T) {
+	t.Setenv("JWT_SECRET", "secret")
+	cfg, err := Load(nil)
+	require.NoError(t, err)
+
+	reloader := NewReloader(cfg, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	var applied *Config
+	reloader.OnReload(func(c *Config) { applied = c })
+
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("SERVER_PORT", "9999")
+	t.Setenv("JWT_VERIFICATION_SECRETS", "old-secret")
+
+	result, err := reloader.Reload()
+
+	require.NoError(t, err)
+	require.NotNil(t, applied)
+	assert.Equal(t, "debug", applied.Logger.Level)
+	assert.Equal(t, []string{"old-secret"}, applied.JWT.VerificationSecrets)
+	assert.Equal(t, 8080, applied.Server.Port)
+
+	require.Len(t, result.RestartRequired, 1)
+	assert.Equal(t, "server.port", result.RestartRequired[0].Key)
+	require.Len(t, result.Applied, 2)
+	for _, change := range result.Applied {
+		assert.NotContains(t, change.New, "old-secret")
+	}
+}
+
+func TestReloader_KeepsConfigOnError(t *testing.T) {
+	t.Setenv("JWT_SECRET", "secret")
+	cfg, err := Load(nil)
+	require.NoError(t, err)
+
+	reloader := NewReloader(cfg, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	t.Setenv("LOG_LEVEL", "loud")
+	_, err = reloader.Reload()
+
+	require.Error(t, err)
+	assert.Same(t, cfg, reloader.Current())
+}
+
+func TestReloader_NewProviderRequiresRestart(t *testing.T) {
+	t.Setenv("JWT_SECRET", "secret")
+	cfg, err := Load(nil)
+	require.NoError(t, err)
+
+	reloader := NewReloader(cfg, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	t.Setenv("OIDC_PROVIDERS", "corp")
+	t.Setenv("OIDC_PROVIDERS_CORP_ISSUER", "https://login.corp.example")
+	t.Setenv("OIDC_PROVIDERS_CORP_CLIENT_ID", "qa-service")
+	t.Setenv("OIDC_PROVIDERS_CORP_CLIENT_SECRET", "corp-secret")
+	t.Setenv("OIDC_PROVIDERS_CORP_REDIRECT_URL", "https://qa.example.com/auth/oidc/corp/callback")
+
+	result, err := reloader.Reload()
+
+	require.NoError(t, err)
+	assert.Empty(t, result.Applied)
+	assert.Empty(t, reloader.Current().OIDC.Providers)
+	var keys []string
+	for _, change := range result.RestartRequired {
+		keys = append(keys, change.Key)
+		assert.NotContains(t, change.New, "corp-secret")
+	}
+	assert.Contains(t, keys, "oidc.providers.corp.issuer")
+	assert.Contains(t, keys, "oidc.providers.corp.client_secret")
+}
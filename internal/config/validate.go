@@ -0,0 +1,218 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	logLevels  = []string{"debug", "info", "warn", "error"}
+	logFormats = []string{"json", "text"}
+	sslModes   = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	rateStores = []string{"memory", "postgres"}
+	mailDriver = []string{"log", "file", "smtp"}
+	contentPol = []string{"anonymize", "delete"}
+	storageDrv = []string{"local", "s3"}
+	// weekdays are in time.Weekday order.
+	weekdays = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+	providerName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+)
+
+// Validate checks every setting and returns all problems joined together, or
+// nil if the configuration is usable.
+func (c *Config) Validate() error {
+	if errs := c.validate(); len(errs) > 0 {
+		return fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func (c *Config) validate() []error {
+	v := &validator{}
+
+	v.check(c.Server.Host != "", "server.host", "must not be empty")
+	v.port("server.port", c.Server.Port)
+	v.positive("server.read_timeout", c.Server.ReadTimeout)
+	v.positive("server.read_header_timeout", c.Server.ReadHeaderTimeout)
+	v.positive("server.write_timeout", c.Server.WriteTimeout)
+	v.positive("server.idle_timeout", c.Server.IdleTimeout)
+	v.positive("server.shutdown_timeout", c.Server.ShutdownTimeout)
+	v.check(c.Server.MaxBodyBytes > 0, "server.max_body_bytes", "must be positive")
+	for _, proxy := range c.Server.TrustedProxies {
+		v.ipOrCIDR("server.trusted_proxies", proxy)
+	}
+
+	v.check(c.Database.Host != "", "database.host", "must not be empty")
+	v.port("database.port", c.Database.Port)
+	v.check(c.Database.User != "", "database.user", "must not be empty")
+	v.check(c.Database.DBName != "", "database.name", "must not be empty")
+	v.oneOf("database.sslmode", c.Database.SSLMode, sslModes)
+	v.check(c.Database.MaxOpenConns > 0, "database.max_open_conns", "must be positive")
+	v.check(c.Database.MaxIdleConns >= 0, "database.max_idle_conns", "must not be negative")
+	v.check(c.Database.MaxIdleConns <= c.Database.MaxOpenConns, "database.max_idle_conns",
+		"must not exceed database.max_open_conns")
+	v.check(c.Database.ConnMaxLifetime >= 0, "database.conn_max_lifetime", "must not be negative")
+	v.check(c.Database.ConnMaxIdleTime >= 0, "database.conn_max_idle_time", "must not be negative")
+
+	v.oneOf("log.level", c.Logger.Level, logLevels)
+	v.oneOf("log.format", c.Logger.Format, logFormats)
+
+	v.check(c.JWT.Secret != "", "jwt.secret", "is required (set JWT_SECRET or JWT_SECRET_FILE)")
+	v.positive("jwt.access_token_expiry", c.JWT.AccessTokenExpiry)
+	v.positive("jwt.refresh_token_expiry", c.JWT.RefreshTokenExpiry)
+	v.check(c.JWT.RefreshTokenExpiry >= c.JWT.AccessTokenExpiry, "jwt.refresh_token_expiry",
+		"must not be shorter than jwt.access_token_expiry")
+
+	v.lengths("validation.question", c.Validation.QuestionMinLength, c.Validation.QuestionMaxLength)
+	v.lengths("validation.answer", c.Validation.AnswerMinLength, c.Validation.AnswerMaxLength)
+	v.check(c.Validation.PasswordMinLength > 0, "validation.password_min_length", "must be positive")
+	v.check(c.Validation.DisplayNameMaxLength > 0, "validation.display_name_max_length", "must be positive")
+	v.check(c.Validation.BioMaxLength > 0, "validation.bio_max_length", "must be positive")
+	v.check(c.Validation.MaxMentions >= 0, "validation.max_mentions", "must not be negative")
+	v.check(c.Validation.SourceLengthFactor >= 1, "validation.source_length_factor", "must be at least 1")
+
+	v.oneOf("rate_limit.store", c.RateLimit.Store, rateStores)
+	v.ratePolicy("rate_limit.auth", c.RateLimit.Auth)
+	v.ratePolicy("rate_limit.writes", c.RateLimit.Writes)
+	v.ratePolicy("rate_limit.reads", c.RateLimit.Reads)
+
+	v.check(c.Login.MaxAccountFailures > 0, "login.max_account_failures", "must be positive")
+	v.check(c.Login.MaxIPFailures > 0, "login.max_ip_failures", "must be positive")
+	v.positive("login.failure_window", c.Login.FailureWindow)
+	v.positive("login.lockout_duration", c.Login.LockoutDuration)
+	v.check(c.Login.DelayBase >= 0, "login.delay_base", "must not be negative")
+	v.check(c.Login.MaxDelay >= c.Login.DelayBase, "login.max_delay", "must not be less than login.delay_base")
+
+	v.oneOf("mail.driver", c.Mail.Driver, mailDriver)
+	v.check(c.Mail.From != "", "mail.from", "must not be empty")
+	v.check(c.Mail.LinkBaseURL != "", "mail.link_base_url", "must not be empty")
+	if c.Mail.Driver == "file" {
+		v.check(c.Mail.Dir != "", "mail.dir", "is required for the file driver")
+	}
+	if c.Mail.Driver == "smtp" {
+		v.check(c.Mail.SMTPHost != "", "mail.smtp_host", "is required for the smtp driver")
+		v.port("mail.smtp_port", c.Mail.SMTPPort)
+	}
+
+	v.positive("tokens.email_verification_ttl", c.Tokens.EmailVerificationTTL)
+	v.positive("tokens.password_reset_ttl", c.Tokens.PasswordResetTTL)
+
+	v.check(c.MFA.Issuer != "", "mfa.issuer", "must not be empty")
+	v.check(!strings.Contains(c.MFA.Issuer, ":"), "mfa.issuer", "must not contain a colon")
+	v.positive("mfa.challenge_ttl", c.MFA.ChallengeTTL)
+	v.check(c.MFA.RecoveryCodes > 0, "mfa.recovery_codes", "must be positive")
+
+	for _, name := range slices.Sorted(maps.Keys(c.OIDC.Providers)) {
+		p, prefix := c.OIDC.Providers[name], "oidc.providers."+name
+		v.check(providerName.MatchString(name), prefix, "name must be lowercase letters, digits and dashes")
+		v.absoluteURL(prefix+".issuer", p.Issuer)
+		v.check(p.ClientID != "", prefix+".client_id", "must not be empty")
+		v.absoluteURL(prefix+".redirect_url", p.RedirectURL)
+		v.check(slices.Contains(p.Scopes, "openid"), prefix+".scopes", `must include "openid"`)
+	}
+	if len(c.OIDC.Providers) > 0 {
+		v.positive("oidc.flow_ttl", c.OIDC.FlowTTL)
+	}
+
+	v.check(c.Account.DeletionGracePeriod >= 0, "account.deletion_grace_period", "must not be negative")
+	v.oneOf("account.content_policy", c.Account.ContentPolicy, contentPol)
+	v.positive("account.export_ttl", c.Account.ExportTTL)
+	v.check(c.Account.ReauthWindow >= 0, "account.reauth_window", "must not be negative")
+
+	v.check(c.Moderation.HideThreshold >= 0, "moderation.hide_threshold", "must not be negative")
+
+	v.check(c.Filter.MaxLinks >= 0, "filter.max_links", "must not be negative")
+	v.check(c.Filter.DuplicateWindow >= 0, "filter.duplicate_window", "must not be negative")
+	v.check(c.Filter.SpamHold > 0 && c.Filter.SpamHold <= 1, "filter.spam_hold", "must be in (0, 1]")
+	v.check(c.Filter.SpamReject > 0 && c.Filter.SpamReject <= 1, "filter.spam_reject", "must be in (0, 1]")
+	v.check(c.Filter.SpamHold <= c.Filter.SpamReject, "filter.spam_hold", "must not exceed filter.spam_reject")
+
+	v.check(c.Similarity.Threshold > 0 && c.Similarity.Threshold <= 1, "similarity.threshold", "must be in (0, 1]")
+	v.check(c.Similarity.Limit >= 0, "similarity.limit", "must not be negative")
+	v.check(c.Similarity.Related >= 0, "similarity.related", "must not be negative")
+
+	v.check(c.Digest.Hour >= 0 && c.Digest.Hour <= 23, "digest.hour", "must be between 0 and 23")
+	v.oneOf("digest.weekday", c.Digest.Weekday, weekdays)
+	v.positive("digest.interval", c.Digest.Interval)
+
+	v.oneOf("storage.driver", c.Storage.Driver, storageDrv)
+	if c.Storage.Driver == "local" {
+		v.check(c.Storage.Dir != "", "storage.dir", "is required for the local driver")
+	}
+	if c.Storage.Driver == "s3" {
+		v.absoluteURL("storage.s3_endpoint", c.Storage.S3Endpoint)
+		v.check(c.Storage.S3Region != "", "storage.s3_region", "is required for the s3 driver")
+		v.check(c.Storage.S3Bucket != "", "storage.s3_bucket", "is required for the s3 driver")
+		v.check(c.Storage.S3AccessKey != "", "storage.s3_access_key", "is required for the s3 driver")
+		v.check(c.Storage.S3SecretKey != "", "storage.s3_secret_key", "is required for the s3 driver")
+	}
+
+	v.check(c.Attachments.MaxSize > 0, "attachments.max_size", "must be positive")
+	v.check(c.Attachments.MaxPerPost >= 0, "attachments.max_per_post", "must not be negative")
+	for _, t := range c.Attachments.AllowedTypes {
+		v.check(strings.Count(t, "/") == 1 && !strings.ContainsAny(t, " ;"), "attachments.allowed_types",
+			fmt.Sprintf("%q is not a media type", t))
+	}
+	v.check(c.Attachments.MaxImageWidth > 0, "attachments.max_image_width", "must be positive")
+	v.check(c.Attachments.MaxImageHeight > 0, "attachments.max_image_height", "must be positive")
+
+	return v.errs
+}
+
+type validator struct {
+	errs []error
+}
+
+func (v *validator) check(ok bool, key, msg string) {
+	if !ok {
+		v.errs = append(v.errs, fmt.Errorf("%s: %s", key, msg))
+	}
+}
+
+func (v *validator) absoluteURL(key, value string) {
+	u, err := url.Parse(value)
+	v.check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", key, "must be an absolute http(s) URL")
+}
+
+func (v *validator) port(key string, port int) {
+	v.check(port > 0 && port <= 65535, key, fmt.Sprintf("must be between 1 and 65535, got %d", port))
+}
+
+func (v *validator) positive(key string, d time.Duration) {
+	v.check(d > 0, key, fmt.Sprintf("must be positive, got %s", d))
+}
+
+func (v *validator) oneOf(key, value string, allowed []string) {
+	v.check(slices.Contains(allowed, value), key, fmt.Sprintf("must be one of %v, got %q", allowed, value))
+}
+
+func (v *validator) lengths(prefix string, min, max int) {
+	v.check(min > 0, prefix+"_min_length", "must be positive")
+	v.check(max >= min, prefix+"_max_length", "must not be less than "+prefix+"_min_length")
+}
+
+func (v *validator) ipOrCIDR(key, value string) {
+	if strings.Contains(value, "/") {
+		_, _, err := net.ParseCIDR(value)
+		v.check(err == nil, key, fmt.Sprintf("invalid CIDR %q", value))
+		return
+	}
+	v.check(net.ParseIP(value) != nil, key, fmt.Sprintf("invalid IP %q", value))
+}
+
+func (v *validator) ratePolicy(prefix string, p RateLimitPolicy) {
+	v.check(p.Requests >= 0, prefix+".requests", "must not be negative")
+	if p.Requests == 0 {
+		return
+	}
+	v.positive(prefix+".period", p.Period)
+	v.check(p.Burst > 0, prefix+".burst", "must be positive")
+}
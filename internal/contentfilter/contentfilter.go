@@ -0,0 +1,125 @@
+// Package contentfilter checks new questions and answers for spam and
+// unwanted content before they are stored.
+package contentfilter
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"hitalent-test/internal/config"
+)
+
+// Verdict is the outcome of a check. Higher verdicts are stricter.
+type Verdict int
+
+const (
+	Allow Verdict = iota
+	// Hold stores the content hidden until a moderator reviews it.
+	Hold
+	Reject
+)
+
+func (v Verdict) String() string {
+	switch v {
+	case Allow:
+		return "allow"
+	case Hold:
+		return "hold"
+	case Reject:
+		return "reject"
+	}
+	return fmt.Sprintf("Verdict(%d)", int(v))
+}
+
+// Content is a question or an answer about to be stored.
+type Content struct {
+	// Kind is "question" or "answer".
+	Kind string
+	// UserID is empty for anonymous questions.
+	UserID string
+	Text   string
+	At     time.Time
+}
+
+// Result explains a verdict. Filter is the name of the filter that gave it
+// and is empty when the content is allowed.
+type Result struct {
+	Verdict Verdict
+	Filter  string
+	Reason  string
+}
+
+// Filter is one check of the pipeline. Check returns Allow with an empty
+// reason for acceptable content. An error fails the whole check.
+type Filter interface {
+	Name() string
+	Check(c Content) (Verdict, string, error)
+}
+
+// Pipeline runs the built-in filters, configured from config.FilterConfig,
+// followed by any filters added with Use.
+type Pipeline struct {
+	builtin atomic.Pointer[[]Filter]
+	mu      sync.RWMutex
+	custom  []Filter
+	history PostHistory
+}
+
+// New builds the pipeline. The duplicate check looks for earlier posts in
+// history.
+func New(cfg config.FilterConfig, history PostHistory) *Pipeline {
+	p := &Pipeline{history: history}
+	p.SetConfig(cfg)
+	return p
+}
+
+// SetConfig rebuilds the built-in filters. Disabling the pipeline turns off
+// the built-in filters only.
+func (p *Pipeline) SetConfig(cfg config.FilterConfig) {
+	var filters []Filter
+	if cfg.Enabled {
+		if len(cfg.BannedWords) > 0 || len(cfg.HoldWords) > 0 {
+			filters = append(filters, NewWordFilter(cfg.BannedWords, cfg.HoldWords))
+		}
+		if cfg.MaxLinks > 0 {
+			filters = append(filters, &LinkFilter{MaxLinks: cfg.MaxLinks})
+		}
+		if cfg.DuplicateWindow > 0 {
+			filters = append(filters, NewDuplicateFilter(cfg.DuplicateWindow, p.history))
+		}
+		filters = append(filters, &SpamFilter{HoldScore: cfg.SpamHold, RejectScore: cfg.SpamReject})
+	}
+	p.builtin.Store(&filters)
+}
+
+// Use appends filters that run after the built-in ones on every check.
+func (p *Pipeline) Use(filters ...Filter) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.custom = append(p.custom, filters...)
+}
+
+// Check runs the filters in order and returns the strictest verdict. It
+// stops at the first rejection; otherwise the first hold is reported.
+func (p *Pipeline) Check(c Content) (Result, error) {
+	p.mu.RLock()
+	filters := append(append([]Filter(nil), *p.builtin.Load()...), p.custom...)
+	p.mu.RUnlock()
+
+	result := Result{Verdict: Allow}
+	for _, f := range filters {
+		verdict, reason, err := f.Check(c)
+		if err != nil {
+			return Result{}, fmt.Errorf("content filter %s: %w", f.Name(), err)
+		}
+		if verdict > result.Verdict {
+			result = Result{Verdict: verdict, Filter: f.Name(), Reason: reason}
+		}
+		if result.Verdict == Reject {
+			break
+		}
+	}
+	return result, nil
+}
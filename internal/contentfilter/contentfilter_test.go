@@ -0,0 +1,142 @@
+package contentfilter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"hitalent-test/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig() config.FilterConfig {
+	return config.FilterConfig{
+		Enabled:         true,
+		BannedWords:     []string{"badword", "very bad phrase"},
+		HoldWords:       []string{"suspicious"},
+		MaxLinks:        2,
+		DuplicateWindow: 10 * time.Minute,
+		SpamHold:        0.5,
+		SpamReject:      0.9,
+	}
+}
+
+func TestPipeline_Check(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		text    string
+		verdict Verdict
+		filter  string
+	}{
+		{"plain question", "What is the capital of France?", Allow, ""},
+		{"banned word", "This is a BadWord, honestly.", Reject, "words"},
+		{"banned phrase", "That was a very   bad phrase!", Reject, "words"},
+		{"banned word inside another word", "badwords are fine here", Allow, ""},
+		{"hold word", "A suspicious question about Paris", Hold, "words"},
+		{"too many links", "See https://a.example, https://b.example and www.c.example", Hold, "links"},
+		{"obvious spam", "BUY NOW!!! CLICK HERE FOR FREE MONEY http://spam.example", Reject, "spam"},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(testConfig(), &postHistory{})
+
+			result, err := p.Check(Content{Kind: "question", UserID: "user", Text: tt.text, At: now.Add(time.Duration(i) * time.Hour)})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.verdict, result.Verdict, result.Reason)
+			assert.Equal(t, tt.filter, result.Filter)
+		})
+	}
+}
+
+func TestPipeline_Disabled(t *testing.T) {
+	cfg := testConfig()
+	cfg.Enabled = false
+	p := New(cfg, &postHistory{})
+
+	result, err := p.Check(Content{Text: "badword badword badword"})
+
+	require.NoError(t, err)
+	assert.Equal(t, Allow, result.Verdict)
+}
+
+// postHistory holds the posts stored so far.
+type postHistory struct {
+	posts []Content
+}
+
+func (h *postHistory) RecentTexts(userID string, since time.Time) ([]string, error) {
+	var texts []string
+	for _, p := range h.posts {
+		if p.UserID == userID && p.At.After(since) {
+			texts = append(texts, p.Text)
+		}
+	}
+	return texts, nil
+}
+
+func TestDuplicateFilter(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	history := &postHistory{}
+	f := NewDuplicateFilter(10*time.Minute, history)
+	check := func(userID, text string, at time.Time) Verdict {
+		verdict, _, err := f.Check(Content{UserID: userID, Text: text, At: at})
+		require.NoError(t, err)
+		return verdict
+	}
+
+	assert.Equal(t, Allow, check("alice", "What is the capital of France?", now))
+	assert.Equal(t, Allow, check("alice", "What is the capital of France?", now), "nothing was stored yet")
+
+	history.posts = append(history.posts, Content{UserID: "alice", Text: "What is the capital of France?", At: now})
+	assert.Equal(t, Reject, check("alice", "what is the capital of   france", now.Add(time.Minute)))
+	assert.Equal(t, Allow, check("alice", "What is the capital of Spain?", now.Add(time.Minute)))
+	assert.Equal(t, Allow, check("bob", "What is the capital of France?", now.Add(time.Minute)))
+	assert.Equal(t, Allow, check("", "What is the capital of France?", now), "anonymous posts are not checked")
+	assert.Equal(t, Allow, check("alice", "What is the capital of France?", now.Add(10*time.Minute)))
+}
+
+func TestSpamScore(t *testing.T) {
+	assert.Zero(t, SpamScore("What is the capital of France?"))
+	assert.Greater(t, SpamScore("FREE MONEY!!! VISIT http://a.example http://b.example NOW"), 0.5)
+	assert.LessOrEqual(t, SpamScore("buy now casino viagra click here http://a http://b http://c http://d AAAAAAAA!!!!"), 1.0)
+}
+
+type failingFilter struct{}
+
+func (failingFilter) Name() string { return "failing" }
+
+func (failingFilter) Check(Content) (Verdict, string, error) {
+	return Allow, "", errors.New("backend unavailable")
+}
+
+type holdAllFilter struct{}
+
+func (holdAllFilter) Name() string { return "hold-all" }
+
+func (holdAllFilter) Check(Content) (Verdict, string, error) {
+	return Hold, "everything is held", nil
+}
+
+func TestPipeline_Use(t *testing.T) {
+	p := New(testConfig(), &postHistory{})
+	p.Use(holdAllFilter{})
+
+	result, err := p.Check(Content{Text: "What is the capital of France?"})
+	require.NoError(t, err)
+	assert.Equal(t, Result{Verdict: Hold, Filter: "hold-all", Reason: "everything is held"}, result)
+
+	// Custom filters survive a reload.
+	p.SetConfig(testConfig())
+	result, err = p.Check(Content{Text: "A badword"})
+	require.NoError(t, err)
+	assert.Equal(t, Reject, result.Verdict, "a rejection outranks a hold")
+
+	p.Use(failingFilter{})
+	_, err = p.Check(Content{Text: "What is the capital of France?"})
+	assert.Error(t, err)
+}
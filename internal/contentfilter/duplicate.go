@@ -0,0 +1,49 @@
+package contentfilter
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// PostHistory looks up what a user has already posted.
+type PostHistory interface {
+	// RecentTexts returns the texts of the questions and answers the user
+	// posted after since.
+	RecentTexts(userID string, since time.Time) ([]string, error)
+}
+
+// DuplicateFilter rejects a text the same user already posted within
+// Window. Texts are compared ignoring case, punctuation and spacing.
+// Anonymous posts are not checked. The history is what was stored, so
+// posts refused by a later filter or never stored do not count, and every
+// instance sees the same history.
+type DuplicateFilter struct {
+	Window  time.Duration
+	History PostHistory
+}
+
+func NewDuplicateFilter(window time.Duration, history PostHistory) *DuplicateFilter {
+	return &DuplicateFilter{Window: window, History: history}
+}
+
+func (f *DuplicateFilter) Name() string {
+	return "duplicate"
+}
+
+func (f *DuplicateFilter) Check(c Content) (Verdict, string, error) {
+	if c.UserID == "" {
+		return Allow, "", nil
+	}
+	texts, err := f.History.RecentTexts(c.UserID, c.At.Add(-f.Window))
+	if err != nil {
+		return Allow, "", err
+	}
+	normalized := strings.Join(words(c.Text), " ")
+	if slices.ContainsFunc(texts, func(text string) bool {
+		return strings.Join(words(text), " ") == normalized
+	}) {
+		return Reject, "you already posted this text recently", nil
+	}
+	return Allow, "", nil
+}
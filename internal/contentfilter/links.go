@@ -0,0 +1,28 @@
+package contentfilter
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+
+// LinkFilter holds texts with more than MaxLinks links.
+type LinkFilter struct {
+	MaxLinks int
+}
+
+func (f *LinkFilter) Name() string {
+	return "links"
+}
+
+func (f *LinkFilter) Check(c Content) (Verdict, string, error) {
+	if n := countLinks(c.Text); n > f.MaxLinks {
+		return Hold, fmt.Sprintf("contains %d links, at most %d are allowed", n, f.MaxLinks), nil
+	}
+	return Allow, "", nil
+}
+
+func countLinks(text string) int {
+	return len(linkPattern.FindAllStringIndex(text, -1))
+}
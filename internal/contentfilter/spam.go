@@ -0,0 +1,114 @@
+package contentfilter
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// spamPhrases are typical of advertising spam. They are matched as whole
+// words, like banned words.
+var spamPhrases = []string{
+	"buy now", "click here", "free money", "limited offer", "work from home",
+	"casino", "viagra", "crypto giveaway", "earn money",
+	"заработок", "казино", "кликните",
+}
+
+var (
+	repeatedPunctuation = regexp.MustCompile(`[!?$€]{3,}`)
+	normalizedSpam      = normalizeAll(spamPhrases)
+)
+
+// SpamFilter scores texts with SpamScore and holds or rejects those at or
+// above the thresholds.
+type SpamFilter struct {
+	HoldScore   float64
+	RejectScore float64
+}
+
+func (f *SpamFilter) Name() string {
+	return "spam"
+}
+
+func (f *SpamFilter) Check(c Content) (Verdict, string, error) {
+	score := SpamScore(c.Text)
+	switch {
+	case score >= f.RejectScore:
+		return Reject, fmt.Sprintf("looks like spam (score %.2f)", score), nil
+	case score >= f.HoldScore:
+		return Hold, fmt.Sprintf("may be spam (score %.2f)", score), nil
+	}
+	return Allow, "", nil
+}
+
+// SpamScore rates how much text looks like spam, from 0 to 1, by adding up
+// signals: links, spam phrases, shouting, repeated punctuation, long runs
+// of one character and a single word repeated over and over.
+func SpamScore(text string) float64 {
+	var score float64
+
+	score += math.Min(float64(countLinks(text))*0.15, 0.45)
+
+	tokens := words(text)
+	padded := " " + strings.Join(tokens, " ") + " "
+	var phrases float64
+	for _, p := range normalizedSpam {
+		if strings.Contains(padded, " "+p+" ") {
+			phrases += 0.3
+		}
+	}
+	score += math.Min(phrases, 0.6)
+
+	var letters, upper int
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	if letters >= 20 && float64(upper) > 0.7*float64(letters) {
+		score += 0.25
+	}
+
+	if repeatedPunctuation.MatchString(text) {
+		score += 0.15
+	}
+
+	if longestRun(text) >= 6 {
+		score += 0.15
+	}
+
+	if len(tokens) >= 10 {
+		counts := make(map[string]int)
+		for _, t := range tokens {
+			counts[t]++
+			if float64(counts[t]) > 0.3*float64(len(tokens)) {
+				score += 0.2
+				break
+			}
+		}
+	}
+
+	return math.Min(score, 1)
+}
+
+// longestRun returns the length of the longest run of one repeated
+// non-space character.
+func longestRun(text string) int {
+	longest, run := 0, 0
+	var prev rune
+	for _, r := range text {
+		if r == prev && !unicode.IsSpace(r) {
+			run++
+		} else {
+			run = 1
+		}
+		prev = r
+		longest = max(longest, run)
+	}
+	return longest
+}
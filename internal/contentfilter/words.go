@@ -0,0 +1,56 @@
+package contentfilter
+
+import (
+	"strings"
+	"unicode"
+)
+
+// WordFilter rejects or holds texts containing listed words or phrases.
+// Matching ignores case and punctuation and only matches whole words, so
+// "ass" does not match "class".
+type WordFilter struct {
+	banned []string
+	held   []string
+}
+
+func NewWordFilter(banned, held []string) *WordFilter {
+	return &WordFilter{banned: normalizeAll(banned), held: normalizeAll(held)}
+}
+
+func (f *WordFilter) Name() string {
+	return "words"
+}
+
+func (f *WordFilter) Check(c Content) (Verdict, string, error) {
+	// Padding with spaces makes every match start and end at a word
+	// boundary.
+	text := " " + strings.Join(words(c.Text), " ") + " "
+	for _, w := range f.banned {
+		if strings.Contains(text, " "+w+" ") {
+			return Reject, "contains a banned word", nil
+		}
+	}
+	for _, w := range f.held {
+		if strings.Contains(text, " "+w+" ") {
+			return Hold, "contains a word that needs review", nil
+		}
+	}
+	return Allow, "", nil
+}
+
+// words splits text into lowercase words of letters and digits.
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func normalizeAll(phrases []string) []string {
+	var normalized []string
+	for _, p := range phrases {
+		if w := strings.Join(words(p), " "); w != "" {
+			normalized = append(normalized, w)
+		}
+	}
+	return normalized
+}
@@ -0,0 +1,129 @@
+// Package digest renders the emails about activity on watched questions:
+// one per new answer for users who want them instantly, and daily or
+// weekly digests for the others.
+package digest
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+	"unicode/utf8"
+
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/mailer"
+)
+
+const excerptLength = 200
+
+//go:embed templates
+var templateFS embed.FS
+
+var funcs = map[string]any{
+	"date": func(t time.Time) string { return t.Format("2 Jan 2006 15:04") },
+}
+
+var (
+	textTemplates = texttemplate.Must(texttemplate.New("").Funcs(funcs).ParseFS(templateFS, "templates/*.txt"))
+	htmlTemplates = htmltemplate.Must(htmltemplate.New("").Funcs(funcs).ParseFS(templateFS, "templates/*.html"))
+)
+
+// Question is a watched question with its recent activity.
+type Question struct {
+	ID  uint
+	URL string
+	// Title is the start of the question text.
+	Title   string
+	Status  string
+	Answers []Answer
+	// StatusChanged is set when someone else changed the status during the
+	// period.
+	StatusChanged bool
+}
+
+type Answer struct {
+	Author    string
+	Excerpt   string
+	CreatedAt time.Time
+}
+
+// Digest is the activity on a user's watched questions over a period.
+// Times are in the recipient's time zone.
+type Digest struct {
+	Name      string
+	Frequency string
+	Since     time.Time
+	Until     time.Time
+	Questions []Question
+	// SettingsURL is where the recipient changes how often they get email.
+	SettingsURL string
+}
+
+// AnswerEmail tells a watcher about a new answer right away.
+type AnswerEmail struct {
+	Name        string
+	Question    Question
+	Answer      Answer
+	SettingsURL string
+}
+
+// NewQuestion describes q for an email. authors maps user IDs to display
+// names; links point below baseURL.
+func NewQuestion(q *domain.Question, baseURL string, authors map[string]string, loc *time.Location) Question {
+	question := Question{
+		ID:     q.ID,
+		URL:    fmt.Sprintf("%s/questions/%d", strings.TrimRight(baseURL, "/"), q.ID),
+		Title:  Excerpt(q.Text, 80),
+		Status: q.Status,
+	}
+	for _, a := range q.Answers {
+		question.Answers = append(question.Answers, Answer{
+			Author:    authors[a.UserID],
+			Excerpt:   Excerpt(a.Text, excerptLength),
+			CreatedAt: a.CreatedAt.In(loc),
+		})
+	}
+	return question
+}
+
+// Excerpt shortens text to at most n runes on a word boundary, collapsing
+// whitespace.
+func Excerpt(text string, n int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+	runes := []rune(text)[:n]
+	if i := strings.LastIndexByte(string(runes), ' '); i > 0 {
+		return string(runes)[:i] + "…"
+	}
+	return string(runes) + "…"
+}
+
+// RenderDigest builds the digest email; the caller sets the recipient.
+func RenderDigest(d *Digest) (mailer.Message, error) {
+	subject := fmt.Sprintf("Your %s digest: activity on %d watched question", d.Frequency, len(d.Questions))
+	if len(d.Questions) != 1 {
+		subject += "s"
+	}
+	return render("digest", subject, d)
+}
+
+// RenderAnswer builds the email about a single new answer.
+func RenderAnswer(e *AnswerEmail) (mailer.Message, error) {
+	return render("answer", fmt.Sprintf("New answer to \"%s\"", Excerpt(e.Question.Title, 60)), e)
+}
+
+func render(name, subject string, data any) (mailer.Message, error) {
+	var text, html bytes.Buffer
+	if err := textTemplates.ExecuteTemplate(&text, name+".txt", data); err != nil {
+		return mailer.Message{}, fmt.Errorf("failed to render %s text: %w", name, err)
+	}
+	if err := htmlTemplates.ExecuteTemplate(&html, name+".html", data); err != nil {
+		return mailer.Message{}, fmt.Errorf("failed to render %s html: %w", name, err)
+	}
+	return mailer.Message{Subject: subject, Text: text.String(), HTML: html.String()}, nil
+}
@@ -0,0 +1,101 @@
+package digest
+
+import (
+	"testing"
+	"time"
+
+	"hitalent-test/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule_Slot(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+	schedule := Schedule{Hour: 8, Weekday: time.Monday}
+	// Wednesday 2025-01-15 06:30 UTC is 09:30 in Moscow.
+	now := time.Date(2025, 1, 15, 6, 30, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2025, 1, 15, 8, 0, 0, 0, moscow), schedule.Slot(domain.EmailDaily, moscow, now))
+	assert.Equal(t, time.Date(2025, 1, 14, 8, 0, 0, 0, time.UTC), schedule.Slot(domain.EmailDaily, time.UTC, now),
+		"08:00 has not come yet in UTC")
+	assert.Equal(t, time.Date(2025, 1, 13, 8, 0, 0, 0, moscow), schedule.Slot(domain.EmailWeekly, moscow, now))
+	assert.True(t, schedule.Slot(domain.EmailInstant, moscow, now).IsZero())
+	assert.True(t, schedule.Slot(domain.EmailOff, moscow, now).IsZero())
+}
+
+func TestSchedule_Due(t *testing.T) {
+	schedule := Schedule{Hour: 8, Weekday: time.Monday}
+	now := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+	slot := time.Date(2025, 1, 15, 8, 0, 0, 0, time.UTC)
+
+	due, since := schedule.Due(domain.EmailDaily, time.UTC, nil, now)
+	assert.True(t, due)
+	assert.Equal(t, slot.AddDate(0, 0, -1), since, "a first digest covers one period")
+
+	lastSent := slot.Add(-23 * time.Hour)
+	due, since = schedule.Due(domain.EmailDaily, time.UTC, &lastSent, now)
+	assert.True(t, due)
+	assert.Equal(t, lastSent, since)
+
+	lastSent = slot.Add(time.Minute)
+	due, _ = schedule.Due(domain.EmailDaily, time.UTC, &lastSent, now)
+	assert.False(t, due, "already sent for this slot")
+
+	due, since = schedule.Due(domain.EmailWeekly, time.UTC, nil, now)
+	assert.True(t, due)
+	assert.Equal(t, time.Date(2025, 1, 6, 8, 0, 0, 0, time.UTC), since)
+
+	due, _ = schedule.Due(domain.EmailInstant, time.UTC, nil, now)
+	assert.False(t, due)
+}
+
+func TestExcerpt(t *testing.T) {
+	assert.Equal(t, "short text", Excerpt("  short\n\ttext ", 20))
+	assert.Equal(t, "Paris is the…", Excerpt("Paris is the capital of France", 14))
+	assert.Equal(t, "Пари…", Excerpt("Парижский", 4))
+}
+
+func TestRenderDigest(t *testing.T) {
+	created := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	question := NewQuestion(&domain.Question{
+		ID:             7,
+		Text:           "What is <b>the</b> capital of France?",
+		QuestionStatus: domain.QuestionStatus{Status: domain.QuestionClosed},
+		Answers:        []domain.Answer{{UserID: "u1", Text: "Paris", CreatedAt: created}},
+	}, "https://example.com/", map[string]string{"u1": "Alice"}, time.UTC)
+	question.StatusChanged = true
+
+	msg, err := RenderDigest(&Digest{
+		Name:        "Bob",
+		Frequency:   domain.EmailDaily,
+		Since:       created.Add(-24 * time.Hour),
+		Until:       created.Add(time.Hour),
+		Questions:   []Question{question},
+		SettingsURL: "https://example.com/settings/notifications",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Your daily digest: activity on 1 watched question", msg.Subject)
+	assert.Contains(t, msg.Text, "https://example.com/questions/7")
+	assert.Contains(t, msg.Text, "Alice answered on 15 Jan 2025 10:00:")
+	assert.Contains(t, msg.Text, "The question is now closed.")
+	assert.Contains(t, msg.HTML, `<a href="https://example.com/questions/7">What is &lt;b&gt;the&lt;/b&gt; capital of France?</a>`)
+	assert.NotContains(t, msg.HTML, "<b>the</b>")
+}
+
+func TestRenderAnswer(t *testing.T) {
+	question := NewQuestion(&domain.Question{
+		ID:      7,
+		Text:    "What is the capital of France?",
+		Answers: []domain.Answer{{UserID: "u1", Text: "Paris"}},
+	}, "https://example.com", map[string]string{"u1": "Alice"}, time.UTC)
+
+	msg, err := RenderAnswer(&AnswerEmail{Name: "Bob", Question: question, Answer: question.Answers[0]})
+	require.NoError(t, err)
+
+	assert.Equal(t, `New answer to "What is the capital of France?"`, msg.Subject)
+	assert.Contains(t, msg.Text, "Alice answered a question you watch")
+	assert.Contains(t, msg.HTML, "<blockquote>Paris</blockquote>")
+}
@@ -0,0 +1,54 @@
+package digest
+
+import (
+	"time"
+
+	"hitalent-test/internal/domain"
+)
+
+// Schedule says when digests go out: every day at Hour in the recipient's
+// time zone, and on Weekday for weekly digests.
+type Schedule struct {
+	Hour    int
+	Weekday time.Weekday
+}
+
+// Slot returns the latest time at or before now when a digest of the given
+// frequency was scheduled in loc. It is the zero time for frequencies that
+// have no digest.
+func (s Schedule) Slot(frequency string, loc *time.Location, now time.Time) time.Time {
+	local := now.In(loc)
+	slot := time.Date(local.Year(), local.Month(), local.Day(), s.Hour, 0, 0, 0, loc)
+	if slot.After(local) {
+		slot = slot.AddDate(0, 0, -1)
+	}
+
+	switch frequency {
+	case domain.EmailDaily:
+		return slot
+	case domain.EmailWeekly:
+		back := (int(slot.Weekday()) - int(s.Weekday) + 7) % 7
+		return slot.AddDate(0, 0, -back)
+	default:
+		return time.Time{}
+	}
+}
+
+// Due reports whether a user who last got a digest at lastSent, if ever,
+// should get one now. It returns the start of the period the digest
+// covers.
+func (s Schedule) Due(frequency string, loc *time.Location, lastSent *time.Time, now time.Time) (bool, time.Time) {
+	slot := s.Slot(frequency, loc, now)
+	if slot.IsZero() {
+		return false, time.Time{}
+	}
+	if lastSent != nil {
+		return lastSent.Before(slot), *lastSent
+	}
+
+	since := slot.AddDate(0, 0, -1)
+	if frequency == domain.EmailWeekly {
+		since = slot.AddDate(0, 0, -7)
+	}
+	return true, since
+}
@@ -0,0 +1,59 @@
+package domain
+
+import "time"
+
+// Policies for the questions and answers of an erased account.
+const (
+	ContentPolicyAnonymize = "anonymize"
+	ContentPolicyDelete    = "delete"
+)
+
+const (
+	ExportFormatJSON = "json"
+	ExportFormatZIP  = "zip"
+)
+
+const (
+	ExportStatusPending = "pending"
+	ExportStatusReady   = "ready"
+	ExportStatusFailed  = "failed"
+)
+
+// DataExport is an archive of a user's personal data, built in the
+// background and downloadable until it expires.
+type DataExport struct {
+	ID          string     `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID      string     `gorm:"type:uuid;not null;index" json:"-"`
+	Format      string     `gorm:"type:varchar(8);not null" json:"format"`
+	Status      string     `gorm:"type:varchar(16);not null" json:"status"`
+	Data        []byte     `json:"-"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+func (DataExport) TableName() string {
+	return "data_exports"
+}
+
+// Session is a refresh token issued to the user.
+type Session struct {
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PersonalData is the content of a data export.
+type PersonalData struct {
+	ExportedAt   time.Time             `json:"exported_at"`
+	Profile      *User                 `json:"profile"`
+	Questions    []Question            `json:"questions"`
+	Answers      []Answer              `json:"answers"`
+	Sessions     []Session             `json:"sessions"`
+	Identities   []UserIdentity        `json:"identities"`
+	AccessTokens []PersonalAccessToken `json:"access_tokens"`
+}
+
+// AccountDeletion reports when a scheduled deletion takes effect.
+type AccountDeletion struct {
+	ScheduledAt time.Time `json:"deletion_scheduled_at"`
+}
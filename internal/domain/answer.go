@@ -3,11 +3,25 @@ package domain
 import "time"
 
 type Answer struct {
-	ID         uint      `gorm:"primaryKey" json:"id"`
-	QuestionID uint      `gorm:"not null;index" json:"question_id"`
-	UserID     string    `gorm:"type:varchar(255);not null;index" json:"user_id"`
-	Text       string    `gorm:"type:text;not null" json:"text"`
-	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	ID         uint         `gorm:"primaryKey" json:"id"`
+	QuestionID uint         `gorm:"not null;index" json:"question_id"`
+	UserID     string       `gorm:"type:varchar(255);not null;index" json:"-"`
+	Author     *UserSummary `gorm:"-" json:"author,omitempty"`
+	Text       string       `gorm:"type:text;not null" json:"text"`
+	Accepted   bool         `gorm:"-" json:"accepted,omitempty"`
+	HiddenAt   *time.Time   `json:"-"`
+	// PendingReview is set in the response to a post that the content
+	// filter held for moderation.
+	PendingReview bool      `gorm:"-" json:"pending_review,omitempty"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+	Mentions      []Mention `gorm:"-" json:"mentions,omitempty"`
+	// TextHTML is Text rendered from Markdown and sanitized. It is empty
+	// for answers stored before rendering was introduced.
+	TextHTML    string       `gorm:"column:text_html;type:text;not null;default:''" json:"-"`
+	Attachments []Attachment `gorm:"foreignKey:AnswerID" json:"attachments,omitempty"`
+	// ExternalID identifies answers created by a bulk import within their
+	// source, so that importing the same file again updates them.
+	ExternalID *string `gorm:"type:varchar(255)" json:"-"`
 }
 
 func (Answer) TableName() string {
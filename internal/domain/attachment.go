@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"io"
+	"time"
+)
+
+// Attachment is a file uploaded to a question or an answer. Exactly one of
+// QuestionID and AnswerID is set. The content is kept in blob storage under
+// a key derived from Checksum, so identical files are stored once.
+type Attachment struct {
+	ID         uint    `gorm:"primaryKey" json:"id"`
+	QuestionID *uint   `json:"question_id,omitempty"`
+	AnswerID   *uint   `json:"answer_id,omitempty"`
+	UserID     *string `gorm:"type:uuid" json:"-"`
+	Filename   string  `gorm:"type:varchar(255);not null" json:"filename"`
+	// ContentType is detected from the content, not taken from the client.
+	ContentType string `gorm:"type:varchar(255);not null" json:"content_type"`
+	Size        int64  `gorm:"not null" json:"size"`
+	// Checksum is the hex-encoded SHA-256 of the content.
+	Checksum string `gorm:"type:char(64);not null" json:"checksum"`
+	// Width and Height are set for images.
+	Width     *int      `json:"width,omitempty"`
+	Height    *int      `json:"height,omitempty"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (Attachment) TableName() string {
+	return "attachments"
+}
+
+// IsImage reports whether the attachment is shown inline by browsers.
+func (a *Attachment) IsImage() bool {
+	return a.Width != nil
+}
+
+// Upload is a file received from a client. Content must be positioned at
+// its start.
+type Upload struct {
+	Filename string
+	Content  io.ReadSeeker
+}
@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audited actions.
+const (
+	AuditLogin           = "auth.login"
+	AuditLoginFailed     = "auth.login_failed"
+	AuditRegister        = "auth.register"
+	AuditUnlock          = "auth.unlock"
+	AuditPasswordChanged = "user.password_changed"
+	AuditPasswordReset   = "user.password_reset"
+	AuditRoleChanged     = "user.role_changed"
+	AuditDeletionRequest = "user.deletion_requested"
+	AuditDeletionCancel  = "user.deletion_cancelled"
+	AuditUserErased      = "user.erased"
+	AuditTokenRevoked    = "token.revoked"
+	AuditQuestionDeleted = "question.deleted"
+	AuditAnswerDeleted   = "answer.deleted"
+	AuditQuestionLocked  = "question.locked"
+	AuditQuestionStatus  = "question.status_changed"
+	AuditContentHidden   = "moderation.hidden"
+	AuditFlagsDismissed  = "moderation.dismissed"
+	AuditUserWarned      = "user.warned"
+	AuditDataImported    = "data.imported"
+)
+
+// Kinds of audited objects.
+const (
+	AuditTargetUser     = "user"
+	AuditTargetQuestion = "question"
+	AuditTargetAnswer   = "answer"
+	AuditTargetToken    = "token"
+	AuditTargetImport   = "import"
+)
+
+// Kinds of actors. Anonymous actors are unauthenticated clients; system
+// actions come from background jobs and the CLI.
+const (
+	ActorUser      = "user"
+	ActorAnonymous = "anonymous"
+	ActorSystem    = "system"
+)
+
+// RequestMeta describes who performed an action and from where. Services
+// that record audit entries take it from the handler.
+type RequestMeta struct {
+	ActorID   string
+	IP        string
+	UserAgent string
+	RequestID string
+	// System marks actions not caused by a client request.
+	System bool
+}
+
+// SystemMeta is the RequestMeta of background jobs and CLI commands.
+func SystemMeta(source string) RequestMeta {
+	return RequestMeta{UserAgent: source, System: true}
+}
+
+// AuditEntry is an immutable record of a security- or moderation-relevant
+// action. Before and After are JSON snapshots of the target.
+type AuditEntry struct {
+	ID         int64           `gorm:"primaryKey" json:"id"`
+	ActorType  string          `gorm:"type:varchar(16);not null" json:"actor_type"`
+	ActorID    *string         `gorm:"type:uuid" json:"actor_id,omitempty"`
+	Action     string          `gorm:"type:varchar(64);not null" json:"action"`
+	TargetType string          `gorm:"type:varchar(32);not null" json:"target_type"`
+	TargetID   string          `gorm:"type:varchar(255);not null" json:"target_id"`
+	IP         string          `gorm:"type:varchar(64);not null" json:"ip,omitempty"`
+	UserAgent  string          `gorm:"type:varchar(512);not null" json:"user_agent,omitempty"`
+	RequestID  string          `gorm:"type:varchar(64);not null" json:"request_id,omitempty"`
+	Before     json.RawMessage `gorm:"type:jsonb;serializer:json" json:"before,omitempty"`
+	After      json.RawMessage `gorm:"type:jsonb;serializer:json" json:"after,omitempty"`
+	CreatedAt  time.Time       `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (AuditEntry) TableName() string {
+	return "audit_log"
+}
+
+// AuditFilter selects audit entries; empty fields match everything.
+// Entries are returned newest first, starting below Cursor if it is set.
+type AuditFilter struct {
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	From       *time.Time
+	To         *time.Time
+	Cursor     int64
+	Limit      int
+}
+
+// AuditPage is a page of audit entries. NextCursor is passed as cursor to
+// get the following page; it is absent on the last page.
+type AuditPage struct {
+	Entries    []AuditEntry `json:"entries"`
+	NextCursor *int64       `json:"next_cursor,omitempty"`
+}
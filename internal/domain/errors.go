@@ -1,9 +1,57 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var (
-	ErrQuestionNotFound = errors.New("question not found")
-	ErrAnswerNotFound   = errors.New("answer not found")
-	ErrInvalidInput     = errors.New("invalid input data")
+	ErrQuestionNotFound     = errors.New("question not found")
+	ErrAnswerNotFound       = errors.New("answer not found")
+	ErrUserNotFound         = errors.New("user not found")
+	ErrTokenNotFound        = errors.New("token not found")
+	ErrExportNotFound       = errors.New("export not found")
+	ErrNotificationNotFound = errors.New("notification not found")
+	ErrAttachmentNotFound   = errors.New("attachment not found")
+	ErrCheckpointNotFound   = errors.New("import checkpoint not found")
+	ErrInvalidInput         = errors.New("invalid input data")
+	ErrUnauthorized         = errors.New("unauthorized")
+	ErrForbidden            = errors.New("forbidden")
+	ErrTooManyRequests      = errors.New("too many requests")
+	ErrQuestionClosed       = errors.New("question is not open for answers")
+	ErrSimilarQuestions     = errors.New("similar questions already exist")
+	ErrFileTooLarge         = errors.New("file too large")
+	ErrBodyTooLarge         = errors.New("request body too large")
+	ErrUnsupportedFileType  = errors.New("unsupported file type")
+	ErrPreconditionFailed   = errors.New("resource has changed")
+	ErrPreconditionRequired = errors.New("If-Match header is required")
 )
+
+// RetryAfterError is returned when a request is refused for a limited time.
+// It matches ErrTooManyRequests with errors.Is.
+type RetryAfterError struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return ErrTooManyRequests.Error() + ": " + e.Reason
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return ErrTooManyRequests
+}
+
+// SimilarQuestionsError is returned when a new question resembles existing
+// ones. It matches ErrSimilarQuestions with errors.Is.
+type SimilarQuestionsError struct {
+	Questions []SimilarQuestion
+}
+
+func (e *SimilarQuestionsError) Error() string {
+	return ErrSimilarQuestions.Error()
+}
+
+func (e *SimilarQuestionsError) Unwrap() error {
+	return ErrSimilarQuestions
+}
@@ -0,0 +1,87 @@
+package domain
+
+import "time"
+
+// Kinds of content that can be flagged.
+const (
+	ContentQuestion = "question"
+	ContentAnswer   = "answer"
+)
+
+// Reasons a user may give for flagging content.
+const (
+	FlagReasonSpam      = "spam"
+	FlagReasonAbuse     = "abuse"
+	FlagReasonOffTopic  = "off_topic"
+	FlagReasonDuplicate = "duplicate"
+	FlagReasonOther     = "other"
+)
+
+var FlagReasons = []string{FlagReasonSpam, FlagReasonAbuse, FlagReasonOffTopic, FlagReasonDuplicate, FlagReasonOther}
+
+// FlagReasonFilter marks flags raised by the content filter for content it
+// held. Users cannot choose it.
+const FlagReasonFilter = "filter"
+
+// A flag is open until a moderator dismisses it or acts on the content.
+const (
+	FlagStatusOpen      = "open"
+	FlagStatusDismissed = "dismissed"
+	FlagStatusActioned  = "actioned"
+)
+
+// Moderator decisions on flagged content.
+const (
+	ModerationDismiss = "dismiss"
+	ModerationDelete  = "delete"
+	ModerationLock    = "lock"
+	ModerationWarn    = "warn"
+)
+
+// Flag is a user's report of a question or an answer. A user can flag the
+// same item only once. Flags raised by the content filter have no
+// reporter.
+type Flag struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	TargetType string     `gorm:"type:varchar(16);not null" json:"target_type"`
+	TargetID   uint       `gorm:"not null" json:"target_id"`
+	ReporterID *string    `gorm:"type:uuid" json:"reporter_id,omitempty"`
+	Reason     string     `gorm:"type:varchar(32);not null" json:"reason"`
+	Comment    string     `gorm:"type:text;not null;default:''" json:"comment,omitempty"`
+	Status     string     `gorm:"type:varchar(16);not null;default:open" json:"status"`
+	ResolvedBy *string    `gorm:"type:uuid" json:"resolved_by,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (Flag) TableName() string {
+	return "flags"
+}
+
+// ModerationItem is a flagged question or answer in the moderation queue,
+// with its open flags counted by reason.
+type ModerationItem struct {
+	TargetType     string         `json:"target_type"`
+	TargetID       uint           `json:"target_id"`
+	FlagCount      int            `json:"flag_count"`
+	Reasons        map[string]int `json:"reasons"`
+	FirstFlaggedAt time.Time      `json:"first_flagged_at"`
+	LastFlaggedAt  time.Time      `json:"last_flagged_at"`
+	// Text and Author are empty if the content no longer exists.
+	Text   string       `json:"text"`
+	Author *UserSummary `json:"author,omitempty"`
+	Hidden bool         `json:"hidden"`
+	Locked bool         `json:"locked,omitempty"`
+}
+
+type CreateFlagRequest struct {
+	Reason  string `json:"reason"`
+	Comment string `json:"comment"`
+}
+
+// ModerationActionRequest is a moderator's decision on a queue item. Note
+// is passed on to the author.
+type ModerationActionRequest struct {
+	Action string `json:"action"`
+	Note   string `json:"note"`
+}
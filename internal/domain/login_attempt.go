@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	LockoutActionLock   = "lock"
+	LockoutActionUnlock = "unlock"
+)
+
+// AccountLoginKeyPrefix starts the login keys of accounts.
+const AccountLoginKeyPrefix = "account:"
+
+// AccountLoginKey is the login key of the account with the given email.
+func AccountLoginKey(email string) string {
+	return AccountLoginKeyPrefix + strings.ToLower(strings.TrimSpace(email))
+}
+
+// LoginFailure counts recent failed logins for a key, which is either an
+// account ("account:<email>") or a client IP ("ip:<addr>").
+type LoginFailure struct {
+	Key           string     `gorm:"type:varchar(320);primaryKey" json:"key"`
+	Failures      int        `gorm:"not null;default:0" json:"failures"`
+	LastFailureAt time.Time  `gorm:"not null" json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`
+}
+
+func (LoginFailure) TableName() string {
+	return "login_failures"
+}
+
+// LockoutEvent records every lock and unlock of a login key.
+type LockoutEvent struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Action      string     `gorm:"type:varchar(16);not null" json:"action"`
+	Key         string     `gorm:"type:varchar(320);not null;index" json:"key"`
+	UserID      *string    `gorm:"type:uuid" json:"user_id,omitempty"`
+	ActorID     *string    `gorm:"type:uuid" json:"actor_id,omitempty"`
+	IP          string     `gorm:"type:varchar(64)" json:"ip,omitempty"`
+	Failures    int        `json:"failures"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (LockoutEvent) TableName() string {
+	return "lockout_events"
+}
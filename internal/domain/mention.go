@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// Mention records that a question or an answer mentions a user with
+// @handle. Exactly one of QuestionID and AnswerID is set. Handle is written
+// as in the text, so clients can link it to the user.
+type Mention struct {
+	ID         uint         `gorm:"primaryKey" json:"-"`
+	QuestionID *uint        `json:"-"`
+	AnswerID   *uint        `json:"-"`
+	UserID     string       `gorm:"type:uuid;not null" json:"-"`
+	Handle     string       `gorm:"type:varchar(64);not null" json:"handle"`
+	User       *UserSummary `gorm:"-" json:"user,omitempty"`
+	CreatedAt  time.Time    `gorm:"autoCreateTime" json:"-"`
+}
+
+func (Mention) TableName() string {
+	return "mentions"
+}
@@ -0,0 +1,67 @@
+package domain
+
+import "time"
+
+// Kinds of notifications.
+const (
+	// NotificationAnswer tells the author of a question about a new answer.
+	NotificationAnswer = "answer.created"
+	// NotificationAccepted tells the author of an answer that it was
+	// accepted.
+	NotificationAccepted = "answer.accepted"
+	// NotificationModeration tells an author about a moderator decision on
+	// their content.
+	NotificationModeration = "moderation"
+	// NotificationMention tells a user that a post mentions them.
+	NotificationMention = "mention"
+)
+
+var NotificationTypes = []string{NotificationAnswer, NotificationAccepted, NotificationModeration, NotificationMention}
+
+// Notification is an entry in a user's inbox. ActorID is the user who
+// caused it, if any; QuestionID and AnswerID point to the content it is
+// about.
+type Notification struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     string     `gorm:"type:uuid;not null;index" json:"-"`
+	Type       string     `gorm:"type:varchar(32);not null" json:"type"`
+	ActorID    *string    `gorm:"type:uuid" json:"actor_id,omitempty"`
+	QuestionID *uint      `json:"question_id,omitempty"`
+	AnswerID   *uint      `json:"answer_id,omitempty"`
+	Message    string     `gorm:"type:text;not null" json:"message"`
+	ReadAt     *time.Time `json:"read_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// NotificationPreference turns one kind of notification on or off for a
+// user. Kinds without a stored preference are enabled.
+type NotificationPreference struct {
+	UserID  string `gorm:"type:uuid;primaryKey"`
+	Type    string `gorm:"type:varchar(32);primaryKey"`
+	Enabled bool   `gorm:"not null"`
+}
+
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
+
+// NotificationFilter selects a user's notifications, newest first,
+// starting below Cursor if it is set.
+type NotificationFilter struct {
+	UserID     string
+	UnreadOnly bool
+	Cursor     uint
+	Limit      int
+}
+
+// NotificationPage is a page of notifications with the user's total unread
+// count. NextCursor is absent on the last page.
+type NotificationPage struct {
+	Notifications []Notification `json:"notifications"`
+	NextCursor    *uint          `json:"next_cursor,omitempty"`
+	UnreadCount   int64          `json:"unread_count"`
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+// PersonalAccessTokenPrefix starts every personal access token, so that
+// middleware can tell them from JWTs and leaked tokens are easy to find.
+const PersonalAccessTokenPrefix = "qa_pat_"
+
+const (
+	ScopeRead           = "read"
+	ScopeWriteAnswers   = "write:answers"
+	ScopeWriteQuestions = "write:questions"
+	ScopeModerate       = "moderate"
+	ScopeAdmin          = "admin"
+)
+
+var Scopes = []string{ScopeRead, ScopeWriteAnswers, ScopeWriteQuestions, ScopeModerate, ScopeAdmin}
+
+func IsValidScope(scope string) bool {
+	return slices.Contains(Scopes, scope)
+}
+
+// PersonalAccessToken is a long-lived token for scripts and bots. Only a
+// SHA-256 hash of the token is stored; the plaintext is shown once.
+type PersonalAccessToken struct {
+	ID         string     `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID     string     `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name       string     `gorm:"type:varchar(100);not null" json:"name"`
+	Scopes     []string   `gorm:"type:jsonb;serializer:json;not null" json:"scopes"`
+	TokenHash  string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (PersonalAccessToken) TableName() string {
+	return "personal_access_tokens"
+}
+
+func (t *PersonalAccessToken) HasScope(scope string) bool {
+	return slices.Contains(t.Scopes, scope)
+}
+
+// CreatedPersonalAccessToken is returned once, at creation, with the
+// plaintext token.
+type CreatedPersonalAccessToken struct {
+	Token string `json:"token"`
+	*PersonalAccessToken
+}
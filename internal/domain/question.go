@@ -2,13 +2,97 @@ package domain
 
 import "time"
 
+// Question statuses. Only open questions accept new answers.
+const (
+	QuestionOpen      = "open"
+	QuestionClosed    = "closed"
+	QuestionLocked    = "locked"
+	QuestionDuplicate = "duplicate"
+)
+
+var QuestionStatuses = []string{QuestionOpen, QuestionClosed, QuestionLocked, QuestionDuplicate}
+
+// Reasons for closing a question.
+const (
+	CloseAnswered = "answered"
+	CloseOffTopic = "off_topic"
+	CloseUnclear  = "unclear"
+	CloseTooBroad = "too_broad"
+	CloseOther    = "other"
+)
+
+var CloseReasons = []string{CloseAnswered, CloseOffTopic, CloseUnclear, CloseTooBroad, CloseOther}
+
 type Question struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	Text      string    `gorm:"type:text;not null" json:"text"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
-	Answers   []Answer  `gorm:"foreignKey:QuestionID;constraint:OnDelete:CASCADE" json:"answers,omitempty"`
+	ID uint `gorm:"primaryKey" json:"id"`
+	// UserID is nil for questions asked anonymously.
+	UserID           *string      `gorm:"type:uuid;index" json:"-"`
+	Author           *UserSummary `gorm:"-" json:"author,omitempty"`
+	Text             string       `gorm:"type:text;not null" json:"text"`
+	AcceptedAnswerID *uint        `json:"accepted_answer_id,omitempty"`
+	QuestionStatus   `gorm:"embedded"`
+	// HiddenAt is set while the question is hidden for collecting too many
+	// flags.
+	HiddenAt *time.Time `json:"-"`
+	// PendingReview is set in the response to a post that the content
+	// filter held for moderation.
+	PendingReview bool      `gorm:"-" json:"pending_review,omitempty"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+	Answers       []Answer  `gorm:"foreignKey:QuestionID;constraint:OnDelete:CASCADE" json:"answers,omitempty"`
+	Mentions      []Mention `gorm:"-" json:"mentions,omitempty"`
+	// Related lists similar questions when a single question is shown.
+	Related []SimilarQuestion `gorm:"-" json:"related,omitempty"`
+	// TextHTML is Text rendered from Markdown and sanitized. It is empty
+	// for questions stored before rendering was introduced.
+	TextHTML    string       `gorm:"column:text_html;type:text;not null;default:''" json:"-"`
+	Attachments []Attachment `gorm:"foreignKey:QuestionID" json:"attachments,omitempty"`
+	// ExternalID identifies questions created by a bulk import within their
+	// source, so that importing the same file again updates them.
+	ExternalID *string `gorm:"type:varchar(255)" json:"-"`
 }
 
 func (Question) TableName() string {
 	return "questions"
 }
+
+// QuestionStatus says whether a question accepts answers. CloseReason is
+// set for closed questions and DuplicateOfID for duplicates.
+type QuestionStatus struct {
+	Status          string     `gorm:"type:varchar(16);not null;default:open" json:"status"`
+	CloseReason     string     `gorm:"type:varchar(32);not null" json:"close_reason,omitempty"`
+	DuplicateOfID   *uint      `json:"duplicate_of_id,omitempty"`
+	StatusChangedBy *string    `gorm:"type:uuid" json:"-"`
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty"`
+}
+
+// AcceptsAnswers reports whether new answers may be posted. Questions
+// stored before statuses existed have an empty status and are open.
+func (s QuestionStatus) AcceptsAnswers() bool {
+	return s.Status == QuestionOpen || s.Status == ""
+}
+
+// Formats in which GET endpoints return the text of questions and
+// answers: the Markdown source, sanitized HTML or text without markup.
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatPlain    = "plain"
+)
+
+var TextFormats = []string{FormatMarkdown, FormatHTML, FormatPlain}
+
+// QuestionFilter selects questions for the list; empty fields match
+// everything.
+type QuestionFilter struct {
+	Status string
+}
+
+// SimilarQuestion is a question whose text resembles another one. Score is
+// the trigram similarity, between 0 and 1.
+type SimilarQuestion struct {
+	ID        uint      `json:"id"`
+	Text      string    `json:"text"`
+	Status    string    `json:"status"`
+	Score     float64   `json:"score"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// RecoveryCode is a one-time code that replaces a TOTP code when the
+// authenticator is unavailable. Only a SHA-256 hash of the code is stored.
+type RecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    string     `gorm:"type:uuid;not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"type:varchar(64);not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (RecoveryCode) TableName() string {
+	return "recovery_codes"
+}
@@ -1,7 +1,35 @@
 package domain
 
+import "time"
+
 type CreateQuestionRequest struct {
-	Text string `json:"text"`
+	// UserID is set from the authenticated user, if any.
+	UserID string `json:"-"`
+	Text   string `json:"text"`
+	// Force creates the question even if similar ones exist.
+	Force bool `json:"force,omitempty"`
+}
+
+// AcceptAnswerRequest marks an answer as accepted; a null AnswerID clears
+// the accepted answer.
+type AcceptAnswerRequest struct {
+	AnswerID *uint `json:"answer_id"`
+}
+
+// UpdateQuestionStatusRequest changes a question's status. Reason is
+// required when closing and DuplicateOfID when marking a duplicate.
+type UpdateQuestionStatusRequest struct {
+	Status        string `json:"status"`
+	Reason        string `json:"reason,omitempty"`
+	DuplicateOfID *uint  `json:"duplicate_of_id,omitempty"`
+}
+
+// UpdateProfileRequest changes only the fields that are present.
+type UpdateProfileRequest struct {
+	DisplayName *string       `json:"display_name"`
+	Bio         *string       `json:"bio"`
+	AvatarURL   *string       `json:"avatar_url"`
+	Settings    *UserSettings `json:"settings"`
 }
 
 type CreateAnswerRequest struct {
@@ -19,6 +47,52 @@ type LoginRequest struct {
 	Password string `json:"password"`
 }
 
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+type TOTPConfirmRequest struct {
+	Code string `json:"code"`
+}
+
+// ReauthRequest re-authenticates a signed-in user with two factors before a
+// sensitive change. Code is a TOTP code or a recovery code. Account deletion
+// also accepts a recent sign-in through a linked identity provider in place
+// of the password.
+type ReauthRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+type CreatePersonalAccessTokenRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
 type RefreshRequest struct {
 	RefreshToken string `json:"refresh_token"`
 }
@@ -28,3 +102,21 @@ type AuthResponse struct {
 	RefreshToken string `json:"refresh_token"`
 	User         *User  `json:"user"`
 }
+
+// MFAChallenge is returned by login instead of tokens when the account has
+// two-factor authentication enabled. MFAToken is exchanged together with a
+// code at POST /auth/login/mfa.
+type MFAChallenge struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
@@ -0,0 +1,101 @@
+package domain
+
+import "time"
+
+// Formats of bulk exports and imports: one JSON question per line with its
+// answers nested, or CSV with a row per question followed by rows for its
+// answers.
+const (
+	TransferJSONL = "jsonl"
+	TransferCSV   = "csv"
+)
+
+var TransferFormats = []string{TransferJSONL, TransferCSV}
+
+// TransferQuestion is a question as written by a bulk export and read by a
+// bulk import. IDs are those of the source; authors are identified by
+// email, and an empty email stands for an anonymous or erased author.
+type TransferQuestion struct {
+	ID               uint             `json:"id"`
+	AuthorEmail      string           `json:"author_email,omitempty"`
+	Text             string           `json:"text"`
+	Status           string           `json:"status,omitempty"`
+	CloseReason      string           `json:"close_reason,omitempty"`
+	DuplicateOfID    *uint            `json:"duplicate_of_id,omitempty"`
+	AcceptedAnswerID *uint            `json:"accepted_answer_id,omitempty"`
+	Hidden           bool             `json:"hidden,omitempty"`
+	CreatedAt        time.Time        `json:"created_at"`
+	Answers          []TransferAnswer `json:"answers"`
+}
+
+type TransferAnswer struct {
+	ID          uint      `json:"id"`
+	AuthorEmail string    `json:"author_email,omitempty"`
+	Text        string    `json:"text"`
+	Hidden      bool      `json:"hidden,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ImportOptions controls a bulk import. Records are matched to the posts
+// of earlier imports from the same Source by their IDs, so importing a file
+// again updates what it created instead of duplicating it. A dry run
+// validates the file and reports what would change without writing.
+type ImportOptions struct {
+	Format string
+	Source string
+	DryRun bool
+}
+
+// ImportReport summarizes a bulk import. Records with errors are skipped;
+// warnings describe data that was adjusted, such as unknown authors.
+type ImportReport struct {
+	DryRun           bool          `json:"dry_run"`
+	QuestionsCreated int           `json:"questions_created"`
+	QuestionsUpdated int           `json:"questions_updated"`
+	AnswersCreated   int           `json:"answers_created"`
+	AnswersUpdated   int           `json:"answers_updated"`
+	Skipped          int           `json:"skipped"`
+	Errors           []ImportIssue `json:"errors"`
+	Warnings         []ImportIssue `json:"warnings"`
+	// UsersCreated counts the placeholder users of StackExchange imports.
+	UsersCreated int `json:"users_created,omitempty"`
+}
+
+// ImportIssue points at a record of the imported file by its line number
+// and source ID.
+type ImportIssue struct {
+	Line    int    `json:"line"`
+	ID      uint   `json:"id,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportReference sets the links of an imported question to other posts
+// once all of them have IDs.
+type ImportReference struct {
+	QuestionID       uint
+	AcceptedAnswerID *uint
+	DuplicateOfID    *uint
+}
+
+// Phases of a StackExchange import, in order: placeholder users are created
+// from Users.xml, then questions and answers from Posts.xml, and finally
+// accepted answers are linked in a second pass over Posts.xml.
+const (
+	ImportPhaseUsers      = "users"
+	ImportPhasePosts      = "posts"
+	ImportPhaseReferences = "references"
+)
+
+// ImportCheckpoint records how many rows of the file of its phase an import
+// from Source has saved, so that an interrupted import can resume after
+// them.
+type ImportCheckpoint struct {
+	Source    string `gorm:"type:varchar(64);primaryKey"`
+	Phase     string `gorm:"type:varchar(32);not null"`
+	RowsDone  int64  `gorm:"not null;default:0"`
+	UpdatedAt time.Time
+}
+
+func (ImportCheckpoint) TableName() string {
+	return "import_checkpoints"
+}
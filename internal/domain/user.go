@@ -2,11 +2,139 @@ package domain
 
 import "time"
 
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// DeletedUserID is the placeholder account that keeps the content of erased
+// users under the "anonymize" policy.
+const DeletedUserID = "00000000-0000-0000-0000-000000000000"
+
 type User struct {
-	ID           string    `gorm:"type:uuid;primaryKey" json:"id"`
-	Email        string    `gorm:"type:varchar(255);uniqueIndex;not null" json:"email"`
-	PasswordHash string    `gorm:"type:varchar(255);not null" json:"-"`
-	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+	ID              string       `gorm:"type:uuid;primaryKey" json:"id"`
+	Email           string       `gorm:"type:varchar(255);uniqueIndex;not null" json:"email"`
+	PasswordHash    string       `gorm:"type:varchar(255);not null" json:"-"`
+	Role            string       `gorm:"type:varchar(32);not null;default:user" json:"role"`
+	DisplayName     string       `gorm:"type:varchar(64);not null;default:''" json:"display_name"`
+	Bio             string       `gorm:"type:text;not null;default:''" json:"bio"`
+	AvatarURL       string       `gorm:"type:varchar(2048);not null;default:''" json:"avatar_url"`
+	Settings        UserSettings `gorm:"type:jsonb;serializer:json;not null" json:"settings"`
+	EmailVerified   bool         `gorm:"not null;default:false" json:"email_verified"`
+	EmailVerifiedAt *time.Time   `json:"email_verified_at,omitempty"`
+	// TOTPSecret is set on enrollment and only takes effect once confirmed
+	// with a first code, which sets TOTPEnabled.
+	TOTPSecret    string     `gorm:"column:totp_secret;type:varchar(64);not null;default:''" json:"-"`
+	TOTPEnabled   bool       `gorm:"column:totp_enabled;not null;default:false" json:"totp_enabled"`
+	TOTPEnabledAt *time.Time `gorm:"column:totp_enabled_at" json:"totp_enabled_at,omitempty"`
+	// TOTPLastStep is the time step of the last accepted code; codes of
+	// that step or earlier are rejected to prevent replay.
+	TOTPLastStep int64 `gorm:"column:totp_last_step;not null;default:0" json:"-"`
+	// DeletionScheduledAt is set while a deletion request may still be
+	// cancelled; the account is erased once it has passed.
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+	// DigestSentAt is when the last activity digest was mailed.
+	DigestSentAt *time.Time `json:"-"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// UserSettings are preferences that only the user sees.
+type UserSettings struct {
+	// Timezone is an IANA time zone name such as "Europe/Moscow".
+	Timezone string `json:"timezone,omitempty"`
+	// Locale is a language tag such as "en" or "ru-RU".
+	Locale string `json:"locale,omitempty"`
+	// EmailFrequency is one of EmailFrequencies; empty means
+	// DefaultEmailFrequency.
+	EmailFrequency string `json:"email_frequency,omitempty"`
+}
+
+// Frequency returns how often the user gets email about watched questions.
+func (s UserSettings) Frequency() string {
+	if s.EmailFrequency == "" {
+		return DefaultEmailFrequency
+	}
+	return s.EmailFrequency
+}
+
+// Location returns the user's time zone, or UTC if it is unset or unknown.
+func (s UserSettings) Location() *time.Location {
+	if s.Timezone != "" {
+		if loc, err := time.LoadLocation(s.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+// UserStats counts a user's activity.
+type UserStats struct {
+	QuestionsAsked  int64 `json:"questions_asked"`
+	AnswersGiven    int64 `json:"answers_given"`
+	AcceptedAnswers int64 `json:"accepted_answers"`
+}
+
+// UserProfile is the signed-in user's own view of the account.
+type UserProfile struct {
+	*User
+	Stats UserStats `json:"stats"`
+}
+
+// PublicUser is what anyone may see about a user; it has no email or
+// settings.
+type PublicUser struct {
+	ID          string    `json:"id"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	AvatarURL   string    `json:"avatar_url"`
+	Role        string    `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+	Stats       UserStats `json:"stats"`
+}
+
+// UserSummary identifies the author of a question or an answer.
+type UserSummary struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"display_name"`
+	AvatarURL   string `json:"avatar_url,omitempty"`
+}
+
+// PublicName is the display name, or a stable placeholder for users who
+// have not set one. The email is never used, as it is private.
+func (u *User) PublicName() string {
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+	id := u.ID
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	return "user-" + id
+}
+
+func (u *User) Summary() *UserSummary {
+	return &UserSummary{
+		ID:          u.ID,
+		DisplayName: u.PublicName(),
+		AvatarURL:   u.AvatarURL,
+	}
+}
+
+func (u *User) Public(stats UserStats) *PublicUser {
+	return &PublicUser{
+		ID:          u.ID,
+		DisplayName: u.PublicName(),
+		Bio:         u.Bio,
+		AvatarURL:   u.AvatarURL,
+		Role:        u.Role,
+		CreatedAt:   u.CreatedAt,
+		Stats:       stats,
+	}
+}
+
+func IsValidRole(role string) bool {
+	return role == RoleUser || role == RoleModerator || role == RoleAdmin
 }
 
 func (User) TableName() string {
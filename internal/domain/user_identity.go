@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// UserIdentity links a user to an account at an external identity provider.
+// A user may have identities at several providers.
+type UserIdentity struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	UserID      string    `gorm:"type:uuid;not null;index" json:"user_id"`
+	Provider    string    `gorm:"type:varchar(64);not null" json:"provider"`
+	Subject     string    `gorm:"type:varchar(255);not null" json:"subject"`
+	Email       string    `gorm:"type:varchar(255)" json:"email"`
+	LastLoginAt time.Time `gorm:"not null" json:"last_login_at"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+const (
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposePasswordReset     = "password_reset"
+	// TokenPurposeMFAChallenge marks the signed token that links the two
+	// steps of a login with two-factor authentication. It is not stored.
+	TokenPurposeMFAChallenge = "mfa_challenge"
+)
+
+// UserToken is a single-use token sent to a user by email. Only a SHA-256
+// hash of the token (or of its identifier, for signed tokens) is stored.
+type UserToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    string     `gorm:"type:uuid;not null;index" json:"user_id"`
+	Purpose   string     `gorm:"type:varchar(32);not null" json:"purpose"`
+	TokenHash string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (UserToken) TableName() string {
+	return "user_tokens"
+}
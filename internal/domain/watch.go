@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// How often a user gets email about activity on watched questions.
+const (
+	EmailOff     = "off"
+	EmailInstant = "instant"
+	EmailDaily   = "daily"
+	EmailWeekly  = "weekly"
+)
+
+var EmailFrequencies = []string{EmailOff, EmailInstant, EmailDaily, EmailWeekly}
+
+// DefaultEmailFrequency applies to users who have not chosen one.
+const DefaultEmailFrequency = EmailDaily
+
+// Watch makes a user follow the activity on a question. Users watch the
+// questions they ask and answer automatically.
+type Watch struct {
+	UserID     string    `gorm:"type:uuid;primaryKey" json:"-"`
+	QuestionID uint      `gorm:"primaryKey" json:"question_id"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (Watch) TableName() string {
+	return "question_watches"
+}
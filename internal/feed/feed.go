@@ -0,0 +1,156 @@
+// Package feed renders Atom and RSS 2.0 feeds. Entries carry the HTML of
+// the posts, which feed readers show as is, so it must already be
+// sanitized.
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+type Feed struct {
+	Title string
+	// ID identifies the feed permanently; it is also the link to the page
+	// that the feed follows.
+	ID      string
+	Updated time.Time
+	Entries []Entry
+}
+
+type Entry struct {
+	// ID is the permanent identifier of the entry, Link its page.
+	ID        string
+	Title     string
+	Link      string
+	Author    string
+	Published time.Time
+	Updated   time.Time
+	HTML      string
+}
+
+// Atom renders the feed as Atom.
+func (f *Feed) Atom() ([]byte, error) {
+	doc := atomFeed{
+		Xmlns:   "http://www.w3.org/2005/Atom",
+		Title:   f.Title,
+		ID:      f.ID,
+		Link:    atomLink{Rel: "alternate", Href: f.ID},
+		Updated: atomTime(f.Updated),
+	}
+	for _, e := range f.Entries {
+		entry := atomEntry{
+			ID:        e.ID,
+			Title:     e.Title,
+			Link:      atomLink{Rel: "alternate", Href: e.Link},
+			Published: atomTime(e.Published),
+			Updated:   atomTime(e.Updated),
+			Content:   atomContent{Type: "html", Body: e.HTML},
+		}
+		if e.Author != "" {
+			entry.Author = &atomAuthor{Name: e.Author}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return marshal(doc)
+}
+
+// RSS renders the feed as RSS 2.0.
+func (f *Feed) RSS() ([]byte, error) {
+	doc := rssDoc{
+		Version: "2.0",
+		XmlnsDC: "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.ID,
+			Description:   f.Title,
+			LastBuildDate: f.Updated.UTC().Format(time.RFC1123Z),
+		},
+	}
+	for _, e := range f.Entries {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       e.Title,
+			Link:        e.Link,
+			GUID:        rssGUID{IsPermaLink: "false", Value: e.ID},
+			PubDate:     e.Published.UTC().Format(time.RFC1123Z),
+			Creator:     e.Author,
+			Description: e.HTML,
+		})
+	}
+	return marshal(doc)
+}
+
+func atomTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func marshal(doc any) ([]byte, error) {
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Link    atomLink    `xml:"link"`
+	Updated string      `xml:"updated"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Link      atomLink    `xml:"link"`
+	Author    *atomAuthor `xml:"author,omitempty"`
+	Published string      `xml:"published"`
+	Updated   string      `xml:"updated"`
+	Content   atomContent `xml:"content"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type rssDoc struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	XmlnsDC string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title string  `xml:"title"`
+	Link  string  `xml:"link"`
+	GUID  rssGUID `xml:"guid"`
+	// RSS wants an email address in author, which is not public here.
+	Creator     string `xml:"dc:creator,omitempty"`
+	PubDate     string `xml:"pubDate"`
+	Description string `xml:"description"`
+}
+
+type rssGUID struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
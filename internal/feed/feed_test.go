@@ -0,0 +1,62 @@
+package feed
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testFeed() *Feed {
+	published := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	return &Feed{
+		Title:   "Questions",
+		ID:      "https://qa.example/questions",
+		Updated: published.Add(time.Hour),
+		Entries: []Entry{{
+			ID:        "https://qa.example/questions/7",
+			Title:     "Why <b>?",
+			Link:      "https://qa.example/questions/7",
+			Author:    "Alice",
+			Published: published,
+			Updated:   published.Add(time.Hour),
+			HTML:      "<p>Why &amp; how?</p>",
+		}},
+	}
+}
+
+func TestAtom(t *testing.T) {
+	body, err := testFeed().Atom()
+	require.NoError(t, err)
+
+	var doc atomFeed
+	require.NoError(t, xml.Unmarshal(body, &doc))
+	assert.Equal(t, "http://www.w3.org/2005/Atom", doc.XMLName.Space)
+	assert.Equal(t, "2024-03-01T10:00:00Z", doc.Updated)
+	require.Len(t, doc.Entries, 1)
+	entry := doc.Entries[0]
+	assert.Equal(t, "Why <b>?", entry.Title)
+	assert.Equal(t, "2024-03-01T09:00:00Z", entry.Published)
+	assert.Equal(t, "Alice", entry.Author.Name)
+	assert.Equal(t, "html", entry.Content.Type)
+	assert.Equal(t, "<p>Why &amp; how?</p>", entry.Content.Body)
+	assert.Contains(t, string(body), "&lt;p&gt;Why &amp;amp; how?&lt;/p&gt;")
+}
+
+func TestRSS(t *testing.T) {
+	body, err := testFeed().RSS()
+	require.NoError(t, err)
+
+	var doc rssDoc
+	require.NoError(t, xml.Unmarshal(body, &doc))
+	assert.Equal(t, "2.0", doc.Version)
+	assert.Equal(t, "Fri, 01 Mar 2024 10:00:00 +0000", doc.Channel.LastBuildDate)
+	require.Len(t, doc.Channel.Items, 1)
+	item := doc.Channel.Items[0]
+	assert.Equal(t, "https://qa.example/questions/7", item.GUID.Value)
+	assert.Equal(t, "Fri, 01 Mar 2024 09:00:00 +0000", item.PubDate)
+	assert.Equal(t, "<p>Why &amp; how?</p>", item.Description)
+	assert.Contains(t, string(body), "<dc:creator>Alice</dc:creator>")
+}
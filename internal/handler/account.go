@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"fmt"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/service"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// exportRetryAfter is the polling interval suggested while an export is
+// being built.
+const exportRetryAfter = 5
+
+type AccountHandler struct {
+	service *service.AccountService
+	logger  *slog.Logger
+}
+
+func NewAccountHandler(service *service.AccountService, logger *slog.Logger) *AccountHandler {
+	return &AccountHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *AccountHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	userID := r.Context().Value("user_id").(string)
+
+	var req domain.ReauthRequest
+	if err := decodeJSON(r, &req); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	deletion, err := h.service.RequestDeletion(userID, req, requestMeta(r))
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	if deletion == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	respondJSON(w, http.StatusAccepted, deletion)
+}
+
+func (h *AccountHandler) CancelDeletion(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	userID := r.Context().Value("user_id").(string)
+
+	if err := h.service.CancelDeletion(userID, requestMeta(r)); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Export returns the archive once it is ready; until then it answers 202
+// with the export's status, and the client polls again.
+func (h *AccountHandler) Export(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	userID := r.Context().Value("user_id").(string)
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = domain.ExportFormatJSON
+	}
+
+	export, err := h.service.Export(userID, format)
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	if export.Status != domain.ExportStatusReady {
+		w.Header().Set("Retry-After", strconv.Itoa(exportRetryAfter))
+		respondJSON(w, http.StatusAccepted, export)
+		return
+	}
+
+	contentType := "application/json"
+	if export.Format == domain.ExportFormatZIP {
+		contentType = "application/zip"
+	}
+	filename := fmt.Sprintf("qa-export-%s.%s", export.CreatedAt.UTC().Format("20060102"), export.Format)
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Content-Length", strconv.Itoa(len(export.Data)))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(export.Data)
+}
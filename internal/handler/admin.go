@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"fmt"
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
+	"log/slog"
+	"net/http"
+)
+
+type AdminHandler struct {
+	reloader *config.Reloader
+	logger   *slog.Logger
+}
+
+func NewAdminHandler(reloader *config.Reloader, logger *slog.Logger) *AdminHandler {
+	return &AdminHandler{
+		reloader: reloader,
+		logger:   logger,
+	}
+}
+
+func (h *AdminHandler) ReloadConfig(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	userID := r.Context().Value("user_id").(string)
+
+	result, err := h.reloader.Reload()
+	if err != nil {
+		HandleError(w, h.logger, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err), requestID)
+		return
+	}
+
+	h.logger.Info("configuration reloaded via API",
+		slog.String("request_id", requestID),
+		slog.String("user_id", userID),
+		slog.Int("applied", len(result.Applied)),
+		slog.Int("restart_required", len(result.RestartRequired)),
+	)
+
+	respondJSON(w, http.StatusOK, result)
+}
@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"encoding/json"
 	"hitalent-test/internal/domain"
 	"hitalent-test/internal/service"
 	"log/slog"
@@ -33,8 +32,8 @@ func (h *AnswerHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req domain.CreateAnswerRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		HandleError(w, h.logger, domain.ErrInvalidInput, requestID)
+	if err := decodeJSON(r, &req); err != nil {
+		HandleError(w, h.logger, err, requestID)
 		return
 	}
 
@@ -64,6 +63,18 @@ func (h *AnswerHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		HandleError(w, h.logger, err, requestID)
 		return
 	}
+	tag, err := entityTag(answer)
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+	if notModified(w, r, tag) {
+		return
+	}
+	if err := h.service.Format(r.URL.Query().Get("format"), answer); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
 
 	respondJSON(w, http.StatusOK, answer)
 }
@@ -78,7 +89,22 @@ func (h *AnswerHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.Delete(uint(id)); err != nil {
+	answer, err := h.service.GetByID(uint(id))
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+	tag, err := entityTag(answer)
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+	if err := checkIfMatch(r, tag, true); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	if err := h.service.Delete(uint(id), requestMeta(r)); err != nil {
 		HandleError(w, h.logger, err, requestID)
 		return
 	}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"errors"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/service"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// multipartOverhead allows for the boundaries and part headers around
+	// the uploaded file.
+	multipartOverhead = 64 << 10
+	// uploadMemory is how much of an upload is kept in memory; the rest is
+	// spooled to a temporary file.
+	uploadMemory = 1 << 20
+)
+
+type AttachmentHandler struct {
+	service *service.AttachmentService
+	logger  *slog.Logger
+}
+
+func NewAttachmentHandler(service *service.AttachmentService, logger *slog.Logger) *AttachmentHandler {
+	return &AttachmentHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *AttachmentHandler) UploadToQuestion(w http.ResponseWriter, r *http.Request) {
+	h.upload(w, r, domain.ContentQuestion)
+}
+
+func (h *AttachmentHandler) UploadToAnswer(w http.ResponseWriter, r *http.Request) {
+	h.upload(w, r, domain.ContentAnswer)
+}
+
+// upload reads the file from the "file" field of a multipart form. The
+// router exempts these routes from the global body limit; the limit here
+// follows the configured maximum file size.
+func (h *AttachmentHandler) upload(w http.ResponseWriter, r *http.Request, contentType string) {
+	requestID := r.Context().Value("request_id").(string)
+	userID := r.Context().Value("user_id").(string)
+	userRole := r.Context().Value("user_role").(string)
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil {
+		HandleError(w, h.logger, domain.ErrInvalidInput, requestID)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.service.MaxSize()+multipartOverhead)
+	if err := r.ParseMultipartForm(uploadMemory); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			HandleError(w, h.logger, domain.ErrFileTooLarge, requestID)
+			return
+		}
+		HandleError(w, h.logger, domain.ErrInvalidInput, requestID)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		HandleError(w, h.logger, domain.ErrInvalidInput, requestID)
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.service.Upload(r.Context(), contentType, uint(id), userID, userRole, domain.Upload{
+		Filename: header.Filename,
+		Content:  file,
+	})
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, attachment)
+}
+
+// Download serves an attachment. Byte ranges and conditional requests are
+// handled by http.ServeContent. Only images are shown inline, and the
+// sandbox policy keeps browsers from running anything in the content.
+func (h *AttachmentHandler) Download(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	userID := r.Context().Value("user_id").(string)
+	userRole := r.Context().Value("user_role").(string)
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil {
+		HandleError(w, h.logger, domain.ErrInvalidInput, requestID)
+		return
+	}
+
+	attachment, content, err := h.service.Open(r.Context(), uint(id), userID, userRole)
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+	defer content.Close()
+
+	disposition := "attachment"
+	if attachment.IsImage() {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; sandbox")
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.Header().Set("ETag", `"`+attachment.Checksum+`"`)
+	http.ServeContent(w, r, "", attachment.CreatedAt, content)
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/service"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type AuditHandler struct {
+	service *service.AuditService
+	logger  *slog.Logger
+}
+
+func NewAuditHandler(service *service.AuditService, logger *slog.Logger) *AuditHandler {
+	return &AuditHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// List returns audit entries newest first. Filters: actor_id, action,
+// target_type, target_id, from and to (RFC 3339); pagination: limit and
+// cursor.
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	page, err := h.service.List(filter)
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, page)
+}
+
+func parseAuditFilter(r *http.Request) (domain.AuditFilter, error) {
+	query := r.URL.Query()
+	filter := domain.AuditFilter{
+		ActorID:    query.Get("actor_id"),
+		Action:     query.Get("action"),
+		TargetType: query.Get("target_type"),
+		TargetID:   query.Get("target_id"),
+	}
+
+	for name, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if v := query.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, domain.ErrInvalidInput
+			}
+			*dst = &t
+		}
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return filter, domain.ErrInvalidInput
+		}
+		filter.Limit = limit
+	}
+
+	if v := query.Get("cursor"); v != "" {
+		cursor, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || cursor < 1 {
+			return filter, domain.ErrInvalidInput
+		}
+		filter.Cursor = cursor
+	}
+
+	return filter, nil
+}
@@ -1,22 +1,29 @@
 package handler
 
 import (
-	"encoding/json"
 	"hitalent-test/internal/domain"
 	"hitalent-test/internal/service"
 	"log/slog"
 	"net/http"
+
+	"github.com/google/uuid"
 )
 
 type AuthHandler struct {
-	authService *service.AuthService
-	logger      *slog.Logger
+	authService         *service.AuthService
+	verificationService *service.EmailVerificationService
+	logger              *slog.Logger
 }
 
-func NewAuthHandler(authService *service.AuthService, logger *slog.Logger) *AuthHandler {
+func NewAuthHandler(
+	authService *service.AuthService,
+	verificationService *service.EmailVerificationService,
+	logger *slog.Logger,
+) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
-		logger:      logger,
+		authService:         authService,
+		verificationService: verificationService,
+		logger:              logger,
 	}
 }
 
@@ -24,30 +31,98 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	requestID := r.Context().Value("request_id").(string)
 
 	var req domain.RegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		HandleError(w, h.logger, domain.ErrInvalidInput, requestID)
+	if err := decodeJSON(r, &req); err != nil {
+		HandleError(w, h.logger, err, requestID)
 		return
 	}
 
-	user, err := h.authService.Register(req.Email, req.Password)
+	user, err := h.authService.Register(req.Email, req.Password, requestMeta(r))
 	if err != nil {
 		HandleError(w, h.logger, err, requestID)
 		return
 	}
 
+	// The account exists at this point; a failed mail is recoverable through
+	// the resend endpoint, so it must not fail the registration.
+	if err := h.verificationService.Send(user); err != nil {
+		h.logger.Error("failed to send verification email",
+			slog.String("request_id", requestID),
+			slog.String("user_id", user.ID),
+			slog.String("error", err.Error()),
+		)
+	}
+
 	respondJSON(w, http.StatusCreated, user)
 }
 
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+
+	var req domain.VerifyEmailRequest
+	if err := decodeJSON(r, &req); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	user, err := h.verificationService.Verify(req.Token)
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, user)
+}
+
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+
+	var req domain.ResendVerificationRequest
+	if err := decodeJSON(r, &req); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	if err := h.verificationService.Resend(req.Email); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	requestID := r.Context().Value("request_id").(string)
 
 	var req domain.LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		HandleError(w, h.logger, domain.ErrInvalidInput, requestID)
+	if err := decodeJSON(r, &req); err != nil {
+		HandleError(w, h.logger, err, requestID)
 		return
 	}
 
-	authResp, err := h.authService.Login(req.Email, req.Password)
+	authResp, challenge, err := h.authService.Login(req.Email, req.Password, requestMeta(r))
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	if challenge != nil {
+		respondJSON(w, http.StatusOK, challenge)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, authResp)
+}
+
+func (h *AuthHandler) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+
+	var req domain.MFALoginRequest
+	if err := decodeJSON(r, &req); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	authResp, err := h.authService.CompleteLogin(req.MFAToken, req.Code, requestMeta(r))
 	if err != nil {
 		HandleError(w, h.logger, err, requestID)
 		return
@@ -60,8 +135,8 @@ func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 	requestID := r.Context().Value("request_id").(string)
 
 	var req domain.RefreshRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		HandleError(w, h.logger, domain.ErrInvalidInput, requestID)
+	if err := decodeJSON(r, &req); err != nil {
+		HandleError(w, h.logger, err, requestID)
 		return
 	}
 
@@ -75,3 +150,20 @@ func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 		"access_token": accessToken,
 	})
 }
+
+func (h *AuthHandler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+
+	userID := r.PathValue("id")
+	if _, err := uuid.Parse(userID); err != nil {
+		HandleError(w, h.logger, domain.ErrInvalidInput, requestID)
+		return
+	}
+
+	if err := h.authService.Unlock(userID, requestMeta(r)); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
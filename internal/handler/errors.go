@@ -3,28 +3,75 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"hitalent-test/internal/domain"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
 )
 
 type ErrorResponse struct {
 	Error   string `json:"error"`
 	Message string `json:"message,omitempty"`
+	// Similar lists the existing questions a new one was refused for.
+	Similar []domain.SimilarQuestion `json:"similar,omitempty"`
 }
 
 func HandleError(w http.ResponseWriter, logger *slog.Logger, err error, requestID string) {
 	var statusCode int
 	var message string
+	var similar []domain.SimilarQuestion
 
 	switch {
 	case errors.Is(err, domain.ErrQuestionNotFound),
-		errors.Is(err, domain.ErrAnswerNotFound):
+		errors.Is(err, domain.ErrAnswerNotFound),
+		errors.Is(err, domain.ErrUserNotFound),
+		errors.Is(err, domain.ErrTokenNotFound),
+		errors.Is(err, domain.ErrExportNotFound),
+		errors.Is(err, domain.ErrNotificationNotFound),
+		errors.Is(err, domain.ErrAttachmentNotFound):
 		statusCode = http.StatusNotFound
 		message = err.Error()
 	case errors.Is(err, domain.ErrInvalidInput):
 		statusCode = http.StatusBadRequest
 		message = err.Error()
+	case errors.Is(err, domain.ErrUnauthorized):
+		statusCode = http.StatusUnauthorized
+		message = err.Error()
+	case errors.Is(err, domain.ErrForbidden):
+		statusCode = http.StatusForbidden
+		message = err.Error()
+	case errors.Is(err, domain.ErrQuestionClosed):
+		statusCode = http.StatusConflict
+		message = err.Error()
+	case errors.Is(err, domain.ErrSimilarQuestions):
+		statusCode = http.StatusConflict
+		message = err.Error()
+		var similarErr *domain.SimilarQuestionsError
+		if errors.As(err, &similarErr) {
+			similar = similarErr.Questions
+		}
+	case errors.Is(err, domain.ErrFileTooLarge),
+		errors.Is(err, domain.ErrBodyTooLarge):
+		statusCode = http.StatusRequestEntityTooLarge
+		message = err.Error()
+	case errors.Is(err, domain.ErrUnsupportedFileType):
+		statusCode = http.StatusUnsupportedMediaType
+		message = err.Error()
+	case errors.Is(err, domain.ErrPreconditionFailed):
+		statusCode = http.StatusPreconditionFailed
+		message = err.Error()
+	case errors.Is(err, domain.ErrPreconditionRequired):
+		statusCode = http.StatusPreconditionRequired
+		message = err.Error()
+	case errors.Is(err, domain.ErrTooManyRequests):
+		statusCode = http.StatusTooManyRequests
+		message = err.Error()
+		var retry *domain.RetryAfterError
+		if errors.As(err, &retry) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.RetryAfter.Seconds()))))
+		}
 	default:
 		statusCode = http.StatusInternalServerError
 		message = "internal server error"
@@ -34,12 +81,28 @@ func HandleError(w http.ResponseWriter, logger *slog.Logger, err error, requestI
 		)
 	}
 
+	// Errors are never cached, whatever the route allows for its responses.
+	w.Header().Set("Cache-Control", "no-store")
 	respondJSON(w, statusCode, ErrorResponse{
 		Error:   http.StatusText(statusCode),
 		Message: message,
+		Similar: similar,
 	})
 }
 
+// decodeJSON reads a JSON request body into v. Bodies cut off by the body
+// limit are reported as too large rather than malformed.
+func decodeJSON(r *http.Request, v any) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return fmt.Errorf("%w: the limit is %d bytes", domain.ErrBodyTooLarge, tooLarge.Limit)
+		}
+		return domain.ErrInvalidInput
+	}
+	return nil
+}
+
 func respondJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
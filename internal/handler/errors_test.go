@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"hitalent-test/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	var v struct {
+		Text string `json:"text"`
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/questions/", strings.NewReader(`{"text":`))
+	assert.ErrorIs(t, decodeJSON(r, &v), domain.ErrInvalidInput)
+
+	w := httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/questions/", strings.NewReader(`{"text":"`+strings.Repeat("a", 100)+`"}`))
+	r.Body = http.MaxBytesReader(w, r.Body, 16)
+	err := decodeJSON(r, &v)
+	assert.ErrorIs(t, err, domain.ErrBodyTooLarge)
+
+	HandleError(w, nil, err, "request")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hitalent-test/internal/domain"
+	"net/http"
+	"strings"
+)
+
+// entityTag returns a strong entity tag for a resource: a hash of the full
+// response body in the default Markdown format, taken before the text is
+// formatted. The other formats are rendered from the same fields, so their
+// bodies change only when this one does; every format shares the tag and a
+// client may send it back with any write.
+func entityTag(resource any) (string, error) {
+	body, err := json.Marshal(resource)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:]) + `"`, nil
+}
+
+// notModified sets the ETag header and, when the request's If-None-Match
+// lists the tag, answers 304 Not Modified and returns true.
+func notModified(w http.ResponseWriter, r *http.Request, tag string) bool {
+	w.Header().Set("ETag", tag)
+	if !matchesTag(r.Header.Get("If-None-Match"), tag, true) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// checkIfMatch enforces If-Match on a write to a resource with the given
+// current tag. Without the header the write is refused when required is
+// set and let through otherwise.
+//
+// The check and the write are not atomic: this catches changes made since
+// the client read the resource, not writes racing within the same moment.
+func checkIfMatch(r *http.Request, tag string, required bool) error {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		if required {
+			return domain.ErrPreconditionRequired
+		}
+		return nil
+	}
+	if !matchesTag(header, tag, false) {
+		return domain.ErrPreconditionFailed
+	}
+	return nil
+}
+
+// matchesTag reports whether a list of entity tags from If-Match or
+// If-None-Match contains tag or is "*". If-None-Match compares weakly,
+// ignoring the W/ prefix; If-Match compares strongly, so weak tags never
+// match.
+func matchesTag(header, tag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == tag {
+			return true
+		}
+	}
+	return false
+}
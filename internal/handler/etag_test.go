@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"hitalent-test/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntityTag(t *testing.T) {
+	tag, err := entityTag(&domain.Answer{ID: 1, Text: "first"})
+	require.NoError(t, err)
+	assert.Regexp(t, `^"[0-9a-f]{64}"$`, tag)
+
+	same, err := entityTag(&domain.Answer{ID: 1, Text: "first"})
+	require.NoError(t, err)
+	assert.Equal(t, tag, same)
+
+	changed, err := entityTag(&domain.Answer{ID: 1, Text: "first", Accepted: true})
+	require.NoError(t, err)
+	assert.NotEqual(t, tag, changed)
+}
+
+func TestNotModified(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		want        bool
+	}{
+		{"no header", "", false},
+		{"same tag", `"abc"`, true},
+		{"weak tag", `W/"abc"`, true},
+		{"one of several", `"old", "abc"`, true},
+		{"any", "*", true},
+		{"other tag", `"old"`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/questions/1", nil)
+			if tt.ifNoneMatch != "" {
+				r.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			w := httptest.NewRecorder()
+
+			assert.Equal(t, tt.want, notModified(w, r, `"abc"`))
+			assert.Equal(t, `"abc"`, w.Header().Get("ETag"))
+			if tt.want {
+				assert.Equal(t, http.StatusNotModified, w.Code)
+			}
+		})
+	}
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		ifMatch  string
+		required bool
+		want     error
+	}{
+		{"required but missing", "", true, domain.ErrPreconditionRequired},
+		{"optional and missing", "", false, nil},
+		{"same tag", `"abc"`, true, nil},
+		{"one of several", `"old", "abc"`, true, nil},
+		{"any", "*", true, nil},
+		{"changed", `"old"`, true, domain.ErrPreconditionFailed},
+		{"weak tag", `W/"abc"`, false, domain.ErrPreconditionFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodDelete, "/questions/1", nil)
+			if tt.ifMatch != "" {
+				r.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			err := checkIfMatch(r, `"abc"`, tt.required)
+			if tt.want == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.want)
+			}
+		})
+	}
+}
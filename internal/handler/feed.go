@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/feed"
+	"hitalent-test/internal/service"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+const (
+	atomContentType = "application/atom+xml; charset=utf-8"
+	rssContentType  = "application/rss+xml; charset=utf-8"
+)
+
+type FeedHandler struct {
+	service *service.FeedService
+	logger  *slog.Logger
+}
+
+func NewFeedHandler(service *service.FeedService, logger *slog.Logger) *FeedHandler {
+	return &FeedHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *FeedHandler) QuestionsAtom(w http.ResponseWriter, r *http.Request) {
+	h.questions(w, r, (*feed.Feed).Atom, atomContentType)
+}
+
+func (h *FeedHandler) QuestionsRSS(w http.ResponseWriter, r *http.Request) {
+	h.questions(w, r, (*feed.Feed).RSS, rssContentType)
+}
+
+func (h *FeedHandler) questions(w http.ResponseWriter, r *http.Request, render func(*feed.Feed) ([]byte, error), contentType string) {
+	requestID := r.Context().Value("request_id").(string)
+
+	f, err := h.service.Questions()
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+	h.serve(w, r, f, render, contentType)
+}
+
+func (h *FeedHandler) AnswersAtom(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil {
+		HandleError(w, h.logger, domain.ErrInvalidInput, requestID)
+		return
+	}
+
+	f, err := h.service.Answers(uint(id))
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+	h.serve(w, r, f, (*feed.Feed).Atom, atomContentType)
+}
+
+// serve writes the rendered feed. Its ETag is a hash of the body and its
+// modification time that of the newest entry, so that conditional requests
+// from feed readers polling an unchanged feed get 304 Not Modified.
+func (h *FeedHandler) serve(w http.ResponseWriter, r *http.Request, f *feed.Feed, render func(*feed.Feed) ([]byte, error), contentType string) {
+	requestID := r.Context().Value("request_id").(string)
+
+	body, err := render(f)
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+	sum := sha256.Sum256(body)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+	http.ServeContent(w, r, "", f.Updated, bytes.NewReader(body))
+}
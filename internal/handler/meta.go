@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"hitalent-test/internal/domain"
+	"net/http"
+)
+
+// requestMeta describes the client of a request for the audit log. The
+// actor is empty for unauthenticated requests.
+func requestMeta(r *http.Request) domain.RequestMeta {
+	ctx := r.Context()
+	actorID, _ := ctx.Value("user_id").(string)
+	clientIP, _ := ctx.Value("client_ip").(string)
+	requestID, _ := ctx.Value("request_id").(string)
+	return domain.RequestMeta{
+		ActorID:   actorID,
+		IP:        clientIP,
+		UserAgent: r.UserAgent(),
+		RequestID: requestID,
+	}
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/service"
+	"log/slog"
+	"net/http"
+)
+
+type MFAHandler struct {
+	service *service.MFAService
+	logger  *slog.Logger
+}
+
+func NewMFAHandler(service *service.MFAService, logger *slog.Logger) *MFAHandler {
+	return &MFAHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *MFAHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	userID := r.Context().Value("user_id").(string)
+
+	enrollment, err := h.service.Enroll(userID)
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, enrollment)
+}
+
+func (h *MFAHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	userID := r.Context().Value("user_id").(string)
+
+	var req domain.TOTPConfirmRequest
+	if err := decodeJSON(r, &req); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	codes, err := h.service.Confirm(userID, req.Code)
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, domain.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h *MFAHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	userID := r.Context().Value("user_id").(string)
+
+	var req domain.ReauthRequest
+	if err := decodeJSON(r, &req); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	if err := h.service.Disable(userID, req.Password, req.Code); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *MFAHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	userID := r.Context().Value("user_id").(string)
+
+	var req domain.ReauthRequest
+	if err := decodeJSON(r, &req); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(userID, req.Password, req.Code)
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, domain.RecoveryCodesResponse{RecoveryCodes: codes})
+}
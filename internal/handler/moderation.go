@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/service"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+type ModerationHandler struct {
+	service *service.ModerationService
+	logger  *slog.Logger
+}
+
+func NewModerationHandler(service *service.ModerationService, logger *slog.Logger) *ModerationHandler {
+	return &ModerationHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *ModerationHandler) FlagQuestion(w http.ResponseWriter, r *http.Request) {
+	h.flag(w, r, domain.ContentQuestion)
+}
+
+func (h *ModerationHandler) FlagAnswer(w http.ResponseWriter, r *http.Request) {
+	h.flag(w, r, domain.ContentAnswer)
+}
+
+func (h *ModerationHandler) flag(w http.ResponseWriter, r *http.Request, targetType string) {
+	requestID := r.Context().Value("request_id").(string)
+	userID := r.Context().Value("user_id").(string)
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil {
+		HandleError(w, h.logger, domain.ErrInvalidInput, requestID)
+		return
+	}
+
+	var req domain.CreateFlagRequest
+	if err := decodeJSON(r, &req); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	flag, err := h.service.Flag(targetType, uint(id), userID, &req)
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, flag)
+}
+
+// Queue lists flagged content, most flagged first. Filters: target_type;
+// pagination: limit and offset.
+func (h *ModerationHandler) Queue(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	query := r.URL.Query()
+
+	var limit, offset int
+	for name, dst := range map[string]*int{"limit": &limit, "offset": &offset} {
+		if v := query.Get(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				HandleError(w, h.logger, domain.ErrInvalidInput, requestID)
+				return
+			}
+			*dst = n
+		}
+	}
+
+	items, err := h.service.Queue(query.Get("target_type"), limit, offset)
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, items)
+}
+
+func (h *ModerationHandler) Act(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil {
+		HandleError(w, h.logger, domain.ErrInvalidInput, requestID)
+		return
+	}
+
+	var req domain.ModerationActionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	if err := h.service.Act(r.PathValue("type"), uint(id), &req, requestMeta(r)); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
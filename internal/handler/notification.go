@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/service"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+type NotificationHandler struct {
+	service *service.NotificationService
+	logger  *slog.Logger
+}
+
+func NewNotificationHandler(service *service.NotificationService, logger *slog.Logger) *NotificationHandler {
+	return &NotificationHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// List returns the user's notifications newest first. Filters: unread;
+// pagination: limit and cursor.
+func (h *NotificationHandler) List(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	userID := r.Context().Value("user_id").(string)
+	query := r.URL.Query()
+
+	filter := domain.NotificationFilter{UserID: userID}
+	if v := query.Get("unread"); v != "" {
+		unread, err := strconv.ParseBool(v)
+		if err != nil {
+			HandleError(w, h.logger, domain.ErrInvalidInput, requestID)
+			return
+		}
+		filter.UnreadOnly = unread
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			HandleError(w, h.logger, domain.ErrInvalidInput, requestID)
+			return
+		}
+		filter.Limit = limit
+	}
+	if v := query.Get("cursor"); v != "" {
+		cursor, err := strconv.ParseUint(v, 10, 32)
+		if err != nil || cursor < 1 {
+			HandleError(w, h.logger, domain.ErrInvalidInput, requestID)
+			return
+		}
+		filter.Cursor = uint(cursor)
+	}
+
+	page, err := h.service.List(filter)
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, page)
+}
+
+type unreadCountResponse struct {
+	Unread int64 `json:"unread"`
+}
+
+func (h *NotificationHandler) UnreadCount(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	userID := r.Context().Value("user_id").(string)
+
+	count, err := h.service.UnreadCount(userID)
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, unreadCountResponse{Unread: count})
+}
+
+func (h *NotificationHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	userID := r.Context().Value("user_id").(string)
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil {
+		HandleError(w, h.logger, domain.ErrInvalidInput, requestID)
+		return
+	}
+
+	if err := h.service.MarkRead(userID, uint(id)); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *NotificationHandler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	userID := r.Context().Value("user_id").(string)
+
+	if err := h.service.MarkAllRead(userID); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *NotificationHandler) Preferences(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	userID := r.Context().Value("user_id").(string)
+
+	prefs, err := h.service.Preferences(userID)
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, prefs)
+}
+
+// UpdatePreferences takes a map from notification type to whether it is
+// enabled; types that are left out keep their setting.
+func (h *NotificationHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	userID := r.Context().Value("user_id").(string)
+
+	var changes map[string]bool
+	if err := decodeJSON(r, &changes); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	prefs, err := h.service.UpdatePreferences(userID, changes)
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, prefs)
+}
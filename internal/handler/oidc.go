@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"fmt"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/service"
+	"log/slog"
+	"net/http"
+)
+
+const oidcFlowCookie = "oidc_flow"
+
+type OIDCHandler struct {
+	service *service.OIDCService
+	logger  *slog.Logger
+}
+
+func NewOIDCHandler(service *service.OIDCService, logger *slog.Logger) *OIDCHandler {
+	return &OIDCHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Login redirects the browser to the identity provider. The flow secrets
+// travel in a cookie scoped to the provider's callback path.
+func (h *OIDCHandler) Login(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	provider := r.PathValue("provider")
+
+	authorization, err := h.service.Begin(r.Context(), provider)
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcFlowCookie,
+		Value:    authorization.FlowToken,
+		Path:     "/auth/oidc/" + provider,
+		MaxAge:   int(authorization.TTL.Seconds()),
+		HttpOnly: true,
+		Secure:   authorization.SecureCookie,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authorization.URL, http.StatusFound)
+}
+
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	provider := r.PathValue("provider")
+	query := r.URL.Query()
+
+	// The flow cookie is single-use whatever the outcome.
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcFlowCookie,
+		Path:     "/auth/oidc/" + provider,
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	if providerErr := query.Get("error"); providerErr != "" {
+		err := fmt.Errorf("%w: %s: %s", domain.ErrUnauthorized, providerErr, query.Get("error_description"))
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	cookie, err := r.Cookie(oidcFlowCookie)
+	if err != nil {
+		err := fmt.Errorf("%w: sign-in was not started from this browser", domain.ErrUnauthorized)
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	authResp, challenge, err := h.service.Complete(r.Context(), provider, cookie.Value, query.Get("state"), query.Get("code"), requestMeta(r))
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	if challenge != nil {
+		respondJSON(w, http.StatusOK, challenge)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, authResp)
+}
+
+func (h *OIDCHandler) Identities(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	userID := r.Context().Value("user_id").(string)
+
+	identities, err := h.service.Identities(userID)
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, identities)
+}
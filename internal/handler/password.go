@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/service"
+	"log/slog"
+	"net/http"
+)
+
+type PasswordHandler struct {
+	service *service.PasswordService
+	logger  *slog.Logger
+}
+
+func NewPasswordHandler(service *service.PasswordService, logger *slog.Logger) *PasswordHandler {
+	return &PasswordHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *PasswordHandler) Forgot(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+
+	var req domain.ForgotPasswordRequest
+	if err := decodeJSON(r, &req); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	if err := h.service.Forgot(req.Email); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *PasswordHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+
+	var req domain.ResetPasswordRequest
+	if err := decodeJSON(r, &req); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	if err := h.service.Reset(req.Token, req.NewPassword, requestMeta(r)); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *PasswordHandler) Change(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	userID := r.Context().Value("user_id").(string)
+
+	var req domain.ChangePasswordRequest
+	if err := decodeJSON(r, &req); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	if err := h.service.Change(userID, req.OldPassword, req.NewPassword, requestMeta(r)); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
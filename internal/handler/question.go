@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"encoding/json"
 	"hitalent-test/internal/domain"
 	"hitalent-test/internal/service"
 	"log/slog"
@@ -25,10 +24,16 @@ func (h *QuestionHandler) Create(w http.ResponseWriter, r *http.Request) {
 	requestID := r.Context().Value("request_id").(string)
 
 	var req domain.CreateQuestionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		HandleError(w, h.logger, domain.ErrInvalidInput, requestID)
+	if err := decodeJSON(r, &req); err != nil {
+		HandleError(w, h.logger, err, requestID)
 		return
 	}
+	// Anonymous questions are still allowed; the author is recorded when
+	// the request is authenticated.
+	req.UserID, _ = r.Context().Value("user_id").(string)
+	if r.URL.Query().Get("force") == "true" {
+		req.Force = true
+	}
 
 	question, err := h.service.Create(&req)
 	if err != nil {
@@ -54,18 +59,51 @@ func (h *QuestionHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		HandleError(w, h.logger, err, requestID)
 		return
 	}
+	tag, err := h.entityTag(question)
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+	if notModified(w, r, tag) {
+		return
+	}
+	if err := h.service.Format(r.URL.Query().Get("format"), question); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
 
 	respondJSON(w, http.StatusOK, question)
 }
 
+// Similar lists existing questions resembling the text query parameter.
+func (h *QuestionHandler) Similar(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+
+	similar, err := h.service.FindSimilar(r.URL.Query().Get("text"))
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, similar)
+}
+
 func (h *QuestionHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	requestID := r.Context().Value("request_id").(string)
 
-	questions, err := h.service.GetAll()
+	questions, err := h.service.GetAll(r.URL.Query().Get("status"))
 	if err != nil {
 		HandleError(w, h.logger, err, requestID)
 		return
 	}
+	ptrs := make([]*domain.Question, len(questions))
+	for i := range questions {
+		ptrs[i] = &questions[i]
+	}
+	if err := h.service.Format(r.URL.Query().Get("format"), ptrs...); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
 
 	respondJSON(w, http.StatusOK, questions)
 }
@@ -80,10 +118,118 @@ func (h *QuestionHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.Delete(uint(id)); err != nil {
+	if err := h.checkIfMatch(r, uint(id), true); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	if err := h.service.Delete(uint(id), requestMeta(r)); err != nil {
 		HandleError(w, h.logger, err, requestID)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (h *QuestionHandler) AcceptAnswer(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	userID := r.Context().Value("user_id").(string)
+	userRole := r.Context().Value("user_role").(string)
+
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		HandleError(w, h.logger, domain.ErrInvalidInput, requestID)
+		return
+	}
+
+	var req domain.AcceptAnswerRequest
+	if err := decodeJSON(r, &req); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	if err := h.checkIfMatch(r, uint(id), false); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	question, err := h.service.AcceptAnswer(uint(id), req.AnswerID, userID, userRole)
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	h.respondWithTag(w, requestID, question)
+}
+
+// SetStatus closes, locks, reopens or marks a question as a duplicate.
+func (h *QuestionHandler) SetStatus(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	userID := r.Context().Value("user_id").(string)
+	userRole := r.Context().Value("user_role").(string)
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil {
+		HandleError(w, h.logger, domain.ErrInvalidInput, requestID)
+		return
+	}
+
+	var req domain.UpdateQuestionStatusRequest
+	if err := decodeJSON(r, &req); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	if err := h.checkIfMatch(r, uint(id), false); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	question, err := h.service.SetStatus(uint(id), &req, userID, userRole, requestMeta(r))
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	h.respondWithTag(w, requestID, question)
+}
+
+// checkIfMatch compares the request's If-Match with the current tag of the
+// question, as GetByID would return it.
+func (h *QuestionHandler) checkIfMatch(r *http.Request, id uint, required bool) error {
+	if !required && r.Header.Get("If-Match") == "" {
+		return nil
+	}
+	question, err := h.service.GetByID(id)
+	if err != nil {
+		return err
+	}
+	tag, err := h.entityTag(question)
+	if err != nil {
+		return err
+	}
+	return checkIfMatch(r, tag, required)
+}
+
+// entityTag fills in the related questions, which are part of the response
+// to GetByID, and returns the tag of the question.
+func (h *QuestionHandler) entityTag(question *domain.Question) (string, error) {
+	if err := h.service.AttachRelated(question); err != nil {
+		return "", err
+	}
+	return entityTag(question)
+}
+
+// respondWithTag sends a changed question as GetByID would, with its new
+// tag, so that the client can make further conditional writes without
+// reading it again.
+func (h *QuestionHandler) respondWithTag(w http.ResponseWriter, requestID string, question *domain.Question) {
+	tag, err := h.entityTag(question)
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+	w.Header().Set("ETag", tag)
+	respondJSON(w, http.StatusOK, question)
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"context"
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/repository"
+	"hitalent-test/internal/service"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// questionRepository holds one question and the questions related to it.
+type questionRepository struct {
+	repository.QuestionRepository
+	related []domain.SimilarQuestion
+	deleted bool
+}
+
+func (r *questionRepository) GetByID(id uint) (*domain.Question, error) {
+	if id != 1 || r.deleted {
+		return nil, domain.ErrQuestionNotFound
+	}
+	return &domain.Question{ID: 1, Text: "What is the capital of France?"}, nil
+}
+
+func (r *questionRepository) FindSimilar(text string, excludeID uint, threshold float64, limit int) ([]domain.SimilarQuestion, error) {
+	return r.related, nil
+}
+
+func (r *questionRepository) Delete(id uint) error {
+	r.deleted = true
+	return nil
+}
+
+type userRepository struct {
+	repository.UserRepository
+}
+
+func (userRepository) GetByIDs(ids []string) ([]domain.User, error) {
+	return nil, nil
+}
+
+func newQuestionTestHandler(repo *questionRepository) *QuestionHandler {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := config.Default()
+	svc := service.NewQuestionService(repo, userRepository{}, service.PostDeps{}, &cfg.Validation)
+	svc.SetSimilarity(config.SimilarityConfig{Threshold: 0.3, Related: 5})
+	return NewQuestionHandler(svc, logger)
+}
+
+func serveQuestion(h http.HandlerFunc, method, target string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, nil)
+	r.SetPathValue("id", "1")
+	ctx := context.WithValue(r.Context(), "request_id", "test")
+	ctx = context.WithValue(ctx, "user_id", "")
+	ctx = context.WithValue(ctx, "user_role", "")
+	r = r.WithContext(ctx)
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	h(w, r)
+	return w
+}
+
+func TestQuestionHandler_GetByID_ConditionalRequests(t *testing.T) {
+	repo := &questionRepository{related: []domain.SimilarQuestion{{ID: 2, Text: "Capital of Spain?"}}}
+	h := newQuestionTestHandler(repo)
+
+	first := serveQuestion(h.GetByID, http.MethodGet, "/questions/1", nil)
+	require.Equal(t, http.StatusOK, first.Code)
+	tag := first.Header().Get("ETag")
+	require.NotEmpty(t, tag)
+
+	w := serveQuestion(h.GetByID, http.MethodGet, "/questions/1?format=html", http.Header{"If-None-Match": {tag}})
+	assert.Equal(t, http.StatusNotModified, w.Code, "the tag does not depend on the format")
+
+	repo.related = append(repo.related, domain.SimilarQuestion{ID: 3, Text: "Capital of Italy?"})
+	w = serveQuestion(h.GetByID, http.MethodGet, "/questions/1", http.Header{"If-None-Match": {tag}})
+	assert.Equal(t, http.StatusOK, w.Code, "related questions are part of the body and of the tag")
+	assert.NotEqual(t, tag, w.Header().Get("ETag"))
+	assert.Contains(t, w.Body.String(), "Capital of Italy?")
+}
+
+func TestQuestionHandler_Delete_IfMatch(t *testing.T) {
+	repo := &questionRepository{}
+	h := newQuestionTestHandler(repo)
+	tag := serveQuestion(h.GetByID, http.MethodGet, "/questions/1", nil).Header().Get("ETag")
+
+	w := serveQuestion(h.Delete, http.MethodDelete, "/questions/1", nil)
+	assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+
+	repo.related = []domain.SimilarQuestion{{ID: 2, Text: "Capital of Spain?"}}
+	w = serveQuestion(h.Delete, http.MethodDelete, "/questions/1", http.Header{"If-Match": {tag}})
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.False(t, repo.deleted)
+
+	tag = serveQuestion(h.GetByID, http.MethodGet, "/questions/1", nil).Header().Get("ETag")
+	w = serveQuestion(h.Delete, http.MethodDelete, "/questions/1", http.Header{"If-Match": {tag}})
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.True(t, repo.deleted)
+}
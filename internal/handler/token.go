@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/service"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type TokenHandler struct {
+	service *service.PersonalAccessTokenService
+	logger  *slog.Logger
+}
+
+func NewTokenHandler(service *service.PersonalAccessTokenService, logger *slog.Logger) *TokenHandler {
+	return &TokenHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *TokenHandler) List(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	userID := r.Context().Value("user_id").(string)
+
+	tokens, err := h.service.List(userID)
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, tokens)
+}
+
+func (h *TokenHandler) Create(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	userID := r.Context().Value("user_id").(string)
+
+	var req domain.CreatePersonalAccessTokenRequest
+	if err := decodeJSON(r, &req); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	token, err := h.service.Create(userID, req)
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, token)
+}
+
+func (h *TokenHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	userID := r.Context().Value("user_id").(string)
+
+	id := r.PathValue("id")
+	if _, err := uuid.Parse(id); err != nil {
+		HandleError(w, h.logger, domain.ErrInvalidInput, requestID)
+		return
+	}
+
+	if err := h.service.Revoke(userID, id, requestMeta(r)); err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"fmt"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/service"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+type TransferHandler struct {
+	service *service.TransferService
+	logger  *slog.Logger
+}
+
+func NewTransferHandler(service *service.TransferService, logger *slog.Logger) *TransferHandler {
+	return &TransferHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+var transferContentTypes = map[string]string{
+	domain.TransferJSONL: "application/x-ndjson",
+	domain.TransferCSV:   "text/csv; charset=utf-8",
+}
+
+// Export streams all questions with their answers as a file download. An
+// export can outlast the server's write timeout, so the deadline is lifted.
+func (h *TransferHandler) Export(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+
+	format, err := transferFormat(r)
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn("cannot lift write deadline for export", slog.String("request_id", requestID), slog.String("error", err.Error()))
+	}
+
+	filename := "export-" + time.Now().UTC().Format("20060102-150405") + "." + format
+	w.Header().Set("Content-Type", transferContentTypes[format])
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+
+	cw := &countingWriter{w: w}
+	if err := h.service.Export(cw, format); err != nil {
+		if cw.n == 0 {
+			w.Header().Del("Content-Disposition")
+			HandleError(w, h.logger, err, requestID)
+			return
+		}
+		// The status is sent; all that can be done is to cut the response
+		// short so that the client sees it is incomplete.
+		h.logger.Error("export failed", slog.String("request_id", requestID), slog.String("error", err.Error()))
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// Import reads a file from the request body and reports what was, or in a
+// dry run would be, imported. The router exempts this route from the body
+// limit and the read and write deadlines are lifted for large files.
+func (h *TransferHandler) Import(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	userID := r.Context().Value("user_id").(string)
+
+	format, err := transferFormat(r)
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+	var dryRun bool
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			HandleError(w, h.logger, fmt.Errorf("%w: dry_run must be a boolean", domain.ErrInvalidInput), requestID)
+			return
+		}
+	}
+
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Time{}); err != nil {
+		h.logger.Warn("cannot lift read deadline for import", slog.String("request_id", requestID), slog.String("error", err.Error()))
+	}
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn("cannot lift write deadline for import", slog.String("request_id", requestID), slog.String("error", err.Error()))
+	}
+
+	report, err := h.service.Import(r.Body, domain.ImportOptions{
+		Format: format,
+		Source: r.URL.Query().Get("source"),
+		DryRun: dryRun,
+	}, requestMeta(r))
+	if err != nil {
+		HandleError(w, h.logger, err, requestID)
+		return
+	}
+
+	h.logger.Info("data imported",
+		slog.String("request_id", requestID),
+		slog.String("user_id", userID),
+		slog.Bool("dry_run", report.DryRun),
+		slog.Int("questions_created", report.QuestionsCreated),
+		slog.Int("questions_updated", report.QuestionsUpdated),
+		slog.Int("skipped", report.Skipped),
+	)
+
+	respondJSON(w, http.StatusOK, report)
+}
+
+// transferFormat reads the format query parameter, which defaults to JSONL.
+func transferFormat(r *http.Request) (string, error) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		return domain.TransferJSONL, nil
+	}
+	if !slices.Contains(domain.TransferFormats, format) {
+		return "", fmt.Errorf("%w: format must be one of %v", domain.ErrInvalidInput, domain.TransferFormats)
+	}
+	return format, nil
+}
+
+// countingWriter tells whether anything was written yet.
+type countingWriter struct {
+	w http.ResponseWriter
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,32 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileMailer drops every message as an .eml file into a directory, which is
+// handy for local development without an SMTP server.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir, from string) *FileMailer {
+	return &FileMailer{dir: dir, from: from}
+}
+
+func (m *FileMailer) Send(msg Message) error {
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create mail directory: %w", err)
+	}
+
+	now := time.Now()
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000"), newBoundary()[:8])
+	if err := os.WriteFile(filepath.Join(m.dir, name), msg.Bytes(m.from, now), 0o644); err != nil {
+		return fmt.Errorf("failed to write mail file: %w", err)
+	}
+	return nil
+}
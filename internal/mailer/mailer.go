@@ -0,0 +1,68 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"strings"
+	"time"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer delivers transactional email such as verification links.
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// Bytes renders msg as an RFC 5322 message. When both Text and HTML are set
+// the body is multipart/alternative.
+func (m Message) Bytes(from string, now time.Time) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", m.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	if m.HTML == "" {
+		writePart(&buf, "text/plain", m.Text)
+		return buf.Bytes()
+	}
+
+	boundary := newBoundary()
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", m.Text},
+		{"text/html", m.HTML},
+	} {
+		fmt.Fprintf(&buf, "--%s\r\n", boundary)
+		writePart(&buf, part.contentType, part.body)
+		buf.WriteString("\r\n")
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+	return buf.Bytes()
+}
+
+func writePart(buf *bytes.Buffer, contentType, body string) {
+	fmt.Fprintf(buf, "Content-Type: %s; charset=utf-8\r\n", contentType)
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	w := quotedprintable.NewWriter(buf)
+	w.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n")))
+	w.Close()
+	buf.WriteString("\r\n")
+}
+
+func newBoundary() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,60 @@
+package mailer
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessage_BytesPlainText(t *testing.T) {
+	msg := Message{To: "user@example.com", Subject: "Hello", Text: "line one\nline two"}
+
+	raw := string(msg.Bytes("no-reply@example.com", time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)))
+
+	assert.Contains(t, raw, "From: no-reply@example.com\r\n")
+	assert.Contains(t, raw, "To: user@example.com\r\n")
+	assert.Contains(t, raw, "Subject: Hello\r\n")
+	assert.Contains(t, raw, "Content-Type: text/plain; charset=utf-8\r\n")
+	assert.Contains(t, raw, "line one\r\nline two")
+	assert.NotContains(t, raw, "multipart")
+}
+
+func TestMessage_BytesMultipart(t *testing.T) {
+	msg := Message{To: "user@example.com", Subject: "Привет", Text: "text", HTML: "<p>html</p>"}
+
+	raw := string(msg.Bytes("no-reply@example.com", time.Now()))
+
+	assert.Contains(t, raw, "multipart/alternative")
+	assert.Contains(t, raw, "Content-Type: text/html; charset=utf-8")
+	assert.Contains(t, raw, "Subject: =?utf-8?q?")
+}
+
+func TestFileMailer_Send(t *testing.T) {
+	dir := t.TempDir()
+	m := NewFileMailer(dir, "no-reply@example.com")
+
+	require.NoError(t, m.Send(Message{To: "user@example.com", Subject: "Hi", Text: "body"}))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "To: user@example.com")
+}
+
+func TestLogMailer_LeavesOutBody(t *testing.T) {
+	var logs bytes.Buffer
+	m := NewLogMailer(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	require.NoError(t, m.Send(Message{To: "a@example.com", Subject: "Reset your password", Text: "https://example.com/reset?token=secret"}))
+
+	assert.Contains(t, logs.String(), "Reset your password")
+	assert.NotContains(t, logs.String(), "secret")
+}
@@ -0,0 +1,33 @@
+// Package mailertest provides a mailer for tests that keeps every message
+// in memory instead of delivering it.
+package mailertest
+
+import (
+	"sync"
+
+	"hitalent-test/internal/mailer"
+)
+
+// Recorder is a mailer.Mailer that remembers what it sent.
+type Recorder struct {
+	mu       sync.Mutex
+	messages []mailer.Message
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+func (r *Recorder) Send(msg mailer.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, msg)
+	return nil
+}
+
+// Messages returns a copy of everything sent so far.
+func (r *Recorder) Messages() []mailer.Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]mailer.Message(nil), r.messages...)
+}
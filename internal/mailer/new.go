@@ -0,0 +1,22 @@
+package mailer
+
+import (
+	"fmt"
+	"log/slog"
+
+	"hitalent-test/internal/config"
+)
+
+// New builds the mailer selected by cfg.Driver.
+func New(cfg config.MailConfig, logger *slog.Logger) (Mailer, error) {
+	switch cfg.Driver {
+	case "log":
+		return NewLogMailer(logger), nil
+	case "file":
+		return NewFileMailer(cfg.Dir, cfg.From), nil
+	case "smtp":
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From)
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
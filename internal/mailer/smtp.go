@@ -0,0 +1,41 @@
+package mailer
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+type SMTPMailer struct {
+	addr     string
+	auth     smtp.Auth
+	from     string
+	envelope string
+}
+
+func NewSMTPMailer(host string, port int, username, password, from string) (*SMTPMailer, error) {
+	addr, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address: %w", err)
+	}
+
+	m := &SMTPMailer{
+		addr:     net.JoinHostPort(host, strconv.Itoa(port)),
+		from:     from,
+		envelope: addr.Address,
+	}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m, nil
+}
+
+func (m *SMTPMailer) Send(msg Message) error {
+	if err := smtp.SendMail(m.addr, m.auth, m.envelope, []string{msg.To}, msg.Bytes(m.from, time.Now())); err != nil {
+		return fmt.Errorf("failed to send mail via smtp: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+// Package markdown renders the Markdown text of questions and answers to
+// HTML that is safe to show in a browser. The dialect is CommonMark with
+// GitHub-style tables; raw HTML in the source is dropped and the output is
+// passed through an allowlist sanitizer.
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+
+	"hitalent-test/internal/mention"
+)
+
+var (
+	converter = goldmark.New(
+		goldmark.WithExtensions(extension.NewTable(
+			extension.WithTableCellAlignMethod(extension.TableCellAlignAttribute),
+		)),
+		goldmark.WithParserOptions(parser.WithASTTransformers(
+			util.Prioritized(mentionTransformer{}, 1000),
+		)),
+	)
+	policy = newPolicy()
+	strict = bluemonday.StrictPolicy()
+)
+
+// newPolicy allows the elements the renderer produces and nothing else.
+// URLs must be relative or use http, https or mailto.
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowElements("p", "br", "hr", "h1", "h2", "h3", "h4", "h5", "h6",
+		"strong", "em", "del", "code", "pre", "blockquote", "ul", "li",
+		"table", "thead", "tbody", "tr")
+	p.AllowAttrs("start").Matching(bluemonday.Integer).OnElements("ol")
+	p.AllowElements("ol")
+	p.AllowAttrs("align").Matching(regexp.MustCompile(`^(left|center|right)$`)).OnElements("th", "td")
+	p.AllowElements("th", "td")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#.-]+$`)).OnElements("code")
+
+	p.RequireParseableURLs(true)
+	p.AllowRelativeURLs(true)
+	p.AllowURLSchemes("http", "https", "mailto")
+	p.AllowAttrs("href", "title").OnElements("a")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^mention$`)).OnElements("a")
+	p.RequireNoFollowOnFullyQualifiedLinks(true)
+	p.AllowAttrs("src", "alt", "title").OnElements("img")
+	return p
+}
+
+// Render converts Markdown source to sanitized HTML. mentions maps
+// lower-cased @handles to the URLs they link to; other handles stay text.
+func Render(source string, mentions map[string]string) string {
+	ctx := parser.NewContext()
+	ctx.Set(mentionsKey, mentions)
+
+	var buf bytes.Buffer
+	if err := converter.Convert([]byte(source), &buf, parser.WithContext(ctx)); err != nil {
+		// Rendering to a buffer only fails on writer errors; fall back to
+		// the escaped source rather than losing the post.
+		return "<p>" + html.EscapeString(source) + "</p>\n"
+	}
+	return policy.Sanitize(buf.String())
+}
+
+// PlainText returns the text of rendered HTML without markup, as a reader
+// sees it. Block elements end up on separate lines, with at most one blank
+// line between them.
+func PlainText(rendered string) string {
+	lines := strings.Split(html.UnescapeString(strict.Sanitize(rendered)), "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		line = strings.TrimRightFunc(line, unicode.IsSpace)
+		if line == "" && (len(kept) == 0 || kept[len(kept)-1] == "") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+var mentionsKey = parser.NewContextKey()
+
+// mentionTransformer turns the @handles in the text of a document into
+// links. Handles in code, links and images are left alone.
+type mentionTransformer struct{}
+
+func (mentionTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	mentions, _ := pc.Get(mentionsKey).(map[string]string)
+	if len(mentions) == 0 {
+		return
+	}
+	source := reader.Source()
+
+	var texts []*ast.Text
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.CodeSpan, *ast.Link, *ast.AutoLink, *ast.Image, *ast.CodeBlock, *ast.FencedCodeBlock, *ast.HTMLBlock, *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			texts = append(texts, n)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	for _, t := range texts {
+		// Nodes merged into a preceding one are no longer in the tree.
+		if t.Parent() != nil {
+			linkMentions(mergeText(t), source, mentions)
+		}
+	}
+}
+
+// mergeText joins t with the text nodes that directly follow it in the
+// source, which the parser splits at would-be emphasis delimiters such as
+// the underscore in @john_doe.
+func mergeText(t *ast.Text) *ast.Text {
+	for !t.SoftLineBreak() && !t.HardLineBreak() {
+		next, ok := t.NextSibling().(*ast.Text)
+		if !ok || next.Segment.Start != t.Segment.Stop || next.IsRaw() != t.IsRaw() {
+			break
+		}
+		t.Segment = t.Segment.WithStop(next.Segment.Stop)
+		t.SetSoftLineBreak(next.SoftLineBreak())
+		t.SetHardLineBreak(next.HardLineBreak())
+		t.Parent().RemoveChild(t.Parent(), next)
+	}
+	return t
+}
+
+// linkMentions splits t around the known mentions in it, replacing each
+// with a link.
+func linkMentions(t *ast.Text, source []byte, mentions map[string]string) {
+	parent := t.Parent()
+	segment := t.Segment
+	rest := t
+	for _, m := range mention.Find(string(segment.Value(source))) {
+		url, ok := mentions[strings.ToLower(m.Handle)]
+		if !ok {
+			continue
+		}
+		start, stop := segment.Start+m.Start, segment.Start+m.End
+
+		before := ast.NewTextSegment(text.NewSegment(rest.Segment.Start, start))
+		link := ast.NewLink()
+		link.Destination = []byte(url)
+		link.SetAttributeString("class", []byte("mention"))
+		link.AppendChild(link, ast.NewTextSegment(text.NewSegment(start, stop)))
+		after := ast.NewTextSegment(text.NewSegment(stop, segment.Stop))
+		after.SetSoftLineBreak(rest.SoftLineBreak())
+		after.SetHardLineBreak(rest.HardLineBreak())
+
+		parent.InsertBefore(parent, rest, before)
+		parent.InsertBefore(parent, rest, link)
+		parent.ReplaceChild(parent, rest, after)
+		rest = after
+	}
+}
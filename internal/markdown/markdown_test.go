@@ -0,0 +1,45 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"emphasis", "Use *em* and **strong**", "<p>Use <em>em</em> and <strong>strong</strong></p>\n"},
+		{"fenced code", "```go\nfmt.Println(\"<x>\")\n```", "<pre><code class=\"language-go\">fmt.Println(&#34;&lt;x&gt;&#34;)\n</code></pre>\n"},
+		{"table", "| a | b |\n|:--|--:|\n| 1 | 2 |",
+			"<table>\n<thead>\n<tr>\n<th align=\"left\">a</th>\n<th align=\"right\">b</th>\n</tr>\n</thead>\n" +
+				"<tbody>\n<tr>\n<td align=\"left\">1</td>\n<td align=\"right\">2</td>\n</tr>\n</tbody>\n</table>\n"},
+		{"external link", "[docs](https://example.com)", "<p><a href=\"https://example.com\" rel=\"nofollow\">docs</a></p>\n"},
+		{"raw html", "<script>alert(1)</script>\n\nHi <b onclick=\"x()\">there</b>", "\n<p>Hi there</p>\n"},
+		{"javascript link", "[click](javascript:alert(1))", "<p>click</p>\n"},
+		{"image", "![cat](https://example.com/cat.png \"A cat\")", "<p><img src=\"https://example.com/cat.png\" alt=\"cat\" title=\"A cat\"></p>\n"},
+		{"data image", "![x](data:image/png;base64,AAAA)", "<p><img alt=\"x\"></p>\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Render(tt.source, nil))
+		})
+	}
+}
+
+func TestRender_Mentions(t *testing.T) {
+	mentions := map[string]string{"john_doe": "/users/1", "bob": "/users/2"}
+
+	got := Render("Hi @John_Doe and @bob!\nNot `@bob`, [@bob](https://example.com) or @carol.", mentions)
+	assert.Equal(t, "<p>Hi <a href=\"/users/1\" class=\"mention\">@John_Doe</a> and <a href=\"/users/2\" class=\"mention\">@bob</a>!\n"+
+		"Not <code>@bob</code>, <a href=\"https://example.com\" rel=\"nofollow\">@bob</a> or @carol.</p>\n", got)
+}
+
+func TestPlainText(t *testing.T) {
+	source := "# Title\n\nSome *markup* &amp; [a link](https://example.com).\n\n\n```\n  indented\n```\n\n- one\n- two"
+	assert.Equal(t, "Title\nSome markup & a link.\n  indented\n\none\ntwo", PlainText(Render(source, nil)))
+	assert.Empty(t, PlainText(Render("<div></div>\n\n---", nil)))
+}
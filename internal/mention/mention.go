@@ -0,0 +1,51 @@
+// Package mention finds @handle mentions of users in post text.
+package mention
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MaxHandleLength bounds a handle; longer words after @ are not mentions.
+const MaxHandleLength = 64
+
+// pattern matches an @ at the start of the text or after a character that
+// cannot be part of an email address, so "bob@example.com" mentions
+// nobody. The handle may contain dots and dashes, but not end with them,
+// which leaves sentence punctuation out.
+var pattern = regexp.MustCompile(`(^|[^\p{L}\p{N}_.+@-])@([\p{L}\p{N}_](?:[\p{L}\p{N}_.-]*[\p{L}\p{N}_])?)`)
+
+// Match is a mention in a text. Start and End are byte offsets of the
+// whole mention, including the @.
+type Match struct {
+	Handle     string
+	Start, End int
+}
+
+// Find returns the mentions in text in order of appearance.
+func Find(text string) []Match {
+	var matches []Match
+	for _, loc := range pattern.FindAllStringSubmatchIndex(text, -1) {
+		handle := text[loc[4]:loc[5]]
+		if len(handle) > MaxHandleLength {
+			continue
+		}
+		matches = append(matches, Match{Handle: handle, Start: loc[4] - 1, End: loc[5]})
+	}
+	return matches
+}
+
+// Handles returns the distinct handles mentioned in text, lower-cased, in
+// order of first appearance.
+func Handles(text string) []string {
+	var handles []string
+	seen := make(map[string]bool)
+	for _, m := range Find(text) {
+		handle := strings.ToLower(m.Handle)
+		if !seen[handle] {
+			seen[handle] = true
+			handles = append(handles, handle)
+		}
+	}
+	return handles
+}
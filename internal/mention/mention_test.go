@@ -0,0 +1,37 @@
+package mention
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandles(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"start of text", "@alice what do you think?", []string{"alice"}},
+		{"sentence punctuation", "Thanks, @bob.smith. And @Carol-K!", []string{"bob.smith", "carol-k"}},
+		{"deduplicated case-insensitively", "@Alice and @alice again", []string{"alice"}},
+		{"email address", "Write to bob@example.com", nil},
+		{"double at", "@@alice", nil},
+		{"unicode", "(@Анна) спасибо", []string{"анна"}},
+		{"bare at", "meet @ noon", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Handles(tt.text))
+		})
+	}
+}
+
+func TestFind_Offsets(t *testing.T) {
+	text := "Hi @alice, see @bob."
+	matches := Find(text)
+	assert.Equal(t, []Match{{"alice", 3, 9}, {"bob", 15, 19}}, matches)
+	for _, m := range matches {
+		assert.Equal(t, "@"+m.Handle, text[m.Start:m.End])
+	}
+}
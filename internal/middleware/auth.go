@@ -2,49 +2,157 @@ package middleware
 
 import (
 	"context"
-	"errors"
+	"fmt"
+	"hitalent-test/internal/domain"
 	"hitalent-test/internal/handler"
 	"hitalent-test/internal/service"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
 )
 
-func Auth(tokenService *service.TokenService, logger *slog.Logger) func(http.Handler) http.Handler {
+// Auth requires a bearer token: either a JWT access token or a personal
+// access token. For personal access tokens the granted scopes are stored in
+// the context under "token_scopes"; JWT sessions have no scope restrictions.
+func Auth(tokenService *service.TokenService, pats *service.PersonalAccessTokenService, logger *slog.Logger) func(http.Handler) http.Handler {
+	return authenticate(tokenService, pats, logger, false)
+}
+
+// OptionalAuth authenticates the request like Auth when it carries an
+// Authorization header and lets anonymous requests through.
+func OptionalAuth(tokenService *service.TokenService, pats *service.PersonalAccessTokenService, logger *slog.Logger) func(http.Handler) http.Handler {
+	return authenticate(tokenService, pats, logger, true)
+}
+
+func authenticate(tokenService *service.TokenService, pats *service.PersonalAccessTokenService, logger *slog.Logger, optional bool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			requestID := r.Context().Value("request_id").(string)
 
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
-				err := errors.New("missing authorization header")
+				if optional {
+					next.ServeHTTP(w, r)
+					return
+				}
+				err := fmt.Errorf("%w: missing authorization header", domain.ErrUnauthorized)
 				handler.HandleError(w, logger, err, requestID)
 				return
 			}
 
 			parts := strings.Split(authHeader, " ")
 			if len(parts) != 2 || parts[0] != "Bearer" {
-				err := errors.New("invalid authorization header format")
+				err := fmt.Errorf("%w: invalid authorization header format", domain.ErrUnauthorized)
 				handler.HandleError(w, logger, err, requestID)
 				return
 			}
 
 			token := parts[1]
+			if strings.HasPrefix(token, domain.PersonalAccessTokenPrefix) {
+				user, pat, err := pats.Authenticate(token)
+				if err != nil {
+					handler.HandleError(w, logger, err, requestID)
+					return
+				}
+
+				ctx := context.WithValue(r.Context(), "user_id", user.ID)
+				ctx = context.WithValue(ctx, "user_email", user.Email)
+				ctx = context.WithValue(ctx, "user_role", user.Role)
+				ctx = context.WithValue(ctx, "token_scopes", pat.Scopes)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
 			claims, err := tokenService.VerifyToken(token)
 			if err != nil {
 				logger.Warn("invalid token",
 					slog.String("request_id", requestID),
 					slog.String("error", err.Error()),
 				)
-				http.Error(w, "invalid or expired token", http.StatusUnauthorized)
+				err := fmt.Errorf("%w: invalid or expired token", domain.ErrUnauthorized)
+				handler.HandleError(w, logger, err, requestID)
 				return
 			}
 
 			ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
 			ctx = context.WithValue(ctx, "user_email", claims.Email)
+			ctx = context.WithValue(ctx, "user_role", claims.Role)
 			r = r.WithContext(ctx)
 
 			next.ServeHTTP(w, r)
 		})
 	}
 }
+
+// RequireRole must be chained after Auth. It rejects requests whose token
+// does not carry one of the given roles.
+func RequireRole(logger *slog.Logger, roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Context().Value("request_id").(string)
+			role, _ := r.Context().Value("user_role").(string)
+
+			for _, allowed := range roles {
+				if role == allowed {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			err := fmt.Errorf("%w: insufficient permissions", domain.ErrForbidden)
+			handler.HandleError(w, logger, err, requestID)
+		})
+	}
+}
+
+// RequireScope must be chained after Auth or OptionalAuth. It rejects
+// personal access tokens that were not granted scope; JWT sessions and
+// anonymous requests pass.
+func RequireScope(logger *slog.Logger, scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scopes, isToken := tokenScopes(r)
+			if !isToken || slices.Contains(scopes, scope) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			requestID := r.Context().Value("request_id").(string)
+			err := fmt.Errorf("%w: token lacks the %q scope", domain.ErrForbidden, scope)
+			handler.HandleError(w, logger, err, requestID)
+		})
+	}
+}
+
+// RequireSession must be chained after Auth. It keeps personal access tokens
+// away from account security endpoints, so that a leaked token cannot be
+// used to mint new tokens or change credentials.
+func RequireSession(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, isToken := tokenScopes(r); !isToken {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			requestID := r.Context().Value("request_id").(string)
+			err := fmt.Errorf("%w: not available to personal access tokens", domain.ErrForbidden)
+			handler.HandleError(w, logger, err, requestID)
+		})
+	}
+}
+
+// tokenScopes returns the scopes granted to the personal access token that
+// authenticated the request; isToken is false for JWT sessions and
+// anonymous requests. A value of any other type counts as a token without
+// scopes, so that a wiring mistake refuses requests instead of letting
+// them through.
+func tokenScopes(r *http.Request) (scopes []string, isToken bool) {
+	value := r.Context().Value("token_scopes")
+	if value == nil {
+		return nil, false
+	}
+	scopes, _ = value.([]string)
+	return scopes, true
+}
@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"context"
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/repository"
+	"hitalent-test/internal/service"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const authTestUserID = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+
+// tokenRepository finds every personal access token with the same scopes.
+type tokenRepository struct {
+	repository.PersonalAccessTokenRepository
+	scopes []string
+}
+
+func (r *tokenRepository) GetByHash(hash string) (*domain.PersonalAccessToken, error) {
+	return &domain.PersonalAccessToken{ID: "token-1", UserID: authTestUserID, Scopes: r.scopes}, nil
+}
+
+func (r *tokenRepository) TouchLastUsed(id string, at time.Time) error {
+	return nil
+}
+
+type userRepository struct {
+	repository.UserRepository
+}
+
+func (userRepository) GetByID(id string) (*domain.User, error) {
+	return &domain.User{ID: id, Email: "alice@example.com", Role: domain.RoleUser}, nil
+}
+
+type authTestEnv struct {
+	tokens *service.TokenService
+	pats   *service.PersonalAccessTokenService
+	logger *slog.Logger
+	jwt    string
+}
+
+func newAuthTestEnv(t *testing.T, scopes ...string) *authTestEnv {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	tokens := service.NewTokenService(&config.JWTConfig{Secret: "test-secret", AccessTokenExpiry: time.Hour})
+	jwt, err := tokens.GenerateAccessToken(authTestUserID, "alice@example.com", domain.RoleUser)
+	require.NoError(t, err)
+	return &authTestEnv{
+		tokens: tokens,
+		pats:   service.NewPersonalAccessTokenService(&tokenRepository{scopes: scopes}, userRepository{}, service.NopAuditor{}, logger),
+		logger: logger,
+		jwt:    jwt,
+	}
+}
+
+// serve runs a request with the given Authorization header through h,
+// which ends in a handler answering 200.
+func serve(h func(http.Handler) http.Handler, authorization string) int {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r = r.WithContext(context.WithValue(r.Context(), "request_id", "test"))
+	if authorization != "" {
+		r.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	h(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})).ServeHTTP(w, r)
+	return w.Code
+}
+
+func chain(middlewares ...func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	pat := "Bearer " + domain.PersonalAccessTokenPrefix + "secret"
+	tests := []struct {
+		name          string
+		scopes        []string
+		optional      bool
+		authorization string
+		want          int
+	}{
+		{"token without the scope", []string{domain.ScopeRead}, false, pat, http.StatusForbidden},
+		{"token without scopes", nil, false, pat, http.StatusForbidden},
+		{"token with the scope", []string{domain.ScopeRead, domain.ScopeWriteQuestions}, false, pat, http.StatusOK},
+		{"session", nil, false, "jwt", http.StatusOK},
+		{"anonymous", nil, true, "", http.StatusOK},
+		{"token without the scope on an optional route", []string{domain.ScopeRead}, true, pat, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newAuthTestEnv(t, tt.scopes...)
+			auth := Auth(env.tokens, env.pats, env.logger)
+			if tt.optional {
+				auth = OptionalAuth(env.tokens, env.pats, env.logger)
+			}
+			authorization := tt.authorization
+			if authorization == "jwt" {
+				authorization = "Bearer " + env.jwt
+			}
+
+			code := serve(chain(auth, RequireScope(env.logger, domain.ScopeWriteQuestions)), authorization)
+			assert.Equal(t, tt.want, code)
+		})
+	}
+}
+
+func TestRequireSession(t *testing.T) {
+	env := newAuthTestEnv(t, domain.Scopes...)
+	session := chain(Auth(env.tokens, env.pats, env.logger), RequireSession(env.logger))
+
+	assert.Equal(t, http.StatusForbidden, serve(session, "Bearer "+domain.PersonalAccessTokenPrefix+"secret"))
+	assert.Equal(t, http.StatusOK, serve(session, "Bearer "+env.jwt))
+	assert.Equal(t, http.StatusUnauthorized, serve(session, ""))
+}
+
+func TestTokenScopes_FailsClosed(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	// Scopes stored under the key with an unexpected type still mark the
+	// request as made with a token.
+	misplaced := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "token_scopes", "write:questions")))
+		})
+	}
+
+	assert.Equal(t, http.StatusForbidden, serve(chain(misplaced, RequireScope(logger, domain.ScopeWriteQuestions)), ""))
+	assert.Equal(t, http.StatusForbidden, serve(chain(misplaced, RequireSession(logger)), ""))
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+// BodyLimit caps request bodies at maxBytes. Requests for which exempt
+// returns true are passed through untouched, for handlers that set their
+// own limit.
+func BodyLimit(maxBytes int64, exempt func(*http.Request) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body != nil && (exempt == nil || !exempt(r)) {
+				r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
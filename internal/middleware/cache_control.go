@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+// CacheControl sets the Cache-Control header of responses to value.
+// Handlers may still replace it, and error responses are never cached.
+func CacheControl(value string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", value)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// ClientIP resolves the address of the client behind trusted reverse proxies
+// and stores it in the request context under "client_ip".
+type ClientIP struct {
+	trusted atomic.Pointer[[]*net.IPNet]
+}
+
+func NewClientIP(trustedProxies []string) *ClientIP {
+	c := &ClientIP{}
+	c.SetTrustedProxies(trustedProxies)
+	return c
+}
+
+// SetTrustedProxies replaces the trusted proxy list. Entries are IPs or
+// CIDRs; invalid entries are ignored as they are rejected by config
+// validation.
+func (c *ClientIP) SetTrustedProxies(proxies []string) {
+	nets := make([]*net.IPNet, 0, len(proxies))
+	for _, p := range proxies {
+		if !strings.Contains(p, "/") {
+			if ip := net.ParseIP(p); ip != nil && ip.To4() != nil {
+				p += "/32"
+			} else {
+				p += "/128"
+			}
+		}
+		if _, n, err := net.ParseCIDR(p); err == nil {
+			nets = append(nets, n)
+		}
+	}
+	c.trusted.Store(&nets)
+}
+
+func (c *ClientIP) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), "client_ip", c.resolve(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// resolve walks X-Forwarded-For from the nearest hop outwards while the hop
+// is a trusted proxy, so a client cannot spoof its address by prepending
+// entries to the header.
+func (c *ClientIP) resolve(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	if !c.isTrusted(host) {
+		return host
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		host = hop
+		if !c.isTrusted(hop) {
+			break
+		}
+	}
+	return host
+}
+
+func (c *ClientIP) isTrusted(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range *c.trusted.Load() {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"sync/atomic"
+)
+
+// CORS answers cross-origin requests from an allowlist of origins. The list
+// can be swapped at runtime with SetAllowedOrigins. Only listed origins may
+// send credentials; "*" lets any other origin read responses without them.
+type CORS struct {
+	origins atomic.Pointer[[]string]
+}
+
+func NewCORS(origins []string) *CORS {
+	c := &CORS{}
+	c.SetAllowedOrigins(origins)
+	return c
+}
+
+func (c *CORS) SetAllowedOrigins(origins []string) {
+	origins = append([]string(nil), origins...)
+	c.origins.Store(&origins)
+}
+
+func (c *CORS) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		origins := *c.origins.Load()
+		listed := slices.Contains(origins, origin)
+		allowed := listed || slices.Contains(origins, "*")
+
+		w.Header().Add("Vary", "Origin")
+		if listed {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		} else if allowed {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		}
+		if allowed {
+			// Scripts need the tag to make conditional writes.
+			w.Header().Set("Access-Control-Expose-Headers", "ETag")
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			if allowed {
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, If-Match, If-None-Match")
+				w.Header().Set("Access-Control-Max-Age", "600")
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
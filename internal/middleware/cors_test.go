@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func corsRequest(cors *CORS, method, origin string) *httptest.ResponseRecorder {
+	handler := cors.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	req := httptest.NewRequest(method, "/api/questions", nil)
+	req.Header.Set("Origin", origin)
+	if method == http.MethodOptions {
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCORS_ListedOrigin(t *testing.T) {
+	cors := NewCORS([]string{"https://app.example.com"})
+
+	rec := corsRequest(cors, http.MethodGet, "https://app.example.com")
+	assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+
+	rec = corsRequest(cors, http.MethodGet, "https://evil.example.com")
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestCORS_WildcardSendsNoCredentials(t *testing.T) {
+	cors := NewCORS([]string{"*", "https://app.example.com"})
+
+	for _, method := range []string{http.MethodGet, http.MethodOptions} {
+		rec := corsRequest(cors, method, "https://evil.example.com")
+		assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"), method)
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Credentials"), method)
+	}
+
+	// Listed origins keep their credentials next to the wildcard.
+	rec := corsRequest(cors, http.MethodGet, "https://app.example.com")
+	assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+}
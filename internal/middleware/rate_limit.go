@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"fmt"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/handler"
+	"hitalent-test/internal/ratelimit"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimit enforces the policy of a route group. Requests are keyed by the
+// authenticated user when Auth ran before it and by client IP otherwise.
+// Store failures are logged and the request is let through.
+func RateLimit(limiter *ratelimit.Limiter, group string, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Context().Value("request_id").(string)
+
+			key := "ip:" + clientIP(r)
+			if userID, ok := r.Context().Value("user_id").(string); ok && userID != "" {
+				key = "user:" + userID
+			}
+
+			result, policy, enforced, err := limiter.Take(group, key)
+			if err != nil {
+				logger.Warn("rate limiter unavailable",
+					slog.String("request_id", requestID),
+					slog.String("error", err.Error()),
+				)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !enforced {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d;burst=%d",
+				policy.Requests, int(policy.Period.Seconds()), policy.Burst))
+			h.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			h.Set("RateLimit-Reset", ceilSeconds(result.Reset))
+
+			if !result.Allowed {
+				h.Set("Retry-After", ceilSeconds(result.RetryAfter))
+				logger.Info("rate limit exceeded",
+					slog.String("request_id", requestID),
+					slog.String("group", group),
+					slog.String("key", key),
+				)
+				err := fmt.Errorf("%w: rate limit exceeded, retry later", domain.ErrTooManyRequests)
+				handler.HandleError(w, logger, err, requestID)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func clientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value("client_ip").(string); ok && ip != "" {
+		return ip
+	}
+	return r.RemoteAddr
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
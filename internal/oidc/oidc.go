@@ -0,0 +1,293 @@
+// Package oidc is a minimal OpenID Connect relying party: discovery, the
+// authorization code flow with PKCE and ID token validation against the
+// provider's JWKS.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Config describes a provider registration.
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Claims are the ID token claims the service relies on.
+type Claims struct {
+	Nonce           string `json:"nonce"`
+	Email           string `json:"email"`
+	EmailVerified   bool   `json:"email_verified"`
+	Name            string `json:"name"`
+	AuthorizedParty string `json:"azp"`
+	jwt.RegisteredClaims
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider talks to one OpenID provider. The discovery document is fetched
+// on first use, so the service starts even if the provider is down.
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu          sync.Mutex
+	meta        *discovery
+	keys        map[string]interface{}
+	keysFetched time.Time
+}
+
+// keyRefreshInterval limits JWKS refetches triggered by unknown key IDs.
+const keyRefreshInterval = time.Minute
+
+func NewProvider(cfg Config, client *http.Client) *Provider {
+	return &Provider{cfg: cfg, client: client}
+}
+
+// AuthCodeURL returns the URL to send the browser to. challenge is the
+// S256 PKCE challenge of the verifier later passed to Exchange.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, challenge string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	v := url.Values{}
+	v.Set("response_type", "code")
+	v.Set("client_id", p.cfg.ClientID)
+	v.Set("redirect_uri", p.cfg.RedirectURL)
+	v.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	v.Set("state", state)
+	v.Set("nonce", nonce)
+	v.Set("code_challenge", challenge)
+	v.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + v.Encode(), nil
+}
+
+// Exchange redeems an authorization code and returns the raw ID token.
+func (p *Provider) Exchange(ctx context.Context, code, verifier string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("code_verifier", verifier)
+	form.Set("client_id", p.cfg.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := p.do(req, &token)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %w", err)
+	}
+	if token.Error != "" {
+		return "", fmt.Errorf("token request failed: %s: %s", token.Error, token.ErrorDescription)
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("token request failed: status %d", status)
+	}
+	if token.IDToken == "" {
+		return "", errors.New("token response has no id_token")
+	}
+	return token.IDToken, nil
+}
+
+// VerifyIDToken checks the signature, issuer, audience, expiry and nonce of
+// an ID token.
+func (p *Provider) VerifyIDToken(ctx context.Context, raw, nonce string) (*Claims, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &Claims{}
+	_, err = jwt.ParseWithClaims(raw, claims,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			return p.key(ctx, kid)
+		},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "PS256"}),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id token: %w", err)
+	}
+
+	if claims.Subject == "" {
+		return nil, errors.New("invalid id token: missing subject")
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.cfg.ClientID {
+		return nil, errors.New("invalid id token: unexpected authorized party")
+	}
+	if nonce == "" || claims.Nonce != nonce {
+		return nil, errors.New("invalid id token: nonce mismatch")
+	}
+
+	return claims, nil
+}
+
+func (p *Provider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	endpoint := strings.TrimRight(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build discovery request: %w", err)
+	}
+
+	var meta discovery
+	status, err := p.do(req, &meta)
+	if err != nil {
+		return nil, fmt.Errorf("discovery failed: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("discovery failed: status %d", status)
+	}
+
+	// The issuer must match exactly, otherwise a compromised discovery
+	// document could make tokens of another issuer acceptable.
+	if meta.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("discovery failed: issuer %q does not match %q", meta.Issuer, p.cfg.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, errors.New("discovery failed: incomplete provider metadata")
+	}
+
+	p.meta = &meta
+	return p.meta, nil
+}
+
+// key returns the verification key with the given ID, refetching the JWKS
+// when the provider has rotated its keys.
+func (p *Provider) key(ctx context.Context, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.lookup(kid); ok {
+		return key, nil
+	}
+
+	if time.Since(p.keysFetched) < keyRefreshInterval && p.keys != nil {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.meta.JWKSURI, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build jwks request: %w", err)
+	}
+
+	var set jwkSet
+	status, err := p.do(req, &set)
+	if err != nil {
+		return nil, fmt.Errorf("jwks request failed: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("jwks request failed: status %d", status)
+	}
+
+	p.keys = set.publicKeys()
+	p.keysFetched = time.Now()
+
+	if key, ok := p.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookup finds a key by ID. Tokens without a key ID are accepted only when
+// the provider publishes a single key.
+func (p *Provider) lookup(kid string) (interface{}, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+func (p *Provider) do(req *http.Request, out interface{}) (int, error) {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if err := json.Unmarshal(body, out); err != nil && resp.StatusCode == http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("invalid response: %w", err)
+	}
+	return resp.StatusCode, nil
+}
+
+// NewVerifier returns a random PKCE code verifier, also suitable for state
+// and nonce values.
+func NewVerifier() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Challenge returns the S256 PKCE challenge of verifier.
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
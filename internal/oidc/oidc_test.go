@@ -0,0 +1,117 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"hitalent-test/internal/oidc/oidctest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const redirectURL = "http://localhost:8080/auth/oidc/test/callback"
+
+func newTestProvider(t *testing.T) (*Provider, *oidctest.Provider) {
+	t.Helper()
+	idp := oidctest.NewProvider("client", "secret")
+	t.Cleanup(idp.Close)
+	idp.SetUser(oidctest.User{Subject: "sub-1", Email: "user@example.com", EmailVerified: true})
+
+	p := NewProvider(Config{
+		Issuer:       idp.Issuer(),
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"openid", "email"},
+	}, &http.Client{Timeout: 5 * time.Second})
+	return p, idp
+}
+
+// authorize follows the authorization URL and returns the code the provider
+// redirected back with.
+func authorize(t *testing.T, authURL, state string) string {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, state, location.Query().Get("state"))
+	return location.Query().Get("code")
+}
+
+func TestProvider_AuthorizationCodeFlow(t *testing.T) {
+	p, _ := newTestProvider(t)
+	ctx := context.Background()
+
+	verifier, err := NewVerifier()
+	require.NoError(t, err)
+
+	authURL, err := p.AuthCodeURL(ctx, "state-1", "nonce-1", Challenge(verifier))
+	require.NoError(t, err)
+
+	code := authorize(t, authURL, "state-1")
+	idToken, err := p.Exchange(ctx, code, verifier)
+	require.NoError(t, err)
+
+	claims, err := p.VerifyIDToken(ctx, idToken, "nonce-1")
+	require.NoError(t, err)
+	assert.Equal(t, "sub-1", claims.Subject)
+	assert.Equal(t, "user@example.com", claims.Email)
+	assert.True(t, claims.EmailVerified)
+
+	_, err = p.VerifyIDToken(ctx, idToken, "other-nonce")
+	assert.Error(t, err)
+
+	_, err = p.VerifyIDToken(ctx, idToken[:len(idToken)-4]+"AAAA", "nonce-1")
+	assert.Error(t, err)
+}
+
+func TestProvider_ExchangeRejectsWrongVerifier(t *testing.T) {
+	p, _ := newTestProvider(t)
+	ctx := context.Background()
+
+	verifier, err := NewVerifier()
+	require.NoError(t, err)
+	authURL, err := p.AuthCodeURL(ctx, "state", "nonce", Challenge(verifier))
+	require.NoError(t, err)
+
+	code := authorize(t, authURL, "state")
+	_, err = p.Exchange(ctx, code, "wrong-verifier")
+	assert.ErrorContains(t, err, "invalid_grant")
+}
+
+func TestProvider_VerifyRejectsForeignAudience(t *testing.T) {
+	p, idp := newTestProvider(t)
+	ctx := context.Background()
+	idp.SetAudience("another-client")
+
+	verifier, err := NewVerifier()
+	require.NoError(t, err)
+	authURL, err := p.AuthCodeURL(ctx, "state", "nonce", Challenge(verifier))
+	require.NoError(t, err)
+
+	idToken, err := p.Exchange(ctx, authorize(t, authURL, "state"), verifier)
+	require.NoError(t, err)
+
+	_, err = p.VerifyIDToken(ctx, idToken, "nonce")
+	assert.Error(t, err)
+}
+
+func TestProvider_DiscoveryRejectsIssuerMismatch(t *testing.T) {
+	idp := oidctest.NewProvider("client", "secret")
+	defer idp.Close()
+
+	p := NewProvider(Config{Issuer: idp.Issuer() + "/", ClientID: "client"}, http.DefaultClient)
+	_, err := p.AuthCodeURL(context.Background(), "state", "nonce", "challenge")
+	assert.Error(t, err)
+}
@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"hitalent-test/internal/config"
+)
+
+// MemoryStore keeps buckets in process memory. Limits are per instance.
+type MemoryStore struct {
+	buckets map[string]*Bucket
+	mu      sync.Mutex
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*Bucket),
+	}
+}
+
+func (s *MemoryStore) Take(key string, policy config.RateLimitPolicy, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &Bucket{}
+		s.buckets[key] = b
+	}
+	return take(b, policy, now), nil
+}
+
+func (s *MemoryStore) Cleanup(olderThan time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, b := range s.buckets {
+		if b.UpdatedAt.Before(olderThan) {
+			delete(s.buckets, key)
+		}
+	}
+	return nil
+}
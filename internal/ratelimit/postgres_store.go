@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"time"
+
+	"hitalent-test/internal/config"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type bucketRecord struct {
+	Key       string    `gorm:"type:varchar(255);primaryKey"`
+	Tokens    float64   `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime:false;not null"`
+}
+
+func (bucketRecord) TableName() string {
+	return "rate_limit_buckets"
+}
+
+// PostgresStore keeps buckets in the rate_limit_buckets table so that all
+// replicas share the same limits. Each Take locks the bucket row for the
+// duration of a short transaction.
+type PostgresStore struct {
+	db *gorm.DB
+}
+
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Take(key string, policy config.RateLimitPolicy, now time.Time) (Result, error) {
+	var result Result
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		record := bucketRecord{Key: key, Tokens: float64(policy.Burst), UpdatedAt: now}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&record).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&record, "key = ?", key).Error; err != nil {
+			return err
+		}
+
+		b := Bucket{Tokens: record.Tokens, UpdatedAt: record.UpdatedAt}
+		result = take(&b, policy, now)
+
+		return tx.Model(&record).Updates(map[string]interface{}{
+			"tokens":     b.Tokens,
+			"updated_at": b.UpdatedAt,
+		}).Error
+	})
+	return result, err
+}
+
+func (s *PostgresStore) Cleanup(olderThan time.Time) error {
+	return s.db.Where("updated_at < ?", olderThan).Delete(&bucketRecord{}).Error
+}
@@ -0,0 +1,123 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"hitalent-test/internal/config"
+)
+
+const (
+	GroupAuth   = "auth"
+	GroupWrites = "writes"
+	GroupReads  = "reads"
+)
+
+// Bucket is the persisted state of a token bucket.
+type Bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// Result describes the outcome of taking a token from a bucket.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Store keeps token buckets by key. Take must refill, consume and persist a
+// bucket atomically with respect to other callers using the same key.
+type Store interface {
+	Take(key string, policy config.RateLimitPolicy, now time.Time) (Result, error)
+	Cleanup(olderThan time.Time) error
+}
+
+// Limiter applies the configured policy of a route group to a key.
+type Limiter struct {
+	store Store
+	cfg   atomic.Pointer[config.RateLimitConfig]
+}
+
+func NewLimiter(store Store, cfg config.RateLimitConfig) *Limiter {
+	l := &Limiter{store: store}
+	l.SetConfig(cfg)
+	return l
+}
+
+// SetConfig swaps the policies in effect. The store is fixed at startup.
+func (l *Limiter) SetConfig(cfg config.RateLimitConfig) {
+	l.cfg.Store(&cfg)
+}
+
+// Policy returns the policy of a group and whether it is enforced.
+func (l *Limiter) Policy(group string) (config.RateLimitPolicy, bool) {
+	cfg := l.cfg.Load()
+	if !cfg.Enabled {
+		return config.RateLimitPolicy{}, false
+	}
+
+	var policy config.RateLimitPolicy
+	switch group {
+	case GroupAuth:
+		policy = cfg.Auth
+	case GroupWrites:
+		policy = cfg.Writes
+	case GroupReads:
+		policy = cfg.Reads
+	default:
+		return config.RateLimitPolicy{}, false
+	}
+	return policy, policy.Requests > 0
+}
+
+func (l *Limiter) Take(group, key string) (Result, config.RateLimitPolicy, bool, error) {
+	policy, ok := l.Policy(group)
+	if !ok {
+		return Result{}, policy, false, nil
+	}
+
+	result, err := l.store.Take(fmt.Sprintf("%s:%s", group, key), policy, time.Now())
+	if err != nil {
+		return Result{}, policy, true, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+	return result, policy, true, nil
+}
+
+func (l *Limiter) Cleanup(olderThan time.Time) error {
+	return l.store.Cleanup(olderThan)
+}
+
+// take refills b for the time elapsed since it was last updated and tries to
+// consume a single token from it. A zero Bucket starts full.
+func take(b *Bucket, policy config.RateLimitPolicy, now time.Time) Result {
+	burst := float64(policy.Burst)
+	rate := float64(policy.Requests) / policy.Period.Seconds()
+
+	if b.UpdatedAt.IsZero() {
+		b.Tokens = burst
+	} else if elapsed := now.Sub(b.UpdatedAt).Seconds(); elapsed > 0 {
+		b.Tokens = math.Min(burst, b.Tokens+elapsed*rate)
+	}
+	b.UpdatedAt = now
+
+	result := Result{Limit: policy.Burst}
+	if b.Tokens >= 1 {
+		b.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - b.Tokens) / rate)
+	}
+
+	result.Remaining = int(math.Floor(b.Tokens))
+	result.Reset = seconds((burst - b.Tokens) / rate)
+	return result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"hitalent-test/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testPolicy = config.RateLimitPolicy{Requests: 60, Period: time.Minute, Burst: 3}
+
+func TestMemoryStore_TakeExhaustsBurst(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		result, err := store.Take("k", testPolicy, now)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 2-i, result.Remaining)
+	}
+
+	result, err := store.Take("k", testPolicy, now)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+	assert.Equal(t, 3*time.Second, result.Reset)
+}
+
+func TestMemoryStore_TakeRefills(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+
+	for i := 0; i < 4; i++ {
+		store.Take("k", testPolicy, now)
+	}
+
+	result, err := store.Take("k", testPolicy, now.Add(time.Second))
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	result, err = store.Take("other", testPolicy, now)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func TestLimiter_DisabledPolicies(t *testing.T) {
+	cfg := config.Default().RateLimit
+	cfg.Reads.Requests = 0
+	limiter := NewLimiter(NewMemoryStore(), cfg)
+
+	_, _, enforced, err := limiter.Take(GroupReads, "ip:1.2.3.4")
+	require.NoError(t, err)
+	assert.False(t, enforced)
+
+	cfg.Enabled = false
+	limiter.SetConfig(cfg)
+	_, _, enforced, err = limiter.Take(GroupAuth, "ip:1.2.3.4")
+	require.NoError(t, err)
+	assert.False(t, enforced)
+}
+
+func TestMemoryStore_Cleanup(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+	store.Take("old", testPolicy, now.Add(-2*time.Hour))
+	store.Take("new", testPolicy, now)
+
+	require.NoError(t, store.Cleanup(now.Add(-time.Hour)))
+
+	assert.NotContains(t, store.buckets, "old")
+	assert.Contains(t, store.buckets, "new")
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"errors"
 	"hitalent-test/internal/domain"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -15,6 +16,8 @@ type AnswerRepository interface {
 	Create(answer *domain.Answer) error
 	GetByID(id uint) (*domain.Answer, error)
 	GetByQuestionID(questionID uint) ([]domain.Answer, error)
+	ListByUser(userID string) ([]domain.Answer, error)
+	SetHidden(id uint, hiddenAt *time.Time) error
 	Delete(id uint) error
 }
 
@@ -28,7 +31,7 @@ func (r *answerRepository) Create(answer *domain.Answer) error {
 
 func (r *answerRepository) GetByID(id uint) (*domain.Answer, error) {
 	var answer domain.Answer
-	err := r.db.First(&answer, id).Error
+	err := r.db.Preload("Attachments", orderByID).First(&answer, id).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, domain.ErrAnswerNotFound
 	}
@@ -41,6 +44,23 @@ func (r *answerRepository) GetByQuestionID(questionID uint) ([]domain.Answer, er
 	return answers, err
 }
 
+func (r *answerRepository) ListByUser(userID string) ([]domain.Answer, error) {
+	var answers []domain.Answer
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&answers).Error
+	return answers, err
+}
+
+func (r *answerRepository) SetHidden(id uint, hiddenAt *time.Time) error {
+	result := r.db.Model(&domain.Answer{}).Where("id = ?", id).Update("hidden_at", hiddenAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrAnswerNotFound
+	}
+	return nil
+}
+
 func (r *answerRepository) Delete(id uint) error {
 	result := r.db.Delete(&domain.Answer{}, id)
 	if result.RowsAffected == 0 {
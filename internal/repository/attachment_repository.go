@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"errors"
+	"hitalent-test/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type attachmentRepository struct {
+	db *gorm.DB
+}
+
+// AttachmentRepository stores the files attached to questions and answers.
+// contentType is domain.ContentQuestion or domain.ContentAnswer.
+type AttachmentRepository interface {
+	Create(attachment *domain.Attachment) error
+	GetByID(id uint) (*domain.Attachment, error)
+	Count(contentType string, contentID uint) (int64, error)
+	CountByChecksum(checksum string) (int64, error)
+	// OrphanedChecksums returns up to limit checksums of deleted attachments
+	// whose content no attachment refers to any more.
+	OrphanedChecksums(limit int) ([]string, error)
+	ForgetOrphaned(checksum string) error
+}
+
+func NewAttachmentRepository(db *gorm.DB) AttachmentRepository {
+	return &attachmentRepository{db: db}
+}
+
+func (r *attachmentRepository) Create(attachment *domain.Attachment) error {
+	return r.db.Create(attachment).Error
+}
+
+func (r *attachmentRepository) GetByID(id uint) (*domain.Attachment, error) {
+	var attachment domain.Attachment
+	err := r.db.First(&attachment, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrAttachmentNotFound
+	}
+	return &attachment, err
+}
+
+func (r *attachmentRepository) Count(contentType string, contentID uint) (int64, error) {
+	column, err := contentColumn(contentType)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	err = r.db.Model(&domain.Attachment{}).Where(column+" = ?", contentID).Count(&count).Error
+	return count, err
+}
+
+func (r *attachmentRepository) CountByChecksum(checksum string) (int64, error) {
+	var count int64
+	err := r.db.Model(&domain.Attachment{}).Where("checksum = ?", checksum).Count(&count).Error
+	return count, err
+}
+
+// OrphanedChecksums first forgets the checksums that were uploaded again
+// since their attachments were deleted.
+func (r *attachmentRepository) OrphanedChecksums(limit int) ([]string, error) {
+	err := r.db.Exec(`
+		DELETE FROM orphaned_blobs o
+		WHERE EXISTS (SELECT 1 FROM attachments a WHERE a.checksum = o.checksum)`,
+	).Error
+	if err != nil {
+		return nil, err
+	}
+	var checksums []string
+	err = r.db.Table("orphaned_blobs").Order("created_at").Limit(limit).Pluck("checksum", &checksums).Error
+	return checksums, err
+}
+
+func (r *attachmentRepository) ForgetOrphaned(checksum string) error {
+	return r.db.Exec("DELETE FROM orphaned_blobs WHERE checksum = ?", checksum).Error
+}
+
+// orderByID makes preloaded associations come out in insertion order.
+func orderByID(db *gorm.DB) *gorm.DB {
+	return db.Order("id")
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"errors"
+	"hitalent-test/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+// AuditRepository is append-only: entries cannot be changed or removed
+// through it, and the database rejects updates and deletes as well.
+type AuditRepository interface {
+	Append(entry *domain.AuditEntry) error
+	List(filter domain.AuditFilter) ([]domain.AuditEntry, error)
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+// Append inserts a new entry. Entries that already have an ID are refused,
+// so that an existing row can never be overwritten by an upsert.
+func (r *auditRepository) Append(entry *domain.AuditEntry) error {
+	if entry.ID != 0 {
+		return errors.New("audit entries are append-only")
+	}
+	return r.db.Create(entry).Error
+}
+
+func (r *auditRepository) List(filter domain.AuditFilter) ([]domain.AuditEntry, error) {
+	query := r.db.Model(&domain.AuditEntry{})
+	if filter.ActorID != "" {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	if filter.Cursor > 0 {
+		query = query.Where("id < ?", filter.Cursor)
+	}
+
+	var entries []domain.AuditEntry
+	err := query.Order("id DESC").Limit(filter.Limit).Find(&entries).Error
+	return entries, err
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"errors"
+	"hitalent-test/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type dataExportRepository struct {
+	db *gorm.DB
+}
+
+type DataExportRepository interface {
+	Create(export *domain.DataExport) error
+	GetLatest(userID, format string) (*domain.DataExport, error)
+	Complete(id string, data []byte, completedAt, expiresAt time.Time) error
+	Fail(id string, at time.Time) error
+	DeleteExpired(now, staleBefore time.Time) error
+}
+
+func NewDataExportRepository(db *gorm.DB) DataExportRepository {
+	return &dataExportRepository{db: db}
+}
+
+func (r *dataExportRepository) Create(export *domain.DataExport) error {
+	return r.db.Create(export).Error
+}
+
+// GetLatest returns the most recent export of the user in the given format,
+// or ErrExportNotFound if there is none.
+func (r *dataExportRepository) GetLatest(userID, format string) (*domain.DataExport, error) {
+	var export domain.DataExport
+	err := r.db.Where("user_id = ? AND format = ?", userID, format).
+		Order("created_at DESC").
+		First(&export).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrExportNotFound
+	}
+	return &export, err
+}
+
+func (r *dataExportRepository) Complete(id string, data []byte, completedAt, expiresAt time.Time) error {
+	return r.db.Model(&domain.DataExport{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       domain.ExportStatusReady,
+		"data":         data,
+		"completed_at": completedAt,
+		"expires_at":   expiresAt,
+	}).Error
+}
+
+func (r *dataExportRepository) Fail(id string, at time.Time) error {
+	return r.db.Model(&domain.DataExport{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       domain.ExportStatusFailed,
+		"completed_at": at,
+		"expires_at":   at,
+	}).Error
+}
+
+// DeleteExpired removes expired and failed exports, and pending ones
+// created before staleBefore, whose build was interrupted by a restart.
+func (r *dataExportRepository) DeleteExpired(now, staleBefore time.Time) error {
+	return r.db.Where("expires_at <= ? OR (expires_at IS NULL AND created_at < ?)", now, staleBefore).
+		Delete(&domain.DataExport{}).Error
+}
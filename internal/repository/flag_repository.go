@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"hitalent-test/internal/domain"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type flagRepository struct {
+	db *gorm.DB
+}
+
+type FlagRepository interface {
+	Create(flag *domain.Flag) (bool, error)
+	CountOpen(targetType string, targetID uint) (int64, error)
+	Queue(targetType string, limit, offset int) ([]domain.ModerationItem, error)
+	Resolve(targetType string, targetID uint, status, resolvedBy string, at time.Time) (int64, error)
+}
+
+func NewFlagRepository(db *gorm.DB) FlagRepository {
+	return &flagRepository{db: db}
+}
+
+// Create inserts the flag and reports whether it was new; a user's second
+// flag of the same item is ignored.
+func (r *flagRepository) Create(flag *domain.Flag) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(flag)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *flagRepository) CountOpen(targetType string, targetID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&domain.Flag{}).
+		Where("target_type = ? AND target_id = ? AND status = ?", targetType, targetID, domain.FlagStatusOpen).
+		Count(&count).Error
+	return count, err
+}
+
+// Queue groups the open flags by item, most flagged first. Text and author
+// are left to the caller.
+func (r *flagRepository) Queue(targetType string, limit, offset int) ([]domain.ModerationItem, error) {
+	var rows []struct {
+		TargetType     string
+		TargetID       uint
+		FlagCount      int
+		Reasons        string
+		FirstFlaggedAt time.Time
+		LastFlaggedAt  time.Time
+	}
+
+	query := r.db.Model(&domain.Flag{}).
+		Select("target_type, target_id, COUNT(*) AS flag_count, STRING_AGG(reason, ',') AS reasons, "+
+			"MIN(created_at) AS first_flagged_at, MAX(created_at) AS last_flagged_at").
+		Where("status = ?", domain.FlagStatusOpen)
+	if targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+	err := query.Group("target_type, target_id").
+		Order("flag_count DESC, last_flagged_at DESC").
+		Limit(limit).Offset(offset).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]domain.ModerationItem, len(rows))
+	for i, row := range rows {
+		reasons := make(map[string]int)
+		for _, reason := range strings.Split(row.Reasons, ",") {
+			reasons[reason]++
+		}
+		items[i] = domain.ModerationItem{
+			TargetType:     row.TargetType,
+			TargetID:       row.TargetID,
+			FlagCount:      row.FlagCount,
+			Reasons:        reasons,
+			FirstFlaggedAt: row.FirstFlaggedAt,
+			LastFlaggedAt:  row.LastFlaggedAt,
+		}
+	}
+	return items, nil
+}
+
+// Resolve closes the open flags of an item and returns how many there were.
+func (r *flagRepository) Resolve(targetType string, targetID uint, status, resolvedBy string, at time.Time) (int64, error) {
+	result := r.db.Model(&domain.Flag{}).
+		Where("target_type = ? AND target_id = ? AND status = ?", targetType, targetID, domain.FlagStatusOpen).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"resolved_by": resolvedBy,
+			"resolved_at": at,
+		})
+	return result.RowsAffected, result.Error
+}
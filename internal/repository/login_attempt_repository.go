@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"errors"
+	"hitalent-test/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type loginAttemptRepository struct {
+	db *gorm.DB
+}
+
+type LoginAttemptRepository interface {
+	Get(key string) (*domain.LoginFailure, error)
+	RecordFailure(key string, now, windowStart time.Time) (*domain.LoginFailure, error)
+	Lock(key string, until time.Time) error
+	Reset(key string) error
+	DeleteStale(before time.Time) error
+	CreateEvent(event *domain.LockoutEvent) error
+}
+
+func NewLoginAttemptRepository(db *gorm.DB) LoginAttemptRepository {
+	return &loginAttemptRepository{db: db}
+}
+
+// Get returns the failure record for key, or an empty record if there has
+// been no failure yet.
+func (r *loginAttemptRepository) Get(key string) (*domain.LoginFailure, error) {
+	var failure domain.LoginFailure
+	err := r.db.First(&failure, "key = ?", key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &domain.LoginFailure{Key: key}, nil
+	}
+	return &failure, err
+}
+
+// RecordFailure atomically increments the counter for key. Failures older
+// than windowStart are forgotten and counting starts again from one.
+func (r *loginAttemptRepository) RecordFailure(key string, now, windowStart time.Time) (*domain.LoginFailure, error) {
+	var failure domain.LoginFailure
+	err := r.db.Raw(`
+		INSERT INTO login_failures (key, failures, last_failure_at)
+		VALUES (?, 1, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE
+				WHEN login_failures.last_failure_at < ? THEN 1
+				ELSE login_failures.failures + 1
+			END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING key, failures, last_failure_at, locked_until`,
+		key, now, windowStart,
+	).Scan(&failure).Error
+	return &failure, err
+}
+
+// Lock sets the lockout deadline and clears the counter, so the next lockout
+// needs a fresh run of failures.
+func (r *loginAttemptRepository) Lock(key string, until time.Time) error {
+	return r.db.Model(&domain.LoginFailure{}).
+		Where("key = ?", key).
+		Updates(map[string]interface{}{"failures": 0, "locked_until": until}).Error
+}
+
+func (r *loginAttemptRepository) Reset(key string) error {
+	return r.db.Delete(&domain.LoginFailure{}, "key = ?", key).Error
+}
+
+func (r *loginAttemptRepository) DeleteStale(before time.Time) error {
+	return r.db.
+		Where("last_failure_at < ? AND (locked_until IS NULL OR locked_until < ?)", before, before).
+		Delete(&domain.LoginFailure{}).Error
+}
+
+func (r *loginAttemptRepository) CreateEvent(event *domain.LockoutEvent) error {
+	return r.db.Create(event).Error
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"fmt"
+	"hitalent-test/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type mentionRepository struct {
+	db *gorm.DB
+}
+
+// MentionRepository stores the users mentioned by questions and answers.
+// contentType is domain.ContentQuestion or domain.ContentAnswer.
+type MentionRepository interface {
+	// Replace sets the mentions of a post and returns the IDs of the users
+	// it mentioned before.
+	Replace(contentType string, contentID uint, mentions []domain.Mention) ([]string, error)
+	List(contentType string, contentIDs []uint) ([]domain.Mention, error)
+}
+
+func NewMentionRepository(db *gorm.DB) MentionRepository {
+	return &mentionRepository{db: db}
+}
+
+func contentColumn(contentType string) (string, error) {
+	switch contentType {
+	case domain.ContentQuestion:
+		return "question_id", nil
+	case domain.ContentAnswer:
+		return "answer_id", nil
+	}
+	return "", fmt.Errorf("unknown content type %q", contentType)
+}
+
+func (r *mentionRepository) Replace(contentType string, contentID uint, mentions []domain.Mention) ([]string, error) {
+	column, err := contentColumn(contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	var previous []string
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.Mention{}).Where(column+" = ?", contentID).Pluck("user_id", &previous).Error; err != nil {
+			return err
+		}
+		if err := tx.Where(column+" = ?", contentID).Delete(&domain.Mention{}).Error; err != nil {
+			return err
+		}
+		if len(mentions) == 0 {
+			return nil
+		}
+		for i := range mentions {
+			mentions[i].ID = 0
+			mentions[i].QuestionID, mentions[i].AnswerID = nil, nil
+			if contentType == domain.ContentQuestion {
+				mentions[i].QuestionID = &contentID
+			} else {
+				mentions[i].AnswerID = &contentID
+			}
+		}
+		return tx.Create(&mentions).Error
+	})
+	return previous, err
+}
+
+func (r *mentionRepository) List(contentType string, contentIDs []uint) ([]domain.Mention, error) {
+	column, err := contentColumn(contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	var mentions []domain.Mention
+	if len(contentIDs) == 0 {
+		return mentions, nil
+	}
+	err = r.db.Where(column+" IN ?", contentIDs).Order("id").Find(&mentions).Error
+	return mentions, err
+}
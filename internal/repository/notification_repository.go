@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"hitalent-test/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+type NotificationRepository interface {
+	Create(notification *domain.Notification) error
+	List(filter domain.NotificationFilter) ([]domain.Notification, error)
+	CountUnread(userID string) (int64, error)
+	MarkRead(userID string, id uint, at time.Time) error
+	MarkAllRead(userID string, at time.Time) (int64, error)
+	Preferences(userID string) ([]domain.NotificationPreference, error)
+	SetPreferences(prefs []domain.NotificationPreference) error
+}
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+func (r *notificationRepository) Create(notification *domain.Notification) error {
+	return r.db.Create(notification).Error
+}
+
+func (r *notificationRepository) List(filter domain.NotificationFilter) ([]domain.Notification, error) {
+	query := r.db.Where("user_id = ?", filter.UserID)
+	if filter.UnreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	if filter.Cursor > 0 {
+		query = query.Where("id < ?", filter.Cursor)
+	}
+
+	var notifications []domain.Notification
+	err := query.Order("id DESC").Limit(filter.Limit).Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *notificationRepository) CountUnread(userID string) (int64, error) {
+	var count int64
+	err := r.db.Model(&domain.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkRead marks one of the user's notifications as read. A notification
+// that is already read keeps its original read time.
+func (r *notificationRepository) MarkRead(userID string, id uint, at time.Time) error {
+	result := r.db.Model(&domain.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("read_at", gorm.Expr("COALESCE(read_at, ?)", at))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotificationNotFound
+	}
+	return nil
+}
+
+func (r *notificationRepository) MarkAllRead(userID string, at time.Time) (int64, error) {
+	result := r.db.Model(&domain.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", at)
+	return result.RowsAffected, result.Error
+}
+
+func (r *notificationRepository) Preferences(userID string) ([]domain.NotificationPreference, error) {
+	var prefs []domain.NotificationPreference
+	err := r.db.Where("user_id = ?", userID).Find(&prefs).Error
+	return prefs, err
+}
+
+func (r *notificationRepository) SetPreferences(prefs []domain.NotificationPreference) error {
+	if len(prefs) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled"}),
+	}).Create(&prefs).Error
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"errors"
+	"hitalent-test/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type personalAccessTokenRepository struct {
+	db *gorm.DB
+}
+
+type PersonalAccessTokenRepository interface {
+	Create(token *domain.PersonalAccessToken) error
+	GetByHash(hash string) (*domain.PersonalAccessToken, error)
+	ListByUser(userID string) ([]domain.PersonalAccessToken, error)
+	Delete(userID, id string) error
+	DeleteByUser(userID string) error
+	TouchLastUsed(id string, at time.Time) error
+}
+
+func NewPersonalAccessTokenRepository(db *gorm.DB) PersonalAccessTokenRepository {
+	return &personalAccessTokenRepository{db: db}
+}
+
+func (r *personalAccessTokenRepository) Create(token *domain.PersonalAccessToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *personalAccessTokenRepository) GetByHash(hash string) (*domain.PersonalAccessToken, error) {
+	var token domain.PersonalAccessToken
+	err := r.db.First(&token, "token_hash = ?", hash).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrTokenNotFound
+	}
+	return &token, err
+}
+
+func (r *personalAccessTokenRepository) ListByUser(userID string) ([]domain.PersonalAccessToken, error) {
+	var tokens []domain.PersonalAccessToken
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+// Delete removes a token of the given user, so that users cannot revoke
+// each other's tokens by ID.
+func (r *personalAccessTokenRepository) Delete(userID, id string) error {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&domain.PersonalAccessToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrTokenNotFound
+	}
+	return nil
+}
+
+func (r *personalAccessTokenRepository) DeleteByUser(userID string) error {
+	return r.db.Where("user_id = ?", userID).Delete(&domain.PersonalAccessToken{}).Error
+}
+
+func (r *personalAccessTokenRepository) TouchLastUsed(id string, at time.Time) error {
+	return r.db.Model(&domain.PersonalAccessToken{}).
+		Where("id = ?", id).
+		Update("last_used_at", at).Error
+}
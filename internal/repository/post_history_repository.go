@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type postHistoryRepository struct {
+	db *gorm.DB
+}
+
+// PostHistoryRepository reads a user's recent questions and answers for
+// the content filter's duplicate check.
+type PostHistoryRepository interface {
+	RecentTexts(userID string, since time.Time) ([]string, error)
+}
+
+func NewPostHistoryRepository(db *gorm.DB) PostHistoryRepository {
+	return &postHistoryRepository{db: db}
+}
+
+// RecentTexts includes hidden posts, so that a held post cannot be sent
+// again while it waits for a moderator.
+func (r *postHistoryRepository) RecentTexts(userID string, since time.Time) ([]string, error) {
+	var texts []string
+	err := r.db.Raw(`
+		SELECT text FROM questions WHERE user_id = @user AND created_at > @since
+		UNION ALL
+		SELECT text FROM answers WHERE user_id = @user AND created_at > @since`,
+		map[string]interface{}{"user": userID, "since": since},
+	).Scan(&texts).Error
+	return texts, err
+}
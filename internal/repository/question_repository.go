@@ -3,6 +3,8 @@ package repository
 import (
 	"errors"
 	"hitalent-test/internal/domain"
+	"strconv"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -14,7 +16,14 @@ type questionRepository struct {
 type QuestionRepository interface {
 	Create(question *domain.Question) error
 	GetByID(id uint) (*domain.Question, error)
-	GetAll() ([]domain.Question, error)
+	GetAll(filter domain.QuestionFilter) ([]domain.Question, error)
+	// Latest returns the newest visible questions, newest first.
+	Latest(limit int) ([]domain.Question, error)
+	ListByUser(userID string) ([]domain.Question, error)
+	FindSimilar(text string, excludeID uint, threshold float64, limit int) ([]domain.SimilarQuestion, error)
+	SetAcceptedAnswer(id uint, answerID *uint) error
+	SetHidden(id uint, hiddenAt *time.Time) error
+	SetStatus(id uint, status domain.QuestionStatus) error
 	Delete(id uint) error
 }
 
@@ -28,19 +37,93 @@ func (r *questionRepository) Create(question *domain.Question) error {
 
 func (r *questionRepository) GetByID(id uint) (*domain.Question, error) {
 	var question domain.Question
-	err := r.db.Preload("Answers").First(&question, id).Error
+	err := r.db.Preload("Answers").
+		Preload("Attachments", orderByID).
+		Preload("Answers.Attachments", orderByID).
+		First(&question, id).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, domain.ErrQuestionNotFound
 	}
 	return &question, err
 }
 
-func (r *questionRepository) GetAll() ([]domain.Question, error) {
+// GetAll returns the questions that are not hidden by moderation.
+func (r *questionRepository) GetAll(filter domain.QuestionFilter) ([]domain.Question, error) {
 	var questions []domain.Question
-	err := r.db.Find(&questions).Error
+	query := r.db.Where("hidden_at IS NULL")
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	err := query.Find(&questions).Error
 	return questions, err
 }
 
+func (r *questionRepository) Latest(limit int) ([]domain.Question, error) {
+	var questions []domain.Question
+	err := r.db.Where("hidden_at IS NULL").Order("created_at DESC, id DESC").Limit(limit).Find(&questions).Error
+	return questions, err
+}
+
+func (r *questionRepository) ListByUser(userID string) ([]domain.Question, error) {
+	var questions []domain.Question
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&questions).Error
+	return questions, err
+}
+
+// FindSimilar returns visible questions whose trigram similarity to text
+// reaches threshold, most similar first. The % operator lets PostgreSQL use
+// the trigram index; its threshold is set for this transaction only.
+func (r *questionRepository) FindSimilar(text string, excludeID uint, threshold float64, limit int) ([]domain.SimilarQuestion, error) {
+	var similar []domain.SimilarQuestion
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT set_config('pg_trgm.similarity_threshold', ?, true)", strconv.FormatFloat(threshold, 'f', -1, 64)).Error; err != nil {
+			return err
+		}
+		return tx.Model(&domain.Question{}).
+			Select("id, text, status, created_at, similarity(text, ?) AS score", text).
+			Where("hidden_at IS NULL AND id <> ? AND text % ?", excludeID, text).
+			Order("score DESC, id").
+			Limit(limit).
+			Scan(&similar).Error
+	})
+	return similar, err
+}
+
+func (r *questionRepository) SetAcceptedAnswer(id uint, answerID *uint) error {
+	return r.update(id, "accepted_answer_id", answerID)
+}
+
+func (r *questionRepository) SetHidden(id uint, hiddenAt *time.Time) error {
+	return r.update(id, "hidden_at", hiddenAt)
+}
+
+// SetStatus replaces all status fields at once, so a reopened question
+// loses its close reason and duplicate link.
+func (r *questionRepository) SetStatus(id uint, status domain.QuestionStatus) error {
+	return r.updates(id, map[string]interface{}{
+		"status":            status.Status,
+		"close_reason":      status.CloseReason,
+		"duplicate_of_id":   status.DuplicateOfID,
+		"status_changed_by": status.StatusChangedBy,
+		"status_changed_at": status.StatusChangedAt,
+	})
+}
+
+func (r *questionRepository) update(id uint, column string, value interface{}) error {
+	return r.updates(id, map[string]interface{}{column: value})
+}
+
+func (r *questionRepository) updates(id uint, values map[string]interface{}) error {
+	result := r.db.Model(&domain.Question{}).Where("id = ?", id).Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrQuestionNotFound
+	}
+	return nil
+}
+
 func (r *questionRepository) Delete(id uint) error {
 	result := r.db.Delete(&domain.Question{}, id)
 	if result.RowsAffected == 0 {
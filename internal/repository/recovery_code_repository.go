@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"hitalent-test/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+type RecoveryCodeRepository interface {
+	Replace(userID string, hashes []string) error
+	Use(userID, hash string, at time.Time) (bool, error)
+	CountUnused(userID string) (int64, error)
+	DeleteForUser(userID string) error
+}
+
+func NewRecoveryCodeRepository(db *gorm.DB) RecoveryCodeRepository {
+	return &recoveryCodeRepository{db: db}
+}
+
+// Replace discards the user's recovery codes and stores a new set.
+func (r *recoveryCodeRepository) Replace(userID string, hashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&domain.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]domain.RecoveryCode, len(hashes))
+		for i, hash := range hashes {
+			codes[i] = domain.RecoveryCode{UserID: userID, CodeHash: hash}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// Use consumes an unused code and reports whether one matched.
+func (r *recoveryCodeRepository) Use(userID, hash string, at time.Time) (bool, error) {
+	result := r.db.Model(&domain.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", at)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *recoveryCodeRepository) CountUnused(userID string) (int64, error) {
+	var count int64
+	err := r.db.Model(&domain.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *recoveryCodeRepository) DeleteForUser(userID string) error {
+	return r.db.Where("user_id = ?", userID).Delete(&domain.RecoveryCode{}).Error
+}
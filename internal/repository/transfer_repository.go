@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"errors"
+	"hitalent-test/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type transferRepository struct {
+	db *gorm.DB
+}
+
+// TransferRepository reads and writes questions with their answers in
+// bulk. contentType is domain.ContentQuestion or domain.ContentAnswer.
+type TransferRepository interface {
+	// Each calls fn with batches of all questions, hidden ones included, in
+	// ID order and with their answers loaded.
+	Each(batchSize int, fn func([]domain.Question) error) error
+	// ExternalIDs maps the given external IDs to the IDs of the posts that
+	// have them; unknown ones are left out.
+	ExternalIDs(contentType string, externalIDs []string) (map[string]uint, error)
+	// SaveBatch stores questions with their answers, and answers to
+	// questions saved before, in one transaction. Posts with an ID are
+	// updated, the others created.
+	SaveBatch(questions []*domain.Question, answers []*domain.Answer) error
+	SetReferences(refs []domain.ImportReference) error
+	Checkpoint(source string) (*domain.ImportCheckpoint, error)
+	SaveCheckpoint(checkpoint *domain.ImportCheckpoint) error
+	DeleteCheckpoint(source string) error
+}
+
+func NewTransferRepository(db *gorm.DB) TransferRepository {
+	return &transferRepository{db: db}
+}
+
+func (r *transferRepository) Each(batchSize int, fn func([]domain.Question) error) error {
+	var lastID uint
+	for {
+		var questions []domain.Question
+		err := r.db.Preload("Answers", orderByID).
+			Where("id > ?", lastID).
+			Order("id").
+			Limit(batchSize).
+			Find(&questions).Error
+		if err != nil {
+			return err
+		}
+		if len(questions) == 0 {
+			return nil
+		}
+		if err := fn(questions); err != nil {
+			return err
+		}
+		lastID = questions[len(questions)-1].ID
+	}
+}
+
+func (r *transferRepository) ExternalIDs(contentType string, externalIDs []string) (map[string]uint, error) {
+	ids := make(map[string]uint, len(externalIDs))
+	if len(externalIDs) == 0 {
+		return ids, nil
+	}
+
+	var model interface{} = &domain.Question{}
+	if contentType == domain.ContentAnswer {
+		model = &domain.Answer{}
+	}
+	var rows []struct {
+		ID         uint
+		ExternalID string
+	}
+	err := r.db.Model(model).Select("id, external_id").Where("external_id IN ?", externalIDs).Scan(&rows).Error
+	for _, row := range rows {
+		ids[row.ExternalID] = row.ID
+	}
+	return ids, err
+}
+
+func (r *transferRepository) SaveBatch(questions []*domain.Question, answers []*domain.Answer) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, q := range questions {
+			if q.ID == 0 {
+				if err := tx.Omit(clause.Associations).Create(q).Error; err != nil {
+					return err
+				}
+			} else if err := tx.Model(&domain.Question{}).Where("id = ?", q.ID).Updates(withCreatedAt(map[string]interface{}{
+				"user_id":      q.UserID,
+				"text":         q.Text,
+				"text_html":    q.TextHTML,
+				"status":       q.Status,
+				"close_reason": q.CloseReason,
+				"hidden_at":    q.HiddenAt,
+			}, q.CreatedAt)).Error; err != nil {
+				return err
+			}
+
+			for i := range q.Answers {
+				q.Answers[i].QuestionID = q.ID
+				if err := saveAnswer(tx, &q.Answers[i]); err != nil {
+					return err
+				}
+			}
+		}
+		for _, a := range answers {
+			if err := saveAnswer(tx, a); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func saveAnswer(tx *gorm.DB, a *domain.Answer) error {
+	if a.ID == 0 {
+		return tx.Omit(clause.Associations).Create(a).Error
+	}
+	return tx.Model(&domain.Answer{}).Where("id = ?", a.ID).Updates(withCreatedAt(map[string]interface{}{
+		"question_id": a.QuestionID,
+		"user_id":     a.UserID,
+		"text":        a.Text,
+		"text_html":   a.TextHTML,
+		"hidden_at":   a.HiddenAt,
+	}, a.CreatedAt)).Error
+}
+
+// withCreatedAt adds the creation time to the updated columns unless the
+// imported record left it out.
+func withCreatedAt(values map[string]interface{}, createdAt time.Time) map[string]interface{} {
+	if !createdAt.IsZero() {
+		values["created_at"] = createdAt
+	}
+	return values
+}
+
+func (r *transferRepository) SetReferences(refs []domain.ImportReference) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, ref := range refs {
+			if err := tx.Model(&domain.Question{}).Where("id = ?", ref.QuestionID).Updates(map[string]interface{}{
+				"accepted_answer_id": ref.AcceptedAnswerID,
+				"duplicate_of_id":    ref.DuplicateOfID,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *transferRepository) Checkpoint(source string) (*domain.ImportCheckpoint, error) {
+	var checkpoint domain.ImportCheckpoint
+	err := r.db.Where("source = ?", source).First(&checkpoint).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrCheckpointNotFound
+	}
+	return &checkpoint, err
+}
+
+func (r *transferRepository) SaveCheckpoint(checkpoint *domain.ImportCheckpoint) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "source"}},
+		DoUpdates: clause.AssignmentColumns([]string{"phase", "rows_done", "updated_at"}),
+	}).Create(checkpoint).Error
+}
+
+func (r *transferRepository) DeleteCheckpoint(source string) error {
+	return r.db.Where("source = ?", source).Delete(&domain.ImportCheckpoint{}).Error
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"errors"
+	"hitalent-test/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type userIdentityRepository struct {
+	db *gorm.DB
+}
+
+type UserIdentityRepository interface {
+	Create(identity *domain.UserIdentity) error
+	Get(provider, subject string) (*domain.UserIdentity, error)
+	ListByUser(userID string) ([]domain.UserIdentity, error)
+	RecordLogin(id uint, email string, at time.Time) error
+}
+
+func NewUserIdentityRepository(db *gorm.DB) UserIdentityRepository {
+	return &userIdentityRepository{db: db}
+}
+
+func (r *userIdentityRepository) Create(identity *domain.UserIdentity) error {
+	return r.db.Create(identity).Error
+}
+
+// Get returns the identity with the given provider subject, or
+// domain.ErrUserNotFound if none is linked yet.
+func (r *userIdentityRepository) Get(provider, subject string) (*domain.UserIdentity, error) {
+	var identity domain.UserIdentity
+	err := r.db.First(&identity, "provider = ? AND subject = ?", provider, subject).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrUserNotFound
+	}
+	return &identity, err
+}
+
+func (r *userIdentityRepository) ListByUser(userID string) ([]domain.UserIdentity, error) {
+	var identities []domain.UserIdentity
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&identities).Error
+	return identities, err
+}
+
+func (r *userIdentityRepository) RecordLogin(id uint, email string, at time.Time) error {
+	return r.db.Model(&domain.UserIdentity{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"email": email, "last_login_at": at}).Error
+}
@@ -2,7 +2,9 @@ package repository
 
 import (
 	"errors"
+	"fmt"
 	"hitalent-test/internal/domain"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -15,6 +17,13 @@ type UserRepository interface {
 	Create(user *domain.User) error
 	GetByID(id string) (*domain.User, error)
 	GetByEmail(email string) (*domain.User, error)
+	GetByIDs(ids []string) ([]domain.User, error)
+	FindByHandles(handles []string) ([]domain.User, error)
+	Stats(id string) (*domain.UserStats, error)
+	Update(user *domain.User) error
+	AdvanceTOTPStep(id string, step int64) (bool, error)
+	ListDueForDeletion(before time.Time) ([]domain.User, error)
+	Erase(id string, contentPolicy string) error
 }
 
 func NewUserRepository(db *gorm.DB) UserRepository {
@@ -29,7 +38,7 @@ func (r *userRepository) GetByID(id string) (*domain.User, error) {
 	var user domain.User
 	err := r.db.First(&user, "id = ?", id).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, domain.ErrQuestionNotFound
+		return nil, domain.ErrUserNotFound
 	}
 	return &user, err
 }
@@ -38,7 +47,124 @@ func (r *userRepository) GetByEmail(email string) (*domain.User, error) {
 	var user domain.User
 	err := r.db.First(&user, "email = ?", email).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, domain.ErrQuestionNotFound
+		return nil, domain.ErrUserNotFound
 	}
 	return &user, err
 }
+
+func (r *userRepository) GetByIDs(ids []string) ([]domain.User, error) {
+	var users []domain.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	err := r.db.Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
+// FindByHandles returns the users whose display name or email local part
+// equals one of the lower-cased handles, ignoring case.
+func (r *userRepository) FindByHandles(handles []string) ([]domain.User, error) {
+	var users []domain.User
+	if len(handles) == 0 {
+		return users, nil
+	}
+	err := r.db.Where("id <> ? AND (lower(display_name) IN ? OR lower(split_part(email, '@', 1)) IN ?)",
+		domain.DeletedUserID, handles, handles).
+		Find(&users).Error
+	return users, err
+}
+
+func (r *userRepository) Stats(id string) (*domain.UserStats, error) {
+	var stats domain.UserStats
+	err := r.db.Raw(`
+		SELECT
+			(SELECT COUNT(*) FROM questions WHERE user_id = @id) AS questions_asked,
+			(SELECT COUNT(*) FROM answers WHERE user_id = @id) AS answers_given,
+			(SELECT COUNT(*) FROM answers a JOIN questions q ON q.accepted_answer_id = a.id
+				WHERE a.user_id = @id) AS accepted_answers`,
+		map[string]interface{}{"id": id},
+	).Scan(&stats).Error
+	return &stats, err
+}
+
+func (r *userRepository) Update(user *domain.User) error {
+	return r.db.Save(user).Error
+}
+
+// AdvanceTOTPStep records step as the last accepted TOTP step and reports
+// false if that step or a later one was already used, so that a code is
+// accepted at most once even by concurrent logins.
+func (r *userRepository) AdvanceTOTPStep(id string, step int64) (bool, error) {
+	result := r.db.Model(&domain.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		Update("totp_last_step", step)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *userRepository) ListDueForDeletion(before time.Time) ([]domain.User, error) {
+	var users []domain.User
+	err := r.db.Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?", before).
+		Order("deletion_scheduled_at").
+		Find(&users).Error
+	return users, err
+}
+
+// Erase deletes the user in one transaction. Under the anonymize policy the
+// user's questions and answers are first moved to the deleted user
+// placeholder; otherwise they are deleted, along with answers to those
+// questions. Tokens, identities and exports go with the user by cascade.
+func (r *userRepository) Erase(id string, contentPolicy string) error {
+	if id == domain.DeletedUserID {
+		return fmt.Errorf("%w: the deleted user placeholder cannot be erased", domain.ErrInvalidInput)
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var user domain.User
+		if err := tx.Select("email").First(&user, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.ErrUserNotFound
+			}
+			return err
+		}
+
+		// Login failures and lockout events name the account by its email
+		// and are never pruned by user, so they go with it.
+		key := domain.AccountLoginKey(user.Email)
+		if err := tx.Where("user_id = ? OR key = ?", id, key).Delete(&domain.LockoutEvent{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&domain.LockoutEvent{}).Where("actor_id = ?", id).Update("actor_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("key = ?", key).Delete(&domain.LoginFailure{}).Error; err != nil {
+			return err
+		}
+
+		switch contentPolicy {
+		case domain.ContentPolicyAnonymize:
+			if err := tx.Model(&domain.Question{}).Where("user_id = ?", id).
+				Update("user_id", domain.DeletedUserID).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&domain.Answer{}).Where("user_id = ?", id).
+				Update("user_id", domain.DeletedUserID).Error; err != nil {
+				return err
+			}
+		case domain.ContentPolicyDelete:
+			if err := tx.Where("user_id = ?", id).Delete(&domain.Question{}).Error; err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown content policy %q", contentPolicy)
+		}
+
+		result := tx.Delete(&domain.User{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrUserNotFound
+		}
+		return nil
+	})
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"errors"
+	"hitalent-test/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type userTokenRepository struct {
+	db *gorm.DB
+}
+
+type UserTokenRepository interface {
+	Create(token *domain.UserToken) error
+	GetByHash(purpose, hash string) (*domain.UserToken, error)
+	MarkUsed(id uint, at time.Time) (bool, error)
+	InvalidateForUser(userID, purpose string, at time.Time) error
+	DeleteExpired(before time.Time) error
+}
+
+func NewUserTokenRepository(db *gorm.DB) UserTokenRepository {
+	return &userTokenRepository{db: db}
+}
+
+func (r *userTokenRepository) Create(token *domain.UserToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *userTokenRepository) GetByHash(purpose, hash string) (*domain.UserToken, error) {
+	var token domain.UserToken
+	err := r.db.First(&token, "purpose = ? AND token_hash = ?", purpose, hash).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrInvalidInput
+	}
+	return &token, err
+}
+
+// MarkUsed consumes the token and reports false if it had already been used,
+// so that concurrent redemptions succeed at most once.
+func (r *userTokenRepository) MarkUsed(id uint, at time.Time) (bool, error) {
+	result := r.db.Model(&domain.UserToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", at)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *userTokenRepository) InvalidateForUser(userID, purpose string, at time.Time) error {
+	return r.db.Model(&domain.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", at).Error
+}
+
+func (r *userTokenRepository) DeleteExpired(before time.Time) error {
+	return r.db.Where("expires_at < ?", before).Delete(&domain.UserToken{}).Error
+}
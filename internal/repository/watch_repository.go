@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"hitalent-test/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type watchRepository struct {
+	db *gorm.DB
+}
+
+// WatchRepository stores who follows which questions and what the activity
+// digests need to know about it.
+type WatchRepository interface {
+	Watch(userID string, questionID uint) error
+	Unwatch(userID string, questionID uint) (bool, error)
+	IsWatching(userID string, questionID uint) (bool, error)
+	Watchers(questionID uint) ([]domain.User, error)
+	Subscribers() ([]domain.User, error)
+	Activity(userID string, since time.Time) ([]domain.Question, error)
+	MarkDigestSent(userID string, at time.Time) error
+}
+
+func NewWatchRepository(db *gorm.DB) WatchRepository {
+	return &watchRepository{db: db}
+}
+
+// Watch is idempotent: watching a question twice keeps the first watch.
+func (r *watchRepository) Watch(userID string, questionID uint) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&domain.Watch{UserID: userID, QuestionID: questionID}).Error
+}
+
+func (r *watchRepository) Unwatch(userID string, questionID uint) (bool, error) {
+	result := r.db.Delete(&domain.Watch{}, "user_id = ? AND question_id = ?", userID, questionID)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *watchRepository) IsWatching(userID string, questionID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&domain.Watch{}).
+		Where("user_id = ? AND question_id = ?", userID, questionID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *watchRepository) Watchers(questionID uint) ([]domain.User, error) {
+	var users []domain.User
+	err := r.db.Joins("JOIN question_watches w ON w.user_id = users.id").
+		Where("w.question_id = ?", questionID).
+		Find(&users).Error
+	return users, err
+}
+
+// Subscribers returns the users who watch at least one question.
+func (r *watchRepository) Subscribers() ([]domain.User, error) {
+	var users []domain.User
+	err := r.db.Where("EXISTS (SELECT 1 FROM question_watches w WHERE w.user_id = users.id)").
+		Find(&users).Error
+	return users, err
+}
+
+// Activity returns the visible questions the user watches that got answers
+// from other users, or had their status changed by someone else, after
+// since. Only the new answers are loaded.
+func (r *watchRepository) Activity(userID string, since time.Time) ([]domain.Question, error) {
+	newAnswers := "answers.created_at > ? AND answers.user_id <> ? AND answers.hidden_at IS NULL"
+
+	var questions []domain.Question
+	err := r.db.
+		Preload("Answers", func(db *gorm.DB) *gorm.DB {
+			return db.Where(newAnswers, since, userID).Order("answers.created_at")
+		}).
+		Joins("JOIN question_watches w ON w.question_id = questions.id AND w.user_id = ?", userID).
+		Where("questions.hidden_at IS NULL").
+		Where(r.db.
+			Where("EXISTS (SELECT 1 FROM answers WHERE answers.question_id = questions.id AND "+newAnswers+")", since, userID).
+			Or("questions.status_changed_at > ? AND questions.status_changed_by IS DISTINCT FROM ?", since, userID)).
+		Order("questions.id").
+		Find(&questions).Error
+	return questions, err
+}
+
+func (r *watchRepository) MarkDigestSent(userID string, at time.Time) error {
+	return r.db.Model(&domain.User{}).Where("id = ?", userID).Update("digest_sent_at", at).Error
+}
@@ -1,40 +1,183 @@
 package server
 
 import (
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
 	"hitalent-test/internal/handler"
 	"hitalent-test/internal/middleware"
+	"hitalent-test/internal/ratelimit"
 	"hitalent-test/internal/service"
 	"log/slog"
 	"net/http"
 )
 
+// Upload routes, which are exempt from the global body limit.
+const (
+	uploadToQuestion = "POST /questions/{id}/attachments"
+	uploadToAnswer   = "POST /answers/{id}/attachments"
+	adminImport      = "POST /admin/import"
+)
+
 func NewRouter(
 	questionHandler *handler.QuestionHandler,
 	answerHandler *handler.AnswerHandler,
 	authHandler *handler.AuthHandler,
+	passwordHandler *handler.PasswordHandler,
+	mfaHandler *handler.MFAHandler,
+	tokenHandler *handler.TokenHandler,
+	oidcHandler *handler.OIDCHandler,
+	userHandler *handler.UserHandler,
+	accountHandler *handler.AccountHandler,
+	moderationHandler *handler.ModerationHandler,
+	notificationHandler *handler.NotificationHandler,
+	watchHandler *handler.WatchHandler,
+	attachmentHandler *handler.AttachmentHandler,
+	transferHandler *handler.TransferHandler,
+	feedHandler *handler.FeedHandler,
+	adminHandler *handler.AdminHandler,
+	auditHandler *handler.AuditHandler,
 	tokenService *service.TokenService,
+	pats *service.PersonalAccessTokenService,
+	limiter *ratelimit.Limiter,
+	clientIP *middleware.ClientIP,
+	cors *middleware.CORS,
+	cfg *config.ServerConfig,
 	logger *slog.Logger,
 ) http.Handler {
 	mux := http.NewServeMux()
 
-	authMiddleware := middleware.Auth(tokenService, logger)
+	authMiddleware := middleware.Auth(tokenService, pats, logger)
+	optionalAuth := middleware.OptionalAuth(tokenService, pats, logger)
+	sessionOnly := middleware.RequireSession(logger)
+	scope := func(s string) func(http.Handler) http.Handler {
+		return middleware.RequireScope(logger, s)
+	}
+	authLimit := middleware.RateLimit(limiter, ratelimit.GroupAuth, logger)
+	writeLimit := middleware.RateLimit(limiter, ratelimit.GroupWrites, logger)
+	readLimit := middleware.RateLimit(limiter, ratelimit.GroupReads, logger)
+
+	// Cache policies. Questions and answers may be stored by any cache but
+	// are revalidated with their ETag on every use; lists and feeds may be
+	// served stale for a short while; responses for a signed-in user are
+	// kept out of caches altogether.
+	const (
+		revalidate = "public, no-cache"
+		shortLived = "public, max-age=60"
+		feed       = "public, max-age=300"
+		personal   = "private, no-store"
+	)
+	cache := func(policy string, h http.HandlerFunc) http.HandlerFunc {
+		return middleware.CacheControl(policy)(h).ServeHTTP
+	}
+
+	auth := func(h http.HandlerFunc) http.HandlerFunc {
+		return authLimit(h).ServeHTTP
+	}
+	read := func(h http.HandlerFunc) http.HandlerFunc {
+		return readLimit(h).ServeHTTP
+	}
+	// Account security endpoints are not reachable with personal access
+	// tokens.
+	authedAuth := func(h http.HandlerFunc) http.HandlerFunc {
+		return authMiddleware(sessionOnly(authLimit(h))).ServeHTTP
+	}
+	authedRead := func(required string, h http.HandlerFunc) http.HandlerFunc {
+		return authMiddleware(scope(required)(readLimit(cache(personal, h)))).ServeHTTP
+	}
+	// Profile changes are account settings, so they are kept away from
+	// personal access tokens like the other /users/me endpoints.
+	sessionWrite := func(h http.HandlerFunc) http.HandlerFunc {
+		return authMiddleware(sessionOnly(writeLimit(h))).ServeHTTP
+	}
+	authedWrite := func(required string, h http.HandlerFunc) http.HandlerFunc {
+		return authMiddleware(scope(required)(writeLimit(h))).ServeHTTP
+	}
+	optionalWrite := func(required string, h http.HandlerFunc) http.HandlerFunc {
+		return optionalAuth(scope(required)(writeLimit(h))).ServeHTTP
+	}
+	moderatorOnly := func(h http.HandlerFunc) http.HandlerFunc {
+		return authMiddleware(middleware.RequireRole(logger, domain.RoleModerator, domain.RoleAdmin)(scope(domain.ScopeModerate)(writeLimit(h)))).ServeHTTP
+	}
+	adminOnly := func(h http.HandlerFunc) http.HandlerFunc {
+		return authMiddleware(middleware.RequireRole(logger, domain.RoleAdmin)(scope(domain.ScopeAdmin)(writeLimit(h)))).ServeHTTP
+	}
+
+	mux.HandleFunc("POST /auth/register", auth(authHandler.Register))
+	mux.HandleFunc("POST /auth/login", auth(authHandler.Login))
+	mux.HandleFunc("POST /auth/login/mfa", auth(authHandler.LoginMFA))
+	mux.HandleFunc("POST /auth/refresh", auth(authHandler.Refresh))
+	mux.HandleFunc("POST /auth/verify", auth(authHandler.VerifyEmail))
+	mux.HandleFunc("POST /auth/verify/resend", auth(authHandler.ResendVerification))
+	mux.HandleFunc("POST /auth/password/forgot", auth(passwordHandler.Forgot))
+	mux.HandleFunc("POST /auth/password/reset", auth(passwordHandler.Reset))
+	mux.HandleFunc("POST /auth/password/change", authedAuth(passwordHandler.Change))
+	mux.HandleFunc("GET /auth/oidc/{provider}/login", auth(oidcHandler.Login))
+	mux.HandleFunc("GET /auth/oidc/{provider}/callback", auth(oidcHandler.Callback))
+
+	mux.HandleFunc("GET /users/me", authedRead(domain.ScopeRead, userHandler.Me))
+	mux.HandleFunc("PATCH /users/me", sessionWrite(userHandler.UpdateMe))
+	mux.HandleFunc("GET /users/{id}", read(cache(shortLived, userHandler.GetByID)))
+	mux.HandleFunc("DELETE /users/me", authedAuth(accountHandler.Delete))
+	mux.HandleFunc("POST /users/me/deletion/cancel", authedAuth(accountHandler.CancelDeletion))
+	mux.HandleFunc("GET /users/me/export", authedAuth(accountHandler.Export))
+
+	mux.HandleFunc("POST /users/me/2fa/enroll", authedAuth(mfaHandler.Enroll))
+	mux.HandleFunc("POST /users/me/2fa/confirm", authedAuth(mfaHandler.Confirm))
+	mux.HandleFunc("POST /users/me/2fa/disable", authedAuth(mfaHandler.Disable))
+	mux.HandleFunc("POST /users/me/2fa/recovery-codes", authedAuth(mfaHandler.RegenerateRecoveryCodes))
+
+	mux.HandleFunc("GET /users/me/identities", authedRead(domain.ScopeRead, oidcHandler.Identities))
+
+	mux.HandleFunc("GET /users/me/notification-preferences", authedRead(domain.ScopeRead, notificationHandler.Preferences))
+	mux.HandleFunc("PUT /users/me/notification-preferences", sessionWrite(notificationHandler.UpdatePreferences))
+
+	mux.HandleFunc("GET /users/me/tokens", authedRead(domain.ScopeRead, tokenHandler.List))
+	mux.HandleFunc("POST /users/me/tokens", authedAuth(tokenHandler.Create))
+	mux.HandleFunc("DELETE /users/me/tokens/{id}", authedAuth(tokenHandler.Revoke))
+
+	mux.HandleFunc("GET /questions/", read(cache(shortLived, questionHandler.GetAll)))
+	mux.HandleFunc("POST /questions/", optionalWrite(domain.ScopeWriteQuestions, questionHandler.Create))
+	mux.HandleFunc("GET /questions/similar", read(cache(shortLived, questionHandler.Similar)))
+	mux.HandleFunc("GET /questions/{id}", read(cache(revalidate, questionHandler.GetByID)))
+	mux.HandleFunc("GET /questions/{id}/answers.atom", read(cache(feed, feedHandler.AnswersAtom)))
+	mux.HandleFunc("DELETE /questions/{id}", optionalWrite(domain.ScopeWriteQuestions, questionHandler.Delete))
+	mux.HandleFunc("PUT /questions/{id}/accepted-answer", authedWrite(domain.ScopeWriteQuestions, questionHandler.AcceptAnswer))
+	mux.HandleFunc("PUT /questions/{id}/status", authedWrite(domain.ScopeWriteQuestions, questionHandler.SetStatus))
+	// Following a question only changes what the caller is told about, so
+	// the read scope is enough.
+	mux.HandleFunc("POST /questions/{id}/watch", authedWrite(domain.ScopeRead, watchHandler.Watch))
+	mux.HandleFunc("DELETE /questions/{id}/watch", authedWrite(domain.ScopeRead, watchHandler.Unwatch))
+	mux.HandleFunc("POST /questions/{id}/flags", authedWrite(domain.ScopeWriteQuestions, moderationHandler.FlagQuestion))
+	mux.HandleFunc(uploadToQuestion, authedWrite(domain.ScopeWriteQuestions, attachmentHandler.UploadToQuestion))
+
+	mux.HandleFunc("POST /questions/{id}/answers/", authedWrite(domain.ScopeWriteAnswers, answerHandler.Create))
+
+	mux.HandleFunc("GET /answers/{id}", read(cache(revalidate, answerHandler.GetByID)))
+
+	mux.HandleFunc("DELETE /answers/{id}", authedWrite(domain.ScopeWriteAnswers, answerHandler.Delete))
+	mux.HandleFunc("POST /answers/{id}/flags", authedWrite(domain.ScopeWriteAnswers, moderationHandler.FlagAnswer))
+	mux.HandleFunc(uploadToAnswer, authedWrite(domain.ScopeWriteAnswers, attachmentHandler.UploadToAnswer))
 
-	mux.HandleFunc("POST /auth/register", authHandler.Register)
-	mux.HandleFunc("POST /auth/login", authHandler.Login)
-	mux.HandleFunc("POST /auth/refresh", authHandler.Refresh)
+	mux.HandleFunc("GET /attachments/{id}", authedRead(domain.ScopeRead, attachmentHandler.Download))
 
-	mux.HandleFunc("GET /questions/", questionHandler.GetAll)
-	mux.HandleFunc("POST /questions/", questionHandler.Create)
-	mux.HandleFunc("GET /questions/{id}", questionHandler.GetByID)
-	mux.HandleFunc("DELETE /questions/{id}", questionHandler.Delete)
+	mux.HandleFunc("GET /feeds/questions.atom", read(cache(feed, feedHandler.QuestionsAtom)))
+	mux.HandleFunc("GET /feeds/questions.rss", read(cache(feed, feedHandler.QuestionsRSS)))
 
-	mux.HandleFunc("POST /questions/{id}/answers/",
-		authMiddleware(http.HandlerFunc(answerHandler.Create)).ServeHTTP)
+	// Marking notifications as read only changes the caller's own inbox, so
+	// the read scope is enough.
+	mux.HandleFunc("GET /notifications", authedRead(domain.ScopeRead, notificationHandler.List))
+	mux.HandleFunc("GET /notifications/unread-count", authedRead(domain.ScopeRead, notificationHandler.UnreadCount))
+	mux.HandleFunc("POST /notifications/{id}/read", authedWrite(domain.ScopeRead, notificationHandler.MarkRead))
+	mux.HandleFunc("POST /notifications/read-all", authedWrite(domain.ScopeRead, notificationHandler.MarkAllRead))
 
-	mux.HandleFunc("GET /answers/{id}", answerHandler.GetByID)
+	mux.HandleFunc("GET /moderation/queue", moderatorOnly(moderationHandler.Queue))
+	mux.HandleFunc("POST /moderation/queue/{type}/{id}", moderatorOnly(moderationHandler.Act))
 
-	mux.HandleFunc("DELETE /answers/{id}",
-		authMiddleware(http.HandlerFunc(answerHandler.Delete)).ServeHTTP)
+	mux.HandleFunc("POST /admin/config/reload", adminOnly(adminHandler.ReloadConfig))
+	mux.HandleFunc("POST /admin/users/{id}/unlock", adminOnly(authHandler.UnlockUser))
+	mux.HandleFunc("GET /admin/audit", adminOnly(auditHandler.List))
+	mux.HandleFunc("GET /admin/export", adminOnly(transferHandler.Export))
+	mux.HandleFunc(adminImport, adminOnly(transferHandler.Import))
 
 	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -42,6 +185,14 @@ func NewRouter(
 	})
 
 	var h http.Handler = mux
+	// Uploads are limited by the attachment settings instead, and imports
+	// by nothing but the admin role.
+	h = middleware.BodyLimit(cfg.MaxBodyBytes, func(r *http.Request) bool {
+		_, pattern := mux.Handler(r)
+		return pattern == uploadToQuestion || pattern == uploadToAnswer || pattern == adminImport
+	})(h)
+	h = cors.Handler(h)
+	h = clientIP.Handler(h)
 	h = middleware.Logger(logger)(h)
 
 	return h
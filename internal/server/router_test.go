@@ -0,0 +1,86 @@
+package server
+
+import (
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/middleware"
+	"hitalent-test/internal/ratelimit"
+	"hitalent-test/internal/repository"
+	"hitalent-test/internal/service"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tokenRepository struct {
+	repository.PersonalAccessTokenRepository
+	scopes []string
+}
+
+func (r *tokenRepository) GetByHash(hash string) (*domain.PersonalAccessToken, error) {
+	return &domain.PersonalAccessToken{ID: "token-1", UserID: "user-1", Scopes: r.scopes}, nil
+}
+
+func (r *tokenRepository) TouchLastUsed(id string, at time.Time) error {
+	return nil
+}
+
+type userRepository struct {
+	repository.UserRepository
+}
+
+func (userRepository) GetByID(id string) (*domain.User, error) {
+	return &domain.User{ID: id, Email: "alice@example.com", Role: domain.RoleAdmin}, nil
+}
+
+// newTestRouter builds the router without handlers: the requests it is
+// given must be refused before reaching one. Requests authenticate with a
+// personal access token granted scopes.
+func newTestRouter(scopes ...string) http.Handler {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := config.Default()
+	return NewRouter(
+		nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		service.NewTokenService(&cfg.JWT),
+		service.NewPersonalAccessTokenService(&tokenRepository{scopes: scopes}, userRepository{}, service.NopAuditor{}, logger),
+		ratelimit.NewLimiter(ratelimit.NewMemoryStore(), config.RateLimitConfig{}),
+		middleware.NewClientIP(nil),
+		middleware.NewCORS(nil),
+		&cfg.Server,
+		logger,
+	)
+}
+
+func TestRouter_PersonalAccessTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []string
+		method string
+		path   string
+	}{
+		{"mint a token", domain.Scopes, http.MethodPost, "/users/me/tokens"},
+		{"revoke a token", domain.Scopes, http.MethodDelete, "/users/me/tokens/token-2"},
+		{"delete the account", domain.Scopes, http.MethodDelete, "/users/me"},
+		{"change the profile", domain.Scopes, http.MethodPatch, "/users/me"},
+		{"change the password", domain.Scopes, http.MethodPost, "/auth/password/change"},
+		{"ask without write:questions", []string{domain.ScopeRead}, http.MethodPost, "/questions/"},
+		{"answer without write:answers", []string{domain.ScopeRead, domain.ScopeWriteQuestions}, http.MethodPost, "/questions/1/answers/"},
+		{"moderate without moderate", []string{domain.ScopeRead}, http.MethodGet, "/moderation/queue"},
+		{"export without admin", []string{domain.ScopeRead, domain.ScopeModerate}, http.MethodGet, "/admin/export"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			r.Header.Set("Authorization", "Bearer "+domain.PersonalAccessTokenPrefix+"secret")
+			w := httptest.NewRecorder()
+
+			newTestRouter(tt.scopes...).ServeHTTP(w, r)
+			assert.Equal(t, http.StatusForbidden, w.Code)
+		})
+	}
+}
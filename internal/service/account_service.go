@@ -0,0 +1,356 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/repository"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// exportStaleAfter is how long a pending export may take before it is
+// assumed lost, e.g. to a restart, and a new one is started.
+const exportStaleAfter = 10 * time.Minute
+
+type AccountService struct {
+	userRepo      repository.UserRepository
+	questionRepo  repository.QuestionRepository
+	answerRepo    repository.AnswerRepository
+	identityRepo  repository.UserIdentityRepository
+	patRepo       repository.PersonalAccessTokenRepository
+	exportRepo    repository.DataExportRepository
+	refreshTokens *RefreshTokenStore
+	mfa           *MFAService
+	audit         Auditor
+	cfg           *config.AccountConfig
+	logger        *slog.Logger
+	now           func() time.Time
+	// async runs export builds; tests replace it to build synchronously.
+	async func(func())
+}
+
+func NewAccountService(
+	userRepo repository.UserRepository,
+	questionRepo repository.QuestionRepository,
+	answerRepo repository.AnswerRepository,
+	identityRepo repository.UserIdentityRepository,
+	patRepo repository.PersonalAccessTokenRepository,
+	exportRepo repository.DataExportRepository,
+	refreshTokens *RefreshTokenStore,
+	mfa *MFAService,
+	audit Auditor,
+	cfg *config.AccountConfig,
+	logger *slog.Logger,
+) *AccountService {
+	return &AccountService{
+		userRepo:      userRepo,
+		questionRepo:  questionRepo,
+		answerRepo:    answerRepo,
+		identityRepo:  identityRepo,
+		patRepo:       patRepo,
+		exportRepo:    exportRepo,
+		refreshTokens: refreshTokens,
+		mfa:           mfa,
+		audit:         audit,
+		cfg:           cfg,
+		logger:        logger,
+		now:           time.Now,
+		async:         func(f func()) { go f() },
+	}
+}
+
+// RequestDeletion schedules the account for erasure after re-authentication
+// and signs the user out, revoking their personal access tokens too. It returns nil if there is no grace period and
+// the account was erased at once.
+//
+// Without a password, a sign-in through a linked identity provider within
+// the re-authentication window counts instead: accounts created through
+// one never had a password to give.
+func (s *AccountService) RequestDeletion(userID string, req domain.ReauthRequest, meta domain.RequestMeta) (*domain.AccountDeletion, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Password == "" {
+		recent, err := s.signedInRecently(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !recent {
+			return nil, fmt.Errorf("%w: enter the current password or sign in again through a linked identity provider",
+				domain.ErrInvalidInput)
+		}
+	} else if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return nil, fmt.Errorf("%w: current password is incorrect", domain.ErrInvalidInput)
+	}
+	if user.TOTPEnabled {
+		ok, err := s.mfa.Verify(user, req.Code)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("%w: invalid two-factor code", domain.ErrInvalidInput)
+		}
+	}
+
+	s.refreshTokens.DeleteByUser(user.ID)
+	if err := s.patRepo.DeleteByUser(user.ID); err != nil {
+		return nil, fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+
+	if s.cfg.DeletionGracePeriod == 0 {
+		return nil, s.erase(user, meta)
+	}
+
+	if user.DeletionScheduledAt == nil {
+		scheduledAt := s.now().Add(s.cfg.DeletionGracePeriod)
+		user.DeletionScheduledAt = &scheduledAt
+		if err := s.userRepo.Update(user); err != nil {
+			return nil, fmt.Errorf("failed to update user: %w", err)
+		}
+		s.audit.Record(meta, domain.AuditDeletionRequest, domain.AuditTargetUser, user.ID, nil,
+			map[string]interface{}{"deletion_scheduled_at": scheduledAt})
+	}
+
+	return &domain.AccountDeletion{ScheduledAt: *user.DeletionScheduledAt}, nil
+}
+
+// signedInRecently reports whether the user signed in through one of their
+// linked identities within the re-authentication window.
+func (s *AccountService) signedInRecently(userID string) (bool, error) {
+	if s.cfg.ReauthWindow == 0 {
+		return false, nil
+	}
+	identities, err := s.identityRepo.ListByUser(userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to list identities: %w", err)
+	}
+	since := s.now().Add(-s.cfg.ReauthWindow)
+	return slices.ContainsFunc(identities, func(identity domain.UserIdentity) bool {
+		return identity.LastLoginAt.After(since)
+	}), nil
+}
+
+// CancelDeletion keeps an account whose deletion was requested.
+func (s *AccountService) CancelDeletion(userID string, meta domain.RequestMeta) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if user.DeletionScheduledAt == nil {
+		return fmt.Errorf("%w: account deletion is not scheduled", domain.ErrInvalidInput)
+	}
+
+	before := map[string]interface{}{"deletion_scheduled_at": *user.DeletionScheduledAt}
+	user.DeletionScheduledAt = nil
+	if err := s.userRepo.Update(user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	s.audit.Record(meta, domain.AuditDeletionCancel, domain.AuditTargetUser, user.ID, before, nil)
+	return nil
+}
+
+// PurgeDue erases the accounts whose grace period has passed and returns
+// how many were erased.
+func (s *AccountService) PurgeDue() (int, error) {
+	users, err := s.userRepo.ListDueForDeletion(s.now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to list accounts due for deletion: %w", err)
+	}
+
+	erased := 0
+	for i := range users {
+		user := &users[i]
+		if err := s.erase(user, domain.SystemMeta("account purge")); err != nil {
+			s.logger.Error("failed to erase account",
+				slog.String("user_id", user.ID),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+		erased++
+	}
+	return erased, nil
+}
+
+func (s *AccountService) erase(user *domain.User, meta domain.RequestMeta) error {
+	if err := s.userRepo.Erase(user.ID, s.cfg.ContentPolicy); err != nil {
+		return fmt.Errorf("failed to erase account: %w", err)
+	}
+	s.refreshTokens.DeleteByUser(user.ID)
+	s.audit.Record(meta, domain.AuditUserErased, domain.AuditTargetUser, user.ID, userSnapshot(user),
+		map[string]string{"content_policy": s.cfg.ContentPolicy})
+	s.logger.Info("account erased",
+		slog.String("user_id", user.ID),
+		slog.String("content_policy", s.cfg.ContentPolicy),
+	)
+	return nil
+}
+
+// Export returns the user's latest export in the given format. If there is
+// none that is ready or still being built, a new one is started in the
+// background and returned as pending.
+func (s *AccountService) Export(userID, format string) (*domain.DataExport, error) {
+	if format != domain.ExportFormatJSON && format != domain.ExportFormatZIP {
+		return nil, fmt.Errorf("%w: format must be %q or %q", domain.ErrInvalidInput, domain.ExportFormatJSON, domain.ExportFormatZIP)
+	}
+
+	now := s.now()
+	export, err := s.exportRepo.GetLatest(userID, format)
+	switch {
+	case err == nil:
+		if export.Status == domain.ExportStatusReady && export.ExpiresAt != nil && export.ExpiresAt.After(now) {
+			return export, nil
+		}
+		if export.Status == domain.ExportStatusPending && now.Sub(export.CreatedAt) < exportStaleAfter {
+			return export, nil
+		}
+	case !errors.Is(err, domain.ErrExportNotFound):
+		return nil, fmt.Errorf("failed to get export: %w", err)
+	}
+
+	export = &domain.DataExport{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Format:    format,
+		Status:    domain.ExportStatusPending,
+		CreatedAt: now,
+	}
+	if err := s.exportRepo.Create(export); err != nil {
+		return nil, fmt.Errorf("failed to create export: %w", err)
+	}
+
+	pending := *export
+	s.async(func() { s.build(&pending) })
+	return export, nil
+}
+
+// CleanupExports deletes expired archives.
+func (s *AccountService) CleanupExports() error {
+	now := s.now()
+	return s.exportRepo.DeleteExpired(now, now.Add(-exportStaleAfter))
+}
+
+func (s *AccountService) build(export *domain.DataExport) {
+	data, err := s.collect(export.UserID)
+	var archive []byte
+	if err == nil {
+		archive, err = encodeExport(data, export.Format)
+	}
+
+	now := s.now()
+	if err != nil {
+		s.logger.Error("failed to build data export",
+			slog.String("export_id", export.ID),
+			slog.String("error", err.Error()),
+		)
+		if err := s.exportRepo.Fail(export.ID, now); err != nil {
+			s.logger.Error("failed to mark data export as failed",
+				slog.String("export_id", export.ID),
+				slog.String("error", err.Error()),
+			)
+		}
+		return
+	}
+
+	if err := s.exportRepo.Complete(export.ID, archive, now, now.Add(s.cfg.ExportTTL)); err != nil {
+		s.logger.Error("failed to store data export",
+			slog.String("export_id", export.ID),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+func (s *AccountService) collect(userID string) (*domain.PersonalData, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	questions, err := s.questionRepo.ListByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list questions: %w", err)
+	}
+
+	answers, err := s.answerRepo.ListByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list answers: %w", err)
+	}
+
+	identities, err := s.identityRepo.ListByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list identities: %w", err)
+	}
+
+	tokens, err := s.patRepo.ListByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tokens: %w", err)
+	}
+
+	var sessions []domain.Session
+	for _, info := range s.refreshTokens.ListByUser(userID) {
+		sessions = append(sessions, domain.Session{IssuedAt: info.IssuedAt, ExpiresAt: info.ExpiresAt})
+	}
+	slices.SortFunc(sessions, func(a, b domain.Session) int { return a.IssuedAt.Compare(b.IssuedAt) })
+
+	return &domain.PersonalData{
+		ExportedAt:   s.now().UTC(),
+		Profile:      user,
+		Questions:    questions,
+		Answers:      answers,
+		Sessions:     sessions,
+		Identities:   identities,
+		AccessTokens: tokens,
+	}, nil
+}
+
+// encodeExport renders the data as one JSON document, or as a ZIP archive
+// with a JSON file per section.
+func encodeExport(data *domain.PersonalData, format string) ([]byte, error) {
+	if format == domain.ExportFormatJSON {
+		return json.MarshalIndent(data, "", "  ")
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"profile.json", data.Profile},
+		{"questions.json", data.Questions},
+		{"answers.json", data.Answers},
+		{"sessions.json", data.Sessions},
+		{"identities.json", data.Identities},
+		{"access_tokens.json", data.AccessTokens},
+	}
+	for _, f := range files {
+		content, err := json.MarshalIndent(f.content, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: data.ExportedAt})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(content); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
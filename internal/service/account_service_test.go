@@ -0,0 +1,395 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const accountTestUserID = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+
+type fakeAnswerRepository struct {
+	answers []*domain.Answer
+}
+
+func (r *fakeAnswerRepository) Create(answer *domain.Answer) error {
+	answer.ID = uint(len(r.answers) + 1)
+	if answer.CreatedAt.IsZero() {
+		answer.CreatedAt = time.Now()
+	}
+	r.answers = append(r.answers, answer)
+	return nil
+}
+
+func (r *fakeAnswerRepository) GetByID(id uint) (*domain.Answer, error) {
+	for _, a := range r.answers {
+		if a.ID == id {
+			copied := *a
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrAnswerNotFound
+}
+
+func (r *fakeAnswerRepository) GetByQuestionID(questionID uint) ([]domain.Answer, error) {
+	var answers []domain.Answer
+	for _, a := range r.answers {
+		if a.QuestionID == questionID {
+			answers = append(answers, *a)
+		}
+	}
+	return answers, nil
+}
+
+func (r *fakeAnswerRepository) ListByUser(userID string) ([]domain.Answer, error) {
+	var answers []domain.Answer
+	for _, a := range r.answers {
+		if a.UserID == userID {
+			answers = append(answers, *a)
+		}
+	}
+	return answers, nil
+}
+
+func (r *fakeAnswerRepository) SetHidden(id uint, hiddenAt *time.Time) error {
+	for _, a := range r.answers {
+		if a.ID == id {
+			a.HiddenAt = hiddenAt
+			return nil
+		}
+	}
+	return domain.ErrAnswerNotFound
+}
+
+func (r *fakeAnswerRepository) Delete(id uint) error {
+	for i, a := range r.answers {
+		if a.ID == id {
+			r.answers = append(r.answers[:i], r.answers[i+1:]...)
+			return nil
+		}
+	}
+	return domain.ErrAnswerNotFound
+}
+
+type fakeDataExportRepository struct {
+	exports []*domain.DataExport
+}
+
+func (r *fakeDataExportRepository) Create(export *domain.DataExport) error {
+	copied := *export
+	r.exports = append(r.exports, &copied)
+	return nil
+}
+
+func (r *fakeDataExportRepository) GetLatest(userID, format string) (*domain.DataExport, error) {
+	for i := len(r.exports) - 1; i >= 0; i-- {
+		if e := r.exports[i]; e.UserID == userID && e.Format == format {
+			copied := *e
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrExportNotFound
+}
+
+func (r *fakeDataExportRepository) Complete(id string, data []byte, completedAt, expiresAt time.Time) error {
+	for _, e := range r.exports {
+		if e.ID == id {
+			e.Status = domain.ExportStatusReady
+			e.Data = data
+			e.CompletedAt = &completedAt
+			e.ExpiresAt = &expiresAt
+		}
+	}
+	return nil
+}
+
+func (r *fakeDataExportRepository) Fail(id string, at time.Time) error {
+	for _, e := range r.exports {
+		if e.ID == id {
+			e.Status = domain.ExportStatusFailed
+			e.CompletedAt = &at
+			e.ExpiresAt = &at
+		}
+	}
+	return nil
+}
+
+func (r *fakeDataExportRepository) DeleteExpired(now, staleBefore time.Time) error {
+	kept := r.exports[:0]
+	for _, e := range r.exports {
+		if e.ExpiresAt != nil && !e.ExpiresAt.After(now) || e.ExpiresAt == nil && e.CreatedAt.Before(staleBefore) {
+			continue
+		}
+		kept = append(kept, e)
+	}
+	r.exports = kept
+	return nil
+}
+
+type accountTestEnv struct {
+	now        time.Time
+	cfg        *config.Config
+	users      *fakeUserRepository
+	answers    *fakeAnswerRepository
+	identities *fakeUserIdentityRepository
+	pats       *fakePersonalAccessTokenRepository
+	exports    *fakeDataExportRepository
+	audit      *fakeAuditRepository
+	sessions   *RefreshTokenStore
+	account    *AccountService
+}
+
+func newAccountTestEnv(t *testing.T) *accountTestEnv {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	env := &accountTestEnv{
+		now: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+		cfg: config.Default(),
+		users: newFakeUserRepository(&domain.User{
+			ID:           accountTestUserID,
+			Email:        "user@example.com",
+			PasswordHash: string(hash),
+			Role:         domain.RoleUser,
+		}),
+		answers:    &fakeAnswerRepository{},
+		identities: &fakeUserIdentityRepository{},
+		pats:       &fakePersonalAccessTokenRepository{},
+		exports:    &fakeDataExportRepository{},
+		audit:      &fakeAuditRepository{},
+		sessions:   NewRefreshTokenStore(),
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	questions := new(MockQuestionRepository)
+	questions.On("ListByUser", mock.Anything).Return([]domain.Question{{ID: 1, Text: "What is the capital of France?"}}, nil)
+
+	mfa := NewMFAService(env.users, &fakeRecoveryCodeRepository{}, &env.cfg.MFA)
+	env.account = NewAccountService(env.users, questions, env.answers, env.identities,
+		env.pats, env.exports, env.sessions, mfa, NewAuditService(env.audit, logger), &env.cfg.Account,
+		logger)
+	env.account.now = func() time.Time { return env.now }
+	env.account.async = func(f func()) { f() }
+	return env
+}
+
+func TestAccountService_RequestDeletion(t *testing.T) {
+	env := newAccountTestEnv(t)
+	env.sessions.Save("refresh", &RefreshTokenInfo{UserID: accountTestUserID, ExpiresAt: time.Now().Add(time.Hour)})
+	require.NoError(t, env.pats.Create(&domain.PersonalAccessToken{ID: "pat-1", UserID: accountTestUserID}))
+	require.NoError(t, env.pats.Create(&domain.PersonalAccessToken{ID: "pat-2", UserID: "someone-else"}))
+
+	_, err := env.account.RequestDeletion(accountTestUserID, domain.ReauthRequest{Password: "wrong"}, domain.RequestMeta{})
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+
+	deletion, err := env.account.RequestDeletion(accountTestUserID, domain.ReauthRequest{Password: "password123"}, domain.RequestMeta{})
+	require.NoError(t, err)
+	require.NotNil(t, deletion)
+	assert.Equal(t, env.now.Add(env.cfg.Account.DeletionGracePeriod), deletion.ScheduledAt)
+
+	_, ok := env.sessions.Get("refresh")
+	assert.False(t, ok, "sessions must be revoked")
+	tokens, err := env.pats.ListByUser(accountTestUserID)
+	require.NoError(t, err)
+	assert.Empty(t, tokens, "access tokens must be revoked")
+	others, err := env.pats.ListByUser("someone-else")
+	require.NoError(t, err)
+	assert.Len(t, others, 1)
+
+	// Asking again does not push the date back.
+	env.now = env.now.Add(time.Hour)
+	again, err := env.account.RequestDeletion(accountTestUserID, domain.ReauthRequest{Password: "password123"}, domain.RequestMeta{})
+	require.NoError(t, err)
+	assert.Equal(t, deletion.ScheduledAt, again.ScheduledAt)
+}
+
+func TestAccountService_RequestDeletion_AfterProviderSignIn(t *testing.T) {
+	env := newAccountTestEnv(t)
+
+	_, err := env.account.RequestDeletion(accountTestUserID, domain.ReauthRequest{}, domain.RequestMeta{})
+	assert.ErrorIs(t, err, domain.ErrInvalidInput, "no linked identity")
+
+	require.NoError(t, env.identities.Create(&domain.UserIdentity{
+		UserID:      accountTestUserID,
+		Provider:    "sso",
+		Subject:     "subject-1",
+		LastLoginAt: env.now.Add(-env.cfg.Account.ReauthWindow - time.Second),
+	}))
+	_, err = env.account.RequestDeletion(accountTestUserID, domain.ReauthRequest{}, domain.RequestMeta{})
+	assert.ErrorIs(t, err, domain.ErrInvalidInput, "the sign-in is too old")
+
+	// A password, when given, must still be right.
+	require.NoError(t, env.identities.RecordLogin(1, "user@example.com", env.now.Add(-time.Minute)))
+	_, err = env.account.RequestDeletion(accountTestUserID, domain.ReauthRequest{Password: "wrong"}, domain.RequestMeta{})
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+
+	deletion, err := env.account.RequestDeletion(accountTestUserID, domain.ReauthRequest{}, domain.RequestMeta{})
+	require.NoError(t, err)
+	assert.Equal(t, env.now.Add(env.cfg.Account.DeletionGracePeriod), deletion.ScheduledAt)
+}
+
+func TestAccountService_RequestDeletion_ProviderSignInDisabled(t *testing.T) {
+	env := newAccountTestEnv(t)
+	env.cfg.Account.ReauthWindow = 0
+	require.NoError(t, env.identities.Create(&domain.UserIdentity{
+		UserID: accountTestUserID, Provider: "sso", Subject: "subject-1", LastLoginAt: env.now,
+	}))
+
+	_, err := env.account.RequestDeletion(accountTestUserID, domain.ReauthRequest{}, domain.RequestMeta{})
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+}
+
+func TestAccountService_CancelDeletion(t *testing.T) {
+	env := newAccountTestEnv(t)
+
+	assert.ErrorIs(t, env.account.CancelDeletion(accountTestUserID, domain.RequestMeta{}), domain.ErrInvalidInput)
+
+	_, err := env.account.RequestDeletion(accountTestUserID, domain.ReauthRequest{Password: "password123"}, domain.RequestMeta{})
+	require.NoError(t, err)
+	require.NoError(t, env.account.CancelDeletion(accountTestUserID, domain.RequestMeta{}))
+
+	env.now = env.now.Add(env.cfg.Account.DeletionGracePeriod + time.Hour)
+	erased, err := env.account.PurgeDue()
+	require.NoError(t, err)
+	assert.Zero(t, erased)
+
+	user, err := env.users.GetByID(accountTestUserID)
+	require.NoError(t, err)
+	assert.Nil(t, user.DeletionScheduledAt)
+}
+
+func TestAccountService_PurgeDue(t *testing.T) {
+	env := newAccountTestEnv(t)
+	env.cfg.Account.ContentPolicy = domain.ContentPolicyDelete
+
+	_, err := env.account.RequestDeletion(accountTestUserID, domain.ReauthRequest{Password: "password123"}, domain.RequestMeta{})
+	require.NoError(t, err)
+
+	env.now = env.now.Add(env.cfg.Account.DeletionGracePeriod - time.Minute)
+	erased, err := env.account.PurgeDue()
+	require.NoError(t, err)
+	assert.Zero(t, erased, "grace period has not passed")
+
+	env.now = env.now.Add(time.Minute)
+	erased, err = env.account.PurgeDue()
+	require.NoError(t, err)
+	assert.Equal(t, 1, erased)
+	assert.Equal(t, domain.ContentPolicyDelete, env.users.erased[accountTestUserID])
+
+	_, err = env.users.GetByID(accountTestUserID)
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+}
+
+func TestAccountService_RequestDeletion_WithoutGracePeriod(t *testing.T) {
+	env := newAccountTestEnv(t)
+	env.cfg.Account.DeletionGracePeriod = 0
+
+	deletion, err := env.account.RequestDeletion(accountTestUserID, domain.ReauthRequest{Password: "password123"}, domain.RequestMeta{})
+
+	require.NoError(t, err)
+	assert.Nil(t, deletion)
+	assert.Equal(t, domain.ContentPolicyAnonymize, env.users.erased[accountTestUserID])
+}
+
+func TestAccountService_Erase_AuditKeepsNoEmail(t *testing.T) {
+	env := newAccountTestEnv(t)
+	env.cfg.Account.DeletionGracePeriod = 0
+
+	_, err := env.account.RequestDeletion(accountTestUserID, domain.ReauthRequest{Password: "password123"}, domain.RequestMeta{})
+	require.NoError(t, err)
+
+	require.NotEmpty(t, env.audit.entries)
+	erased := env.audit.entries[len(env.audit.entries)-1]
+	assert.Equal(t, domain.AuditUserErased, erased.Action)
+	assert.Equal(t, accountTestUserID, erased.TargetID)
+	assert.JSONEq(t, `{"role":"user","email_verified":false}`, string(erased.Before))
+	for _, entry := range env.audit.entries {
+		assert.NotContains(t, string(entry.Before)+string(entry.After), "user@example.com", entry.Action)
+	}
+}
+
+func TestAccountService_Export_JSON(t *testing.T) {
+	env := newAccountTestEnv(t)
+	require.NoError(t, env.answers.Create(&domain.Answer{QuestionID: 1, UserID: accountTestUserID, Text: "Paris"}))
+	require.NoError(t, env.answers.Create(&domain.Answer{QuestionID: 1, UserID: "someone-else", Text: "Lyon"}))
+	env.sessions.Save("refresh", &RefreshTokenInfo{
+		UserID:    accountTestUserID,
+		IssuedAt:  env.now,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+
+	export, err := env.account.Export(accountTestUserID, domain.ExportFormatJSON)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ExportStatusPending, export.Status)
+
+	export, err = env.account.Export(accountTestUserID, domain.ExportFormatJSON)
+	require.NoError(t, err)
+	require.Equal(t, domain.ExportStatusReady, export.Status)
+
+	var data domain.PersonalData
+	require.NoError(t, json.Unmarshal(export.Data, &data))
+	assert.Equal(t, "user@example.com", data.Profile.Email)
+	assert.Len(t, data.Questions, 1)
+	require.Len(t, data.Answers, 1)
+	assert.Equal(t, "Paris", data.Answers[0].Text)
+	assert.Len(t, data.Sessions, 1)
+	assert.NotContains(t, string(export.Data), "password")
+}
+
+func TestAccountService_Export_ZIP(t *testing.T) {
+	env := newAccountTestEnv(t)
+
+	_, err := env.account.Export(accountTestUserID, domain.ExportFormatZIP)
+	require.NoError(t, err)
+	export, err := env.account.Export(accountTestUserID, domain.ExportFormatZIP)
+	require.NoError(t, err)
+	require.Equal(t, domain.ExportStatusReady, export.Status)
+
+	zr, err := zip.NewReader(bytes.NewReader(export.Data), int64(len(export.Data)))
+	require.NoError(t, err)
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	assert.Contains(t, names, "profile.json")
+	assert.Contains(t, names, "questions.json")
+	assert.Contains(t, names, "answers.json")
+	assert.Contains(t, names, "sessions.json")
+}
+
+func TestAccountService_Export_RebuildsExpired(t *testing.T) {
+	env := newAccountTestEnv(t)
+
+	first, err := env.account.Export(accountTestUserID, domain.ExportFormatJSON)
+	require.NoError(t, err)
+
+	env.now = env.now.Add(env.cfg.Account.ExportTTL + time.Minute)
+	second, err := env.account.Export(accountTestUserID, domain.ExportFormatJSON)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID, second.ID)
+	assert.Equal(t, domain.ExportStatusPending, second.Status)
+
+	require.NoError(t, env.account.CleanupExports())
+	assert.Len(t, env.exports.exports, 1, "the expired export is removed")
+}
+
+func TestAccountService_Export_InvalidFormat(t *testing.T) {
+	env := newAccountTestEnv(t)
+
+	_, err := env.account.Export(accountTestUserID, "xml")
+
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+}
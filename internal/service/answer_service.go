@@ -2,54 +2,184 @@ package service
 
 import (
 	"fmt"
+	"hitalent-test/internal/config"
 	"hitalent-test/internal/domain"
+	"hitalent-test/internal/markdown"
 	"hitalent-test/internal/repository"
+	"strconv"
 	"strings"
+	"sync/atomic"
 
 	"github.com/google/uuid"
 )
 
 type AnswerService struct {
-	answerRepo   repository.AnswerRepository
-	questionRepo repository.QuestionRepository
+	answerRepo      repository.AnswerRepository
+	questionRepo    repository.QuestionRepository
+	userRepo        repository.UserRepository
+	filter          ContentChecker
+	audit           Auditor
+	notifications   Notifier
+	watches         QuestionWatcher
+	mentions        MentionTracker
+	limits          *config.ValidationConfig
+	requireVerified atomic.Bool
 }
 
-func NewAnswerService(answerRepo repository.AnswerRepository, questionRepo repository.QuestionRepository) *AnswerService {
+func NewAnswerService(
+	answerRepo repository.AnswerRepository,
+	questionRepo repository.QuestionRepository,
+	userRepo repository.UserRepository,
+	deps PostDeps,
+	limits *config.ValidationConfig,
+) *AnswerService {
+	deps = deps.withDefaults()
 	return &AnswerService{
-		answerRepo:   answerRepo,
-		questionRepo: questionRepo,
+		answerRepo:    answerRepo,
+		questionRepo:  questionRepo,
+		userRepo:      userRepo,
+		filter:        deps.Filter,
+		audit:         deps.Audit,
+		notifications: deps.Notifications,
+		watches:       deps.Watches,
+		mentions:      deps.Mentions,
+		limits:        limits,
 	}
 }
 
+// SetRequireVerifiedEmail toggles whether only users with a verified email
+// may post answers.
+func (s *AnswerService) SetRequireVerifiedEmail(required bool) {
+	s.requireVerified.Store(required)
+}
+
 func (s *AnswerService) Create(questionID uint, req *domain.CreateAnswerRequest) (*domain.Answer, error) {
-	_, err := s.questionRepo.GetByID(questionID)
+	question, err := s.questionRepo.GetByID(questionID)
 	if err != nil {
 		return nil, err
 	}
+	if question.HiddenAt != nil {
+		return nil, domain.ErrQuestionNotFound
+	}
+	if !question.AcceptsAnswers() {
+		return nil, fmt.Errorf("%w: question is %s", domain.ErrQuestionClosed, question.Status)
+	}
 
 	if err := s.validateCreateRequest(req); err != nil {
 		return nil, err
 	}
 
+	if s.requireVerified.Load() {
+		user, err := s.userRepo.GetByID(strings.TrimSpace(req.UserID))
+		if err != nil {
+			return nil, err
+		}
+		if !user.EmailVerified {
+			return nil, fmt.Errorf("%w: email address is not verified", domain.ErrForbidden)
+		}
+	}
+
+	userID := strings.TrimSpace(req.UserID)
+	text := strings.TrimSpace(req.Text)
+	mentions, err := s.mentions.Resolve(text)
+	if err != nil {
+		return nil, err
+	}
+
+	hiddenAt, reason, err := s.filter.Check(domain.ContentAnswer, userID, text)
+	if err != nil {
+		return nil, err
+	}
+
 	answer := &domain.Answer{
-		QuestionID: questionID,
-		UserID:     strings.TrimSpace(req.UserID),
-		Text:       strings.TrimSpace(req.Text),
+		QuestionID:    questionID,
+		UserID:        userID,
+		Text:          text,
+		TextHTML:      markdown.Render(text, mentionLinks(mentions)),
+		HiddenAt:      hiddenAt,
+		PendingReview: hiddenAt != nil,
 	}
 
 	if err := s.answerRepo.Create(answer); err != nil {
 		return nil, fmt.Errorf("failed to create answer: %w", err)
 	}
 
+	if hiddenAt != nil {
+		if err := s.filter.Hold(domain.ContentAnswer, answer.ID, reason); err != nil {
+			return nil, err
+		}
+	} else if question.UserID != nil {
+		s.notifications.Notify(&domain.Notification{
+			UserID:     *question.UserID,
+			Type:       domain.NotificationAnswer,
+			ActorID:    &answer.UserID,
+			QuestionID: &question.ID,
+			AnswerID:   &answer.ID,
+			Message:    fmt.Sprintf("Your question #%d has a new answer.", question.ID),
+		})
+	}
+	if hiddenAt == nil {
+		s.watches.AnswerPosted(question, answer)
+	}
+	s.watches.Follow(userID, questionID)
+	s.mentions.Save(domain.ContentAnswer, answer.ID, questionID, userID, mentions, hiddenAt == nil)
+
+	if err := s.attachAuthor(answer); err != nil {
+		return nil, err
+	}
+	if err := s.mentions.AttachAnswer(answer); err != nil {
+		return nil, err
+	}
+
 	return answer, nil
 }
 
 func (s *AnswerService) GetByID(id uint) (*domain.Answer, error) {
-	return s.answerRepo.GetByID(id)
+	answer, err := s.answerRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	question, err := s.questionRepo.GetByID(answer.QuestionID)
+	if err != nil {
+		return nil, err
+	}
+	if answer.HiddenAt != nil || question.HiddenAt != nil {
+		return nil, domain.ErrAnswerNotFound
+	}
+	answer.Accepted = question.AcceptedAnswerID != nil && *question.AcceptedAnswerID == answer.ID
+
+	if err := s.attachAuthor(answer); err != nil {
+		return nil, err
+	}
+	if err := s.mentions.AttachAnswer(answer); err != nil {
+		return nil, err
+	}
+
+	return answer, nil
 }
 
-func (s *AnswerService) Delete(id uint) error {
-	return s.answerRepo.Delete(id)
+func (s *AnswerService) attachAuthor(answer *domain.Answer) error {
+	authors, err := authorSummaries(s.userRepo, []string{answer.UserID})
+	if err != nil {
+		return err
+	}
+	answer.Author = authors[answer.UserID]
+	return nil
+}
+
+func (s *AnswerService) Delete(id uint, meta domain.RequestMeta) error {
+	answer, err := s.answerRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.answerRepo.Delete(id); err != nil {
+		return err
+	}
+
+	s.audit.Record(meta, domain.AuditAnswerDeleted, domain.AuditTargetAnswer, strconv.FormatUint(uint64(id), 10), answerSnapshot(answer), nil)
+	return nil
 }
 
 func (s *AnswerService) validateCreateRequest(req *domain.CreateAnswerRequest) error {
@@ -62,15 +192,18 @@ func (s *AnswerService) validateCreateRequest(req *domain.CreateAnswerRequest) e
 		return fmt.Errorf("%w: user_id must be a valid UUID", domain.ErrInvalidInput)
 	}
 
-	text := strings.TrimSpace(req.Text)
+	if maxSource := s.limits.AnswerMaxLength * s.limits.SourceLengthFactor; len(req.Text) > maxSource {
+		return fmt.Errorf("%w: answer text must not exceed %d characters including markup", domain.ErrInvalidInput, maxSource)
+	}
+	text := plainText(req.Text)
 	if text == "" {
 		return fmt.Errorf("%w: answer text is required", domain.ErrInvalidInput)
 	}
-	if len(text) < 5 {
-		return fmt.Errorf("%w: answer text must be at least 5 characters", domain.ErrInvalidInput)
+	if len(text) < s.limits.AnswerMinLength {
+		return fmt.Errorf("%w: answer text must be at least %d characters", domain.ErrInvalidInput, s.limits.AnswerMinLength)
 	}
-	if len(text) > 1000 {
-		return fmt.Errorf("%w: answer text must not exceed 1000 characters", domain.ErrInvalidInput)
+	if len(text) > s.limits.AnswerMaxLength {
+		return fmt.Errorf("%w: answer text must not exceed %d characters", domain.ErrInvalidInput, s.limits.AnswerMaxLength)
 	}
 
 	return nil
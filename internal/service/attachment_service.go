@@ -0,0 +1,347 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+
+	_ "golang.org/x/image/webp"
+
+	"hitalent-test/internal/blob"
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/repository"
+)
+
+const (
+	// maxFilenameLength is the longest filename kept, in bytes.
+	maxFilenameLength = 255
+	// orphanBatchSize is how many unreferenced blobs CleanupBlobs looks up
+	// at a time.
+	orphanBatchSize = 100
+)
+
+// AttachmentService stores files uploaded to questions and answers and
+// serves them back to the users who may see the post.
+type AttachmentService struct {
+	repo         repository.AttachmentRepository
+	questionRepo repository.QuestionRepository
+	answerRepo   repository.AnswerRepository
+	store        blob.Store
+	cfg          atomic.Pointer[config.AttachmentsConfig]
+	logger       *slog.Logger
+}
+
+func NewAttachmentService(
+	repo repository.AttachmentRepository,
+	questionRepo repository.QuestionRepository,
+	answerRepo repository.AnswerRepository,
+	store blob.Store,
+	cfg config.AttachmentsConfig,
+	logger *slog.Logger,
+) *AttachmentService {
+	s := &AttachmentService{
+		repo:         repo,
+		questionRepo: questionRepo,
+		answerRepo:   answerRepo,
+		store:        store,
+		logger:       logger,
+	}
+	s.SetConfig(cfg)
+	return s
+}
+
+// SetConfig changes the limits applied to new uploads.
+func (s *AttachmentService) SetConfig(cfg config.AttachmentsConfig) {
+	s.cfg.Store(&cfg)
+}
+
+// MaxSize is the largest file accepted, in bytes.
+func (s *AttachmentService) MaxSize() int64 {
+	return s.cfg.Load().MaxSize
+}
+
+// Upload attaches a file to a visible question or answer. Only the post's
+// author and moderators may attach files. The type is detected from the
+// content and must be allowed; images must also decode and fit the size
+// limits. A file whose content is already stored is not stored again.
+func (s *AttachmentService) Upload(ctx context.Context, contentType string, contentID uint, actorID, actorRole string, upload domain.Upload) (*domain.Attachment, error) {
+	cfg := s.cfg.Load()
+
+	authorID, err := s.author(contentType, contentID)
+	if err != nil {
+		return nil, err
+	}
+	isAuthor := authorID != nil && *authorID == actorID
+	if !isAuthor && actorRole != domain.RoleModerator && actorRole != domain.RoleAdmin {
+		return nil, fmt.Errorf("%w: only the author may attach files", domain.ErrForbidden)
+	}
+
+	count, err := s.repo.Count(contentType, contentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count attachments: %w", err)
+	}
+	if count >= int64(cfg.MaxPerPost) {
+		return nil, fmt.Errorf("%w: at most %d files may be attached to a post", domain.ErrInvalidInput, cfg.MaxPerPost)
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, io.LimitReader(upload.Content, cfg.MaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	if size > cfg.MaxSize {
+		return nil, fmt.Errorf("%w: files must not exceed %d bytes", domain.ErrFileTooLarge, cfg.MaxSize)
+	}
+	if size == 0 {
+		return nil, fmt.Errorf("%w: file is empty", domain.ErrInvalidInput)
+	}
+	checksum := hex.EncodeToString(hash.Sum(nil))
+
+	attachment := &domain.Attachment{
+		UserID:   &actorID,
+		Filename: sanitizeFilename(upload.Filename),
+		Size:     size,
+		Checksum: checksum,
+	}
+	if contentType == domain.ContentQuestion {
+		attachment.QuestionID = &contentID
+	} else {
+		attachment.AnswerID = &contentID
+	}
+
+	attachment.ContentType, err = sniff(upload.Content)
+	if err != nil {
+		return nil, err
+	}
+	mediaType, _, _ := mime.ParseMediaType(attachment.ContentType)
+	if !slices.Contains(cfg.AllowedTypes, mediaType) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrUnsupportedFileType, mediaType)
+	}
+
+	if strings.HasPrefix(mediaType, "image/") {
+		if err := checkImage(upload.Content, attachment, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	stored, err := s.storeBlob(ctx, upload.Content, attachment)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(attachment); err != nil {
+		if stored {
+			if _, releaseErr := s.releaseBlob(ctx, checksum); releaseErr != nil {
+				s.logger.Warn("failed to delete blob of a failed upload",
+					slog.String("checksum", checksum),
+					slog.String("error", releaseErr.Error()),
+				)
+			}
+		}
+		return nil, fmt.Errorf("failed to create attachment: %w", err)
+	}
+	return attachment, nil
+}
+
+// CleanupBlobs deletes the blobs of deleted attachments that no attachment
+// refers to any more and returns how many were deleted. Attachments are
+// deleted along with their posts, so the database notes their checksums
+// and this sweep removes the content later.
+//
+// An upload of the same content may find the blob just before the sweep
+// deletes it; the attachment then has no content and has to be uploaded
+// again.
+func (s *AttachmentService) CleanupBlobs(ctx context.Context) (int, error) {
+	deleted := 0
+	for {
+		checksums, err := s.repo.OrphanedChecksums(orphanBatchSize)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to look up orphaned blobs: %w", err)
+		}
+		for _, checksum := range checksums {
+			released, err := s.releaseBlob(ctx, checksum)
+			if err != nil {
+				return deleted, err
+			}
+			if released {
+				deleted++
+			}
+			if err := s.repo.ForgetOrphaned(checksum); err != nil {
+				return deleted, fmt.Errorf("failed to forget orphaned blob: %w", err)
+			}
+		}
+		if len(checksums) < orphanBatchSize {
+			return deleted, nil
+		}
+	}
+}
+
+// releaseBlob deletes the blob with the checksum unless an attachment still
+// refers to it, and reports whether it did.
+func (s *AttachmentService) releaseBlob(ctx context.Context, checksum string) (bool, error) {
+	count, err := s.repo.CountByChecksum(checksum)
+	if err != nil {
+		return false, fmt.Errorf("failed to count attachments: %w", err)
+	}
+	if count > 0 {
+		return false, nil
+	}
+	if err := s.store.Delete(ctx, blobKey(checksum)); err != nil {
+		return false, fmt.Errorf("failed to delete blob: %w", err)
+	}
+	return true, nil
+}
+
+// Open returns an attachment with a reader for its content. Attachments of
+// hidden posts are only served to their uploader and to moderators. The
+// caller closes the reader.
+func (s *AttachmentService) Open(ctx context.Context, id uint, actorID, actorRole string) (*domain.Attachment, io.ReadSeekCloser, error) {
+	attachment, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	isUploader := attachment.UserID != nil && *attachment.UserID == actorID
+	if !isUploader && actorRole != domain.RoleModerator && actorRole != domain.RoleAdmin {
+		contentType, contentID := domain.ContentQuestion, attachment.QuestionID
+		if attachment.AnswerID != nil {
+			contentType, contentID = domain.ContentAnswer, attachment.AnswerID
+		}
+		if _, err := s.author(contentType, *contentID); err != nil {
+			if errors.Is(err, domain.ErrQuestionNotFound) || errors.Is(err, domain.ErrAnswerNotFound) {
+				return nil, nil, domain.ErrAttachmentNotFound
+			}
+			return nil, nil, err
+		}
+	}
+
+	content, err := s.store.Open(ctx, blobKey(attachment.Checksum), attachment.Size)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open attachment %d: %w", attachment.ID, err)
+	}
+	return attachment, content, nil
+}
+
+// author returns the author of a visible post, which is nil for anonymous
+// questions.
+func (s *AttachmentService) author(contentType string, contentID uint) (*string, error) {
+	switch contentType {
+	case domain.ContentQuestion:
+		question, err := s.questionRepo.GetByID(contentID)
+		if err != nil {
+			return nil, err
+		}
+		if question.HiddenAt != nil {
+			return nil, domain.ErrQuestionNotFound
+		}
+		return question.UserID, nil
+	case domain.ContentAnswer:
+		answer, err := s.answerRepo.GetByID(contentID)
+		if err != nil {
+			return nil, err
+		}
+		question, err := s.questionRepo.GetByID(answer.QuestionID)
+		if err != nil {
+			return nil, err
+		}
+		if answer.HiddenAt != nil || question.HiddenAt != nil {
+			return nil, domain.ErrAnswerNotFound
+		}
+		return &answer.UserID, nil
+	}
+	return nil, fmt.Errorf("%w: unknown content type %q", domain.ErrInvalidInput, contentType)
+}
+
+// storeBlob writes the content unless a blob with the same checksum is
+// already stored, and reports whether it wrote one.
+func (s *AttachmentService) storeBlob(ctx context.Context, content io.ReadSeeker, attachment *domain.Attachment) (bool, error) {
+	key := blobKey(attachment.Checksum)
+	exists, err := s.store.Exists(ctx, key)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up blob: %w", err)
+	}
+	if exists {
+		return false, nil
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return false, fmt.Errorf("failed to rewind upload: %w", err)
+	}
+	if err := s.store.Put(ctx, key, content, attachment.Size, attachment.ContentType); err != nil {
+		return false, fmt.Errorf("failed to store blob: %w", err)
+	}
+	return true, nil
+}
+
+// blobKey spreads blobs over 256 prefixes so that no directory of the
+// local store grows too large.
+func blobKey(checksum string) string {
+	return "sha256/" + checksum[:2] + "/" + checksum
+}
+
+// sniff detects the media type from the first bytes of the content.
+func sniff(content io.ReadSeeker) (string, error) {
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to rewind upload: %w", err)
+	}
+	head := make([]byte, 512)
+	n, err := io.ReadFull(content, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", fmt.Errorf("failed to read upload: %w", err)
+	}
+	return http.DetectContentType(head[:n]), nil
+}
+
+// checkImage records the dimensions of an image, refusing images that do
+// not decode or are too large.
+func checkImage(content io.ReadSeeker, attachment *domain.Attachment, cfg *config.AttachmentsConfig) error {
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind upload: %w", err)
+	}
+	img, _, err := image.DecodeConfig(content)
+	if err != nil {
+		return fmt.Errorf("%w: image cannot be decoded", domain.ErrUnsupportedFileType)
+	}
+	if img.Width > cfg.MaxImageWidth || img.Height > cfg.MaxImageHeight {
+		return fmt.Errorf("%w: images must not exceed %dx%d pixels", domain.ErrInvalidInput, cfg.MaxImageWidth, cfg.MaxImageHeight)
+	}
+	attachment.Width, attachment.Height = &img.Width, &img.Height
+	return nil
+}
+
+// sanitizeFilename keeps the base name of a client-supplied filename,
+// without control characters and cut to maxFilenameLength bytes.
+func sanitizeFilename(name string) string {
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	for len(name) > maxFilenameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	if name == "" || name == "." || name == ".." {
+		return "file"
+	}
+	return name
+}
@@ -0,0 +1,326 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"hitalent-test/internal/blob"
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	attachmentTestAuthorID = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	attachmentTestOtherID  = "11111111-1111-4111-8111-111111111111"
+)
+
+type fakeAttachmentRepository struct {
+	attachments []domain.Attachment
+	orphaned    []string
+	failCreate  error
+}
+
+func (r *fakeAttachmentRepository) Create(attachment *domain.Attachment) error {
+	if r.failCreate != nil {
+		return r.failCreate
+	}
+	attachment.ID = uint(len(r.attachments) + 1)
+	attachment.CreatedAt = time.Now()
+	r.attachments = append(r.attachments, *attachment)
+	return nil
+}
+
+func (r *fakeAttachmentRepository) GetByID(id uint) (*domain.Attachment, error) {
+	for _, a := range r.attachments {
+		if a.ID == id {
+			return &a, nil
+		}
+	}
+	return nil, domain.ErrAttachmentNotFound
+}
+
+func (r *fakeAttachmentRepository) Count(contentType string, contentID uint) (int64, error) {
+	var count int64
+	for _, a := range r.attachments {
+		id := a.AnswerID
+		if contentType == domain.ContentQuestion {
+			id = a.QuestionID
+		}
+		if id != nil && *id == contentID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeAttachmentRepository) CountByChecksum(checksum string) (int64, error) {
+	var count int64
+	for _, a := range r.attachments {
+		if a.Checksum == checksum {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeAttachmentRepository) OrphanedChecksums(limit int) ([]string, error) {
+	r.orphaned = slices.DeleteFunc(r.orphaned, func(checksum string) bool {
+		count, _ := r.CountByChecksum(checksum)
+		return count > 0
+	})
+	return slices.Clone(r.orphaned[:min(limit, len(r.orphaned))]), nil
+}
+
+func (r *fakeAttachmentRepository) ForgetOrphaned(checksum string) error {
+	r.orphaned = slices.DeleteFunc(r.orphaned, func(c string) bool { return c == checksum })
+	return nil
+}
+
+// delete removes an attachment as the cascade from its post does, noting
+// its checksum the way the database trigger does.
+func (r *fakeAttachmentRepository) delete(id uint) {
+	for i, a := range r.attachments {
+		if a.ID == id {
+			r.attachments = slices.Delete(r.attachments, i, i+1)
+			if !slices.Contains(r.orphaned, a.Checksum) {
+				r.orphaned = append(r.orphaned, a.Checksum)
+			}
+			return
+		}
+	}
+}
+
+type attachmentTestEnv struct {
+	questions   *fakeQuestionRepository
+	answers     *fakeAnswerRepository
+	attachments *fakeAttachmentRepository
+	dir         string
+	svc         *AttachmentService
+}
+
+func newAttachmentTestEnv(t *testing.T, cfg config.AttachmentsConfig) *attachmentTestEnv {
+	t.Helper()
+	authorID := attachmentTestAuthorID
+	env := &attachmentTestEnv{
+		questions:   &fakeQuestionRepository{},
+		answers:     &fakeAnswerRepository{},
+		attachments: &fakeAttachmentRepository{},
+		dir:         t.TempDir(),
+	}
+	require.NoError(t, env.questions.Create(&domain.Question{UserID: &authorID, Text: "Why does my build fail?"}))
+	require.NoError(t, env.answers.Create(&domain.Answer{QuestionID: 1, UserID: authorID, Text: "See the log."}))
+	env.svc = NewAttachmentService(env.attachments, env.questions, env.answers, blob.NewLocalStore(env.dir), cfg,
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+	return env
+}
+
+func (env *attachmentTestEnv) upload(contentType string, id uint, actorID, role, name string, content []byte) (*domain.Attachment, error) {
+	return env.svc.Upload(context.Background(), contentType, id, actorID, role,
+		domain.Upload{Filename: name, Content: bytes.NewReader(content)})
+}
+
+func pngImage(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))))
+	return buf.Bytes()
+}
+
+func TestAttachmentService_Upload(t *testing.T) {
+	env := newAttachmentTestEnv(t, config.Default().Attachments)
+
+	img, err := env.upload(domain.ContentQuestion, 1, attachmentTestAuthorID, domain.RoleUser, `C:\shots\screen.png`, pngImage(t, 40, 30))
+	require.NoError(t, err)
+	assert.Equal(t, "screen.png", img.Filename)
+	assert.Equal(t, "image/png", img.ContentType)
+	require.NotNil(t, img.Width)
+	assert.Equal(t, 40, *img.Width)
+	assert.Equal(t, 30, *img.Height)
+	assert.True(t, img.IsImage())
+
+	// The client's name says nothing about the type.
+	log, err := env.upload(domain.ContentAnswer, 1, attachmentTestAuthorID, domain.RoleUser, "../build.png", []byte("error: exit status 1\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "build.png", log.Filename)
+	assert.Equal(t, "text/plain; charset=utf-8", log.ContentType)
+	assert.Nil(t, log.Width)
+	assert.Equal(t, int64(21), log.Size)
+	require.NotNil(t, log.AnswerID)
+	assert.Equal(t, uint(1), *log.AnswerID)
+
+	_, err = env.upload(domain.ContentQuestion, 1, attachmentTestOtherID, domain.RoleUser, "x.txt", []byte("hello"))
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+	_, err = env.upload(domain.ContentQuestion, 1, attachmentTestOtherID, domain.RoleModerator, "x.txt", []byte("hello"))
+	assert.NoError(t, err)
+	_, err = env.upload(domain.ContentQuestion, 9, attachmentTestAuthorID, domain.RoleUser, "x.txt", []byte("hello"))
+	assert.ErrorIs(t, err, domain.ErrQuestionNotFound)
+}
+
+func TestAttachmentService_UploadLimits(t *testing.T) {
+	cfg := config.Default().Attachments
+	cfg.MaxSize = 1024
+	cfg.MaxPerPost = 2
+	cfg.MaxImageWidth = 100
+	env := newAttachmentTestEnv(t, cfg)
+
+	_, err := env.upload(domain.ContentQuestion, 1, attachmentTestAuthorID, domain.RoleUser, "big.txt", bytes.Repeat([]byte("a"), 1025))
+	assert.ErrorIs(t, err, domain.ErrFileTooLarge)
+
+	_, err = env.upload(domain.ContentQuestion, 1, attachmentTestAuthorID, domain.RoleUser, "page.html", []byte("<html><body>hi</body></html>"))
+	assert.ErrorIs(t, err, domain.ErrUnsupportedFileType)
+
+	_, err = env.upload(domain.ContentQuestion, 1, attachmentTestAuthorID, domain.RoleUser, "broken.png", []byte("\x89PNG\r\n\x1a\nnot really"))
+	assert.ErrorIs(t, err, domain.ErrUnsupportedFileType)
+
+	_, err = env.upload(domain.ContentQuestion, 1, attachmentTestAuthorID, domain.RoleUser, "wide.png", pngImage(t, 101, 10))
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+
+	_, err = env.upload(domain.ContentQuestion, 1, attachmentTestAuthorID, domain.RoleUser, "empty.txt", nil)
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+
+	for i := 0; i < 2; i++ {
+		_, err = env.upload(domain.ContentQuestion, 1, attachmentTestAuthorID, domain.RoleUser, "ok.txt", []byte("fine"))
+		require.NoError(t, err)
+	}
+	_, err = env.upload(domain.ContentQuestion, 1, attachmentTestAuthorID, domain.RoleUser, "third.txt", []byte("fine"))
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+}
+
+func TestAttachmentService_DeduplicatesContent(t *testing.T) {
+	env := newAttachmentTestEnv(t, config.Default().Attachments)
+
+	first, err := env.upload(domain.ContentQuestion, 1, attachmentTestAuthorID, domain.RoleUser, "a.txt", []byte("same content"))
+	require.NoError(t, err)
+	second, err := env.upload(domain.ContentAnswer, 1, attachmentTestAuthorID, domain.RoleUser, "b.txt", []byte("same content"))
+	require.NoError(t, err)
+
+	assert.NotEqual(t, first.ID, second.ID)
+	assert.Equal(t, first.Checksum, second.Checksum)
+	blobs, err := filepath.Glob(filepath.Join(env.dir, "sha256", "*", "*"))
+	require.NoError(t, err)
+	assert.Len(t, blobs, 1)
+	assert.Equal(t, filepath.Join(env.dir, "sha256", first.Checksum[:2], first.Checksum), blobs[0])
+}
+
+func (env *attachmentTestEnv) blobs(t *testing.T) []string {
+	t.Helper()
+	blobs, err := filepath.Glob(filepath.Join(env.dir, "sha256", "*", "*"))
+	require.NoError(t, err)
+	return blobs
+}
+
+func TestAttachmentService_CleanupBlobs(t *testing.T) {
+	env := newAttachmentTestEnv(t, config.Default().Attachments)
+	ctx := context.Background()
+
+	first, err := env.upload(domain.ContentQuestion, 1, attachmentTestAuthorID, domain.RoleUser, "a.txt", []byte("same content"))
+	require.NoError(t, err)
+	second, err := env.upload(domain.ContentAnswer, 1, attachmentTestAuthorID, domain.RoleUser, "b.txt", []byte("same content"))
+	require.NoError(t, err)
+	other, err := env.upload(domain.ContentAnswer, 1, attachmentTestAuthorID, domain.RoleUser, "c.txt", []byte("other content"))
+	require.NoError(t, err)
+	require.Len(t, env.blobs(t), 2)
+
+	// The answer's attachments go; the question still refers to the first
+	// blob.
+	env.attachments.delete(second.ID)
+	env.attachments.delete(other.ID)
+	deleted, err := env.svc.CleanupBlobs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	assert.Equal(t, []string{filepath.Join(env.dir, "sha256", first.Checksum[:2], first.Checksum)}, env.blobs(t))
+	assert.Empty(t, env.attachments.orphaned)
+
+	env.attachments.delete(first.ID)
+	deleted, err = env.svc.CleanupBlobs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	assert.Empty(t, env.blobs(t))
+	_, _, err = env.svc.Open(ctx, first.ID, attachmentTestAuthorID, domain.RoleUser)
+	assert.ErrorIs(t, err, domain.ErrAttachmentNotFound)
+}
+
+func TestAttachmentService_CleanupBlobs_KeepsContentUploadedAgain(t *testing.T) {
+	env := newAttachmentTestEnv(t, config.Default().Attachments)
+
+	first, err := env.upload(domain.ContentQuestion, 1, attachmentTestAuthorID, domain.RoleUser, "a.txt", []byte("same content"))
+	require.NoError(t, err)
+	env.attachments.delete(first.ID)
+	_, err = env.upload(domain.ContentAnswer, 1, attachmentTestAuthorID, domain.RoleUser, "b.txt", []byte("same content"))
+	require.NoError(t, err)
+
+	deleted, err := env.svc.CleanupBlobs(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+	assert.Len(t, env.blobs(t), 1)
+}
+
+func TestAttachmentService_Upload_DeletesBlobWhenCreateFails(t *testing.T) {
+	env := newAttachmentTestEnv(t, config.Default().Attachments)
+	_, err := env.upload(domain.ContentQuestion, 1, attachmentTestAuthorID, domain.RoleUser, "a.txt", []byte("kept"))
+	require.NoError(t, err)
+
+	env.attachments.failCreate = errors.New("connection reset")
+	_, err = env.upload(domain.ContentAnswer, 1, attachmentTestAuthorID, domain.RoleUser, "b.txt", []byte("dropped"))
+	require.Error(t, err)
+	// Content that an earlier attachment already refers to stays.
+	_, err = env.upload(domain.ContentAnswer, 1, attachmentTestAuthorID, domain.RoleUser, "c.txt", []byte("kept"))
+	require.Error(t, err)
+
+	assert.Len(t, env.blobs(t), 1)
+}
+
+func TestAttachmentService_OpenHidesContentOfHiddenPosts(t *testing.T) {
+	env := newAttachmentTestEnv(t, config.Default().Attachments)
+	attachment, err := env.upload(domain.ContentAnswer, 1, attachmentTestAuthorID, domain.RoleUser, "log.txt", []byte("stack trace"))
+	require.NoError(t, err)
+
+	got, content, err := env.svc.Open(context.Background(), attachment.ID, attachmentTestOtherID, domain.RoleUser)
+	require.NoError(t, err)
+	data, err := io.ReadAll(content)
+	require.NoError(t, err)
+	require.NoError(t, content.Close())
+	assert.Equal(t, "stack trace", string(data))
+	assert.Equal(t, "log.txt", got.Filename)
+
+	hiddenAt := time.Now()
+	env.questions.questions[0].HiddenAt = &hiddenAt
+	_, _, err = env.svc.Open(context.Background(), attachment.ID, attachmentTestOtherID, domain.RoleUser)
+	assert.ErrorIs(t, err, domain.ErrAttachmentNotFound)
+
+	for _, actor := range []struct{ id, role string }{
+		{attachmentTestAuthorID, domain.RoleUser},
+		{attachmentTestOtherID, domain.RoleModerator},
+	} {
+		_, content, err := env.svc.Open(context.Background(), attachment.ID, actor.id, actor.role)
+		require.NoError(t, err, actor.role)
+		content.Close()
+	}
+
+	_, _, err = env.svc.Open(context.Background(), 99, attachmentTestAuthorID, domain.RoleUser)
+	assert.ErrorIs(t, err, domain.ErrAttachmentNotFound)
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	assert.Equal(t, "report.pdf", sanitizeFilename("/etc/report.pdf"))
+	assert.Equal(t, "a b.txt", sanitizeFilename(" a\x00 b.txt\n"))
+	assert.Equal(t, "file", sanitizeFilename(".."))
+	assert.Equal(t, "file", sanitizeFilename(""))
+
+	long := sanitizeFilename(strings.Repeat("я", 200))
+	assert.LessOrEqual(t, len(long), maxFilenameLength)
+	assert.True(t, strings.HasPrefix(strings.Repeat("я", 200), long))
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 200
+)
+
+// Auditor records security- and moderation-relevant actions.
+type Auditor interface {
+	Record(meta domain.RequestMeta, action, targetType, targetID string, before, after interface{})
+}
+
+// NopAuditor is an Auditor that records nothing.
+type NopAuditor struct{}
+
+func (NopAuditor) Record(domain.RequestMeta, string, string, string, interface{}, interface{}) {}
+
+// AuditService is the Auditor that appends to the audit log.
+type AuditService struct {
+	repo   repository.AuditRepository
+	logger *slog.Logger
+}
+
+func NewAuditService(repo repository.AuditRepository, logger *slog.Logger) *AuditService {
+	return &AuditService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Record appends an entry. before and after are snapshots of the target and
+// may be nil. Failures are logged rather than returned: the action itself
+// has already happened and must not be reported as failed.
+func (s *AuditService) Record(meta domain.RequestMeta, action, targetType, targetID string, before, after interface{}) {
+	entry := &domain.AuditEntry{
+		ActorType:  domain.ActorAnonymous,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		IP:         meta.IP,
+		UserAgent:  truncate(meta.UserAgent, 512),
+		RequestID:  meta.RequestID,
+	}
+	switch {
+	case meta.System:
+		entry.ActorType = domain.ActorSystem
+	case meta.ActorID != "":
+		entry.ActorType = domain.ActorUser
+		actorID := meta.ActorID
+		entry.ActorID = &actorID
+	}
+
+	var err error
+	if entry.Before, err = snapshot(before); err == nil {
+		entry.After, err = snapshot(after)
+	}
+	if err == nil {
+		err = s.repo.Append(entry)
+	}
+	if err != nil {
+		s.logger.Error("failed to record audit entry",
+			slog.String("request_id", meta.RequestID),
+			slog.String("action", action),
+			slog.String("target_id", targetID),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+func (s *AuditService) List(filter domain.AuditFilter) (*domain.AuditPage, error) {
+	if filter.ActorID != "" {
+		if _, err := uuid.Parse(filter.ActorID); err != nil {
+			return nil, fmt.Errorf("%w: actor_id must be a valid UUID", domain.ErrInvalidInput)
+		}
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, fmt.Errorf("%w: from must be before to", domain.ErrInvalidInput)
+	}
+	if filter.Limit < 0 || filter.Limit > maxAuditPageSize {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", domain.ErrInvalidInput, maxAuditPageSize)
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultAuditPageSize
+	}
+
+	// One extra entry tells whether there is a next page.
+	pageSize := filter.Limit
+	filter.Limit++
+	entries, err := s.repo.List(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+
+	page := &domain.AuditPage{Entries: entries}
+	if len(entries) > pageSize {
+		page.Entries = entries[:pageSize]
+		next := page.Entries[pageSize-1].ID
+		page.NextCursor = &next
+	}
+	if page.Entries == nil {
+		page.Entries = []domain.AuditEntry{}
+	}
+	return page, nil
+}
+
+// questionSnapshot is what the audit log keeps of a deleted or moderated
+// question. The text and the author's profile are left out, as erasing the
+// author's account has to remove them; the text's length and hash still
+// tell whether two entries are about the same content.
+func questionSnapshot(q *domain.Question) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          q.ID,
+		"user_id":     q.UserID,
+		"status":      q.Status,
+		"hidden_at":   q.HiddenAt,
+		"created_at":  q.CreatedAt,
+		"answers":     len(q.Answers),
+		"text_length": utf8.RuneCountInString(q.Text),
+		"text_sha256": textHash(q.Text),
+	}
+}
+
+// answerSnapshot is the answer counterpart of questionSnapshot.
+func answerSnapshot(a *domain.Answer) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          a.ID,
+		"question_id": a.QuestionID,
+		"user_id":     a.UserID,
+		"hidden_at":   a.HiddenAt,
+		"created_at":  a.CreatedAt,
+		"text_length": utf8.RuneCountInString(a.Text),
+		"text_sha256": textHash(a.Text),
+	}
+}
+
+func textHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+func snapshot(v interface{}) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit snapshot: %w", err)
+	}
+	return data, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
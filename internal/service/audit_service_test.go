@@ -0,0 +1,115 @@
+package service
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"hitalent-test/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAuditRepository struct {
+	entries []domain.AuditEntry
+}
+
+func (r *fakeAuditRepository) Append(entry *domain.AuditEntry) error {
+	entry.ID = int64(len(r.entries) + 1)
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+func (r *fakeAuditRepository) List(filter domain.AuditFilter) ([]domain.AuditEntry, error) {
+	var entries []domain.AuditEntry
+	for i := len(r.entries) - 1; i >= 0 && len(entries) < filter.Limit; i-- {
+		e := r.entries[i]
+		if filter.Cursor > 0 && e.ID >= filter.Cursor {
+			continue
+		}
+		if filter.Action != "" && e.Action != filter.Action {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func newTestAuditService() (*AuditService, *fakeAuditRepository) {
+	repo := &fakeAuditRepository{}
+	return NewAuditService(repo, slog.New(slog.NewTextHandler(io.Discard, nil))), repo
+}
+
+func TestAuditService_Record_ActorTypes(t *testing.T) {
+	svc, repo := newTestAuditService()
+
+	svc.Record(domain.RequestMeta{ActorID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", IP: "192.0.2.1", RequestID: "req-1"},
+		domain.AuditRoleChanged, domain.AuditTargetUser, "target", map[string]string{"role": "user"}, map[string]string{"role": "admin"})
+	svc.Record(domain.RequestMeta{IP: "192.0.2.2"}, domain.AuditLoginFailed, domain.AuditTargetUser, "", nil, nil)
+	svc.Record(domain.SystemMeta("cli"), domain.AuditUserErased, domain.AuditTargetUser, "target", nil, nil)
+
+	require.Len(t, repo.entries, 3)
+
+	user := repo.entries[0]
+	assert.Equal(t, domain.ActorUser, user.ActorType)
+	require.NotNil(t, user.ActorID)
+	assert.Equal(t, "7c9e6679-7425-40de-944b-e07fc1f90ae7", *user.ActorID)
+	assert.Equal(t, "192.0.2.1", user.IP)
+	assert.Equal(t, "req-1", user.RequestID)
+	assert.JSONEq(t, `{"role":"user"}`, string(user.Before))
+	assert.JSONEq(t, `{"role":"admin"}`, string(user.After))
+
+	assert.Equal(t, domain.ActorAnonymous, repo.entries[1].ActorType)
+	assert.Nil(t, repo.entries[1].ActorID)
+	assert.Nil(t, repo.entries[1].Before)
+
+	assert.Equal(t, domain.ActorSystem, repo.entries[2].ActorType)
+	assert.Equal(t, "cli", repo.entries[2].UserAgent)
+}
+
+func TestAuditService_List_Pagination(t *testing.T) {
+	svc, _ := newTestAuditService()
+	for i := 0; i < 5; i++ {
+		svc.Record(domain.RequestMeta{}, domain.AuditLogin, domain.AuditTargetUser, "target", nil, nil)
+	}
+
+	page, err := svc.List(domain.AuditFilter{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 2)
+	assert.Equal(t, int64(5), page.Entries[0].ID)
+	require.NotNil(t, page.NextCursor)
+	assert.Equal(t, int64(4), *page.NextCursor)
+
+	page, err = svc.List(domain.AuditFilter{Limit: 2, Cursor: *page.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 2)
+	assert.Equal(t, int64(3), page.Entries[0].ID)
+
+	page, err = svc.List(domain.AuditFilter{Limit: 2, Cursor: *page.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 1)
+	assert.Nil(t, page.NextCursor)
+}
+
+func TestAuditService_List_InvalidFilter(t *testing.T) {
+	svc, _ := newTestAuditService()
+	from := time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)
+	to := from.Add(-time.Hour)
+
+	tests := []struct {
+		name   string
+		filter domain.AuditFilter
+	}{
+		{"actor is not a UUID", domain.AuditFilter{ActorID: "nope"}},
+		{"from after to", domain.AuditFilter{From: &from, To: &to}},
+		{"limit too large", domain.AuditFilter{Limit: maxAuditPageSize + 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.List(tt.filter)
+			assert.ErrorIs(t, err, domain.ErrInvalidInput)
+		})
+	}
+}
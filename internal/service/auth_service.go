@@ -1,11 +1,15 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"hitalent-test/internal/config"
 	"hitalent-test/internal/domain"
 	"hitalent-test/internal/repository"
 
@@ -13,31 +17,64 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// dummyPasswordHash is compared against when the email is unknown, so that
+// a failed login takes the same time whether or not the account exists.
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, err := bcrypt.GenerateFromPassword([]byte(uuid.New().String()), bcrypt.DefaultCost)
+	if err != nil {
+		panic(fmt.Sprintf("failed to generate dummy password hash: %v", err))
+	}
+	return hash
+})
+
 type AuthService struct {
 	userRepo      repository.UserRepository
 	tokenService  *TokenService
 	refreshTokens *RefreshTokenStore
+	throttle      *LoginThrottle
+	mfa           *MFAService
+	audit         Auditor
+	limits        *config.ValidationConfig
+	registration  atomic.Bool
 }
 
 func NewAuthService(
 	userRepo repository.UserRepository,
 	tokenService *TokenService,
 	refreshTokens *RefreshTokenStore,
+	throttle *LoginThrottle,
+	mfa *MFAService,
+	audit Auditor,
+	limits *config.ValidationConfig,
 ) *AuthService {
-	return &AuthService{
+	s := &AuthService{
 		userRepo:      userRepo,
 		tokenService:  tokenService,
 		refreshTokens: refreshTokens,
+		throttle:      throttle,
+		mfa:           mfa,
+		audit:         audit,
+		limits:        limits,
 	}
+	s.registration.Store(true)
+	return s
+}
+
+func (s *AuthService) SetRegistrationEnabled(enabled bool) {
+	s.registration.Store(enabled)
 }
 
-func (s *AuthService) Register(email, password string) (*domain.User, error) {
+func (s *AuthService) Register(email, password string, meta domain.RequestMeta) (*domain.User, error) {
+	if !s.registration.Load() {
+		return nil, fmt.Errorf("%w: registration is disabled", domain.ErrForbidden)
+	}
+
 	if err := validateEmail(email); err != nil {
 		return nil, fmt.Errorf("%w: invalid email format", domain.ErrInvalidInput)
 	}
 
-	if len(password) < 8 {
-		return nil, fmt.Errorf("%w: password must be at least 8 characters", domain.ErrInvalidInput)
+	if err := validatePassword(password, s.limits); err != nil {
+		return nil, err
 	}
 
 	_, err := s.userRepo.GetByEmail(email)
@@ -45,35 +82,113 @@ func (s *AuthService) Register(email, password string) (*domain.User, error) {
 		return nil, fmt.Errorf("%w: user with this email already exists", domain.ErrInvalidInput)
 	}
 
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hash, err := hashPassword(password)
 	if err != nil {
-		return nil, fmt.Errorf("failed to hash password: %w", err)
+		return nil, err
 	}
 
 	user := &domain.User{
 		ID:           uuid.New().String(),
 		Email:        strings.ToLower(email),
-		PasswordHash: string(hash),
+		PasswordHash: hash,
+		Role:         domain.RoleUser,
 	}
 
 	if err := s.userRepo.Create(user); err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
+	s.audit.Record(meta, domain.AuditRegister, domain.AuditTargetUser, user.ID, nil, userSnapshot(user))
+
 	return user, nil
 }
 
-func (s *AuthService) Login(email, password string) (*domain.AuthResponse, error) {
+// Login checks the password. Accounts with two-factor authentication get an
+// MFA challenge instead of tokens, to be completed with CompleteLogin.
+func (s *AuthService) Login(email, password string, meta domain.RequestMeta) (*domain.AuthResponse, *domain.MFAChallenge, error) {
+	if err := s.throttle.Check(email, meta.IP); err != nil {
+		return nil, nil, err
+	}
+
 	user, err := s.userRepo.GetByEmail(strings.ToLower(email))
-	if err != nil {
-		return nil, fmt.Errorf("%w: user not found", domain.ErrInvalidInput)
+	if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user == nil {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(password))
+		return nil, nil, s.loginFailed(email, meta, nil)
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return nil, fmt.Errorf("%w: invalid credentials", domain.ErrInvalidInput)
+		return nil, nil, s.loginFailed(email, meta, &user.ID)
+	}
+
+	return s.StartSession(user, meta)
+}
+
+// StartSession signs in a user whose first factor has been checked, by
+// password or by an identity provider. Accounts with two-factor
+// authentication get an MFA challenge instead of tokens.
+func (s *AuthService) StartSession(user *domain.User, meta domain.RequestMeta) (*domain.AuthResponse, *domain.MFAChallenge, error) {
+	// The failure counter is only reset once the second factor is verified,
+	// otherwise a known password would allow unlimited guesses of the code.
+	if user.TOTPEnabled {
+		ttl := s.mfa.ChallengeTTL()
+		token, err := s.tokenService.GenerateActionToken(user.ID, domain.TokenPurposeMFAChallenge, uuid.New().String(), ttl)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate mfa token: %w", err)
+		}
+		return nil, &domain.MFAChallenge{
+			MFARequired: true,
+			MFAToken:    token,
+			ExpiresIn:   int(ttl.Seconds()),
+		}, nil
+	}
+
+	authResp, err := s.completeLogin(user, meta)
+	return authResp, nil, err
+}
+
+// CompleteLogin finishes a login started by Login with a TOTP code or a
+// recovery code.
+func (s *AuthService) CompleteLogin(mfaToken, code string, meta domain.RequestMeta) (*domain.AuthResponse, error) {
+	claims, err := s.tokenService.VerifyActionToken(mfaToken, domain.TokenPurposeMFAChallenge)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid or expired mfa token", domain.ErrUnauthorized)
+	}
+
+	user, err := s.userRepo.GetByID(claims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if err := s.throttle.Check(user.Email, meta.IP); err != nil {
+		return nil, err
+	}
+
+	ok, err := s.mfa.Verify(user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		s.audit.Record(meta, domain.AuditLoginFailed, domain.AuditTargetUser, user.ID, nil,
+			map[string]string{"reason": "invalid two-factor code"})
+		if err := s.throttle.Failure(user.Email, meta.IP, &user.ID); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: invalid two-factor code", domain.ErrUnauthorized)
+	}
+
+	return s.completeLogin(user, meta)
+}
+
+func (s *AuthService) completeLogin(user *domain.User, meta domain.RequestMeta) (*domain.AuthResponse, error) {
+	if err := s.throttle.Success(user.Email); err != nil {
+		return nil, err
 	}
 
-	accessToken, err := s.tokenService.GenerateAccessToken(user.ID, user.Email)
+	accessToken, err := s.tokenService.GenerateAccessToken(user.ID, user.Email, user.Role)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate access token: %w", err)
 	}
@@ -83,11 +198,18 @@ func (s *AuthService) Login(email, password string) (*domain.AuthResponse, error
 		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
 
+	now := time.Now()
 	s.refreshTokens.Save(refreshToken, &RefreshTokenInfo{
 		UserID:    user.ID,
-		ExpiresAt: time.Now().Add(s.tokenService.cfg.RefreshTokenExpiry),
+		IssuedAt:  now,
+		ExpiresAt: now.Add(s.tokenService.cfg.RefreshTokenExpiry),
 	})
 
+	// The user has proven who they are, so they are the actor even though
+	// the request was not authenticated.
+	meta.ActorID = user.ID
+	s.audit.Record(meta, domain.AuditLogin, domain.AuditTargetUser, user.ID, nil, nil)
+
 	return &domain.AuthResponse{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
@@ -106,7 +228,7 @@ func (s *AuthService) RefreshAccessToken(refreshToken string) (string, error) {
 		return "", fmt.Errorf("failed to get user: %w", err)
 	}
 
-	accessToken, err := s.tokenService.GenerateAccessToken(user.ID, user.Email)
+	accessToken, err := s.tokenService.GenerateAccessToken(user.ID, user.Email, user.Role)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate access token: %w", err)
 	}
@@ -114,6 +236,58 @@ func (s *AuthService) RefreshAccessToken(refreshToken string) (string, error) {
 	return accessToken, nil
 }
 
+// Unlock lifts a login lockout of the given user on behalf of the actor.
+func (s *AuthService) Unlock(userID string, meta domain.RequestMeta) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := s.throttle.Unlock(user.Email, user.ID, meta.ActorID); err != nil {
+		return err
+	}
+	s.audit.Record(meta, domain.AuditUnlock, domain.AuditTargetUser, user.ID, nil, nil)
+	return nil
+}
+
+func (s *AuthService) SetRole(email, role string, meta domain.RequestMeta) (*domain.User, error) {
+	if !domain.IsValidRole(role) {
+		return nil, fmt.Errorf("%w: unknown role %q", domain.ErrInvalidInput, role)
+	}
+
+	user, err := s.userRepo.GetByEmail(strings.ToLower(email))
+	if err != nil {
+		return nil, err
+	}
+
+	before := userSnapshot(user)
+	user.Role = role
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	s.audit.Record(meta, domain.AuditRoleChanged, domain.AuditTargetUser, user.ID, before, userSnapshot(user))
+
+	return user, nil
+}
+
+// loginFailed records the failure and returns the error shown to the client,
+// which never reveals whether the email is registered. The audit entry names
+// the user when there is one but never the email tried: entries cannot be
+// changed, so they must hold nothing that erasing an account has to remove.
+func (s *AuthService) loginFailed(email string, meta domain.RequestMeta, userID *string) error {
+	targetID := ""
+	if userID != nil {
+		targetID = *userID
+	}
+	s.audit.Record(meta, domain.AuditLoginFailed, domain.AuditTargetUser, targetID, nil,
+		map[string]string{"reason": "invalid email or password"})
+
+	if err := s.throttle.Failure(email, meta.IP, userID); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: invalid email or password", domain.ErrUnauthorized)
+}
+
 func validateEmail(email string) error {
 	const emailRegex = `^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`
 	re := regexp.MustCompile(emailRegex)
@@ -122,3 +296,28 @@ func validateEmail(email string) error {
 	}
 	return nil
 }
+
+func validatePassword(password string, limits *config.ValidationConfig) error {
+	if len(password) < limits.PasswordMinLength {
+		return fmt.Errorf("%w: password must be at least %d characters", domain.ErrInvalidInput, limits.PasswordMinLength)
+	}
+	return nil
+}
+
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// userSnapshot is what the audit log keeps of a user: no secrets and nothing
+// identifying beyond the ID the entry is filed under, only the fields whose
+// changes are audited.
+func userSnapshot(user *domain.User) map[string]interface{} {
+	return map[string]interface{}{
+		"role":           user.Role,
+		"email_verified": user.EmailVerified,
+	}
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"hitalent-test/internal/contentfilter"
+	"hitalent-test/internal/domain"
+)
+
+// ContentChecker decides whether new posts are published, held for review
+// or rejected. See ContentFilterService for the contract of its methods.
+type ContentChecker interface {
+	Check(kind, userID, text string) (hiddenAt *time.Time, reason string, err error)
+	Hold(kind string, id uint, reason string) error
+}
+
+// NopContentChecker is a ContentChecker that lets everything through.
+type NopContentChecker struct{}
+
+func (NopContentChecker) Check(string, string, string) (*time.Time, string, error) {
+	return nil, "", nil
+}
+
+func (NopContentChecker) Hold(string, uint, string) error { return nil }
+
+// ContentFilterService runs the content filter on new questions and answers
+// and sends the posts it holds to the moderation queue.
+type ContentFilterService struct {
+	pipeline   *contentfilter.Pipeline
+	moderation *ModerationService
+	logger     *slog.Logger
+	now        func() time.Time
+}
+
+func NewContentFilterService(pipeline *contentfilter.Pipeline, moderation *ModerationService, logger *slog.Logger) *ContentFilterService {
+	return &ContentFilterService{
+		pipeline:   pipeline,
+		moderation: moderation,
+		logger:     logger,
+		now:        time.Now,
+	}
+}
+
+// Check filters a post before it is stored. Rejected posts give
+// ErrInvalidInput with the reason. For held posts it returns a non-nil
+// hiddenAt to store the post with, and the post must then be passed to
+// Hold.
+func (s *ContentFilterService) Check(kind, userID, text string) (hiddenAt *time.Time, reason string, err error) {
+	now := s.now()
+	result, err := s.pipeline.Check(contentfilter.Content{Kind: kind, UserID: userID, Text: text, At: now})
+	if err != nil {
+		return nil, "", err
+	}
+	if result.Verdict == contentfilter.Allow {
+		return nil, "", nil
+	}
+
+	s.logger.Info("content filtered",
+		slog.String("kind", kind),
+		slog.String("user_id", userID),
+		slog.String("verdict", result.Verdict.String()),
+		slog.String("filter", result.Filter),
+		slog.String("reason", result.Reason),
+	)
+	if result.Verdict == contentfilter.Reject {
+		return nil, "", fmt.Errorf("%w: %s", domain.ErrInvalidInput, result.Reason)
+	}
+	return &now, result.Filter + ": " + result.Reason, nil
+}
+
+// Hold queues a post that Check held for moderation.
+func (s *ContentFilterService) Hold(kind string, id uint, reason string) error {
+	return s.moderation.Hold(kind, id, reason)
+}
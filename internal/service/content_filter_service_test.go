@@ -0,0 +1,91 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/contentfilter"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/mailer/mailertest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestContentFilter(t *testing.T, questions *fakeQuestionRepository, flags *fakeFlagRepository) *ContentFilterService {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := config.Default()
+	cfg.Filter.BannedWords = []string{"forbidden"}
+	cfg.Filter.HoldWords = []string{"suspicious"}
+
+	moderation := NewModerationService(flags, questions, &fakeAnswerRepository{}, newFakeUserRepository(),
+		mailertest.NewRecorder(), NopAuditor{}, NopNotifier{}, &cfg.Moderation, logger)
+	return NewContentFilterService(contentfilter.New(cfg.Filter, questionHistory{questions}), moderation, logger)
+}
+
+// questionHistory gives the duplicate check the stored questions.
+type questionHistory struct {
+	questions *fakeQuestionRepository
+}
+
+func (h questionHistory) RecentTexts(userID string, since time.Time) ([]string, error) {
+	var texts []string
+	for _, q := range h.questions.questions {
+		if q.UserID != nil && *q.UserID == userID && q.CreatedAt.After(since) {
+			texts = append(texts, q.Text)
+		}
+	}
+	return texts, nil
+}
+
+func TestQuestionService_Create_ContentFilter(t *testing.T) {
+	questions := &fakeQuestionRepository{}
+	flags := &fakeFlagRepository{}
+	svc := NewQuestionService(questions, newFakeUserRepository(), PostDeps{Filter: newTestContentFilter(t, questions, flags)},
+		&config.Default().Validation)
+
+	_, err := svc.Create(&domain.CreateQuestionRequest{Text: "Is this forbidden to ask?"})
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	assert.Empty(t, questions.questions)
+
+	question, err := svc.Create(&domain.CreateQuestionRequest{Text: "A suspicious question about Paris"})
+	require.NoError(t, err)
+	assert.True(t, question.PendingReview)
+
+	_, err = svc.GetByID(question.ID)
+	assert.ErrorIs(t, err, domain.ErrQuestionNotFound, "held questions are hidden")
+
+	require.Len(t, flags.flags, 1)
+	assert.Equal(t, domain.FlagReasonFilter, flags.flags[0].Reason)
+	assert.Nil(t, flags.flags[0].ReporterID)
+	assert.Equal(t, question.ID, flags.flags[0].TargetID)
+
+	question, err = svc.Create(&domain.CreateQuestionRequest{Text: "What is the capital of France?"})
+	require.NoError(t, err)
+	assert.False(t, question.PendingReview)
+}
+
+func TestQuestionService_Create_DuplicateCountsStoredPostsOnly(t *testing.T) {
+	questions := &fakeQuestionRepository{}
+	svc := NewQuestionService(questions, newFakeUserRepository(), PostDeps{Filter: newTestContentFilter(t, questions, &fakeFlagRepository{})},
+		&config.Default().Validation)
+	req := &domain.CreateQuestionRequest{UserID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", Text: "What is the capital of France?"}
+
+	questions.failCreate = errors.New("connection reset")
+	_, err := svc.Create(req)
+	require.Error(t, err)
+
+	// The failed attempt was never stored, so the retry is not a duplicate.
+	questions.failCreate = nil
+	_, err = svc.Create(req)
+	require.NoError(t, err)
+
+	_, err = svc.Create(req)
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	assert.Len(t, questions.questions, 1)
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/digest"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/mailer"
+	"hitalent-test/internal/repository"
+)
+
+// DigestService mails daily and weekly digests of the activity on watched
+// questions. SendDue is meant to run periodically.
+type DigestService struct {
+	watches  repository.WatchRepository
+	userRepo repository.UserRepository
+	mailer   mailer.Mailer
+	mailCfg  *config.MailConfig
+	cfg      atomic.Pointer[config.DigestConfig]
+	logger   *slog.Logger
+	now      func() time.Time
+}
+
+func NewDigestService(
+	watches repository.WatchRepository,
+	userRepo repository.UserRepository,
+	mailer mailer.Mailer,
+	mailCfg *config.MailConfig,
+	cfg config.DigestConfig,
+	logger *slog.Logger,
+) *DigestService {
+	s := &DigestService{
+		watches:  watches,
+		userRepo: userRepo,
+		mailer:   mailer,
+		mailCfg:  mailCfg,
+		logger:   logger,
+		now:      time.Now,
+	}
+	s.SetConfig(cfg)
+	return s
+}
+
+func (s *DigestService) SetConfig(cfg config.DigestConfig) {
+	s.cfg.Store(&cfg)
+}
+
+// SendDue mails the digests that are due by now in each subscriber's time
+// zone and returns how many were sent. Subscribers without activity get no
+// email, but their period still ends. A digest that fails is retried on the
+// next run.
+func (s *DigestService) SendDue() (int, error) {
+	cfg := s.cfg.Load()
+	if !cfg.Enabled {
+		return 0, nil
+	}
+	schedule := digest.Schedule{Hour: cfg.Hour, Weekday: cfg.WeekdayValue()}
+
+	users, err := s.watches.Subscribers()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list digest subscribers: %w", err)
+	}
+
+	now := s.now()
+	sent := 0
+	for i := range users {
+		user := &users[i]
+		due, since := schedule.Due(user.Settings.Frequency(), user.Settings.Location(), user.DigestSentAt, now)
+		if !due {
+			continue
+		}
+
+		delivered, err := s.send(user, since, now)
+		if err == nil {
+			err = s.watches.MarkDigestSent(user.ID, now)
+		}
+		if err != nil {
+			s.logger.Error("failed to send digest",
+				slog.String("user_id", user.ID),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+		if delivered {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// send mails the user's digest for the period from since to now, if there
+// was any activity.
+func (s *DigestService) send(user *domain.User, since, now time.Time) (bool, error) {
+	questions, err := s.watches.Activity(user.ID, since)
+	if err != nil {
+		return false, fmt.Errorf("failed to collect activity: %w", err)
+	}
+	if len(questions) == 0 {
+		return false, nil
+	}
+
+	var ids []string
+	for _, q := range questions {
+		for _, a := range q.Answers {
+			ids = append(ids, a.UserID)
+		}
+	}
+	names, err := authorNames(s.userRepo, ids)
+	if err != nil {
+		return false, err
+	}
+
+	loc := user.Settings.Location()
+	d := &digest.Digest{
+		Name:        user.PublicName(),
+		Frequency:   user.Settings.Frequency(),
+		Since:       since.In(loc),
+		Until:       now.In(loc),
+		SettingsURL: settingsURL(s.mailCfg),
+	}
+	for i := range questions {
+		q := &questions[i]
+		question := digest.NewQuestion(q, s.mailCfg.LinkBaseURL, names, loc)
+		question.StatusChanged = q.StatusChangedAt != nil && q.StatusChangedAt.After(since) &&
+			(q.StatusChangedBy == nil || *q.StatusChangedBy != user.ID)
+		d.Questions = append(d.Questions, question)
+	}
+
+	msg, err := digest.RenderDigest(d)
+	if err != nil {
+		return false, err
+	}
+	msg.To = user.Email
+	if err := s.mailer.Send(msg); err != nil {
+		return false, fmt.Errorf("failed to mail digest: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/mailer"
+	"hitalent-test/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type EmailVerificationService struct {
+	userRepo     repository.UserRepository
+	tokenRepo    repository.UserTokenRepository
+	tokenService *TokenService
+	mailer       mailer.Mailer
+	mailCfg      *config.MailConfig
+	ttl          time.Duration
+}
+
+func NewEmailVerificationService(
+	userRepo repository.UserRepository,
+	tokenRepo repository.UserTokenRepository,
+	tokenService *TokenService,
+	mailer mailer.Mailer,
+	mailCfg *config.MailConfig,
+	tokensCfg *config.TokensConfig,
+) *EmailVerificationService {
+	return &EmailVerificationService{
+		userRepo:     userRepo,
+		tokenRepo:    tokenRepo,
+		tokenService: tokenService,
+		mailer:       mailer,
+		mailCfg:      mailCfg,
+		ttl:          tokensCfg.EmailVerificationTTL,
+	}
+}
+
+// Send issues a new verification token for user, invalidating earlier ones,
+// and mails the verification link.
+func (s *EmailVerificationService) Send(user *domain.User) error {
+	now := time.Now()
+	if err := s.tokenRepo.InvalidateForUser(user.ID, domain.TokenPurposeEmailVerification, now); err != nil {
+		return fmt.Errorf("failed to invalidate verification tokens: %w", err)
+	}
+
+	tokenID := uuid.New().String()
+	if err := s.tokenRepo.Create(&domain.UserToken{
+		UserID:    user.ID,
+		Purpose:   domain.TokenPurposeEmailVerification,
+		TokenHash: hashToken(tokenID),
+		ExpiresAt: now.Add(s.ttl),
+	}); err != nil {
+		return fmt.Errorf("failed to store verification token: %w", err)
+	}
+
+	token, err := s.tokenService.GenerateActionToken(user.ID, domain.TokenPurposeEmailVerification, tokenID, s.ttl)
+	if err != nil {
+		return fmt.Errorf("failed to generate verification token: %w", err)
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", strings.TrimRight(s.mailCfg.LinkBaseURL, "/"), url.QueryEscape(token))
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Text: fmt.Sprintf("Welcome!\n\nConfirm your email address by opening the link below:\n\n%s\n\n"+
+			"Or send this token to POST /auth/verify:\n\n%s\n\nThe link expires in %s.\n", link, token, s.ttl),
+	})
+}
+
+// Resend mails a fresh link. Unknown and already verified addresses are
+// silently ignored so the endpoint does not reveal registered emails.
+func (s *EmailVerificationService) Resend(email string) error {
+	user, err := s.userRepo.GetByEmail(strings.ToLower(strings.TrimSpace(email)))
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user.EmailVerified {
+		return nil
+	}
+
+	return s.Send(user)
+}
+
+// Verify redeems a verification token and marks the user's email verified.
+func (s *EmailVerificationService) Verify(token string) (*domain.User, error) {
+	invalid := fmt.Errorf("%w: invalid or expired verification token", domain.ErrInvalidInput)
+
+	claims, err := s.tokenService.VerifyActionToken(token, domain.TokenPurposeEmailVerification)
+	if err != nil {
+		return nil, invalid
+	}
+
+	stored, err := s.tokenRepo.GetByHash(domain.TokenPurposeEmailVerification, hashToken(claims.ID))
+	if errors.Is(err, domain.ErrInvalidInput) {
+		return nil, invalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get verification token: %w", err)
+	}
+
+	now := time.Now()
+	if stored.UserID != claims.Subject || stored.UsedAt != nil || stored.ExpiresAt.Before(now) {
+		return nil, invalid
+	}
+
+	ok, err := s.tokenRepo.MarkUsed(stored.ID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume verification token: %w", err)
+	}
+	if !ok {
+		return nil, invalid
+	}
+
+	user, err := s.userRepo.GetByID(stored.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.EmailVerified {
+		user.EmailVerified = true
+		user.EmailVerifiedAt = &now
+		if err := s.userRepo.Update(user); err != nil {
+			return nil, fmt.Errorf("failed to update user: %w", err)
+		}
+	}
+
+	return user, nil
+}
+
+func (s *EmailVerificationService) Cleanup() error {
+	return s.tokenRepo.DeleteExpired(time.Now())
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/digest"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/feed"
+	"hitalent-test/internal/markdown"
+	"hitalent-test/internal/repository"
+)
+
+const (
+	// feedSize is the number of entries in a feed.
+	feedSize = 50
+	// feedTitleLength is the length of the excerpt that entries are titled
+	// with, since posts have no titles of their own.
+	feedTitleLength = 80
+)
+
+// FeedService builds the Atom and RSS feeds of new questions and of the
+// answers to a question. Links point to the frontend.
+type FeedService struct {
+	questionRepo repository.QuestionRepository
+	userRepo     repository.UserRepository
+	mailCfg      *config.MailConfig
+}
+
+func NewFeedService(
+	questionRepo repository.QuestionRepository,
+	userRepo repository.UserRepository,
+	mailCfg *config.MailConfig,
+) *FeedService {
+	return &FeedService{
+		questionRepo: questionRepo,
+		userRepo:     userRepo,
+		mailCfg:      mailCfg,
+	}
+}
+
+// Questions returns the feed of the newest visible questions.
+func (s *FeedService) Questions() (*feed.Feed, error) {
+	questions, err := s.questionRepo.Latest(feedSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get questions: %w", err)
+	}
+
+	ids := make([]string, 0, len(questions))
+	for _, q := range questions {
+		if q.UserID != nil {
+			ids = append(ids, *q.UserID)
+		}
+	}
+	authors, err := authorSummaries(s.userRepo, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &feed.Feed{Title: "Новые вопросы", ID: s.link("/questions")}
+	for _, q := range questions {
+		link := s.questionLink(q.ID)
+		entry := feed.Entry{
+			ID:        link,
+			Link:      link,
+			Published: q.CreatedAt,
+			Updated:   q.CreatedAt,
+		}
+		if q.StatusChangedAt != nil && q.StatusChangedAt.After(entry.Updated) {
+			entry.Updated = *q.StatusChangedAt
+		}
+		if q.UserID != nil {
+			entry.Author = authorName(authors[*q.UserID])
+		}
+		setContent(&entry, q.Text, q.TextHTML)
+		f.Entries = append(f.Entries, entry)
+		f.Updated = latest(f.Updated, entry.Updated)
+	}
+	return f, nil
+}
+
+// Answers returns the feed of the newest visible answers to a question.
+// Hidden questions have no feed.
+func (s *FeedService) Answers(questionID uint) (*feed.Feed, error) {
+	question, err := s.questionRepo.GetByID(questionID)
+	if err != nil {
+		return nil, err
+	}
+	if question.HiddenAt != nil {
+		return nil, domain.ErrQuestionNotFound
+	}
+
+	answers := slices.DeleteFunc(question.Answers, func(a domain.Answer) bool { return a.HiddenAt != nil })
+	slices.SortFunc(answers, func(a, b domain.Answer) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return int(b.ID) - int(a.ID)
+	})
+	answers = answers[:min(len(answers), feedSize)]
+
+	ids := make([]string, 0, len(answers))
+	for _, a := range answers {
+		ids = append(ids, a.UserID)
+	}
+	authors, err := authorSummaries(s.userRepo, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	link := s.questionLink(question.ID)
+	f := &feed.Feed{
+		Title:   "Ответы: " + digest.Excerpt(plainText(question.Text), feedTitleLength),
+		ID:      link,
+		Updated: question.CreatedAt,
+	}
+	for _, a := range answers {
+		entry := feed.Entry{
+			ID:        fmt.Sprintf("%s#answer-%d", link, a.ID),
+			Link:      fmt.Sprintf("%s#answer-%d", link, a.ID),
+			Author:    authorName(authors[a.UserID]),
+			Published: a.CreatedAt,
+			Updated:   a.CreatedAt,
+		}
+		setContent(&entry, a.Text, a.TextHTML)
+		f.Entries = append(f.Entries, entry)
+		f.Updated = latest(f.Updated, entry.Updated)
+	}
+	return f, nil
+}
+
+func (s *FeedService) link(path string) string {
+	return strings.TrimRight(s.mailCfg.LinkBaseURL, "/") + path
+}
+
+func (s *FeedService) questionLink(id uint) string {
+	return s.link(fmt.Sprintf("/questions/%d", id))
+}
+
+// setContent fills in the title and HTML of an entry from the text of a
+// post, rendering posts stored before rendering was introduced.
+func setContent(entry *feed.Entry, source, rendered string) {
+	if rendered == "" {
+		rendered = markdown.Render(source, nil)
+	}
+	entry.HTML = rendered
+	entry.Title = digest.Excerpt(markdown.PlainText(rendered), feedTitleLength)
+}
+
+// authorName is the name an entry is signed with; authors that are gone
+// leave it empty.
+func authorName(author *domain.UserSummary) string {
+	if author == nil {
+		return ""
+	}
+	return author.DisplayName
+}
+
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const feedTestAuthorID = "00000000-0000-0000-0000-00000000000a"
+
+func newFeedTestService(questions *fakeQuestionRepository) *FeedService {
+	users := newFakeUserRepository(&domain.User{ID: feedTestAuthorID, DisplayName: "Alice"})
+	return NewFeedService(questions, users, &config.MailConfig{LinkBaseURL: "https://qa.example/"})
+}
+
+func TestFeedService_Questions(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	closed := created.Add(2 * time.Hour)
+	author := feedTestAuthorID
+	questions := &fakeQuestionRepository{}
+	require.NoError(t, questions.Create(&domain.Question{UserID: &author, Text: "Why *this*?", CreatedAt: created}))
+	require.NoError(t, questions.Create(&domain.Question{Text: "Hidden", CreatedAt: created, HiddenAt: &created}))
+	require.NoError(t, questions.Create(&domain.Question{
+		Text:           "How?",
+		TextHTML:       "<p>How?</p>\n",
+		CreatedAt:      created.Add(time.Hour),
+		QuestionStatus: domain.QuestionStatus{Status: domain.QuestionClosed, StatusChangedAt: &closed},
+	}))
+
+	f, err := newFeedTestService(questions).Questions()
+	require.NoError(t, err)
+	assert.Equal(t, "https://qa.example/questions", f.ID)
+	assert.Equal(t, closed, f.Updated)
+	require.Len(t, f.Entries, 2)
+
+	assert.Equal(t, "https://qa.example/questions/3", f.Entries[0].Link)
+	assert.Equal(t, "How?", f.Entries[0].Title)
+	assert.Equal(t, "<p>How?</p>\n", f.Entries[0].HTML)
+	assert.Empty(t, f.Entries[0].Author)
+	assert.Equal(t, closed, f.Entries[0].Updated)
+
+	assert.Equal(t, "Why this?", f.Entries[1].Title)
+	assert.Equal(t, "<p>Why <em>this</em>?</p>\n", f.Entries[1].HTML)
+	assert.Equal(t, "Alice", f.Entries[1].Author)
+}
+
+func TestFeedService_Answers(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	questions := &fakeQuestionRepository{}
+	require.NoError(t, questions.Create(&domain.Question{
+		Text:      "Why?",
+		CreatedAt: created,
+		Answers: []domain.Answer{
+			{ID: 1, UserID: feedTestAuthorID, Text: "First", CreatedAt: created.Add(time.Hour)},
+			{ID: 2, UserID: feedTestAuthorID, Text: "Hidden", CreatedAt: created.Add(3 * time.Hour), HiddenAt: &created},
+			{ID: 3, UserID: "gone", Text: "Second", CreatedAt: created.Add(2 * time.Hour)},
+		},
+	}))
+	svc := newFeedTestService(questions)
+
+	f, err := svc.Answers(1)
+	require.NoError(t, err)
+	assert.Equal(t, "https://qa.example/questions/1", f.ID)
+	assert.Equal(t, created.Add(2*time.Hour), f.Updated)
+	require.Len(t, f.Entries, 2)
+	assert.Equal(t, "https://qa.example/questions/1#answer-3", f.Entries[0].ID)
+	assert.Empty(t, f.Entries[0].Author)
+	assert.Equal(t, "First", f.Entries[1].Title)
+	assert.Equal(t, "Alice", f.Entries[1].Author)
+
+	_, err = svc.Answers(2)
+	assert.ErrorIs(t, err, domain.ErrQuestionNotFound)
+}
+
+func TestFeedService_Answers_HiddenQuestion(t *testing.T) {
+	hidden := time.Now()
+	questions := &fakeQuestionRepository{}
+	require.NoError(t, questions.Create(&domain.Question{Text: "Why?", HiddenAt: &hidden}))
+
+	_, err := newFeedTestService(questions).Answers(1)
+	assert.ErrorIs(t, err, domain.ErrQuestionNotFound)
+}
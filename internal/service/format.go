@@ -0,0 +1,75 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/markdown"
+)
+
+// plainText is the text of Markdown source as a reader sees it, which is
+// what length limits apply to.
+func plainText(source string) string {
+	return markdown.PlainText(markdown.Render(strings.TrimSpace(source), nil))
+}
+
+// checkFormat validates a requested text format; empty means Markdown.
+func checkFormat(format string) (string, error) {
+	if format == "" {
+		return domain.FormatMarkdown, nil
+	}
+	if !slices.Contains(domain.TextFormats, format) {
+		return "", fmt.Errorf("%w: format must be one of %s", domain.ErrInvalidInput, strings.Join(domain.TextFormats, ", "))
+	}
+	return format, nil
+}
+
+// formatText returns a post's text in the given format. Posts stored before
+// rendering was introduced have no HTML and are rendered on the fly.
+func formatText(source, rendered, format string) string {
+	if format == domain.FormatMarkdown {
+		return source
+	}
+	if rendered == "" {
+		rendered = markdown.Render(source, nil)
+	}
+	if format == domain.FormatPlain {
+		return markdown.PlainText(rendered)
+	}
+	return rendered
+}
+
+// Format replaces the text of the questions, their answers and related
+// questions with the requested format.
+func (s *QuestionService) Format(format string, questions ...*domain.Question) error {
+	format, err := checkFormat(format)
+	if err != nil {
+		return err
+	}
+	for _, q := range questions {
+		q.Text = formatText(q.Text, q.TextHTML, format)
+		for i := range q.Answers {
+			formatAnswer(&q.Answers[i], format)
+		}
+		for i := range q.Related {
+			q.Related[i].Text = formatText(q.Related[i].Text, "", format)
+		}
+	}
+	return nil
+}
+
+// Format replaces the text of the answer with the requested format.
+func (s *AnswerService) Format(format string, answer *domain.Answer) error {
+	format, err := checkFormat(format)
+	if err != nil {
+		return err
+	}
+	formatAnswer(answer, format)
+	return nil
+}
+
+func formatAnswer(answer *domain.Answer, format string) {
+	answer.Text = formatText(answer.Text, answer.TextHTML, format)
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuestionService_Create_RendersMarkdown(t *testing.T) {
+	env := newMentionTestEnv(t)
+
+	question, err := env.questionS.Create(&domain.CreateQuestionRequest{
+		UserID: mentionTestAuthorID,
+		Text:   "How do I *escape* `<script>` for @bob.smith?<script>alert(1)</script>",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "How do I *escape* `<script>` for @bob.smith?<script>alert(1)</script>", question.Text)
+	assert.Equal(t, `<p>How do I <em>escape</em> <code>&lt;script&gt;</code> for <a href="/users/`+mentionTestBobID+
+		`" class="mention">@bob.smith</a>?alert(1)</p>`+"\n", question.TextHTML)
+}
+
+func TestQuestionService_Create_LengthCountsPlainText(t *testing.T) {
+	svc := NewQuestionService(&fakeQuestionRepository{}, newFakeUserRepository(), PostDeps{}, &config.Default().Validation)
+
+	// Long enough as source, but only four characters without the markup.
+	_, err := svc.Create(&domain.CreateQuestionRequest{Text: "**Why?** [](https://example.com)"})
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+
+	_, err = svc.Create(&domain.CreateQuestionRequest{Text: "<div></div>\n\n---"})
+	assert.ErrorContains(t, err, "question text is required")
+
+	long := "[" + strings.Repeat("a", 900) + "](https://example.com/" + strings.Repeat("b", 900) + ")"
+	_, err = svc.Create(&domain.CreateQuestionRequest{Text: long})
+	assert.NoError(t, err, "link targets do not count towards the limit")
+
+	hidden := "Why does this happen?" + strings.Repeat("<!-- padding -->", 300)
+	_, err = svc.Create(&domain.CreateQuestionRequest{Text: hidden})
+	assert.ErrorContains(t, err, "including markup", "the source itself is still capped")
+}
+
+func TestAnswerService_Create_CapsSource(t *testing.T) {
+	questions := &fakeQuestionRepository{}
+	require.NoError(t, questions.Create(&domain.Question{Text: "What is the capital of France?"}))
+	svc := NewAnswerService(&fakeAnswerRepository{}, questions, newFakeUserRepository(), PostDeps{}, &config.Default().Validation)
+
+	_, err := svc.Create(1, &domain.CreateAnswerRequest{
+		UserID: "7c9e6679-7425-40de-944b-e07fc1f90ae7",
+		Text:   "Paris ![](https://example.com/" + strings.Repeat("a", 4000) + ".png)",
+	})
+	assert.ErrorContains(t, err, "including markup")
+}
+
+func TestQuestionService_Format(t *testing.T) {
+	svc := NewQuestionService(&fakeQuestionRepository{}, newFakeUserRepository(), PostDeps{}, &config.Default().Validation)
+	newQuestion := func() *domain.Question {
+		return &domain.Question{
+			Text:     "Is **this** right?",
+			TextHTML: "<p>Is <strong>this</strong> right?</p>\n",
+			Answers:  []domain.Answer{{Text: "Yes, `it` is"}},
+			Related:  []domain.SimilarQuestion{{Text: "Is *that* right?"}},
+		}
+	}
+
+	q := newQuestion()
+	require.NoError(t, svc.Format("", q))
+	assert.Equal(t, "Is **this** right?", q.Text)
+
+	q = newQuestion()
+	require.NoError(t, svc.Format(domain.FormatHTML, q))
+	assert.Equal(t, "<p>Is <strong>this</strong> right?</p>\n", q.Text)
+	assert.Equal(t, "<p>Yes, <code>it</code> is</p>\n", q.Answers[0].Text, "answers stored without HTML are rendered")
+	assert.Equal(t, "<p>Is <em>that</em> right?</p>\n", q.Related[0].Text)
+
+	q = newQuestion()
+	require.NoError(t, svc.Format(domain.FormatPlain, q))
+	assert.Equal(t, "Is this right?", q.Text)
+	assert.Equal(t, "Yes, it is", q.Answers[0].Text)
+
+	assert.ErrorIs(t, svc.Format("rtf"), domain.ErrInvalidInput, "the format is checked even for an empty list")
+}
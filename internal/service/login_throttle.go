@@ -0,0 +1,168 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/repository"
+)
+
+// LoginThrottle tracks failed logins per account and per client IP and
+// refuses further attempts while a key is delayed or locked out.
+type LoginThrottle struct {
+	repo   repository.LoginAttemptRepository
+	cfg    atomic.Pointer[config.LoginConfig]
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+func NewLoginThrottle(repo repository.LoginAttemptRepository, cfg config.LoginConfig, logger *slog.Logger) *LoginThrottle {
+	t := &LoginThrottle{
+		repo:   repo,
+		logger: logger,
+		now:    time.Now,
+	}
+	t.SetConfig(cfg)
+	return t
+}
+
+func (t *LoginThrottle) SetConfig(cfg config.LoginConfig) {
+	t.cfg.Store(&cfg)
+}
+
+// Check returns a *domain.RetryAfterError if the account or the IP may not
+// attempt a login right now. The reason is identical for existing and
+// unknown accounts.
+func (t *LoginThrottle) Check(email, ip string) error {
+	cfg := t.cfg.Load()
+	now := t.now()
+
+	for _, key := range t.keys(email, ip) {
+		failure, err := t.repo.Get(key)
+		if err != nil {
+			return fmt.Errorf("failed to check login failures: %w", err)
+		}
+
+		if failure.LockedUntil != nil && failure.LockedUntil.After(now) {
+			return &domain.RetryAfterError{
+				Reason:     "too many failed login attempts, try again later",
+				RetryAfter: failure.LockedUntil.Sub(now),
+			}
+		}
+
+		if strings.HasPrefix(key, domain.AccountLoginKeyPrefix) && failure.Failures > 0 &&
+			failure.LastFailureAt.After(now.Add(-cfg.FailureWindow)) {
+			next := failure.LastFailureAt.Add(delayFor(cfg, failure.Failures))
+			if next.After(now) {
+				return &domain.RetryAfterError{
+					Reason:     "too many failed login attempts, try again later",
+					RetryAfter: next.Sub(now),
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Failure records a failed attempt and locks the account or IP once its
+// threshold is reached. userID is nil when the email is unknown.
+func (t *LoginThrottle) Failure(email, ip string, userID *string) error {
+	cfg := t.cfg.Load()
+	now := t.now()
+
+	for _, key := range t.keys(email, ip) {
+		failure, err := t.repo.RecordFailure(key, now, now.Add(-cfg.FailureWindow))
+		if err != nil {
+			return fmt.Errorf("failed to record login failure: %w", err)
+		}
+
+		limit := cfg.MaxIPFailures
+		if strings.HasPrefix(key, domain.AccountLoginKeyPrefix) {
+			limit = cfg.MaxAccountFailures
+		}
+		if failure.Failures < limit {
+			continue
+		}
+
+		until := now.Add(cfg.LockoutDuration)
+		if err := t.repo.Lock(key, until); err != nil {
+			return fmt.Errorf("failed to lock login: %w", err)
+		}
+
+		event := &domain.LockoutEvent{
+			Action:      domain.LockoutActionLock,
+			Key:         key,
+			IP:          ip,
+			Failures:    failure.Failures,
+			LockedUntil: &until,
+		}
+		if strings.HasPrefix(key, domain.AccountLoginKeyPrefix) {
+			event.UserID = userID
+		}
+		if err := t.repo.CreateEvent(event); err != nil {
+			return fmt.Errorf("failed to record lockout: %w", err)
+		}
+
+		t.logger.Warn("login locked out",
+			slog.String("key", key),
+			slog.String("ip", ip),
+			slog.Int("failures", failure.Failures),
+			slog.Time("locked_until", until),
+		)
+	}
+
+	return nil
+}
+
+// Success clears the account counter. The IP counter is left to expire so
+// that one valid login cannot reset a password-spraying IP.
+func (t *LoginThrottle) Success(email string) error {
+	return t.repo.Reset(domain.AccountLoginKey(email))
+}
+
+// Unlock lifts an account lockout and records who did it.
+func (t *LoginThrottle) Unlock(email, userID, actorID string) error {
+	key := domain.AccountLoginKey(email)
+	if err := t.repo.Reset(key); err != nil {
+		return fmt.Errorf("failed to unlock login: %w", err)
+	}
+
+	return t.repo.CreateEvent(&domain.LockoutEvent{
+		Action:  domain.LockoutActionUnlock,
+		Key:     key,
+		UserID:  &userID,
+		ActorID: &actorID,
+	})
+}
+
+func (t *LoginThrottle) Cleanup() error {
+	cfg := t.cfg.Load()
+	return t.repo.DeleteStale(t.now().Add(-cfg.FailureWindow))
+}
+
+const ipKeyPrefix = "ip:"
+
+func (t *LoginThrottle) keys(email, ip string) []string {
+	keys := []string{domain.AccountLoginKey(email)}
+	if ip != "" {
+		keys = append(keys, ipKeyPrefix+ip)
+	}
+	return keys
+}
+
+func delayFor(cfg *config.LoginConfig, failures int) time.Duration {
+	if cfg.DelayBase <= 0 || failures <= 0 {
+		return 0
+	}
+	delay := cfg.DelayBase
+	for i := 1; i < failures && delay < cfg.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, cfg.MaxDelay)
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLoginAttemptRepository struct {
+	failures map[string]*domain.LoginFailure
+	events   []*domain.LockoutEvent
+}
+
+func newFakeLoginAttemptRepository() *fakeLoginAttemptRepository {
+	return &fakeLoginAttemptRepository{failures: make(map[string]*domain.LoginFailure)}
+}
+
+func (r *fakeLoginAttemptRepository) Get(key string) (*domain.LoginFailure, error) {
+	if f, ok := r.failures[key]; ok {
+		copied := *f
+		return &copied, nil
+	}
+	return &domain.LoginFailure{Key: key}, nil
+}
+
+func (r *fakeLoginAttemptRepository) RecordFailure(key string, now, windowStart time.Time) (*domain.LoginFailure, error) {
+	f, ok := r.failures[key]
+	if !ok {
+		f = &domain.LoginFailure{Key: key}
+		r.failures[key] = f
+	}
+	if f.LastFailureAt.Before(windowStart) {
+		f.Failures = 0
+	}
+	f.Failures++
+	f.LastFailureAt = now
+	copied := *f
+	return &copied, nil
+}
+
+func (r *fakeLoginAttemptRepository) Lock(key string, until time.Time) error {
+	r.failures[key].Failures = 0
+	r.failures[key].LockedUntil = &until
+	return nil
+}
+
+func (r *fakeLoginAttemptRepository) Reset(key string) error {
+	delete(r.failures, key)
+	return nil
+}
+
+func (r *fakeLoginAttemptRepository) DeleteStale(before time.Time) error {
+	return nil
+}
+
+func (r *fakeLoginAttemptRepository) CreateEvent(event *domain.LockoutEvent) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func newTestThrottle(repo *fakeLoginAttemptRepository, now *time.Time) *LoginThrottle {
+	cfg := config.Default().Login
+	cfg.MaxAccountFailures = 3
+	cfg.MaxIPFailures = 5
+	throttle := NewLoginThrottle(repo, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	throttle.now = func() time.Time { return *now }
+	return throttle
+}
+
+func TestLoginThrottle_ProgressiveDelay(t *testing.T) {
+	repo := newFakeLoginAttemptRepository()
+	now := time.Now()
+	throttle := newTestThrottle(repo, &now)
+
+	require.NoError(t, throttle.Check("a@example.com", "10.0.0.1"))
+	require.NoError(t, throttle.Failure("a@example.com", "10.0.0.1", nil))
+
+	err := throttle.Check("a@example.com", "10.0.0.1")
+	var retry *domain.RetryAfterError
+	require.True(t, errors.As(err, &retry))
+	assert.Equal(t, time.Second, retry.RetryAfter)
+	assert.ErrorIs(t, err, domain.ErrTooManyRequests)
+
+	now = now.Add(time.Second)
+	require.NoError(t, throttle.Check("a@example.com", "10.0.0.1"))
+	require.NoError(t, throttle.Failure("a@example.com", "10.0.0.1", nil))
+
+	err = throttle.Check("a@example.com", "10.0.0.1")
+	require.True(t, errors.As(err, &retry))
+	assert.Equal(t, 2*time.Second, retry.RetryAfter)
+}
+
+func TestLoginThrottle_LocksAccountAndUnlocks(t *testing.T) {
+	repo := newFakeLoginAttemptRepository()
+	now := time.Now()
+	throttle := newTestThrottle(repo, &now)
+	userID := "550e8400-e29b-41d4-a716-446655440000"
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, throttle.Failure("A@example.com", "10.0.0.1", &userID))
+	}
+
+	require.Len(t, repo.events, 1)
+	assert.Equal(t, domain.LockoutActionLock, repo.events[0].Action)
+	assert.Equal(t, "account:a@example.com", repo.events[0].Key)
+	assert.Equal(t, &userID, repo.events[0].UserID)
+
+	now = now.Add(5 * time.Minute)
+	err := throttle.Check("a@example.com", "10.0.0.2")
+	var retry *domain.RetryAfterError
+	require.True(t, errors.As(err, &retry))
+	assert.Equal(t, 10*time.Minute, retry.RetryAfter)
+
+	require.NoError(t, throttle.Unlock("a@example.com", userID, "admin-id"))
+	require.NoError(t, throttle.Check("a@example.com", "10.0.0.2"))
+	assert.Equal(t, domain.LockoutActionUnlock, repo.events[1].Action)
+}
+
+func TestLoginThrottle_LocksIP(t *testing.T) {
+	repo := newFakeLoginAttemptRepository()
+	now := time.Now()
+	throttle := newTestThrottle(repo, &now)
+
+	emails := []string{"a@x.io", "b@x.io", "c@x.io", "d@x.io", "e@x.io"}
+	for _, email := range emails {
+		require.NoError(t, throttle.Failure(email, "10.0.0.9", nil))
+	}
+
+	err := throttle.Check("fresh@x.io", "10.0.0.9")
+	assert.ErrorIs(t, err, domain.ErrTooManyRequests)
+	require.NoError(t, throttle.Check("fresh@x.io", "10.0.0.10"))
+}
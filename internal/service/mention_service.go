@@ -0,0 +1,240 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/mention"
+	"hitalent-test/internal/repository"
+)
+
+// mentionHandleFactor bounds the distinct @handles of a post at this many
+// times limits.MaxMentions. Handles that match nobody are allowed next to
+// real mentions, but a post of nothing else would otherwise turn into one
+// huge lookup.
+const mentionHandleFactor = 5
+
+// MentionTracker finds, stores and shows the users mentioned in posts. See
+// MentionService for the contract of its methods.
+type MentionTracker interface {
+	Resolve(text string) ([]domain.Mention, error)
+	Save(contentType string, contentID, questionID uint, actorID string, mentions []domain.Mention, notify bool)
+	Attach(questions []*domain.Question) error
+	AttachAnswer(answer *domain.Answer) error
+}
+
+// NopMentionTracker is a MentionTracker that ignores mentions.
+type NopMentionTracker struct{}
+
+func (NopMentionTracker) Resolve(string) ([]domain.Mention, error) { return nil, nil }
+
+func (NopMentionTracker) Save(string, uint, uint, string, []domain.Mention, bool) {}
+
+func (NopMentionTracker) Attach([]*domain.Question) error { return nil }
+
+func (NopMentionTracker) AttachAnswer(*domain.Answer) error { return nil }
+
+// MentionService resolves @handle mentions in questions and answers,
+// stores them and notifies the users mentioned.
+type MentionService struct {
+	repo          repository.MentionRepository
+	userRepo      repository.UserRepository
+	notifications Notifier
+	limits        *config.ValidationConfig
+	logger        *slog.Logger
+}
+
+func NewMentionService(
+	repo repository.MentionRepository,
+	userRepo repository.UserRepository,
+	notifications Notifier,
+	limits *config.ValidationConfig,
+	logger *slog.Logger,
+) *MentionService {
+	return &MentionService{
+		repo:          repo,
+		userRepo:      userRepo,
+		notifications: notifications,
+		limits:        limits,
+		logger:        logger,
+	}
+}
+
+// Resolve finds the users mentioned in text. A handle matches a user's
+// display name or, failing that, the local part of their email address,
+// ignoring case; handles that match nobody or several users are left as
+// plain text. A post mentioning more than limits.MaxMentions users, or
+// with too many distinct handles to look up, is refused.
+func (s *MentionService) Resolve(text string) ([]domain.Mention, error) {
+	matches := mention.Find(text)
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	handles := mention.Handles(text)
+	if maxHandles := max(s.limits.MaxMentions, 1) * mentionHandleFactor; len(handles) > maxHandles {
+		return nil, fmt.Errorf("%w: a post may contain at most %d different @handles", domain.ErrInvalidInput, maxHandles)
+	}
+
+	users, err := s.userRepo.FindByHandles(handles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve mentions: %w", err)
+	}
+	byName := make(map[string][]string)
+	byLocalPart := make(map[string][]string)
+	for _, u := range users {
+		if u.DisplayName != "" {
+			name := strings.ToLower(u.DisplayName)
+			byName[name] = append(byName[name], u.ID)
+		}
+		local, _, _ := strings.Cut(u.Email, "@")
+		local = strings.ToLower(local)
+		byLocalPart[local] = append(byLocalPart[local], u.ID)
+	}
+
+	var mentions []domain.Mention
+	for _, m := range matches {
+		handle := strings.ToLower(m.Handle)
+		ids, ok := byName[handle]
+		if !ok {
+			ids = byLocalPart[handle]
+		}
+		if len(ids) != 1 || slices.ContainsFunc(mentions, func(x domain.Mention) bool { return x.UserID == ids[0] }) {
+			continue
+		}
+		mentions = append(mentions, domain.Mention{UserID: ids[0], Handle: m.Handle})
+	}
+
+	if len(mentions) > s.limits.MaxMentions {
+		return nil, fmt.Errorf("%w: a post may mention at most %d users", domain.ErrInvalidInput, s.limits.MaxMentions)
+	}
+	return mentions, nil
+}
+
+// mentionLinks maps the handles of resolved mentions to the profiles they
+// link to in rendered text.
+func mentionLinks(mentions []domain.Mention) map[string]string {
+	links := make(map[string]string, len(mentions))
+	for _, m := range mentions {
+		links[strings.ToLower(m.Handle)] = "/users/" + m.UserID
+	}
+	return links
+}
+
+// Save stores the mentions of a new or edited post and, if notify is set,
+// notifies the users it did not mention before. Posts held for review are
+// saved without notifying anyone. Failures are logged: the post itself has
+// already been saved.
+func (s *MentionService) Save(contentType string, contentID, questionID uint, actorID string, mentions []domain.Mention, notify bool) {
+	previous, err := s.repo.Replace(contentType, contentID, mentions)
+	if err != nil {
+		s.logger.Error("failed to save mentions",
+			slog.String("content_type", contentType),
+			slog.Uint64("content_id", uint64(contentID)),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+	if !notify {
+		return
+	}
+
+	n := domain.Notification{
+		Type:       domain.NotificationMention,
+		QuestionID: &questionID,
+		Message:    fmt.Sprintf("You were mentioned in question #%d.", questionID),
+	}
+	if contentType == domain.ContentAnswer {
+		n.AnswerID = &contentID
+		n.Message = fmt.Sprintf("You were mentioned in an answer to question #%d.", questionID)
+	}
+	if actorID != "" {
+		n.ActorID = &actorID
+	}
+	for _, m := range mentions {
+		if slices.Contains(previous, m.UserID) {
+			continue
+		}
+		notification := n
+		notification.UserID = m.UserID
+		s.notifications.Notify(&notification)
+	}
+}
+
+// Attach fills in the mentions of the questions and their answers.
+func (s *MentionService) Attach(questions []*domain.Question) error {
+	if len(questions) == 0 {
+		return nil
+	}
+
+	var questionIDs, answerIDs []uint
+	for _, q := range questions {
+		questionIDs = append(questionIDs, q.ID)
+		for _, a := range q.Answers {
+			answerIDs = append(answerIDs, a.ID)
+		}
+	}
+	byQuestion, err := s.list(domain.ContentQuestion, questionIDs)
+	if err != nil {
+		return err
+	}
+	byAnswer, err := s.list(domain.ContentAnswer, answerIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, q := range questions {
+		q.Mentions = byQuestion[q.ID]
+		for i := range q.Answers {
+			q.Answers[i].Mentions = byAnswer[q.Answers[i].ID]
+		}
+	}
+	return nil
+}
+
+// AttachAnswer fills in the mentions of a single answer.
+func (s *MentionService) AttachAnswer(answer *domain.Answer) error {
+	byAnswer, err := s.list(domain.ContentAnswer, []uint{answer.ID})
+	if err != nil {
+		return err
+	}
+	answer.Mentions = byAnswer[answer.ID]
+	return nil
+}
+
+// list returns the mentions of the given posts by post ID, with the
+// mentioned users' summaries.
+func (s *MentionService) list(contentType string, ids []uint) (map[uint][]domain.Mention, error) {
+	byContent := make(map[uint][]domain.Mention)
+	if len(ids) == 0 {
+		return byContent, nil
+	}
+
+	mentions, err := s.repo.List(contentType, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mentions: %w", err)
+	}
+	userIDs := make([]string, len(mentions))
+	for i, m := range mentions {
+		userIDs[i] = m.UserID
+	}
+	slices.Sort(userIDs)
+	users, err := authorSummaries(s.userRepo, slices.Compact(userIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range mentions {
+		m.User = users[m.UserID]
+		id := m.AnswerID
+		if contentType == domain.ContentQuestion {
+			id = m.QuestionID
+		}
+		byContent[*id] = append(byContent[*id], m)
+	}
+	return byContent, nil
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	mentionTestAuthorID = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	mentionTestAliceID  = "11111111-1111-4111-8111-111111111111"
+	mentionTestBobID    = "22222222-2222-4222-8222-222222222222"
+	mentionTestTwinID   = "33333333-3333-4333-8333-333333333333"
+)
+
+type fakeMentionRepository struct {
+	mu       sync.Mutex
+	mentions []domain.Mention
+}
+
+func (r *fakeMentionRepository) Replace(contentType string, contentID uint, mentions []domain.Mention) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var previous []string
+	r.mentions = slices.DeleteFunc(r.mentions, func(m domain.Mention) bool {
+		if fakeMentionTarget(m, contentType) == contentID {
+			previous = append(previous, m.UserID)
+			return true
+		}
+		return false
+	})
+	for _, m := range mentions {
+		id := contentID
+		m.ID = uint(len(r.mentions) + 1)
+		m.QuestionID, m.AnswerID = nil, nil
+		if contentType == domain.ContentQuestion {
+			m.QuestionID = &id
+		} else {
+			m.AnswerID = &id
+		}
+		r.mentions = append(r.mentions, m)
+	}
+	return previous, nil
+}
+
+func (r *fakeMentionRepository) List(contentType string, contentIDs []uint) ([]domain.Mention, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var mentions []domain.Mention
+	for _, m := range r.mentions {
+		if slices.Contains(contentIDs, fakeMentionTarget(m, contentType)) {
+			mentions = append(mentions, m)
+		}
+	}
+	return mentions, nil
+}
+
+// fakeMentionTarget returns the ID of the post of the given type that m
+// belongs to, or 0.
+func fakeMentionTarget(m domain.Mention, contentType string) uint {
+	id := m.AnswerID
+	if contentType == domain.ContentQuestion {
+		id = m.QuestionID
+	}
+	if id == nil {
+		return 0
+	}
+	return *id
+}
+
+type mentionTestEnv struct {
+	questions *fakeQuestionRepository
+	answers   *fakeAnswerRepository
+	mentions  *fakeMentionRepository
+	inbox     *fakeNotificationRepository
+	limits    *config.ValidationConfig
+	questionS *QuestionService
+	answerS   *AnswerService
+}
+
+func newMentionTestEnv(t *testing.T) *mentionTestEnv {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	users := newFakeUserRepository(
+		&domain.User{ID: mentionTestAuthorID, Email: "author@example.com", DisplayName: "Author"},
+		&domain.User{ID: mentionTestAliceID, Email: "alice@example.com", DisplayName: "Alice"},
+		&domain.User{ID: mentionTestBobID, Email: "bob.smith@example.com"},
+		&domain.User{ID: mentionTestTwinID, Email: "twin@example.com", DisplayName: "alice"},
+	)
+	limits := config.Default().Validation
+	env := &mentionTestEnv{
+		questions: &fakeQuestionRepository{},
+		answers:   &fakeAnswerRepository{},
+		mentions:  &fakeMentionRepository{},
+		inbox:     &fakeNotificationRepository{},
+		limits:    &limits,
+	}
+	notifications := NewNotificationService(env.inbox, logger)
+	mentions := NewMentionService(env.mentions, users, notifications, env.limits, logger)
+	env.questionS = NewQuestionService(env.questions, users, PostDeps{Notifications: notifications, Mentions: mentions}, env.limits)
+	env.answerS = NewAnswerService(env.answers, env.questions, users, PostDeps{Notifications: notifications, Mentions: mentions}, env.limits)
+	return env
+}
+
+func TestMentionService_Resolve(t *testing.T) {
+	env := newMentionTestEnv(t)
+
+	question, err := env.questionS.Create(&domain.CreateQuestionRequest{
+		UserID: mentionTestAuthorID,
+		Text:   "@Bob.Smith and @author, what do you think? Not @nobody, mail bob.smith@example.com",
+	})
+	require.NoError(t, err)
+
+	require.Len(t, question.Mentions, 2)
+	assert.Equal(t, "Bob.Smith", question.Mentions[0].Handle)
+	require.NotNil(t, question.Mentions[0].User)
+	assert.Equal(t, mentionTestBobID, question.Mentions[0].User.ID)
+	assert.Equal(t, mentionTestAuthorID, question.Mentions[1].User.ID)
+
+	require.Len(t, env.inbox.notifications, 1, "authors are not notified of their own mentions")
+	n := env.inbox.notifications[0]
+	assert.Equal(t, mentionTestBobID, n.UserID)
+	assert.Equal(t, domain.NotificationMention, n.Type)
+	assert.Equal(t, question.ID, *n.QuestionID)
+	assert.Nil(t, n.AnswerID)
+
+	got, err := env.questionS.GetByID(question.ID)
+	require.NoError(t, err)
+	assert.Len(t, got.Mentions, 2)
+}
+
+func TestMentionService_AmbiguousHandle(t *testing.T) {
+	env := newMentionTestEnv(t)
+
+	// "alice" is the display name of two users and names neither of them
+	// unambiguously; "twin" falls back to an email local part.
+	question, err := env.questionS.Create(&domain.CreateQuestionRequest{
+		UserID: mentionTestAuthorID,
+		Text:   "Asking @alice and @twin about this",
+	})
+	require.NoError(t, err)
+	require.Len(t, question.Mentions, 1)
+	assert.Equal(t, mentionTestTwinID, question.Mentions[0].User.ID)
+}
+
+func TestMentionService_Answer(t *testing.T) {
+	env := newMentionTestEnv(t)
+	author := mentionTestAuthorID
+	require.NoError(t, env.questions.Create(&domain.Question{UserID: &author, Text: "What is the capital of France?"}))
+
+	answer, err := env.answerS.Create(1, &domain.CreateAnswerRequest{UserID: mentionTestAliceID, Text: "Paris, as @bob.smith said"})
+	require.NoError(t, err)
+	require.Len(t, answer.Mentions, 1)
+
+	var mentioned []domain.Notification
+	for _, n := range env.inbox.notifications {
+		if n.Type == domain.NotificationMention {
+			mentioned = append(mentioned, n)
+		}
+	}
+	require.Len(t, mentioned, 1)
+	assert.Equal(t, mentionTestBobID, mentioned[0].UserID)
+	assert.Equal(t, answer.ID, *mentioned[0].AnswerID)
+	assert.Equal(t, "You were mentioned in an answer to question #1.", mentioned[0].Message)
+
+	got, err := env.answerS.GetByID(answer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, answer.Mentions, got.Mentions)
+}
+
+func TestMentionService_Limit(t *testing.T) {
+	env := newMentionTestEnv(t)
+	env.limits.MaxMentions = 1
+
+	_, err := env.questionS.Create(&domain.CreateQuestionRequest{UserID: mentionTestAuthorID, Text: "@bob.smith and @twin, any ideas?"})
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	assert.Empty(t, env.questions.questions)
+	assert.Empty(t, env.inbox.notifications)
+
+	// Repeating a mention or naming unknown users does not count.
+	_, err = env.questionS.Create(&domain.CreateQuestionRequest{UserID: mentionTestAuthorID, Text: "@bob.smith @Bob.Smith @ghost, any ideas?"})
+	assert.NoError(t, err)
+}
+
+// countingUserRepository counts the handle lookups made.
+type countingUserRepository struct {
+	*fakeUserRepository
+	lookups int
+}
+
+func (r *countingUserRepository) FindByHandles(handles []string) ([]domain.User, error) {
+	r.lookups++
+	return r.fakeUserRepository.FindByHandles(handles)
+}
+
+func TestMentionService_TooManyHandles(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	limits := config.Default().Validation
+	limits.MaxMentions = 2
+	users := &countingUserRepository{fakeUserRepository: newFakeUserRepository()}
+	svc := NewMentionService(&fakeMentionRepository{}, users, nil, &limits, logger)
+
+	var text strings.Builder
+	for i := range 2 * mentionHandleFactor {
+		fmt.Fprintf(&text, "@user%d ", i)
+	}
+	_, err := svc.Resolve(text.String())
+	require.NoError(t, err)
+	assert.Equal(t, 1, users.lookups)
+
+	_, err = svc.Resolve(text.String() + "@one.more")
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	assert.Equal(t, 1, users.lookups, "the handles are not looked up")
+}
+
+func TestMentionService_SaveNotifiesNewMentionsOnly(t *testing.T) {
+	env := newMentionTestEnv(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	svc := NewMentionService(env.mentions, newFakeUserRepository(), NewNotificationService(env.inbox, logger), env.limits, logger)
+
+	svc.Save(domain.ContentQuestion, 1, 1, mentionTestAuthorID, []domain.Mention{{UserID: mentionTestAliceID, Handle: "alice"}}, true)
+	// An edit that adds Bob notifies only him; held posts notify nobody.
+	svc.Save(domain.ContentQuestion, 1, 1, mentionTestAuthorID, []domain.Mention{
+		{UserID: mentionTestAliceID, Handle: "alice"},
+		{UserID: mentionTestBobID, Handle: "bob.smith"},
+	}, true)
+	svc.Save(domain.ContentQuestion, 2, 2, mentionTestAuthorID, []domain.Mention{{UserID: mentionTestAliceID, Handle: "alice"}}, false)
+
+	require.Len(t, env.inbox.notifications, 2)
+	assert.Equal(t, mentionTestAliceID, env.inbox.notifications[0].UserID)
+	assert.Equal(t, mentionTestBobID, env.inbox.notifications[1].UserID)
+	assert.Len(t, env.mentions.mentions, 3)
+}
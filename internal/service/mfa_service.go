@@ -0,0 +1,226 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"strings"
+	"time"
+
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/repository"
+	"hitalent-test/internal/totp"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// totpSkew is the number of periods before and after the current one whose
+// codes are still accepted, to tolerate clock drift.
+const totpSkew = 1
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type MFAService struct {
+	userRepo repository.UserRepository
+	codeRepo repository.RecoveryCodeRepository
+	cfg      *config.MFAConfig
+	now      func() time.Time
+}
+
+func NewMFAService(
+	userRepo repository.UserRepository,
+	codeRepo repository.RecoveryCodeRepository,
+	cfg *config.MFAConfig,
+) *MFAService {
+	return &MFAService{
+		userRepo: userRepo,
+		codeRepo: codeRepo,
+		cfg:      cfg,
+		now:      time.Now,
+	}
+}
+
+// Enroll generates a new TOTP secret for the user. It has no effect on login
+// until confirmed with a code from the authenticator app.
+func (s *MFAService) Enroll(userID string) (*domain.TOTPEnrollment, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, fmt.Errorf("%w: two-factor authentication is already enabled", domain.ErrInvalidInput)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	user.TOTPSecret = secret
+	user.TOTPLastStep = 0
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return &domain.TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.cfg.Issuer, user.Email, secret),
+	}, nil
+}
+
+// Confirm enables two-factor authentication once the user proves the
+// authenticator works, and returns the first set of recovery codes.
+func (s *MFAService) Confirm(userID, code string) ([]string, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, fmt.Errorf("%w: two-factor authentication is already enabled", domain.ErrInvalidInput)
+	}
+	if user.TOTPSecret == "" {
+		return nil, fmt.Errorf("%w: two-factor authentication is not enrolled", domain.ErrInvalidInput)
+	}
+
+	ok, err := s.verifyTOTP(user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid two-factor code", domain.ErrInvalidInput)
+	}
+
+	now := s.now()
+	user.TOTPEnabled = true
+	user.TOTPEnabledAt = &now
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return s.newRecoveryCodes(user.ID)
+}
+
+// Disable turns two-factor authentication off after re-authentication and
+// discards the secret and recovery codes.
+func (s *MFAService) Disable(userID, password, code string) error {
+	user, err := s.reauthenticate(userID, password, code)
+	if err != nil {
+		return err
+	}
+
+	user.TOTPSecret = ""
+	user.TOTPEnabled = false
+	user.TOTPEnabledAt = nil
+	user.TOTPLastStep = 0
+	if err := s.userRepo.Update(user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	if err := s.codeRepo.DeleteForUser(user.ID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after
+// re-authentication.
+func (s *MFAService) RegenerateRecoveryCodes(userID, password, code string) ([]string, error) {
+	user, err := s.reauthenticate(userID, password, code)
+	if err != nil {
+		return nil, err
+	}
+	return s.newRecoveryCodes(user.ID)
+}
+
+// Verify checks a second factor of a user with two-factor authentication
+// enabled. code is either a TOTP code or an unused recovery code, which is
+// consumed.
+func (s *MFAService) Verify(user *domain.User, code string) (bool, error) {
+	if !user.TOTPEnabled {
+		return false, nil
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	if len(normalized) == totp.Digits {
+		return s.verifyTOTP(user, normalized)
+	}
+
+	ok, err := s.codeRepo.Use(user.ID, hashToken(normalized), s.now())
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	return ok, nil
+}
+
+func (s *MFAService) ChallengeTTL() time.Duration {
+	return s.cfg.ChallengeTTL
+}
+
+func (s *MFAService) reauthenticate(userID, password, code string) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.TOTPEnabled {
+		return nil, fmt.Errorf("%w: two-factor authentication is not enabled", domain.ErrInvalidInput)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, fmt.Errorf("%w: current password is incorrect", domain.ErrInvalidInput)
+	}
+
+	ok, err := s.Verify(user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid two-factor code", domain.ErrInvalidInput)
+	}
+
+	return user, nil
+}
+
+// verifyTOTP accepts a code only once: the matching time step must be later
+// than the last one used.
+func (s *MFAService) verifyTOTP(user *domain.User, code string) (bool, error) {
+	step, ok := totp.Validate(user.TOTPSecret, code, s.now(), totpSkew)
+	if !ok || step <= user.TOTPLastStep {
+		return false, nil
+	}
+
+	advanced, err := s.userRepo.AdvanceTOTPStep(user.ID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to record totp step: %w", err)
+	}
+	if advanced {
+		user.TOTPLastStep = step
+	}
+	return advanced, nil
+}
+
+func (s *MFAService) newRecoveryCodes(userID string) ([]string, error) {
+	codes := make([]string, s.cfg.RecoveryCodes)
+	hashes := make([]string, len(codes))
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))
+		codes[i] = code[:4] + "-" + code[4:]
+		hashes[i] = hashToken(code)
+	}
+
+	if err := s.codeRepo.Replace(userID, hashes); err != nil {
+		return nil, fmt.Errorf("failed to store recovery codes: %w", err)
+	}
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+}
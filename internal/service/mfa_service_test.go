@@ -0,0 +1,249 @@
+package service
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/totp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const mfaTestUserID = "550e8400-e29b-41d4-a716-446655440000"
+
+func (r *fakeUserRepository) AdvanceTOTPStep(id string, step int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok || u.TOTPLastStep >= step {
+		return false, nil
+	}
+	u.TOTPLastStep = step
+	return true, nil
+}
+
+type fakeRecoveryCodeRepository struct {
+	codes []*domain.RecoveryCode
+}
+
+func (r *fakeRecoveryCodeRepository) Replace(userID string, hashes []string) error {
+	r.DeleteForUser(userID)
+	for _, hash := range hashes {
+		r.codes = append(r.codes, &domain.RecoveryCode{UserID: userID, CodeHash: hash})
+	}
+	return nil
+}
+
+func (r *fakeRecoveryCodeRepository) Use(userID, hash string, at time.Time) (bool, error) {
+	for _, c := range r.codes {
+		if c.UserID == userID && c.CodeHash == hash && c.UsedAt == nil {
+			c.UsedAt = &at
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeRecoveryCodeRepository) CountUnused(userID string) (int64, error) {
+	var count int64
+	for _, c := range r.codes {
+		if c.UserID == userID && c.UsedAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeRecoveryCodeRepository) DeleteForUser(userID string) error {
+	kept := r.codes[:0]
+	for _, c := range r.codes {
+		if c.UserID != userID {
+			kept = append(kept, c)
+		}
+	}
+	r.codes = kept
+	return nil
+}
+
+type mfaTestEnv struct {
+	now   time.Time
+	users *fakeUserRepository
+	audit *fakeAuditRepository
+	mfa   *MFAService
+	auth  *AuthService
+}
+
+func newMFATestEnv(t *testing.T) *mfaTestEnv {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	cfg := config.Default()
+	cfg.JWT.Secret = "test-secret"
+	env := &mfaTestEnv{
+		now: time.Now(),
+		users: newFakeUserRepository(&domain.User{
+			ID:           mfaTestUserID,
+			Email:        "user@example.com",
+			PasswordHash: string(hash),
+			Role:         domain.RoleUser,
+		}),
+		audit: &fakeAuditRepository{},
+	}
+
+	env.mfa = NewMFAService(env.users, &fakeRecoveryCodeRepository{}, &cfg.MFA)
+	env.mfa.now = func() time.Time { return env.now }
+	throttle := newTestThrottle(newFakeLoginAttemptRepository(), &env.now)
+	env.auth = NewAuthService(env.users, NewTokenService(&cfg.JWT), NewRefreshTokenStore(),
+		throttle, env.mfa, NewAuditService(env.audit, slog.New(slog.NewTextHandler(io.Discard, nil))), &cfg.Validation)
+	return env
+}
+
+func (e *mfaTestEnv) code(t *testing.T, secret string) string {
+	t.Helper()
+	code, err := totp.Code(secret, totp.Step(e.now))
+	require.NoError(t, err)
+	return code
+}
+
+// enable enrolls and confirms 2FA and returns the secret and recovery codes.
+func (e *mfaTestEnv) enable(t *testing.T) (string, []string) {
+	t.Helper()
+	enrollment, err := e.mfa.Enroll(mfaTestUserID)
+	require.NoError(t, err)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/")
+
+	codes, err := e.mfa.Confirm(mfaTestUserID, e.code(t, enrollment.Secret))
+	require.NoError(t, err)
+	require.Len(t, codes, config.Default().MFA.RecoveryCodes)
+
+	e.now = e.now.Add(totp.Period)
+	return enrollment.Secret, codes
+}
+
+func TestMFAService_ConfirmRejectsInvalidCode(t *testing.T) {
+	env := newMFATestEnv(t)
+
+	_, err := env.mfa.Enroll(mfaTestUserID)
+	require.NoError(t, err)
+
+	_, err = env.mfa.Confirm(mfaTestUserID, "000000")
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+
+	user, _ := env.users.GetByID(mfaTestUserID)
+	assert.False(t, user.TOTPEnabled)
+}
+
+func TestAuthService_LoginWithoutMFA(t *testing.T) {
+	env := newMFATestEnv(t)
+
+	authResp, challenge, err := env.auth.Login("user@example.com", "password123", domain.RequestMeta{IP: "192.0.2.1"})
+	require.NoError(t, err)
+	assert.Nil(t, challenge)
+	assert.NotEmpty(t, authResp.AccessToken)
+}
+
+func TestAuthService_LoginWithTOTP(t *testing.T) {
+	env := newMFATestEnv(t)
+	secret, _ := env.enable(t)
+
+	authResp, challenge, err := env.auth.Login("user@example.com", "password123", domain.RequestMeta{IP: "192.0.2.1"})
+	require.NoError(t, err)
+	assert.Nil(t, authResp)
+	require.NotNil(t, challenge)
+	assert.True(t, challenge.MFARequired)
+
+	code := env.code(t, secret)
+	authResp, err = env.auth.CompleteLogin(challenge.MFAToken, code, domain.RequestMeta{IP: "192.0.2.1"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, authResp.AccessToken)
+
+	// The same code cannot be used twice.
+	_, err = env.auth.CompleteLogin(challenge.MFAToken, code, domain.RequestMeta{IP: "192.0.2.1"})
+	assert.ErrorIs(t, err, domain.ErrUnauthorized)
+}
+
+func TestAuthService_LoginWithRecoveryCode(t *testing.T) {
+	env := newMFATestEnv(t)
+	_, codes := env.enable(t)
+
+	_, challenge, err := env.auth.Login("user@example.com", "password123", domain.RequestMeta{IP: "192.0.2.1"})
+	require.NoError(t, err)
+
+	authResp, err := env.auth.CompleteLogin(challenge.MFAToken, codes[0], domain.RequestMeta{IP: "192.0.2.1"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, authResp.AccessToken)
+
+	_, err = env.auth.CompleteLogin(challenge.MFAToken, codes[0], domain.RequestMeta{IP: "192.0.2.1"})
+	assert.ErrorIs(t, err, domain.ErrUnauthorized)
+}
+
+func TestAuthService_AuditKeepsNoEmail(t *testing.T) {
+	env := newMFATestEnv(t)
+	meta := domain.RequestMeta{IP: "192.0.2.1"}
+
+	_, _, err := env.auth.Login("user@example.com", "wrong", meta)
+	assert.ErrorIs(t, err, domain.ErrUnauthorized)
+	_, _, err = env.auth.Login("nobody@example.com", "wrong", meta)
+	assert.ErrorIs(t, err, domain.ErrUnauthorized)
+	_, err = env.auth.Register("new@example.com", "password123", meta)
+	require.NoError(t, err)
+	_, err = env.auth.SetRole("user@example.com", domain.RoleModerator, meta)
+	require.NoError(t, err)
+
+	require.Len(t, env.audit.entries, 4)
+	assert.Equal(t, mfaTestUserID, env.audit.entries[0].TargetID)
+	assert.Empty(t, env.audit.entries[1].TargetID)
+	for _, entry := range env.audit.entries {
+		assert.NotContains(t, string(entry.Before)+string(entry.After), "@example.com", entry.Action)
+	}
+	assert.JSONEq(t, `{"role":"moderator","email_verified":false}`, string(env.audit.entries[3].After))
+}
+
+func TestAuthService_CompleteLoginRejectsInvalidToken(t *testing.T) {
+	env := newMFATestEnv(t)
+
+	_, err := env.auth.CompleteLogin("not-a-token", "123456", domain.RequestMeta{IP: "192.0.2.1"})
+	assert.ErrorIs(t, err, domain.ErrUnauthorized)
+}
+
+func TestMFAService_DisableRequiresReauthentication(t *testing.T) {
+	env := newMFATestEnv(t)
+	secret, codes := env.enable(t)
+
+	err := env.mfa.Disable(mfaTestUserID, "wrong-password", env.code(t, secret))
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+
+	err = env.mfa.Disable(mfaTestUserID, "password123", "000000")
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+
+	require.NoError(t, env.mfa.Disable(mfaTestUserID, "password123", codes[1]))
+
+	user, _ := env.users.GetByID(mfaTestUserID)
+	assert.False(t, user.TOTPEnabled)
+	assert.Empty(t, user.TOTPSecret)
+}
+
+func TestMFAService_RegenerateRecoveryCodes(t *testing.T) {
+	env := newMFATestEnv(t)
+	secret, oldCodes := env.enable(t)
+
+	newCodes, err := env.mfa.RegenerateRecoveryCodes(mfaTestUserID, "password123", env.code(t, secret))
+	require.NoError(t, err)
+	assert.NotEqual(t, oldCodes, newCodes)
+
+	user, _ := env.users.GetByID(mfaTestUserID)
+	ok, err := env.mfa.Verify(user, oldCodes[0])
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = env.mfa.Verify(user, newCodes[0])
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
@@ -0,0 +1,421 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/mailer"
+	"hitalent-test/internal/repository"
+)
+
+const (
+	maxFlagCommentLength      = 500
+	maxModerationNoteLength   = 1000
+	defaultModerationPageSize = 50
+	maxModerationPageSize     = 200
+)
+
+type ModerationService struct {
+	flagRepo      repository.FlagRepository
+	questionRepo  repository.QuestionRepository
+	answerRepo    repository.AnswerRepository
+	userRepo      repository.UserRepository
+	mailer        mailer.Mailer
+	audit         Auditor
+	notifications Notifier
+	logger        *slog.Logger
+	hideThreshold atomic.Int64
+	now           func() time.Time
+}
+
+func NewModerationService(
+	flagRepo repository.FlagRepository,
+	questionRepo repository.QuestionRepository,
+	answerRepo repository.AnswerRepository,
+	userRepo repository.UserRepository,
+	mailer mailer.Mailer,
+	audit Auditor,
+	notifications Notifier,
+	cfg *config.ModerationConfig,
+	logger *slog.Logger,
+) *ModerationService {
+	s := &ModerationService{
+		flagRepo:      flagRepo,
+		questionRepo:  questionRepo,
+		answerRepo:    answerRepo,
+		userRepo:      userRepo,
+		mailer:        mailer,
+		audit:         audit,
+		notifications: notifications,
+		logger:        logger,
+		now:           time.Now,
+	}
+	s.SetHideThreshold(cfg.HideThreshold)
+	return s
+}
+
+// SetHideThreshold sets how many open flags hide an item; zero disables
+// automatic hiding.
+func (s *ModerationService) SetHideThreshold(threshold int) {
+	s.hideThreshold.Store(int64(threshold))
+}
+
+// moderatedContent is what moderation needs to know about a question or an
+// answer.
+type moderatedContent struct {
+	// authorID is empty for anonymous questions.
+	authorID string
+	text     string
+	hidden   bool
+	locked   bool
+	snapshot interface{}
+}
+
+// Flag reports a question or an answer. Once the item has collected enough
+// open flags it is hidden until a moderator decides.
+func (s *ModerationService) Flag(targetType string, targetID uint, reporterID string, req *domain.CreateFlagRequest) (*domain.Flag, error) {
+	if !slices.Contains(domain.FlagReasons, req.Reason) {
+		return nil, fmt.Errorf("%w: reason must be one of %s", domain.ErrInvalidInput, strings.Join(domain.FlagReasons, ", "))
+	}
+	comment := strings.TrimSpace(req.Comment)
+	if utf8.RuneCountInString(comment) > maxFlagCommentLength {
+		return nil, fmt.Errorf("%w: comment must not exceed %d characters", domain.ErrInvalidInput, maxFlagCommentLength)
+	}
+
+	content, err := s.content(targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if content.authorID == reporterID {
+		return nil, fmt.Errorf("%w: you cannot flag your own %s", domain.ErrInvalidInput, targetType)
+	}
+
+	flag := &domain.Flag{
+		TargetType: targetType,
+		TargetID:   targetID,
+		ReporterID: &reporterID,
+		Reason:     req.Reason,
+		Comment:    comment,
+		Status:     domain.FlagStatusOpen,
+	}
+	created, err := s.flagRepo.Create(flag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create flag: %w", err)
+	}
+	if !created {
+		return nil, fmt.Errorf("%w: you have already flagged this %s and the flag is still open", domain.ErrInvalidInput, targetType)
+	}
+
+	if threshold := s.hideThreshold.Load(); threshold > 0 && !content.hidden {
+		count, err := s.flagRepo.CountOpen(targetType, targetID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count flags: %w", err)
+		}
+		if count >= threshold {
+			if err := s.hide(targetType, targetID, content, count); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return flag, nil
+}
+
+func (s *ModerationService) hide(targetType string, targetID uint, content *moderatedContent, flags int64) error {
+	now := s.now()
+	if err := s.setHidden(targetType, targetID, &now); err != nil {
+		return err
+	}
+
+	s.audit.Record(domain.SystemMeta("moderation"), domain.AuditContentHidden, targetType, formatID(targetID), nil,
+		map[string]int64{"open_flags": flags})
+	s.notify(targetType, targetID, content.authorID, "", fmt.Sprintf("Your %s has been hidden", targetType),
+		fmt.Sprintf("Your %s #%d was reported by several users and is hidden until a moderator reviews it.\n",
+			targetType, targetID))
+	return nil
+}
+
+// Hold puts content that the content filter held into the moderation queue.
+// The content is expected to be stored hidden already.
+func (s *ModerationService) Hold(targetType string, targetID uint, reason string) error {
+	flag := &domain.Flag{
+		TargetType: targetType,
+		TargetID:   targetID,
+		Reason:     domain.FlagReasonFilter,
+		Comment:    reason,
+		Status:     domain.FlagStatusOpen,
+	}
+	if _, err := s.flagRepo.Create(flag); err != nil {
+		return fmt.Errorf("failed to create flag: %w", err)
+	}
+
+	s.audit.Record(domain.SystemMeta("content filter"), domain.AuditContentHidden, targetType, formatID(targetID), nil,
+		map[string]string{"reason": reason})
+	return nil
+}
+
+// Queue lists the flagged items, most flagged first. targetType may be
+// empty to list both questions and answers.
+func (s *ModerationService) Queue(targetType string, limit, offset int) ([]domain.ModerationItem, error) {
+	if targetType != "" && targetType != domain.ContentQuestion && targetType != domain.ContentAnswer {
+		return nil, fmt.Errorf("%w: target_type must be %q or %q", domain.ErrInvalidInput, domain.ContentQuestion, domain.ContentAnswer)
+	}
+	if limit < 0 || limit > maxModerationPageSize {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", domain.ErrInvalidInput, maxModerationPageSize)
+	}
+	if limit == 0 {
+		limit = defaultModerationPageSize
+	}
+	if offset < 0 {
+		return nil, fmt.Errorf("%w: offset must not be negative", domain.ErrInvalidInput)
+	}
+
+	items, err := s.flagRepo.Queue(targetType, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list flagged content: %w", err)
+	}
+
+	var authorIDs []string
+	contents := make([]*moderatedContent, len(items))
+	for i, item := range items {
+		content, err := s.content(item.TargetType, item.TargetID)
+		if errors.Is(err, domain.ErrQuestionNotFound) || errors.Is(err, domain.ErrAnswerNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		contents[i] = content
+		if content.authorID != "" {
+			authorIDs = append(authorIDs, content.authorID)
+		}
+	}
+	slices.Sort(authorIDs)
+
+	authors, err := authorSummaries(s.userRepo, slices.Compact(authorIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	for i, content := range contents {
+		if content == nil {
+			continue
+		}
+		items[i].Text = content.text
+		items[i].Author = authors[content.authorID]
+		items[i].Hidden = content.hidden
+		items[i].Locked = content.locked
+	}
+	if items == nil {
+		items = []domain.ModerationItem{}
+	}
+	return items, nil
+}
+
+// Act applies a moderator's decision to a question or an answer, closes
+// its open flags and tells the author. Every decision but delete ends the
+// review, so content hidden by flags is shown again.
+func (s *ModerationService) Act(targetType string, targetID uint, req *domain.ModerationActionRequest, meta domain.RequestMeta) error {
+	note := strings.TrimSpace(req.Note)
+	if utf8.RuneCountInString(note) > maxModerationNoteLength {
+		return fmt.Errorf("%w: note must not exceed %d characters", domain.ErrInvalidInput, maxModerationNoteLength)
+	}
+
+	content, err := s.content(targetType, targetID)
+	if err != nil {
+		if req.Action == domain.ModerationDismiss && (errors.Is(err, domain.ErrQuestionNotFound) || errors.Is(err, domain.ErrAnswerNotFound)) {
+			// The flags of deleted content can still be cleared from the queue.
+			return s.resolve(targetType, targetID, domain.FlagStatusDismissed, meta)
+		}
+		return err
+	}
+
+	id := formatID(targetID)
+	status := domain.FlagStatusActioned
+	var subject, message string
+
+	switch req.Action {
+	case domain.ModerationDismiss:
+		status = domain.FlagStatusDismissed
+		if err := s.unhide(targetType, targetID, content); err != nil {
+			return err
+		}
+		s.audit.Record(meta, domain.AuditFlagsDismissed, targetType, id, nil, nil)
+		if content.hidden {
+			subject = fmt.Sprintf("Your %s is visible again", targetType)
+			message = fmt.Sprintf("A moderator reviewed your %s #%d and found no problem. It is visible again.\n", targetType, targetID)
+		}
+
+	case domain.ModerationDelete:
+		if err := s.delete(targetType, targetID); err != nil {
+			return err
+		}
+		action := domain.AuditQuestionDeleted
+		if targetType == domain.ContentAnswer {
+			action = domain.AuditAnswerDeleted
+		}
+		s.audit.Record(meta, action, targetType, id, content.snapshot, nil)
+		subject = fmt.Sprintf("Your %s has been removed", targetType)
+		message = fmt.Sprintf("A moderator removed your %s #%d.\n", targetType, targetID)
+
+	case domain.ModerationLock:
+		if targetType != domain.ContentQuestion {
+			return fmt.Errorf("%w: only questions can be locked", domain.ErrInvalidInput)
+		}
+		if err := s.unhide(targetType, targetID, content); err != nil {
+			return err
+		}
+		if !content.locked {
+			now := s.now()
+			status := domain.QuestionStatus{Status: domain.QuestionLocked, StatusChangedAt: &now}
+			if meta.ActorID != "" {
+				status.StatusChangedBy = &meta.ActorID
+			}
+			if err := s.questionRepo.SetStatus(targetID, status); err != nil {
+				return fmt.Errorf("failed to lock question: %w", err)
+			}
+		}
+		s.audit.Record(meta, domain.AuditQuestionLocked, targetType, id, nil, nil)
+		subject = "Your question has been locked"
+		message = fmt.Sprintf("A moderator locked your question #%d. It stays visible but accepts no new answers.\n", targetID)
+
+	case domain.ModerationWarn:
+		if content.authorID == "" {
+			return fmt.Errorf("%w: anonymous content has no author to warn", domain.ErrInvalidInput)
+		}
+		if err := s.unhide(targetType, targetID, content); err != nil {
+			return err
+		}
+		s.audit.Record(meta, domain.AuditUserWarned, domain.AuditTargetUser, content.authorID, nil,
+			map[string]string{"target_type": targetType, "target_id": id, "note": note})
+		subject = "A warning from the moderators"
+		message = fmt.Sprintf("A moderator reviewed your %s #%d and issued a warning. "+
+			"Repeated violations may lead to your content being removed.\n", targetType, targetID)
+
+	default:
+		return fmt.Errorf("%w: action must be one of %s, %s, %s, %s", domain.ErrInvalidInput,
+			domain.ModerationDismiss, domain.ModerationDelete, domain.ModerationLock, domain.ModerationWarn)
+	}
+
+	if err := s.resolve(targetType, targetID, status, meta); err != nil {
+		return err
+	}
+
+	if subject != "" {
+		if note != "" {
+			message += "\nModerator's note: " + note + "\n"
+		}
+		s.notify(targetType, targetID, content.authorID, meta.ActorID, subject, message)
+	}
+	return nil
+}
+
+func (s *ModerationService) resolve(targetType string, targetID uint, status string, meta domain.RequestMeta) error {
+	if _, err := s.flagRepo.Resolve(targetType, targetID, status, meta.ActorID, s.now()); err != nil {
+		return fmt.Errorf("failed to resolve flags: %w", err)
+	}
+	return nil
+}
+
+func (s *ModerationService) unhide(targetType string, targetID uint, content *moderatedContent) error {
+	if !content.hidden {
+		return nil
+	}
+	return s.setHidden(targetType, targetID, nil)
+}
+
+func (s *ModerationService) content(targetType string, id uint) (*moderatedContent, error) {
+	switch targetType {
+	case domain.ContentQuestion:
+		question, err := s.questionRepo.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		content := &moderatedContent{
+			text:     question.Text,
+			hidden:   question.HiddenAt != nil,
+			locked:   question.Status == domain.QuestionLocked,
+			snapshot: questionSnapshot(question),
+		}
+		if question.UserID != nil {
+			content.authorID = *question.UserID
+		}
+		return content, nil
+	case domain.ContentAnswer:
+		answer, err := s.answerRepo.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		return &moderatedContent{
+			authorID: answer.UserID,
+			text:     answer.Text,
+			hidden:   answer.HiddenAt != nil,
+			snapshot: answerSnapshot(answer),
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: target_type must be %q or %q", domain.ErrInvalidInput, domain.ContentQuestion, domain.ContentAnswer)
+}
+
+func (s *ModerationService) setHidden(targetType string, id uint, hiddenAt *time.Time) error {
+	var err error
+	if targetType == domain.ContentQuestion {
+		err = s.questionRepo.SetHidden(id, hiddenAt)
+	} else {
+		err = s.answerRepo.SetHidden(id, hiddenAt)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update %s visibility: %w", targetType, err)
+	}
+	return nil
+}
+
+func (s *ModerationService) delete(targetType string, id uint) error {
+	if targetType == domain.ContentQuestion {
+		return s.questionRepo.Delete(id)
+	}
+	return s.answerRepo.Delete(id)
+}
+
+// notify mails the author about a moderation decision and leaves the
+// subject in their inbox. actorID is empty for automatic decisions.
+// Failures are logged: the decision has already been applied.
+func (s *ModerationService) notify(targetType string, targetID uint, userID, actorID, subject, text string) {
+	if userID == "" || userID == domain.DeletedUserID {
+		return
+	}
+
+	notification := &domain.Notification{UserID: userID, Type: domain.NotificationModeration, Message: subject}
+	if actorID != "" {
+		notification.ActorID = &actorID
+	}
+	if targetType == domain.ContentQuestion {
+		notification.QuestionID = &targetID
+	} else {
+		notification.AnswerID = &targetID
+	}
+	s.notifications.Notify(notification)
+
+	user, err := s.userRepo.GetByID(userID)
+	if err == nil {
+		err = s.mailer.Send(mailer.Message{To: user.Email, Subject: subject, Text: text})
+	}
+	if err != nil {
+		s.logger.Error("failed to notify author of moderation decision",
+			slog.String("user_id", userID),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+func formatID(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
@@ -0,0 +1,448 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/mailer/mailertest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	modTestAuthorID    = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	modTestModeratorID = "9b2f3a1e-4c5d-4e6f-8a7b-1c2d3e4f5a6b"
+)
+
+type fakeQuestionRepository struct {
+	questions  []*domain.Question
+	failCreate error
+}
+
+func (r *fakeQuestionRepository) Create(question *domain.Question) error {
+	if r.failCreate != nil {
+		return r.failCreate
+	}
+	question.ID = uint(len(r.questions) + 1)
+	if question.CreatedAt.IsZero() {
+		question.CreatedAt = time.Now()
+	}
+	r.questions = append(r.questions, question)
+	return nil
+}
+
+func (r *fakeQuestionRepository) get(id uint) (*domain.Question, error) {
+	for _, q := range r.questions {
+		if q.ID == id {
+			return q, nil
+		}
+	}
+	return nil, domain.ErrQuestionNotFound
+}
+
+func (r *fakeQuestionRepository) GetByID(id uint) (*domain.Question, error) {
+	q, err := r.get(id)
+	if err != nil {
+		return nil, err
+	}
+	copied := *q
+	copied.Answers = slices.Clone(q.Answers)
+	return &copied, nil
+}
+
+func (r *fakeQuestionRepository) GetAll(filter domain.QuestionFilter) ([]domain.Question, error) {
+	var questions []domain.Question
+	for _, q := range r.questions {
+		if q.HiddenAt == nil && (filter.Status == "" || q.Status == filter.Status) {
+			questions = append(questions, *q)
+		}
+	}
+	return questions, nil
+}
+
+func (r *fakeQuestionRepository) Latest(limit int) ([]domain.Question, error) {
+	var questions []domain.Question
+	for i := len(r.questions) - 1; i >= 0 && len(questions) < limit; i-- {
+		if q := r.questions[i]; q.HiddenAt == nil {
+			questions = append(questions, *q)
+		}
+	}
+	return questions, nil
+}
+
+func (r *fakeQuestionRepository) ListByUser(userID string) ([]domain.Question, error) {
+	var questions []domain.Question
+	for _, q := range r.questions {
+		if q.UserID != nil && *q.UserID == userID {
+			questions = append(questions, *q)
+		}
+	}
+	return questions, nil
+}
+
+func (r *fakeQuestionRepository) SetAcceptedAnswer(id uint, answerID *uint) error {
+	q, err := r.get(id)
+	if err == nil {
+		q.AcceptedAnswerID = answerID
+	}
+	return err
+}
+
+func (r *fakeQuestionRepository) SetHidden(id uint, hiddenAt *time.Time) error {
+	q, err := r.get(id)
+	if err == nil {
+		q.HiddenAt = hiddenAt
+	}
+	return err
+}
+
+func (r *fakeQuestionRepository) SetStatus(id uint, status domain.QuestionStatus) error {
+	q, err := r.get(id)
+	if err == nil {
+		q.QuestionStatus = status
+	}
+	return err
+}
+
+func (r *fakeQuestionRepository) Delete(id uint) error {
+	for i, q := range r.questions {
+		if q.ID == id {
+			r.questions = append(r.questions[:i], r.questions[i+1:]...)
+			return nil
+		}
+	}
+	return domain.ErrQuestionNotFound
+}
+
+type fakeFlagRepository struct {
+	flags []*domain.Flag
+}
+
+func (r *fakeFlagRepository) Create(flag *domain.Flag) (bool, error) {
+	for _, f := range r.flags {
+		if f.TargetType == flag.TargetType && f.TargetID == flag.TargetID && f.Status == domain.FlagStatusOpen &&
+			f.ReporterID != nil && flag.ReporterID != nil && *f.ReporterID == *flag.ReporterID {
+			return false, nil
+		}
+	}
+	flag.ID = uint(len(r.flags) + 1)
+	r.flags = append(r.flags, flag)
+	return true, nil
+}
+
+func (r *fakeFlagRepository) CountOpen(targetType string, targetID uint) (int64, error) {
+	var count int64
+	for _, f := range r.flags {
+		if f.TargetType == targetType && f.TargetID == targetID && f.Status == domain.FlagStatusOpen {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeFlagRepository) Queue(targetType string, limit, offset int) ([]domain.ModerationItem, error) {
+	var items []domain.ModerationItem
+	index := make(map[string]int)
+	for _, f := range r.flags {
+		if f.Status != domain.FlagStatusOpen || targetType != "" && f.TargetType != targetType {
+			continue
+		}
+		key := fmt.Sprintf("%s/%d", f.TargetType, f.TargetID)
+		i, ok := index[key]
+		if !ok {
+			i = len(items)
+			index[key] = i
+			items = append(items, domain.ModerationItem{
+				TargetType:     f.TargetType,
+				TargetID:       f.TargetID,
+				Reasons:        make(map[string]int),
+				FirstFlaggedAt: f.CreatedAt,
+			})
+		}
+		items[i].FlagCount++
+		items[i].Reasons[f.Reason]++
+		items[i].LastFlaggedAt = f.CreatedAt
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].FlagCount > items[j].FlagCount })
+	if offset > len(items) {
+		offset = len(items)
+	}
+	items = items[offset:]
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items, nil
+}
+
+func (r *fakeFlagRepository) Resolve(targetType string, targetID uint, status, resolvedBy string, at time.Time) (int64, error) {
+	var count int64
+	for _, f := range r.flags {
+		if f.TargetType == targetType && f.TargetID == targetID && f.Status == domain.FlagStatusOpen {
+			f.Status = status
+			f.ResolvedBy = &resolvedBy
+			f.ResolvedAt = &at
+			count++
+		}
+	}
+	return count, nil
+}
+
+// modTestReporters are distinct users, as each user may flag an item once.
+var modTestReporters = []string{
+	"11111111-1111-4111-8111-111111111111",
+	"22222222-2222-4222-8222-222222222222",
+	"33333333-3333-4333-8333-333333333333",
+}
+
+type moderationTestEnv struct {
+	questions *fakeQuestionRepository
+	answers   *fakeAnswerRepository
+	flags     *fakeFlagRepository
+	audit     *fakeAuditRepository
+	inbox     *fakeNotificationRepository
+	mail      *mailertest.Recorder
+	svc       *ModerationService
+}
+
+func newModerationTestEnv(t *testing.T) *moderationTestEnv {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	author := modTestAuthorID
+	env := &moderationTestEnv{
+		questions: &fakeQuestionRepository{},
+		answers:   &fakeAnswerRepository{},
+		flags:     &fakeFlagRepository{},
+		audit:     &fakeAuditRepository{},
+		inbox:     &fakeNotificationRepository{},
+		mail:      mailertest.NewRecorder(),
+	}
+	require.NoError(t, env.questions.Create(&domain.Question{UserID: &author, Text: "What is the capital of France?"}))
+	require.NoError(t, env.answers.Create(&domain.Answer{QuestionID: 1, UserID: modTestAuthorID, Text: "Buy cheap watches"}))
+
+	users := newFakeUserRepository(&domain.User{ID: modTestAuthorID, Email: "author@example.com"})
+	env.svc = NewModerationService(env.flags, env.questions, env.answers, users, env.mail,
+		NewAuditService(env.audit, logger), NewNotificationService(env.inbox, logger), &config.ModerationConfig{HideThreshold: 2}, logger)
+	return env
+}
+
+func (e *moderationTestEnv) flag(t *testing.T, targetType string, reporters int) {
+	t.Helper()
+	for _, reporter := range modTestReporters[:reporters] {
+		_, err := e.svc.Flag(targetType, 1, reporter, &domain.CreateFlagRequest{Reason: domain.FlagReasonSpam})
+		require.NoError(t, err)
+	}
+}
+
+func TestModerationService_Flag_Validation(t *testing.T) {
+	env := newModerationTestEnv(t)
+
+	_, err := env.svc.Flag(domain.ContentQuestion, 1, modTestReporters[0], &domain.CreateFlagRequest{Reason: "boring"})
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+
+	_, err = env.svc.Flag(domain.ContentQuestion, 1, modTestAuthorID, &domain.CreateFlagRequest{Reason: domain.FlagReasonSpam})
+	assert.ErrorIs(t, err, domain.ErrInvalidInput, "authors cannot flag their own content")
+
+	_, err = env.svc.Flag(domain.ContentQuestion, 99, modTestReporters[0], &domain.CreateFlagRequest{Reason: domain.FlagReasonSpam})
+	assert.ErrorIs(t, err, domain.ErrQuestionNotFound)
+
+	_, err = env.svc.Flag(domain.ContentQuestion, 1, modTestReporters[0], &domain.CreateFlagRequest{Reason: domain.FlagReasonSpam})
+	require.NoError(t, err)
+	_, err = env.svc.Flag(domain.ContentQuestion, 1, modTestReporters[0], &domain.CreateFlagRequest{Reason: domain.FlagReasonAbuse})
+	assert.ErrorIs(t, err, domain.ErrInvalidInput, "a user flags an item once")
+}
+
+func TestModerationService_Flag_HidesAtThreshold(t *testing.T) {
+	env := newModerationTestEnv(t)
+
+	env.flag(t, domain.ContentAnswer, 1)
+	answer, err := env.answers.GetByID(1)
+	require.NoError(t, err)
+	assert.Nil(t, answer.HiddenAt)
+	assert.Empty(t, env.mail.Messages())
+
+	_, err = env.svc.Flag(domain.ContentAnswer, 1, modTestReporters[1], &domain.CreateFlagRequest{Reason: domain.FlagReasonAbuse})
+	require.NoError(t, err)
+	answer, err = env.answers.GetByID(1)
+	require.NoError(t, err)
+	assert.NotNil(t, answer.HiddenAt)
+
+	require.Len(t, env.mail.Messages(), 1)
+	assert.Equal(t, "author@example.com", env.mail.Messages()[0].To)
+	require.Len(t, env.audit.entries, 1)
+	assert.Equal(t, domain.AuditContentHidden, env.audit.entries[0].Action)
+	assert.Equal(t, domain.ActorSystem, env.audit.entries[0].ActorType)
+}
+
+func TestModerationService_Flag_ThresholdDisabled(t *testing.T) {
+	env := newModerationTestEnv(t)
+	env.svc.SetHideThreshold(0)
+
+	env.flag(t, domain.ContentQuestion, 3)
+
+	question, err := env.questions.GetByID(1)
+	require.NoError(t, err)
+	assert.Nil(t, question.HiddenAt)
+}
+
+func TestModerationService_Queue(t *testing.T) {
+	env := newModerationTestEnv(t)
+	env.flag(t, domain.ContentQuestion, 1)
+	env.flag(t, domain.ContentAnswer, 3)
+
+	items, err := env.svc.Queue("", 0, 0)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+
+	assert.Equal(t, domain.ContentAnswer, items[0].TargetType)
+	assert.Equal(t, 3, items[0].FlagCount)
+	assert.Equal(t, map[string]int{domain.FlagReasonSpam: 3}, items[0].Reasons)
+	assert.Equal(t, "Buy cheap watches", items[0].Text)
+	assert.True(t, items[0].Hidden)
+	require.NotNil(t, items[0].Author)
+	assert.Equal(t, modTestAuthorID, items[0].Author.ID)
+
+	items, err = env.svc.Queue(domain.ContentQuestion, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, uint(1), items[0].TargetID)
+
+	_, err = env.svc.Queue("user", 0, 0)
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+}
+
+func TestModerationService_Act_Dismiss(t *testing.T) {
+	env := newModerationTestEnv(t)
+	env.flag(t, domain.ContentAnswer, 2)
+
+	err := env.svc.Act(domain.ContentAnswer, 1, &domain.ModerationActionRequest{Action: domain.ModerationDismiss},
+		domain.RequestMeta{ActorID: modTestModeratorID})
+	require.NoError(t, err)
+
+	answer, err := env.answers.GetByID(1)
+	require.NoError(t, err)
+	assert.Nil(t, answer.HiddenAt, "dismissing restores hidden content")
+	for _, f := range env.flags.flags {
+		assert.Equal(t, domain.FlagStatusDismissed, f.Status)
+		require.NotNil(t, f.ResolvedBy)
+		assert.Equal(t, modTestModeratorID, *f.ResolvedBy)
+	}
+
+	items, err := env.svc.Queue("", 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, items)
+	assert.Len(t, env.mail.Messages(), 2, "hidden, then restored")
+}
+
+func TestModerationService_Flag_AgainAfterDismissal(t *testing.T) {
+	env := newModerationTestEnv(t)
+	env.flag(t, domain.ContentQuestion, 1)
+	require.NoError(t, env.svc.Act(domain.ContentQuestion, 1, &domain.ModerationActionRequest{Action: domain.ModerationDismiss},
+		domain.RequestMeta{ActorID: modTestModeratorID}))
+
+	_, err := env.svc.Flag(domain.ContentQuestion, 1, modTestReporters[0], &domain.CreateFlagRequest{Reason: domain.FlagReasonAbuse})
+	require.NoError(t, err, "a resolved flag does not stop the user from flagging again")
+	count, err := env.flags.CountOpen(domain.ContentQuestion, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestModerationService_Act_Delete(t *testing.T) {
+	env := newModerationTestEnv(t)
+	env.flag(t, domain.ContentAnswer, 1)
+
+	err := env.svc.Act(domain.ContentAnswer, 1, &domain.ModerationActionRequest{Action: domain.ModerationDelete, Note: "Spam"},
+		domain.RequestMeta{ActorID: modTestModeratorID})
+	require.NoError(t, err)
+
+	_, err = env.answers.GetByID(1)
+	assert.ErrorIs(t, err, domain.ErrAnswerNotFound)
+	assert.Equal(t, domain.FlagStatusActioned, env.flags.flags[0].Status)
+
+	require.Len(t, env.audit.entries, 1)
+	assert.Equal(t, domain.AuditAnswerDeleted, env.audit.entries[0].Action)
+	assert.Contains(t, string(env.audit.entries[0].Before), `"text_length":17`)
+	assert.NotContains(t, string(env.audit.entries[0].Before), "Buy cheap watches")
+
+	messages := env.mail.Messages()
+	require.Len(t, messages, 1)
+	assert.Contains(t, messages[0].Text, "Moderator's note: Spam")
+}
+
+func TestModerationService_Act_Lock(t *testing.T) {
+	env := newModerationTestEnv(t)
+
+	err := env.svc.Act(domain.ContentAnswer, 1, &domain.ModerationActionRequest{Action: domain.ModerationLock}, domain.RequestMeta{})
+	assert.ErrorIs(t, err, domain.ErrInvalidInput, "answers cannot be locked")
+
+	err = env.svc.Act(domain.ContentQuestion, 1, &domain.ModerationActionRequest{Action: domain.ModerationLock},
+		domain.RequestMeta{ActorID: modTestModeratorID})
+	require.NoError(t, err)
+
+	question, err := env.questions.GetByID(1)
+	require.NoError(t, err)
+	assert.Equal(t, domain.QuestionLocked, question.Status)
+	require.NotNil(t, question.StatusChangedBy)
+	assert.Equal(t, modTestModeratorID, *question.StatusChangedBy)
+
+	answers := NewAnswerService(env.answers, env.questions, newFakeUserRepository(), PostDeps{}, &config.Default().Validation)
+	_, err = answers.Create(1, &domain.CreateAnswerRequest{UserID: modTestReporters[0], Text: "Paris"})
+	assert.ErrorIs(t, err, domain.ErrQuestionClosed)
+}
+
+func TestModerationService_Act_Warn(t *testing.T) {
+	env := newModerationTestEnv(t)
+	env.flag(t, domain.ContentQuestion, 1)
+
+	err := env.svc.Act(domain.ContentQuestion, 1, &domain.ModerationActionRequest{Action: domain.ModerationWarn},
+		domain.RequestMeta{ActorID: modTestModeratorID})
+	require.NoError(t, err)
+
+	require.Len(t, env.audit.entries, 1)
+	assert.Equal(t, domain.AuditUserWarned, env.audit.entries[0].Action)
+	assert.Equal(t, modTestAuthorID, env.audit.entries[0].TargetID)
+	require.Len(t, env.mail.Messages(), 1)
+	assert.Equal(t, "A warning from the moderators", env.mail.Messages()[0].Subject)
+
+	require.Len(t, env.inbox.notifications, 1)
+	notification := env.inbox.notifications[0]
+	assert.Equal(t, modTestAuthorID, notification.UserID)
+	assert.Equal(t, domain.NotificationModeration, notification.Type)
+	assert.Equal(t, "A warning from the moderators", notification.Message)
+	require.NotNil(t, notification.QuestionID)
+	assert.Equal(t, uint(1), *notification.QuestionID)
+}
+
+func TestModerationService_Act_UnknownAction(t *testing.T) {
+	env := newModerationTestEnv(t)
+
+	err := env.svc.Act(domain.ContentQuestion, 1, &domain.ModerationActionRequest{Action: "ban"}, domain.RequestMeta{})
+
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+}
+
+func TestQuestionService_GetByID_HidesModeratedContent(t *testing.T) {
+	questions := &fakeQuestionRepository{}
+	hiddenAt := time.Now()
+	require.NoError(t, questions.Create(&domain.Question{Text: "What is the capital of France?", Answers: []domain.Answer{
+		{ID: 1, Text: "Paris"},
+		{ID: 2, Text: "Buy cheap watches", HiddenAt: &hiddenAt},
+	}}))
+	require.NoError(t, questions.Create(&domain.Question{Text: "Hidden question", HiddenAt: &hiddenAt}))
+	svc := NewQuestionService(questions, newFakeUserRepository(), PostDeps{}, &config.Default().Validation)
+
+	question, err := svc.GetByID(1)
+	require.NoError(t, err)
+	require.Len(t, question.Answers, 1)
+	assert.Equal(t, "Paris", question.Answers[0].Text)
+
+	_, err = svc.GetByID(2)
+	assert.ErrorIs(t, err, domain.ErrQuestionNotFound)
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/repository"
+)
+
+const (
+	defaultNotificationPageSize = 20
+	maxNotificationPageSize     = 100
+)
+
+// Notifier tells users about things that happened to their posts.
+type Notifier interface {
+	Notify(n *domain.Notification)
+}
+
+// NopNotifier is a Notifier that sends nothing.
+type NopNotifier struct{}
+
+func (NopNotifier) Notify(*domain.Notification) {}
+
+// NotificationService is the Notifier that fills users' inboxes.
+type NotificationService struct {
+	repo   repository.NotificationRepository
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+func NewNotificationService(repo repository.NotificationRepository, logger *slog.Logger) *NotificationService {
+	return &NotificationService{
+		repo:   repo,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Notify adds n to its user's inbox unless the user turned that kind of
+// notification off. Users are not notified about their own actions, and
+// nothing is sent to anonymous or erased authors. Failures are logged
+// rather than returned, as the action that caused the notification has
+// already happened.
+func (s *NotificationService) Notify(n *domain.Notification) {
+	if n.UserID == "" || n.UserID == domain.DeletedUserID {
+		return
+	}
+	if n.ActorID != nil && *n.ActorID == n.UserID {
+		return
+	}
+
+	enabled, err := s.enabled(n.UserID, n.Type)
+	if err == nil && enabled {
+		err = s.repo.Create(n)
+	}
+	if err != nil {
+		s.logger.Error("failed to create notification",
+			slog.String("user_id", n.UserID),
+			slog.String("type", n.Type),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+func (s *NotificationService) enabled(userID, notificationType string) (bool, error) {
+	prefs, err := s.repo.Preferences(userID)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range prefs {
+		if p.Type == notificationType {
+			return p.Enabled, nil
+		}
+	}
+	return true, nil
+}
+
+// List returns the user's notifications, newest first, with the number of
+// unread ones.
+func (s *NotificationService) List(filter domain.NotificationFilter) (*domain.NotificationPage, error) {
+	if filter.Limit < 0 || filter.Limit > maxNotificationPageSize {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", domain.ErrInvalidInput, maxNotificationPageSize)
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultNotificationPageSize
+	}
+
+	// One extra notification tells whether there is a next page.
+	pageSize := filter.Limit
+	filter.Limit++
+	notifications, err := s.repo.List(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+
+	page := &domain.NotificationPage{Notifications: notifications}
+	if len(notifications) > pageSize {
+		page.Notifications = notifications[:pageSize]
+		next := page.Notifications[pageSize-1].ID
+		page.NextCursor = &next
+	}
+	if page.Notifications == nil {
+		page.Notifications = []domain.Notification{}
+	}
+
+	if page.UnreadCount, err = s.UnreadCount(filter.UserID); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (s *NotificationService) UnreadCount(userID string) (int64, error) {
+	count, err := s.repo.CountUnread(userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	return count, nil
+}
+
+func (s *NotificationService) MarkRead(userID string, id uint) error {
+	return s.repo.MarkRead(userID, id, s.now())
+}
+
+func (s *NotificationService) MarkAllRead(userID string) error {
+	if _, err := s.repo.MarkAllRead(userID, s.now()); err != nil {
+		return fmt.Errorf("failed to mark notifications as read: %w", err)
+	}
+	return nil
+}
+
+// Preferences returns whether each kind of notification is enabled for the
+// user.
+func (s *NotificationService) Preferences(userID string) (map[string]bool, error) {
+	stored, err := s.repo.Preferences(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load notification preferences: %w", err)
+	}
+
+	prefs := make(map[string]bool, len(domain.NotificationTypes))
+	for _, t := range domain.NotificationTypes {
+		prefs[t] = true
+	}
+	for _, p := range stored {
+		prefs[p.Type] = p.Enabled
+	}
+	return prefs, nil
+}
+
+// UpdatePreferences changes the given kinds of notifications and leaves the
+// others as they are.
+func (s *NotificationService) UpdatePreferences(userID string, changes map[string]bool) (map[string]bool, error) {
+	var prefs []domain.NotificationPreference
+	for t, enabled := range changes {
+		if !slices.Contains(domain.NotificationTypes, t) {
+			return nil, fmt.Errorf("%w: notification type must be one of %s", domain.ErrInvalidInput,
+				strings.Join(domain.NotificationTypes, ", "))
+		}
+		prefs = append(prefs, domain.NotificationPreference{UserID: userID, Type: t, Enabled: enabled})
+	}
+
+	if err := s.repo.SetPreferences(prefs); err != nil {
+		return nil, fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+	return s.Preferences(userID)
+}
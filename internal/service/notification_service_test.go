@@ -0,0 +1,213 @@
+package service
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	notifyTestAuthorID   = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	notifyTestAnswererID = "11111111-1111-4111-8111-111111111111"
+)
+
+type fakeNotificationRepository struct {
+	notifications []domain.Notification
+	prefs         map[string]bool
+}
+
+func (r *fakeNotificationRepository) Create(notification *domain.Notification) error {
+	notification.ID = uint(len(r.notifications) + 1)
+	r.notifications = append(r.notifications, *notification)
+	return nil
+}
+
+func (r *fakeNotificationRepository) List(filter domain.NotificationFilter) ([]domain.Notification, error) {
+	var notifications []domain.Notification
+	for i := len(r.notifications) - 1; i >= 0 && len(notifications) < filter.Limit; i-- {
+		n := r.notifications[i]
+		if n.UserID != filter.UserID || (filter.UnreadOnly && n.ReadAt != nil) || (filter.Cursor > 0 && n.ID >= filter.Cursor) {
+			continue
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, nil
+}
+
+func (r *fakeNotificationRepository) CountUnread(userID string) (int64, error) {
+	var count int64
+	for _, n := range r.notifications {
+		if n.UserID == userID && n.ReadAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeNotificationRepository) MarkRead(userID string, id uint, at time.Time) error {
+	for i := range r.notifications {
+		if n := &r.notifications[i]; n.ID == id && n.UserID == userID {
+			if n.ReadAt == nil {
+				n.ReadAt = &at
+			}
+			return nil
+		}
+	}
+	return domain.ErrNotificationNotFound
+}
+
+func (r *fakeNotificationRepository) MarkAllRead(userID string, at time.Time) (int64, error) {
+	var count int64
+	for i := range r.notifications {
+		if n := &r.notifications[i]; n.UserID == userID && n.ReadAt == nil {
+			n.ReadAt = &at
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeNotificationRepository) Preferences(userID string) ([]domain.NotificationPreference, error) {
+	var prefs []domain.NotificationPreference
+	for key, enabled := range r.prefs {
+		if u, t, _ := strings.Cut(key, " "); u == userID {
+			prefs = append(prefs, domain.NotificationPreference{UserID: u, Type: t, Enabled: enabled})
+		}
+	}
+	return prefs, nil
+}
+
+func (r *fakeNotificationRepository) SetPreferences(prefs []domain.NotificationPreference) error {
+	if r.prefs == nil {
+		r.prefs = make(map[string]bool)
+	}
+	for _, p := range prefs {
+		r.prefs[p.UserID+" "+p.Type] = p.Enabled
+	}
+	return nil
+}
+
+func newTestNotificationService() (*NotificationService, *fakeNotificationRepository) {
+	repo := &fakeNotificationRepository{}
+	return NewNotificationService(repo, slog.New(slog.NewTextHandler(io.Discard, nil))), repo
+}
+
+func TestNotificationService_Notify(t *testing.T) {
+	svc, repo := newTestNotificationService()
+	actor := notifyTestAnswererID
+	self := notifyTestAuthorID
+
+	svc.Notify(&domain.Notification{UserID: notifyTestAuthorID, Type: domain.NotificationAnswer, ActorID: &actor, Message: "new answer"})
+	svc.Notify(&domain.Notification{UserID: notifyTestAuthorID, Type: domain.NotificationAnswer, ActorID: &self, Message: "own answer"})
+	svc.Notify(&domain.Notification{UserID: domain.DeletedUserID, Type: domain.NotificationAnswer, Message: "erased"})
+	require.Len(t, repo.notifications, 1)
+	assert.Equal(t, "new answer", repo.notifications[0].Message)
+
+	_, err := svc.UpdatePreferences(notifyTestAuthorID, map[string]bool{domain.NotificationAnswer: false})
+	require.NoError(t, err)
+	svc.Notify(&domain.Notification{UserID: notifyTestAuthorID, Type: domain.NotificationAnswer, ActorID: &actor, Message: "muted"})
+	svc.Notify(&domain.Notification{UserID: notifyTestAuthorID, Type: domain.NotificationModeration, Message: "hidden"})
+	require.Len(t, repo.notifications, 2)
+	assert.Equal(t, "hidden", repo.notifications[1].Message)
+}
+
+func TestNotificationService_ListAndMarkRead(t *testing.T) {
+	svc, _ := newTestNotificationService()
+	for range 3 {
+		svc.Notify(&domain.Notification{UserID: notifyTestAuthorID, Type: domain.NotificationModeration, Message: "hidden"})
+	}
+	svc.Notify(&domain.Notification{UserID: notifyTestAnswererID, Type: domain.NotificationModeration, Message: "other inbox"})
+
+	page, err := svc.List(domain.NotificationFilter{UserID: notifyTestAuthorID, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, page.Notifications, 2)
+	assert.Equal(t, uint(3), page.Notifications[0].ID)
+	require.NotNil(t, page.NextCursor)
+	assert.Equal(t, int64(3), page.UnreadCount)
+
+	page, err = svc.List(domain.NotificationFilter{UserID: notifyTestAuthorID, Limit: 2, Cursor: *page.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, page.Notifications, 1)
+	assert.Nil(t, page.NextCursor)
+
+	require.NoError(t, svc.MarkRead(notifyTestAuthorID, 1))
+	assert.ErrorIs(t, svc.MarkRead(notifyTestAuthorID, 4), domain.ErrNotificationNotFound, "another user's notification")
+
+	page, err = svc.List(domain.NotificationFilter{UserID: notifyTestAuthorID, UnreadOnly: true})
+	require.NoError(t, err)
+	assert.Len(t, page.Notifications, 2)
+	assert.Equal(t, int64(2), page.UnreadCount)
+
+	require.NoError(t, svc.MarkAllRead(notifyTestAuthorID))
+	count, err := svc.UnreadCount(notifyTestAuthorID)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	count, err = svc.UnreadCount(notifyTestAnswererID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	_, err = svc.List(domain.NotificationFilter{UserID: notifyTestAuthorID, Limit: 1000})
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+}
+
+func TestNotificationService_Preferences(t *testing.T) {
+	svc, _ := newTestNotificationService()
+
+	prefs, err := svc.Preferences(notifyTestAuthorID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{
+		domain.NotificationAnswer:     true,
+		domain.NotificationAccepted:   true,
+		domain.NotificationModeration: true,
+		domain.NotificationMention:    true,
+	}, prefs)
+
+	prefs, err = svc.UpdatePreferences(notifyTestAuthorID, map[string]bool{domain.NotificationAccepted: false})
+	require.NoError(t, err)
+	assert.False(t, prefs[domain.NotificationAccepted])
+	assert.True(t, prefs[domain.NotificationAnswer])
+
+	_, err = svc.UpdatePreferences(notifyTestAuthorID, map[string]bool{"comment.created": true})
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+}
+
+func TestNotifications_AnswerAndAccept(t *testing.T) {
+	notifications, repo := newTestNotificationService()
+	questions := &fakeQuestionRepository{}
+	answers := &fakeAnswerRepository{}
+	author := notifyTestAuthorID
+	require.NoError(t, questions.Create(&domain.Question{UserID: &author, Text: "What is the capital of France?"}))
+
+	answerService := NewAnswerService(answers, questions, newFakeUserRepository(), PostDeps{Notifications: notifications}, &config.Default().Validation)
+	answer, err := answerService.Create(1, &domain.CreateAnswerRequest{UserID: notifyTestAnswererID, Text: "Paris"})
+	require.NoError(t, err)
+
+	require.Len(t, repo.notifications, 1)
+	assert.Equal(t, notifyTestAuthorID, repo.notifications[0].UserID)
+	assert.Equal(t, domain.NotificationAnswer, repo.notifications[0].Type)
+	require.NotNil(t, repo.notifications[0].AnswerID)
+	assert.Equal(t, answer.ID, *repo.notifications[0].AnswerID)
+
+	// The fake keeps answers apart from questions; attach it for AcceptAnswer.
+	question, err := questions.get(1)
+	require.NoError(t, err)
+	question.Answers = append(question.Answers, *answer)
+
+	questionService := NewQuestionService(questions, newFakeUserRepository(), PostDeps{Notifications: notifications}, &config.Default().Validation)
+	_, err = questionService.AcceptAnswer(1, &answer.ID, notifyTestAuthorID, domain.RoleUser)
+	require.NoError(t, err)
+	_, err = questionService.AcceptAnswer(1, &answer.ID, notifyTestAuthorID, domain.RoleUser)
+	require.NoError(t, err)
+
+	require.Len(t, repo.notifications, 2, "accepting the same answer again sends nothing")
+	assert.Equal(t, notifyTestAnswererID, repo.notifications[1].UserID)
+	assert.Equal(t, domain.NotificationAccepted, repo.notifications[1].Type)
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/oidc"
+	"hitalent-test/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// OIDCAuthorization starts a sign-in: the browser is redirected to URL and
+// FlowToken is kept in a cookie until the callback.
+type OIDCAuthorization struct {
+	URL          string
+	FlowToken    string
+	TTL          time.Duration
+	SecureCookie bool
+}
+
+type OIDCService struct {
+	providers    map[string]*oidc.Provider
+	cfg          *config.OIDCConfig
+	userRepo     repository.UserRepository
+	identityRepo repository.UserIdentityRepository
+	tokenService *TokenService
+	authService  *AuthService
+	audit        Auditor
+	now          func() time.Time
+}
+
+func NewOIDCService(
+	cfg *config.OIDCConfig,
+	client *http.Client,
+	userRepo repository.UserRepository,
+	identityRepo repository.UserIdentityRepository,
+	tokenService *TokenService,
+	authService *AuthService,
+	audit Auditor,
+) *OIDCService {
+	s := &OIDCService{
+		providers:    make(map[string]*oidc.Provider),
+		cfg:          cfg,
+		userRepo:     userRepo,
+		identityRepo: identityRepo,
+		tokenService: tokenService,
+		authService:  authService,
+		audit:        audit,
+		now:          time.Now,
+	}
+	for name, p := range cfg.Providers {
+		s.providers[name] = oidc.NewProvider(oidc.Config{
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+		}, client)
+	}
+	return s
+}
+
+// Begin prepares the redirect to the provider with fresh state, nonce and
+// PKCE verifier.
+func (s *OIDCService) Begin(ctx context.Context, providerName string) (*OIDCAuthorization, error) {
+	provider, err := s.provider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	var values [3]string
+	for i := range values {
+		if values[i], err = oidc.NewVerifier(); err != nil {
+			return nil, err
+		}
+	}
+	state, nonce, verifier := values[0], values[1], values[2]
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, oidc.Challenge(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start sign-in with %s: %w", providerName, err)
+	}
+
+	flowToken, err := s.tokenService.GenerateOIDCFlowToken(providerName, state, nonce, verifier, s.cfg.FlowTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate flow token: %w", err)
+	}
+
+	return &OIDCAuthorization{
+		URL:          authURL,
+		FlowToken:    flowToken,
+		TTL:          s.cfg.FlowTTL,
+		SecureCookie: strings.HasPrefix(s.cfg.Providers[providerName].RedirectURL, "https://"),
+	}, nil
+}
+
+// Complete handles the provider's callback: it checks state against the
+// flow token, redeems the code, validates the ID token and signs in the
+// linked, matched or newly provisioned user.
+func (s *OIDCService) Complete(
+	ctx context.Context,
+	providerName, flowToken, state, code string,
+	meta domain.RequestMeta,
+) (*domain.AuthResponse, *domain.MFAChallenge, error) {
+	provider, err := s.provider(providerName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	flow, err := s.tokenService.VerifyOIDCFlowToken(flowToken, providerName)
+	if err != nil || state == "" || flow.State != state {
+		return nil, nil, fmt.Errorf("%w: invalid or expired sign-in attempt", domain.ErrUnauthorized)
+	}
+
+	idToken, err := provider.Exchange(ctx, code, flow.Verifier)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: sign-in with %s failed: %v", domain.ErrUnauthorized, providerName, err)
+	}
+
+	claims, err := provider.VerifyIDToken(ctx, idToken, flow.Nonce)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: sign-in with %s failed: %v", domain.ErrUnauthorized, providerName, err)
+	}
+
+	user, err := s.resolveUser(providerName, claims, meta)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s.authService.StartSession(user, meta)
+}
+
+func (s *OIDCService) Identities(userID string) ([]domain.UserIdentity, error) {
+	identities, err := s.identityRepo.ListByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list identities: %w", err)
+	}
+	return identities, nil
+}
+
+// resolveUser finds the user linked to the provider subject. Unlinked
+// subjects are linked to the account with the same email if both sides
+// have verified it, or get a new account.
+func (s *OIDCService) resolveUser(providerName string, claims *oidc.Claims, meta domain.RequestMeta) (*domain.User, error) {
+	now := s.now()
+	email := strings.ToLower(strings.TrimSpace(claims.Email))
+
+	identity, err := s.identityRepo.Get(providerName, claims.Subject)
+	if err == nil {
+		if err := s.identityRepo.RecordLogin(identity.ID, email, now); err != nil {
+			return nil, fmt.Errorf("failed to update identity: %w", err)
+		}
+		return s.userRepo.GetByID(identity.UserID)
+	}
+	if !errors.Is(err, domain.ErrUserNotFound) {
+		return nil, fmt.Errorf("failed to get identity: %w", err)
+	}
+
+	if email == "" || !claims.EmailVerified {
+		return nil, fmt.Errorf("%w: %s did not provide a verified email", domain.ErrForbidden, providerName)
+	}
+
+	user, err := s.userRepo.GetByEmail(email)
+	switch {
+	case err == nil:
+		// Linking to an unverified account would hand it to whoever
+		// registered the address first, possibly someone else.
+		if !user.EmailVerified {
+			return nil, fmt.Errorf("%w: verify the email of the existing account before signing in with %s",
+				domain.ErrForbidden, providerName)
+		}
+	case errors.Is(err, domain.ErrUserNotFound):
+		if user, err = s.provision(email, now); err != nil {
+			return nil, err
+		}
+		s.audit.Record(meta, domain.AuditRegister, domain.AuditTargetUser, user.ID, nil, userSnapshot(user))
+	default:
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if err := s.identityRepo.Create(&domain.UserIdentity{
+		UserID:      user.ID,
+		Provider:    providerName,
+		Subject:     claims.Subject,
+		Email:       email,
+		LastLoginAt: now,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to link identity: %w", err)
+	}
+
+	return user, nil
+}
+
+// provision creates an account for a new user. It gets an unusable random
+// password; the user may set one through the password reset flow.
+func (s *OIDCService) provision(email string, now time.Time) (*domain.User, error) {
+	if !s.cfg.AutoProvision {
+		return nil, fmt.Errorf("%w: no account for %s", domain.ErrForbidden, email)
+	}
+
+	hash, err := hashPassword(uuid.New().String())
+	if err != nil {
+		return nil, err
+	}
+
+	user := &domain.User{
+		ID:              uuid.New().String(),
+		Email:           email,
+		PasswordHash:    hash,
+		Role:            domain.RoleUser,
+		EmailVerified:   true,
+		EmailVerifiedAt: &now,
+	}
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	return user, nil
+}
+
+func (s *OIDCService) provider(name string) (*oidc.Provider, error) {
+	provider, ok := s.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown identity provider %q", domain.ErrInvalidInput, name)
+	}
+	return provider, nil
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/oidc/oidctest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeUserIdentityRepository struct {
+	identities []*domain.UserIdentity
+}
+
+func (r *fakeUserIdentityRepository) Create(identity *domain.UserIdentity) error {
+	identity.ID = uint(len(r.identities) + 1)
+	r.identities = append(r.identities, identity)
+	return nil
+}
+
+func (r *fakeUserIdentityRepository) Get(provider, subject string) (*domain.UserIdentity, error) {
+	for _, i := range r.identities {
+		if i.Provider == provider && i.Subject == subject {
+			copied := *i
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+func (r *fakeUserIdentityRepository) ListByUser(userID string) ([]domain.UserIdentity, error) {
+	var identities []domain.UserIdentity
+	for _, i := range r.identities {
+		if i.UserID == userID {
+			identities = append(identities, *i)
+		}
+	}
+	return identities, nil
+}
+
+func (r *fakeUserIdentityRepository) RecordLogin(id uint, email string, at time.Time) error {
+	for _, i := range r.identities {
+		if i.ID == id {
+			i.Email = email
+			i.LastLoginAt = at
+		}
+	}
+	return nil
+}
+
+type oidcTestEnv struct {
+	idp *oidctest.Provider
+	// google is a second provider, registered as "google"; idp is "corp".
+	google     *oidctest.Provider
+	users      *fakeUserRepository
+	identities *fakeUserIdentityRepository
+	svc        *OIDCService
+}
+
+func newOIDCTestEnv(t *testing.T, users ...*domain.User) *oidcTestEnv {
+	t.Helper()
+	idp := oidctest.NewProvider("qa-service", "client-secret")
+	t.Cleanup(idp.Close)
+	google := oidctest.NewProvider("qa-service.apps.googleusercontent.com", "google-secret")
+	t.Cleanup(google.Close)
+
+	cfg := config.Default()
+	cfg.JWT.Secret = "test-secret"
+	cfg.OIDC.Providers = map[string]*config.OIDCProviderConfig{
+		"corp": {
+			Issuer:       idp.Issuer(),
+			ClientID:     "qa-service",
+			ClientSecret: "client-secret",
+			RedirectURL:  "http://localhost:8080/auth/oidc/corp/callback",
+			Scopes:       []string{"openid", "email"},
+		},
+		"google": {
+			Issuer:       google.Issuer(),
+			ClientID:     "qa-service.apps.googleusercontent.com",
+			ClientSecret: "google-secret",
+			RedirectURL:  "https://qa.example.com/auth/oidc/google/callback",
+			Scopes:       []string{"openid", "email"},
+		},
+	}
+
+	env := &oidcTestEnv{
+		idp:        idp,
+		google:     google,
+		users:      newFakeUserRepository(users...),
+		identities: &fakeUserIdentityRepository{},
+	}
+	now := time.Now()
+	tokenService := NewTokenService(&cfg.JWT)
+	mfa := NewMFAService(env.users, &fakeRecoveryCodeRepository{}, &cfg.MFA)
+	auth := NewAuthService(env.users, tokenService, NewRefreshTokenStore(),
+		newTestThrottle(newFakeLoginAttemptRepository(), &now), mfa, NopAuditor{}, &cfg.Validation)
+	env.svc = NewOIDCService(&cfg.OIDC, &http.Client{Timeout: 5 * time.Second},
+		env.users, env.identities, tokenService, auth, NopAuditor{})
+	return env
+}
+
+// signIn runs the whole browser flow against the "corp" stand-in provider.
+func (e *oidcTestEnv) signIn(t *testing.T) (*domain.AuthResponse, error) {
+	t.Helper()
+	return e.signInWith(t, "corp")
+}
+
+func (e *oidcTestEnv) signInWith(t *testing.T, providerName string) (*domain.AuthResponse, error) {
+	t.Helper()
+	ctx := context.Background()
+
+	authorization, err := e.svc.Begin(ctx, providerName)
+	require.NoError(t, err)
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authorization.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+
+	authResp, _, err := e.svc.Complete(ctx, providerName, authorization.FlowToken,
+		callback.Query().Get("state"), callback.Query().Get("code"), domain.RequestMeta{})
+	return authResp, err
+}
+
+func TestOIDCService_ProvisionsNewUser(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	env.idp.SetUser(oidctest.User{Subject: "alice", Email: "Alice@Example.com", EmailVerified: true})
+
+	authResp, err := env.signIn(t)
+	require.NoError(t, err)
+	assert.NotEmpty(t, authResp.AccessToken)
+	assert.Equal(t, "alice@example.com", authResp.User.Email)
+	assert.True(t, authResp.User.EmailVerified)
+
+	identities, err := env.svc.Identities(authResp.User.ID)
+	require.NoError(t, err)
+	require.Len(t, identities, 1)
+	assert.Equal(t, "corp", identities[0].Provider)
+	assert.Equal(t, "alice", identities[0].Subject)
+
+	// A second sign-in uses the linked identity, even after an email change.
+	env.idp.SetUser(oidctest.User{Subject: "alice", Email: "alice@new.example.com", EmailVerified: true})
+	again, err := env.signIn(t)
+	require.NoError(t, err)
+	assert.Equal(t, authResp.User.ID, again.User.ID)
+}
+
+func TestOIDCService_LinksVerifiedAccount(t *testing.T) {
+	env := newOIDCTestEnv(t, &domain.User{
+		ID: "550e8400-e29b-41d4-a716-446655440000", Email: "bob@example.com", EmailVerified: true,
+	})
+	env.idp.SetUser(oidctest.User{Subject: "bob", Email: "bob@example.com", EmailVerified: true})
+
+	authResp, err := env.signIn(t)
+	require.NoError(t, err)
+	assert.Equal(t, "550e8400-e29b-41d4-a716-446655440000", authResp.User.ID)
+	assert.Len(t, env.identities.identities, 1)
+}
+
+func TestOIDCService_LinksTwoProvidersToOneUser(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	env.idp.SetUser(oidctest.User{Subject: "alice-corp", Email: "alice@example.com", EmailVerified: true})
+	env.google.SetUser(oidctest.User{Subject: "1078", Email: "Alice@example.com", EmailVerified: true})
+
+	first, err := env.signInWith(t, "corp")
+	require.NoError(t, err)
+	second, err := env.signInWith(t, "google")
+	require.NoError(t, err)
+	assert.Equal(t, first.User.ID, second.User.ID)
+
+	identities, err := env.svc.Identities(first.User.ID)
+	require.NoError(t, err)
+	require.Len(t, identities, 2)
+	assert.Equal(t, "corp", identities[0].Provider)
+	assert.Equal(t, "alice-corp", identities[0].Subject)
+	assert.Equal(t, "google", identities[1].Provider)
+	assert.Equal(t, "1078", identities[1].Subject)
+
+	// Each provider goes on signing in through its own identity.
+	again, err := env.signInWith(t, "google")
+	require.NoError(t, err)
+	assert.Equal(t, first.User.ID, again.User.ID)
+	assert.Len(t, env.identities.identities, 2)
+}
+
+func TestOIDCService_SecureCookiePerProvider(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	ctx := context.Background()
+
+	corp, err := env.svc.Begin(ctx, "corp")
+	require.NoError(t, err)
+	assert.False(t, corp.SecureCookie)
+	google, err := env.svc.Begin(ctx, "google")
+	require.NoError(t, err)
+	assert.True(t, google.SecureCookie)
+	assert.Contains(t, google.URL, env.google.Issuer())
+	assert.Contains(t, corp.URL, env.idp.Issuer())
+}
+
+func TestOIDCService_RefusesUnverifiedEmails(t *testing.T) {
+	env := newOIDCTestEnv(t, &domain.User{
+		ID: "550e8400-e29b-41d4-a716-446655440000", Email: "carol@example.com",
+	})
+
+	env.idp.SetUser(oidctest.User{Subject: "carol", Email: "carol@example.com", EmailVerified: true})
+	_, err := env.signIn(t)
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+
+	env.idp.SetUser(oidctest.User{Subject: "dave", Email: "dave@example.com", EmailVerified: false})
+	_, err = env.signIn(t)
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+
+	assert.Empty(t, env.identities.identities)
+}
+
+func TestOIDCService_RejectsStateMismatch(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	ctx := context.Background()
+
+	authorization, err := env.svc.Begin(ctx, "corp")
+	require.NoError(t, err)
+
+	_, _, err = env.svc.Complete(ctx, "corp", authorization.FlowToken, "forged-state", "code", domain.RequestMeta{})
+	assert.ErrorIs(t, err, domain.ErrUnauthorized)
+
+	_, err = env.svc.Begin(ctx, "unknown")
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/mailer"
+	"hitalent-test/internal/repository"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type PasswordService struct {
+	userRepo      repository.UserRepository
+	tokenRepo     repository.UserTokenRepository
+	refreshTokens *RefreshTokenStore
+	mailer        mailer.Mailer
+	mailCfg       *config.MailConfig
+	ttl           time.Duration
+	audit         Auditor
+	limits        *config.ValidationConfig
+}
+
+func NewPasswordService(
+	userRepo repository.UserRepository,
+	tokenRepo repository.UserTokenRepository,
+	refreshTokens *RefreshTokenStore,
+	mailer mailer.Mailer,
+	mailCfg *config.MailConfig,
+	tokensCfg *config.TokensConfig,
+	audit Auditor,
+	limits *config.ValidationConfig,
+) *PasswordService {
+	return &PasswordService{
+		userRepo:      userRepo,
+		tokenRepo:     tokenRepo,
+		refreshTokens: refreshTokens,
+		mailer:        mailer,
+		mailCfg:       mailCfg,
+		ttl:           tokensCfg.PasswordResetTTL,
+		audit:         audit,
+		limits:        limits,
+	}
+}
+
+// Forgot mails a password reset token to the account with the given email.
+// Unknown emails are silently ignored so the endpoint does not reveal which
+// addresses are registered.
+func (s *PasswordService) Forgot(email string) error {
+	user, err := s.userRepo.GetByEmail(strings.ToLower(strings.TrimSpace(email)))
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	now := time.Now()
+	if err := s.tokenRepo.InvalidateForUser(user.ID, domain.TokenPurposePasswordReset, now); err != nil {
+		return fmt.Errorf("failed to invalidate reset tokens: %w", err)
+	}
+
+	token, err := newOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	if err := s.tokenRepo.Create(&domain.UserToken{
+		UserID:    user.ID,
+		Purpose:   domain.TokenPurposePasswordReset,
+		TokenHash: hashToken(token),
+		ExpiresAt: now.Add(s.ttl),
+	}); err != nil {
+		return fmt.Errorf("failed to store reset token: %w", err)
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", strings.TrimRight(s.mailCfg.LinkBaseURL, "/"), token)
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Text: fmt.Sprintf("Someone asked to reset the password of your account.\n\n"+
+			"Open the link below to choose a new password:\n\n%s\n\n"+
+			"Or send this token to POST /auth/password/reset:\n\n%s\n\n"+
+			"The link expires in %s. If it wasn't you, ignore this email.\n", link, token, s.ttl),
+	})
+}
+
+// Reset redeems a reset token, sets the new password and signs the user out
+// everywhere.
+func (s *PasswordService) Reset(token, newPassword string, meta domain.RequestMeta) error {
+	if err := validatePassword(newPassword, s.limits); err != nil {
+		return err
+	}
+
+	invalid := fmt.Errorf("%w: invalid or expired reset token", domain.ErrInvalidInput)
+
+	stored, err := s.tokenRepo.GetByHash(domain.TokenPurposePasswordReset, hashToken(strings.TrimSpace(token)))
+	if errors.Is(err, domain.ErrInvalidInput) {
+		return invalid
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get reset token: %w", err)
+	}
+
+	now := time.Now()
+	if stored.UsedAt != nil || stored.ExpiresAt.Before(now) {
+		return invalid
+	}
+
+	ok, err := s.tokenRepo.MarkUsed(stored.ID, now)
+	if err != nil {
+		return fmt.Errorf("failed to consume reset token: %w", err)
+	}
+	if !ok {
+		return invalid
+	}
+
+	user, err := s.userRepo.GetByID(stored.UserID)
+	if err != nil {
+		return err
+	}
+
+	// Following the mailed link proves ownership of the address.
+	if !user.EmailVerified {
+		user.EmailVerified = true
+		user.EmailVerifiedAt = &now
+	}
+
+	meta.ActorID = user.ID
+	return s.setPassword(user, newPassword, domain.AuditPasswordReset, meta)
+}
+
+// Change sets a new password for a signed-in user after checking the
+// current one, and revokes all of the user's refresh tokens.
+func (s *PasswordService) Change(userID, oldPassword, newPassword string, meta domain.RequestMeta) error {
+	if err := validatePassword(newPassword, s.limits); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(oldPassword)); err != nil {
+		return fmt.Errorf("%w: current password is incorrect", domain.ErrInvalidInput)
+	}
+
+	return s.setPassword(user, newPassword, domain.AuditPasswordChanged, meta)
+}
+
+func (s *PasswordService) setPassword(user *domain.User, password, action string, meta domain.RequestMeta) error {
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	user.PasswordHash = hash
+	if err := s.userRepo.Update(user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	s.refreshTokens.DeleteByUser(user.ID)
+	s.audit.Record(meta, action, domain.AuditTargetUser, user.ID, nil, map[string]bool{"sessions_revoked": true})
+	return nil
+}
+
+func newOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/mailer/mailertest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type fakeUserRepository struct {
+	mu    sync.Mutex
+	users map[string]*domain.User
+	// erased records the content policy each erased user was erased with.
+	erased map[string]string
+}
+
+func newFakeUserRepository(users ...*domain.User) *fakeUserRepository {
+	r := &fakeUserRepository{users: make(map[string]*domain.User), erased: make(map[string]string)}
+	for _, u := range users {
+		r.users[u.ID] = u
+	}
+	return r
+}
+
+func (r *fakeUserRepository) Create(user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *fakeUserRepository) GetByID(id string) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u, ok := r.users[id]; ok {
+		copied := *u
+		return &copied, nil
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+func (r *fakeUserRepository) GetByEmail(email string) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.Email == email {
+			copied := *u
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+func (r *fakeUserRepository) GetByIDs(ids []string) ([]domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var users []domain.User
+	for _, id := range ids {
+		if u, ok := r.users[id]; ok {
+			users = append(users, *u)
+		}
+	}
+	return users, nil
+}
+
+func (r *fakeUserRepository) FindByHandles(handles []string) ([]domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var users []domain.User
+	for _, u := range r.users {
+		local, _, _ := strings.Cut(u.Email, "@")
+		if slices.Contains(handles, strings.ToLower(u.DisplayName)) || slices.Contains(handles, strings.ToLower(local)) {
+			users = append(users, *u)
+		}
+	}
+	return users, nil
+}
+
+func (r *fakeUserRepository) Stats(id string) (*domain.UserStats, error) {
+	return &domain.UserStats{}, nil
+}
+
+func (r *fakeUserRepository) Update(user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+func (r *fakeUserRepository) ListDueForDeletion(before time.Time) ([]domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var users []domain.User
+	for _, u := range r.users {
+		if u.DeletionScheduledAt != nil && !u.DeletionScheduledAt.After(before) {
+			users = append(users, *u)
+		}
+	}
+	return users, nil
+}
+
+func (r *fakeUserRepository) Erase(id string, contentPolicy string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[id]; !ok {
+		return domain.ErrUserNotFound
+	}
+	delete(r.users, id)
+	r.erased[id] = contentPolicy
+	return nil
+}
+
+type fakeUserTokenRepository struct {
+	tokens []*domain.UserToken
+}
+
+func (r *fakeUserTokenRepository) Create(token *domain.UserToken) error {
+	token.ID = uint(len(r.tokens) + 1)
+	r.tokens = append(r.tokens, token)
+	return nil
+}
+
+func (r *fakeUserTokenRepository) GetByHash(purpose, hash string) (*domain.UserToken, error) {
+	for _, t := range r.tokens {
+		if t.Purpose == purpose && t.TokenHash == hash {
+			copied := *t
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrInvalidInput
+}
+
+func (r *fakeUserTokenRepository) MarkUsed(id uint, at time.Time) (bool, error) {
+	for _, t := range r.tokens {
+		if t.ID == id && t.UsedAt == nil {
+			t.UsedAt = &at
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeUserTokenRepository) InvalidateForUser(userID, purpose string, at time.Time) error {
+	for _, t := range r.tokens {
+		if t.UserID == userID && t.Purpose == purpose && t.UsedAt == nil {
+			t.UsedAt = &at
+		}
+	}
+	return nil
+}
+
+func (r *fakeUserTokenRepository) DeleteExpired(before time.Time) error {
+	return nil
+}
+
+func newTestPasswordService(t *testing.T) (*PasswordService, *fakeUserRepository, *mailertest.Recorder, *RefreshTokenStore) {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("old-password"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	users := newFakeUserRepository(&domain.User{
+		ID:           "550e8400-e29b-41d4-a716-446655440000",
+		Email:        "user@example.com",
+		PasswordHash: string(hash),
+	})
+	recorder := mailertest.NewRecorder()
+	refreshTokens := NewRefreshTokenStore()
+	cfg := config.Default()
+
+	svc := NewPasswordService(users, &fakeUserTokenRepository{}, refreshTokens, recorder,
+		&cfg.Mail, &cfg.Tokens, NopAuditor{}, &cfg.Validation)
+	return svc, users, recorder, refreshTokens
+}
+
+var resetTokenPattern = regexp.MustCompile(`token=([A-Za-z0-9_\-]+)`)
+
+func TestPasswordService_ForgotAndReset(t *testing.T) {
+	svc, users, recorder, refreshTokens := newTestPasswordService(t)
+	refreshTokens.Save("refresh", &RefreshTokenInfo{
+		UserID:    "550e8400-e29b-41d4-a716-446655440000",
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+
+	require.NoError(t, svc.Forgot("User@Example.com"))
+
+	messages := recorder.Messages()
+	require.Len(t, messages, 1)
+	match := resetTokenPattern.FindStringSubmatch(messages[0].Text)
+	require.Len(t, match, 2)
+
+	require.NoError(t, svc.Reset(match[1], "new-password", domain.RequestMeta{}))
+
+	user, err := users.GetByID("550e8400-e29b-41d4-a716-446655440000")
+	require.NoError(t, err)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte("new-password")))
+	assert.True(t, user.EmailVerified)
+	_, ok := refreshTokens.Get("refresh")
+	assert.False(t, ok)
+
+	err = svc.Reset(match[1], "another-password", domain.RequestMeta{})
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+}
+
+func TestPasswordService_ForgotUnknownEmail(t *testing.T) {
+	svc, _, recorder, _ := newTestPasswordService(t)
+
+	require.NoError(t, svc.Forgot("nobody@example.com"))
+
+	assert.Empty(t, recorder.Messages())
+}
+
+func TestPasswordService_Change(t *testing.T) {
+	svc, users, _, _ := newTestPasswordService(t)
+	userID := "550e8400-e29b-41d4-a716-446655440000"
+
+	err := svc.Change(userID, "wrong-password", "new-password", domain.RequestMeta{})
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+
+	err = svc.Change(userID, "old-password", "short", domain.RequestMeta{})
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+
+	require.NoError(t, svc.Change(userID, "old-password", "new-password", domain.RequestMeta{}))
+	user, _ := users.GetByID(userID)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte("new-password")))
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// lastUsedPrecision bounds how often last_used_at is written, so that a busy
+// bot does not cost an extra UPDATE on every request.
+const lastUsedPrecision = time.Minute
+
+const maxTokenNameLength = 100
+
+type PersonalAccessTokenService struct {
+	repo     repository.PersonalAccessTokenRepository
+	userRepo repository.UserRepository
+	audit    Auditor
+	logger   *slog.Logger
+	now      func() time.Time
+}
+
+func NewPersonalAccessTokenService(
+	repo repository.PersonalAccessTokenRepository,
+	userRepo repository.UserRepository,
+	audit Auditor,
+	logger *slog.Logger,
+) *PersonalAccessTokenService {
+	return &PersonalAccessTokenService{
+		repo:     repo,
+		userRepo: userRepo,
+		audit:    audit,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// Create issues a token for userID. The plaintext is only part of the
+// returned value and cannot be retrieved later.
+func (s *PersonalAccessTokenService) Create(userID string, req domain.CreatePersonalAccessTokenRequest) (*domain.CreatedPersonalAccessToken, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxTokenNameLength {
+		return nil, fmt.Errorf("%w: name must be 1 to %d characters", domain.ErrInvalidInput, maxTokenNameLength)
+	}
+
+	if len(req.Scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", domain.ErrInvalidInput)
+	}
+	for _, scope := range req.Scopes {
+		if !domain.IsValidScope(scope) {
+			return nil, fmt.Errorf("%w: unknown scope %q", domain.ErrInvalidInput, scope)
+		}
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(s.now()) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", domain.ErrInvalidInput)
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if slices.Contains(req.Scopes, domain.ScopeAdmin) && user.Role != domain.RoleAdmin {
+		return nil, fmt.Errorf("%w: only admins may create tokens with the admin scope", domain.ErrForbidden)
+	}
+	if slices.Contains(req.Scopes, domain.ScopeModerate) && user.Role != domain.RoleModerator && user.Role != domain.RoleAdmin {
+		return nil, fmt.Errorf("%w: only moderators may create tokens with the moderate scope", domain.ErrForbidden)
+	}
+
+	secret, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	plaintext := domain.PersonalAccessTokenPrefix + secret
+
+	scopes := slices.Clone(req.Scopes)
+	slices.Sort(scopes)
+	token := &domain.PersonalAccessToken{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		Name:      name,
+		Scopes:    slices.Compact(scopes),
+		TokenHash: hashToken(plaintext),
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := s.repo.Create(token); err != nil {
+		return nil, fmt.Errorf("failed to create token: %w", err)
+	}
+
+	return &domain.CreatedPersonalAccessToken{
+		Token:               plaintext,
+		PersonalAccessToken: token,
+	}, nil
+}
+
+func (s *PersonalAccessTokenService) List(userID string) ([]domain.PersonalAccessToken, error) {
+	tokens, err := s.repo.ListByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+func (s *PersonalAccessTokenService) Revoke(userID, id string, meta domain.RequestMeta) error {
+	if err := s.repo.Delete(userID, id); err != nil {
+		return err
+	}
+	s.audit.Record(meta, domain.AuditTokenRevoked, domain.AuditTargetToken, id, nil, nil)
+	return nil
+}
+
+// Authenticate resolves a personal access token to its owner. The user is
+// loaded on every call, so role changes apply to existing tokens at once.
+func (s *PersonalAccessTokenService) Authenticate(plaintext string) (*domain.User, *domain.PersonalAccessToken, error) {
+	invalid := fmt.Errorf("%w: invalid or expired token", domain.ErrUnauthorized)
+
+	token, err := s.repo.GetByHash(hashToken(plaintext))
+	if errors.Is(err, domain.ErrTokenNotFound) {
+		return nil, nil, invalid
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get token: %w", err)
+	}
+
+	now := s.now()
+	if token.ExpiresAt != nil && !token.ExpiresAt.After(now) {
+		return nil, nil, invalid
+	}
+
+	user, err := s.userRepo.GetByID(token.UserID)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil, nil, invalid
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= lastUsedPrecision {
+		if err := s.repo.TouchLastUsed(token.ID, now); err != nil {
+			s.logger.Warn("failed to record token use",
+				slog.String("token_id", token.ID),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+
+	return user, token, nil
+}
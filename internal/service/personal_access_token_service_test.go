@@ -0,0 +1,175 @@
+package service
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"hitalent-test/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	patTestUserID  = "550e8400-e29b-41d4-a716-446655440000"
+	patTestAdminID = "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+)
+
+type fakePersonalAccessTokenRepository struct {
+	tokens []*domain.PersonalAccessToken
+}
+
+func (r *fakePersonalAccessTokenRepository) Create(token *domain.PersonalAccessToken) error {
+	r.tokens = append(r.tokens, token)
+	return nil
+}
+
+func (r *fakePersonalAccessTokenRepository) GetByHash(hash string) (*domain.PersonalAccessToken, error) {
+	for _, t := range r.tokens {
+		if t.TokenHash == hash {
+			copied := *t
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrTokenNotFound
+}
+
+func (r *fakePersonalAccessTokenRepository) ListByUser(userID string) ([]domain.PersonalAccessToken, error) {
+	var tokens []domain.PersonalAccessToken
+	for _, t := range r.tokens {
+		if t.UserID == userID {
+			tokens = append(tokens, *t)
+		}
+	}
+	return tokens, nil
+}
+
+func (r *fakePersonalAccessTokenRepository) Delete(userID, id string) error {
+	for i, t := range r.tokens {
+		if t.ID == id && t.UserID == userID {
+			r.tokens = append(r.tokens[:i], r.tokens[i+1:]...)
+			return nil
+		}
+	}
+	return domain.ErrTokenNotFound
+}
+
+func (r *fakePersonalAccessTokenRepository) DeleteByUser(userID string) error {
+	kept := r.tokens[:0]
+	for _, t := range r.tokens {
+		if t.UserID != userID {
+			kept = append(kept, t)
+		}
+	}
+	r.tokens = kept
+	return nil
+}
+
+func (r *fakePersonalAccessTokenRepository) TouchLastUsed(id string, at time.Time) error {
+	for _, t := range r.tokens {
+		if t.ID == id {
+			t.LastUsedAt = &at
+		}
+	}
+	return nil
+}
+
+func newTestPATService() (*PersonalAccessTokenService, *fakePersonalAccessTokenRepository, *time.Time) {
+	repo := &fakePersonalAccessTokenRepository{}
+	users := newFakeUserRepository(
+		&domain.User{ID: patTestUserID, Email: "user@example.com", Role: domain.RoleUser},
+		&domain.User{ID: patTestAdminID, Email: "admin@example.com", Role: domain.RoleAdmin},
+	)
+	svc := NewPersonalAccessTokenService(repo, users, NopAuditor{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	now := time.Now()
+	svc.now = func() time.Time { return now }
+	return svc, repo, &now
+}
+
+func TestPersonalAccessTokenService_CreateAndAuthenticate(t *testing.T) {
+	svc, repo, _ := newTestPATService()
+
+	created, err := svc.Create(patTestUserID, domain.CreatePersonalAccessTokenRequest{
+		Name:   " ci bot ",
+		Scopes: []string{domain.ScopeWriteAnswers, domain.ScopeRead, domain.ScopeRead},
+	})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(created.Token, domain.PersonalAccessTokenPrefix))
+	assert.Equal(t, "ci bot", created.Name)
+	assert.Equal(t, []string{domain.ScopeRead, domain.ScopeWriteAnswers}, created.Scopes)
+	assert.NotContains(t, repo.tokens[0].TokenHash, created.Token)
+
+	user, token, err := svc.Authenticate(created.Token)
+	require.NoError(t, err)
+	assert.Equal(t, patTestUserID, user.ID)
+	assert.True(t, token.HasScope(domain.ScopeWriteAnswers))
+	assert.False(t, token.HasScope(domain.ScopeWriteQuestions))
+	assert.NotNil(t, repo.tokens[0].LastUsedAt)
+
+	_, _, err = svc.Authenticate(domain.PersonalAccessTokenPrefix + "unknown")
+	assert.ErrorIs(t, err, domain.ErrUnauthorized)
+}
+
+func TestPersonalAccessTokenService_Expiry(t *testing.T) {
+	svc, _, now := newTestPATService()
+
+	past := now.Add(-time.Minute)
+	_, err := svc.Create(patTestUserID, domain.CreatePersonalAccessTokenRequest{
+		Name: "expired", Scopes: []string{domain.ScopeRead}, ExpiresAt: &past,
+	})
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+
+	future := now.Add(time.Hour)
+	created, err := svc.Create(patTestUserID, domain.CreatePersonalAccessTokenRequest{
+		Name: "short-lived", Scopes: []string{domain.ScopeRead}, ExpiresAt: &future,
+	})
+	require.NoError(t, err)
+
+	*now = now.Add(2 * time.Hour)
+	_, _, err = svc.Authenticate(created.Token)
+	assert.ErrorIs(t, err, domain.ErrUnauthorized)
+}
+
+func TestPersonalAccessTokenService_ValidatesScopes(t *testing.T) {
+	svc, _, _ := newTestPATService()
+
+	_, err := svc.Create(patTestUserID, domain.CreatePersonalAccessTokenRequest{Name: "none"})
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+
+	_, err = svc.Create(patTestUserID, domain.CreatePersonalAccessTokenRequest{
+		Name: "bad", Scopes: []string{"delete:everything"},
+	})
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+
+	_, err = svc.Create(patTestUserID, domain.CreatePersonalAccessTokenRequest{
+		Name: "admin", Scopes: []string{domain.ScopeAdmin},
+	})
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+
+	_, err = svc.Create(patTestAdminID, domain.CreatePersonalAccessTokenRequest{
+		Name: "admin", Scopes: []string{domain.ScopeAdmin},
+	})
+	assert.NoError(t, err)
+}
+
+func TestPersonalAccessTokenService_Revoke(t *testing.T) {
+	svc, _, _ := newTestPATService()
+
+	created, err := svc.Create(patTestUserID, domain.CreatePersonalAccessTokenRequest{
+		Name: "bot", Scopes: []string{domain.ScopeRead},
+	})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, svc.Revoke(patTestAdminID, created.ID, domain.RequestMeta{}), domain.ErrTokenNotFound)
+	require.NoError(t, svc.Revoke(patTestUserID, created.ID, domain.RequestMeta{}))
+
+	_, _, err = svc.Authenticate(created.Token)
+	assert.ErrorIs(t, err, domain.ErrUnauthorized)
+
+	tokens, err := svc.List(patTestUserID)
+	require.NoError(t, err)
+	assert.Empty(t, tokens)
+}
@@ -1,58 +1,398 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"hitalent-test/internal/config"
 	"hitalent-test/internal/domain"
+	"hitalent-test/internal/markdown"
 	"hitalent-test/internal/repository"
+	"slices"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
+// PostDeps are the collaborators QuestionService and AnswerService hand new
+// and changed posts to. Fields left nil get the no-op implementation.
+type PostDeps struct {
+	Filter        ContentChecker
+	Audit         Auditor
+	Notifications Notifier
+	Watches       QuestionWatcher
+	Mentions      MentionTracker
+}
+
+func (d PostDeps) withDefaults() PostDeps {
+	if d.Filter == nil {
+		d.Filter = NopContentChecker{}
+	}
+	if d.Audit == nil {
+		d.Audit = NopAuditor{}
+	}
+	if d.Notifications == nil {
+		d.Notifications = NopNotifier{}
+	}
+	if d.Watches == nil {
+		d.Watches = NopQuestionWatcher{}
+	}
+	if d.Mentions == nil {
+		d.Mentions = NopMentionTracker{}
+	}
+	return d
+}
+
 type QuestionService struct {
-	repo repository.QuestionRepository
+	repo          repository.QuestionRepository
+	userRepo      repository.UserRepository
+	filter        ContentChecker
+	audit         Auditor
+	notifications Notifier
+	watches       QuestionWatcher
+	mentions      MentionTracker
+	limits        *config.ValidationConfig
+	// similarity is nil until SetSimilarity is called, which disables the
+	// duplicate check and related questions.
+	similarity atomic.Pointer[config.SimilarityConfig]
 }
 
-func NewQuestionService(repo repository.QuestionRepository) *QuestionService {
-	return &QuestionService{repo: repo}
+func NewQuestionService(
+	repo repository.QuestionRepository,
+	userRepo repository.UserRepository,
+	deps PostDeps,
+	limits *config.ValidationConfig,
+) *QuestionService {
+	deps = deps.withDefaults()
+	return &QuestionService{
+		repo:          repo,
+		userRepo:      userRepo,
+		filter:        deps.Filter,
+		audit:         deps.Audit,
+		notifications: deps.Notifications,
+		watches:       deps.Watches,
+		mentions:      deps.Mentions,
+		limits:        limits,
+	}
 }
 
+// SetSimilarity changes how near-duplicate questions are found.
+func (s *QuestionService) SetSimilarity(cfg config.SimilarityConfig) {
+	s.similarity.Store(&cfg)
+}
+
+// Create stores a new question. Unless req.Force is set, a question
+// resembling existing ones is refused with a *domain.SimilarQuestionsError
+// listing them.
 func (s *QuestionService) Create(req *domain.CreateQuestionRequest) (*domain.Question, error) {
 	if err := s.validateCreateRequest(req); err != nil {
 		return nil, err
 	}
 
+	text := strings.TrimSpace(req.Text)
+	mentions, err := s.mentions.Resolve(text)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg := s.similarity.Load(); cfg != nil && cfg.Limit > 0 && !req.Force {
+		similar, err := s.repo.FindSimilar(text, 0, cfg.Threshold, cfg.Limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find similar questions: %w", err)
+		}
+		if len(similar) > 0 {
+			return nil, &domain.SimilarQuestionsError{Questions: similar}
+		}
+	}
+
+	hiddenAt, reason, err := s.filter.Check(domain.ContentQuestion, req.UserID, text)
+	if err != nil {
+		return nil, err
+	}
+
 	question := &domain.Question{
-		Text: strings.TrimSpace(req.Text),
+		Text:           text,
+		TextHTML:       markdown.Render(text, mentionLinks(mentions)),
+		QuestionStatus: domain.QuestionStatus{Status: domain.QuestionOpen},
+		HiddenAt:       hiddenAt,
+		PendingReview:  hiddenAt != nil,
+	}
+	if req.UserID != "" {
+		question.UserID = &req.UserID
 	}
 
 	if err := s.repo.Create(question); err != nil {
 		return nil, fmt.Errorf("failed to create question: %w", err)
 	}
 
+	if hiddenAt != nil {
+		if err := s.filter.Hold(domain.ContentQuestion, question.ID, reason); err != nil {
+			return nil, err
+		}
+	}
+	s.watches.Follow(req.UserID, question.ID)
+	s.mentions.Save(domain.ContentQuestion, question.ID, question.ID, req.UserID, mentions, hiddenAt == nil)
+
+	if err := s.attachAuthors([]*domain.Question{question}); err != nil {
+		return nil, err
+	}
+	if err := s.mentions.Attach([]*domain.Question{question}); err != nil {
+		return nil, err
+	}
+
 	return question, nil
 }
 
+// GetByID returns a question with its answers. Content hidden by
+// moderation is left out.
 func (s *QuestionService) GetByID(id uint) (*domain.Question, error) {
-	return s.repo.GetByID(id)
+	question, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if question.HiddenAt != nil {
+		return nil, domain.ErrQuestionNotFound
+	}
+
+	question.Answers = slices.DeleteFunc(question.Answers, func(a domain.Answer) bool { return a.HiddenAt != nil })
+	for i := range question.Answers {
+		answer := &question.Answers[i]
+		answer.Accepted = question.AcceptedAnswerID != nil && *question.AcceptedAnswerID == answer.ID
+	}
+
+	if err := s.attachAuthors([]*domain.Question{question}); err != nil {
+		return nil, err
+	}
+	if err := s.mentions.Attach([]*domain.Question{question}); err != nil {
+		return nil, err
+	}
+
+	return question, nil
 }
 
-func (s *QuestionService) GetAll() ([]domain.Question, error) {
-	return s.repo.GetAll()
+// FindSimilar returns the questions resembling text, most similar first.
+func (s *QuestionService) FindSimilar(text string) ([]domain.SimilarQuestion, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("%w: text is required", domain.ErrInvalidInput)
+	}
+	if len(text) > s.limits.QuestionMaxLength {
+		return nil, fmt.Errorf("%w: text must not exceed %d characters", domain.ErrInvalidInput, s.limits.QuestionMaxLength)
+	}
+
+	cfg := s.similarity.Load()
+	if cfg == nil || cfg.Limit == 0 {
+		return []domain.SimilarQuestion{}, nil
+	}
+	similar, err := s.repo.FindSimilar(text, 0, cfg.Threshold, cfg.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find similar questions: %w", err)
+	}
+	if similar == nil {
+		similar = []domain.SimilarQuestion{}
+	}
+	return similar, nil
 }
 
-func (s *QuestionService) Delete(id uint) error {
-	return s.repo.Delete(id)
+// AttachRelated fills in the questions similar to the given one.
+func (s *QuestionService) AttachRelated(question *domain.Question) error {
+	cfg := s.similarity.Load()
+	if cfg == nil || cfg.Related == 0 {
+		return nil
+	}
+	related, err := s.repo.FindSimilar(question.Text, question.ID, cfg.Threshold, cfg.Related)
+	if err != nil {
+		return fmt.Errorf("failed to find related questions: %w", err)
+	}
+	question.Related = related
+	return nil
+}
+
+// GetAll lists visible questions, optionally only those with the given
+// status.
+func (s *QuestionService) GetAll(status string) ([]domain.Question, error) {
+	if status != "" && !slices.Contains(domain.QuestionStatuses, status) {
+		return nil, fmt.Errorf("%w: unknown status %q", domain.ErrInvalidInput, status)
+	}
+
+	questions, err := s.repo.GetAll(domain.QuestionFilter{Status: status})
+	if err != nil {
+		return nil, err
+	}
+
+	ptrs := make([]*domain.Question, len(questions))
+	for i := range questions {
+		ptrs[i] = &questions[i]
+	}
+	if err := s.attachAuthors(ptrs); err != nil {
+		return nil, err
+	}
+	if err := s.mentions.Attach(ptrs); err != nil {
+		return nil, err
+	}
+
+	return questions, nil
+}
+
+// AcceptAnswer marks one of the question's answers as accepted, or clears
+// the mark when answerID is nil. Only the question's author and moderators
+// may do so.
+func (s *QuestionService) AcceptAnswer(id uint, answerID *uint, actorID, actorRole string) (*domain.Question, error) {
+	question, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	isAuthor := question.UserID != nil && *question.UserID == actorID
+	if !isAuthor && actorRole != domain.RoleModerator && actorRole != domain.RoleAdmin {
+		return nil, fmt.Errorf("%w: only the author may accept an answer", domain.ErrForbidden)
+	}
+
+	if answerID != nil && !slices.ContainsFunc(question.Answers, func(a domain.Answer) bool { return a.ID == *answerID && a.HiddenAt == nil }) {
+		return nil, fmt.Errorf("%w: answer does not belong to this question", domain.ErrInvalidInput)
+	}
+
+	if err := s.repo.SetAcceptedAnswer(id, answerID); err != nil {
+		return nil, fmt.Errorf("failed to accept answer: %w", err)
+	}
+
+	if answerID != nil && (question.AcceptedAnswerID == nil || *question.AcceptedAnswerID != *answerID) {
+		i := slices.IndexFunc(question.Answers, func(a domain.Answer) bool { return a.ID == *answerID })
+		s.notifications.Notify(&domain.Notification{
+			UserID:     question.Answers[i].UserID,
+			Type:       domain.NotificationAccepted,
+			ActorID:    &actorID,
+			QuestionID: &question.ID,
+			AnswerID:   answerID,
+			Message:    fmt.Sprintf("Your answer to question #%d was accepted.", question.ID),
+		})
+	}
+
+	return s.GetByID(id)
+}
+
+// SetStatus closes, locks, reopens or marks a question as a duplicate.
+// Moderators may make any change. Authors may close their question, mark it
+// as a duplicate and reopen it if they were the ones who closed it; locked
+// questions are left to moderators.
+func (s *QuestionService) SetStatus(id uint, req *domain.UpdateQuestionStatusRequest, actorID, actorRole string, meta domain.RequestMeta) (*domain.Question, error) {
+	question, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if question.HiddenAt != nil {
+		return nil, domain.ErrQuestionNotFound
+	}
+
+	status := domain.QuestionStatus{Status: req.Status}
+	switch req.Status {
+	case domain.QuestionOpen, domain.QuestionLocked:
+	case domain.QuestionClosed:
+		if !slices.Contains(domain.CloseReasons, req.Reason) {
+			return nil, fmt.Errorf("%w: reason must be one of %s", domain.ErrInvalidInput, strings.Join(domain.CloseReasons, ", "))
+		}
+		status.CloseReason = req.Reason
+	case domain.QuestionDuplicate:
+		if req.DuplicateOfID == nil || *req.DuplicateOfID == id {
+			return nil, fmt.Errorf("%w: duplicate_of_id must refer to another question", domain.ErrInvalidInput)
+		}
+		original, err := s.repo.GetByID(*req.DuplicateOfID)
+		if errors.Is(err, domain.ErrQuestionNotFound) || (err == nil && original.HiddenAt != nil) {
+			return nil, fmt.Errorf("%w: question %d does not exist", domain.ErrInvalidInput, *req.DuplicateOfID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		status.DuplicateOfID = req.DuplicateOfID
+	default:
+		return nil, fmt.Errorf("%w: status must be one of %s", domain.ErrInvalidInput, strings.Join(domain.QuestionStatuses, ", "))
+	}
+
+	if actorRole != domain.RoleModerator && actorRole != domain.RoleAdmin {
+		if question.UserID == nil || *question.UserID != actorID {
+			return nil, fmt.Errorf("%w: only the author may change the status", domain.ErrForbidden)
+		}
+		if req.Status == domain.QuestionLocked || question.Status == domain.QuestionLocked {
+			return nil, fmt.Errorf("%w: only moderators may lock or unlock a question", domain.ErrForbidden)
+		}
+		closedBySomeoneElse := question.StatusChangedBy != nil && *question.StatusChangedBy != actorID
+		if req.Status == domain.QuestionOpen && !question.AcceptsAnswers() && closedBySomeoneElse {
+			return nil, fmt.Errorf("%w: the question was closed by a moderator", domain.ErrForbidden)
+		}
+	}
+
+	now := time.Now()
+	status.StatusChangedBy = &actorID
+	status.StatusChangedAt = &now
+	if err := s.repo.SetStatus(id, status); err != nil {
+		return nil, fmt.Errorf("failed to change question status: %w", err)
+	}
+
+	s.audit.Record(meta, domain.AuditQuestionStatus, domain.AuditTargetQuestion, strconv.FormatUint(uint64(id), 10),
+		question.QuestionStatus, status)
+
+	return s.GetByID(id)
+}
+
+// Delete removes a question with its answers. The audit entry keeps only
+// the question's metadata.
+func (s *QuestionService) Delete(id uint, meta domain.RequestMeta) error {
+	question, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+
+	s.audit.Record(meta, domain.AuditQuestionDeleted, domain.AuditTargetQuestion, strconv.FormatUint(uint64(id), 10), questionSnapshot(question), nil)
+	return nil
+}
+
+// attachAuthors fills in the author summaries of the questions and their
+// answers.
+func (s *QuestionService) attachAuthors(questions []*domain.Question) error {
+	var ids []string
+	for _, q := range questions {
+		if q.UserID != nil {
+			ids = append(ids, *q.UserID)
+		}
+		for _, a := range q.Answers {
+			ids = append(ids, a.UserID)
+		}
+	}
+	slices.Sort(ids)
+
+	authors, err := authorSummaries(s.userRepo, slices.Compact(ids))
+	if err != nil {
+		return err
+	}
+
+	for _, q := range questions {
+		if q.UserID != nil {
+			q.Author = authors[*q.UserID]
+		}
+		for i := range q.Answers {
+			q.Answers[i].Author = authors[q.Answers[i].UserID]
+		}
+	}
+	return nil
 }
 
 func (s *QuestionService) validateCreateRequest(req *domain.CreateQuestionRequest) error {
-	text := strings.TrimSpace(req.Text)
+	if maxSource := s.limits.QuestionMaxLength * s.limits.SourceLengthFactor; len(req.Text) > maxSource {
+		return fmt.Errorf("%w: question text must not exceed %d characters including markup", domain.ErrInvalidInput, maxSource)
+	}
+	text := plainText(req.Text)
 	if text == "" {
 		return fmt.Errorf("%w: question text is required", domain.ErrInvalidInput)
 	}
-	if len(text) < 10 {
-		return fmt.Errorf("%w: question text must be at least 10 characters", domain.ErrInvalidInput)
+	if len(text) < s.limits.QuestionMinLength {
+		return fmt.Errorf("%w: question text must be at least %d characters", domain.ErrInvalidInput, s.limits.QuestionMinLength)
 	}
-	if len(text) > 1000 {
-		return fmt.Errorf("%w: question text must not exceed 1000 characters", domain.ErrInvalidInput)
+	if len(text) > s.limits.QuestionMaxLength {
+		return fmt.Errorf("%w: question text must not exceed %d characters", domain.ErrInvalidInput, s.limits.QuestionMaxLength)
 	}
 	return nil
 }
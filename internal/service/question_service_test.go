@@ -1,8 +1,15 @@
 package service
 
 import (
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
 	"testing"
+	"time"
+	"unicode"
 
+	"hitalent-test/internal/config"
 	"hitalent-test/internal/domain"
 
 	"github.com/stretchr/testify/assert"
@@ -10,6 +17,53 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// FindSimilar mimics pg_trgm: texts are compared by the share of the
+// three-letter sequences of their words that they have in common.
+func (r *fakeQuestionRepository) FindSimilar(text string, excludeID uint, threshold float64, limit int) ([]domain.SimilarQuestion, error) {
+	var similar []domain.SimilarQuestion
+	for _, q := range r.questions {
+		if q.HiddenAt != nil || q.ID == excludeID {
+			continue
+		}
+		if score := trigramSimilarity(text, q.Text); score >= threshold {
+			similar = append(similar, domain.SimilarQuestion{ID: q.ID, Text: q.Text, Status: q.Status, Score: score, CreatedAt: q.CreatedAt})
+		}
+	}
+	sort.SliceStable(similar, func(i, j int) bool { return similar[i].Score > similar[j].Score })
+	if len(similar) > limit {
+		similar = similar[:limit]
+	}
+	return similar, nil
+}
+
+func trigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	common := 0
+	for t := range ta {
+		if tb[t] {
+			common++
+		}
+	}
+	total := len(ta) + len(tb) - common
+	if total == 0 {
+		return 0
+	}
+	return float64(common) / float64(total)
+}
+
+func trigrams(text string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+	return set
+}
+
 type MockQuestionRepository struct {
 	mock.Mock
 }
@@ -27,8 +81,16 @@ func (m *MockQuestionRepository) GetByID(id uint) (*domain.Question, error) {
 	return args.Get(0).(*domain.Question), args.Error(1)
 }
 
-func (m *MockQuestionRepository) GetAll() ([]domain.Question, error) {
-	args := m.Called()
+func (m *MockQuestionRepository) GetAll(filter domain.QuestionFilter) ([]domain.Question, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Question), args.Error(1)
+}
+
+func (m *MockQuestionRepository) Latest(limit int) ([]domain.Question, error) {
+	args := m.Called(limit)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -40,13 +102,44 @@ func (m *MockQuestionRepository) Delete(id uint) error {
 	return args.Error(0)
 }
 
+func (m *MockQuestionRepository) ListByUser(userID string) ([]domain.Question, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Question), args.Error(1)
+}
+
+func (m *MockQuestionRepository) FindSimilar(text string, excludeID uint, threshold float64, limit int) ([]domain.SimilarQuestion, error) {
+	args := m.Called(text, excludeID, threshold, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.SimilarQuestion), args.Error(1)
+}
+
+func (m *MockQuestionRepository) SetAcceptedAnswer(id uint, answerID *uint) error {
+	args := m.Called(id, answerID)
+	return args.Error(0)
+}
+
+func (m *MockQuestionRepository) SetHidden(id uint, hiddenAt *time.Time) error {
+	args := m.Called(id, hiddenAt)
+	return args.Error(0)
+}
+
+func (m *MockQuestionRepository) SetStatus(id uint, status domain.QuestionStatus) error {
+	args := m.Called(id, status)
+	return args.Error(0)
+}
+
 func TestQuestionService_Create_ValidQuestion(t *testing.T) {
 	mockRepo := new(MockQuestionRepository)
 	mockRepo.On("Create", mock.MatchedBy(func(q *domain.Question) bool {
 		return q.Text == "What is the capital of France?"
 	})).Return(nil)
 
-	service := NewQuestionService(mockRepo)
+	service := NewQuestionService(mockRepo, newFakeUserRepository(), PostDeps{}, &config.Default().Validation)
 
 	req := &domain.CreateQuestionRequest{
 		Text: "What is the capital of France?",
@@ -96,7 +189,7 @@ func TestQuestionService_Create_InvalidCases(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := new(MockQuestionRepository)
-			service := NewQuestionService(mockRepo)
+			service := NewQuestionService(mockRepo, newFakeUserRepository(), PostDeps{}, &config.Default().Validation)
 
 			req := &domain.CreateQuestionRequest{Text: tt.input}
 
@@ -117,7 +210,7 @@ func TestQuestionService_GetByID(t *testing.T) {
 	expectedQuestion := &domain.Question{ID: 1, Text: "Test"}
 	mockRepo.On("GetByID", uint(1)).Return(expectedQuestion, nil)
 
-	service := NewQuestionService(mockRepo)
+	service := NewQuestionService(mockRepo, newFakeUserRepository(), PostDeps{}, &config.Default().Validation)
 	question, err := service.GetByID(1)
 
 	require.NoError(t, err)
@@ -127,11 +220,309 @@ func TestQuestionService_GetByID(t *testing.T) {
 
 func TestQuestionService_Delete(t *testing.T) {
 	mockRepo := new(MockQuestionRepository)
+	mockRepo.On("GetByID", uint(1)).Return(&domain.Question{ID: 1, Text: "What is the capital of France?"}, nil)
 	mockRepo.On("Delete", uint(1)).Return(nil)
+	auditRepo := &fakeAuditRepository{}
+	audit := NewAuditService(auditRepo, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	service := NewQuestionService(mockRepo, newFakeUserRepository(), PostDeps{Audit: audit}, &config.Default().Validation)
+	err := service.Delete(1, domain.RequestMeta{ActorID: "7c9e6679-7425-40de-944b-e07fc1f90ae7"})
+
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	require.Len(t, auditRepo.entries, 1)
+	assert.Equal(t, domain.AuditQuestionDeleted, auditRepo.entries[0].Action)
+	assert.Equal(t, "1", auditRepo.entries[0].TargetID)
+	assert.Contains(t, string(auditRepo.entries[0].Before), `"id":1`)
+	assert.NotContains(t, string(auditRepo.entries[0].Before), "capital of France")
+}
 
-	service := NewQuestionService(mockRepo)
-	err := service.Delete(1)
+func TestQuestionService_Delete_AuditKeepsNoContent(t *testing.T) {
+	author := "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	repo := &fakeQuestionRepository{}
+	require.NoError(t, repo.Create(&domain.Question{
+		UserID: &author,
+		Author: &domain.UserSummary{ID: author, DisplayName: "Alice", AvatarURL: "https://example.com/alice.png"},
+		Text:   "What is the capital of France?",
+		Answers: []domain.Answer{{
+			ID:     1,
+			UserID: "11111111-1111-4111-8111-111111111111",
+			Author: &domain.UserSummary{DisplayName: "Bob"},
+			Text:   "Paris, of course",
+		}},
+	}))
+	answers := &fakeAnswerRepository{}
+	require.NoError(t, answers.Create(&domain.Answer{QuestionID: 1, UserID: author, Text: "Lyon, I think"}))
+	auditRepo := &fakeAuditRepository{}
+	audit := NewAuditService(auditRepo, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	questionService := NewQuestionService(repo, newFakeUserRepository(), PostDeps{Audit: audit}, &config.Default().Validation)
+	answerService := NewAnswerService(answers, repo, newFakeUserRepository(), PostDeps{Audit: audit}, &config.Default().Validation)
+	require.NoError(t, answerService.Delete(1, domain.RequestMeta{ActorID: author}))
+	require.NoError(t, questionService.Delete(1, domain.RequestMeta{ActorID: author}))
+
+	require.Len(t, auditRepo.entries, 2)
+	assert.Equal(t, domain.AuditAnswerDeleted, auditRepo.entries[0].Action)
+	assert.Equal(t, domain.AuditQuestionDeleted, auditRepo.entries[1].Action)
+	for _, entry := range auditRepo.entries {
+		before := string(entry.Before)
+		assert.Contains(t, before, author, entry.Action)
+		for _, content := range []string{"capital of France", "Paris", "Lyon", "Alice", "alice.png", "Bob"} {
+			assert.NotContains(t, before, content, entry.Action)
+		}
+	}
+}
+
+func TestQuestionService_Create_RecordsAuthor(t *testing.T) {
+	mockRepo := new(MockQuestionRepository)
+	mockRepo.On("Create", mock.MatchedBy(func(q *domain.Question) bool {
+		return q.UserID != nil && *q.UserID == "user-1"
+	})).Return(nil)
+	users := newFakeUserRepository(&domain.User{ID: "user-1", Email: "alice@example.com", DisplayName: "Alice"})
+
+	service := NewQuestionService(mockRepo, users, PostDeps{}, &config.Default().Validation)
+	question, err := service.Create(&domain.CreateQuestionRequest{
+		Text:   "What is the capital of France?",
+		UserID: "user-1",
+	})
 
 	require.NoError(t, err)
+	require.NotNil(t, question.Author)
+	assert.Equal(t, "Alice", question.Author.DisplayName)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestQuestionService_GetByID_MarksAcceptedAnswer(t *testing.T) {
+	mockRepo := new(MockQuestionRepository)
+	accepted := uint(2)
+	mockRepo.On("GetByID", uint(1)).Return(&domain.Question{
+		ID:               1,
+		Text:             "Test",
+		AcceptedAnswerID: &accepted,
+		Answers: []domain.Answer{
+			{ID: 1, UserID: "user-1"},
+			{ID: 2, UserID: "user-2"},
+		},
+	}, nil)
+	users := newFakeUserRepository(&domain.User{ID: "user-2", Email: "bob@example.com"})
+
+	service := NewQuestionService(mockRepo, users, PostDeps{}, &config.Default().Validation)
+	question, err := service.GetByID(1)
+
+	require.NoError(t, err)
+	assert.False(t, question.Answers[0].Accepted)
+	assert.Nil(t, question.Answers[0].Author)
+	assert.True(t, question.Answers[1].Accepted)
+	require.NotNil(t, question.Answers[1].Author)
+	assert.Equal(t, "user-2", question.Answers[1].Author.ID)
+}
+
+func TestQuestionService_AcceptAnswer(t *testing.T) {
+	author := "author"
+	answerID := uint(5)
+	otherAnswerID := uint(6)
+	newRepo := func() *MockQuestionRepository {
+		mockRepo := new(MockQuestionRepository)
+		mockRepo.On("GetByID", uint(1)).Return(&domain.Question{
+			ID:      1,
+			UserID:  &author,
+			Answers: []domain.Answer{{ID: answerID, QuestionID: 1, UserID: "someone"}},
+		}, nil)
+		return mockRepo
+	}
+
+	t.Run("author", func(t *testing.T) {
+		mockRepo := newRepo()
+		mockRepo.On("SetAcceptedAnswer", uint(1), &answerID).Return(nil)
+		service := NewQuestionService(mockRepo, newFakeUserRepository(), PostDeps{}, &config.Default().Validation)
+
+		_, err := service.AcceptAnswer(1, &answerID, author, domain.RoleUser)
+
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("moderator clears", func(t *testing.T) {
+		mockRepo := newRepo()
+		mockRepo.On("SetAcceptedAnswer", uint(1), (*uint)(nil)).Return(nil)
+		service := NewQuestionService(mockRepo, newFakeUserRepository(), PostDeps{}, &config.Default().Validation)
+
+		_, err := service.AcceptAnswer(1, nil, "moderator", domain.RoleModerator)
+
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("other user", func(t *testing.T) {
+		service := NewQuestionService(newRepo(), newFakeUserRepository(), PostDeps{}, &config.Default().Validation)
+
+		_, err := service.AcceptAnswer(1, &answerID, "intruder", domain.RoleUser)
+
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+	})
+
+	t.Run("answer of another question", func(t *testing.T) {
+		service := NewQuestionService(newRepo(), newFakeUserRepository(), PostDeps{}, &config.Default().Validation)
+
+		_, err := service.AcceptAnswer(1, &otherAnswerID, author, domain.RoleUser)
+
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+}
+
+func TestQuestionService_SetStatus(t *testing.T) {
+	author := "author"
+	newService := func(t *testing.T) (*QuestionService, *fakeQuestionRepository, *fakeAuditRepository) {
+		t.Helper()
+		repo := &fakeQuestionRepository{}
+		open := domain.QuestionStatus{Status: domain.QuestionOpen}
+		require.NoError(t, repo.Create(&domain.Question{UserID: &author, Text: "What is the capital of France?", QuestionStatus: open}))
+		require.NoError(t, repo.Create(&domain.Question{Text: "Capital of France?", QuestionStatus: open}))
+		audit := &fakeAuditRepository{}
+		logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+		return NewQuestionService(repo, newFakeUserRepository(), PostDeps{Audit: NewAuditService(audit, logger)}, &config.Default().Validation), repo, audit
+	}
+	duplicateOf := func(id uint) *uint { return &id }
+
+	t.Run("author closes and reopens", func(t *testing.T) {
+		service, _, audit := newService(t)
+
+		question, err := service.SetStatus(1, &domain.UpdateQuestionStatusRequest{Status: domain.QuestionClosed, Reason: domain.CloseAnswered},
+			author, domain.RoleUser, domain.RequestMeta{})
+		require.NoError(t, err)
+		assert.Equal(t, domain.QuestionClosed, question.Status)
+		assert.Equal(t, domain.CloseAnswered, question.CloseReason)
+
+		question, err = service.SetStatus(1, &domain.UpdateQuestionStatusRequest{Status: domain.QuestionOpen}, author, domain.RoleUser, domain.RequestMeta{})
+		require.NoError(t, err)
+		assert.Equal(t, domain.QuestionOpen, question.Status)
+		assert.Empty(t, question.CloseReason)
+
+		require.Len(t, audit.entries, 2)
+		assert.Equal(t, domain.AuditQuestionStatus, audit.entries[0].Action)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		service, _, _ := newService(t)
+		for name, req := range map[string]*domain.UpdateQuestionStatusRequest{
+			"unknown status":      {Status: "archived"},
+			"missing reason":      {Status: domain.QuestionClosed},
+			"unknown reason":      {Status: domain.QuestionClosed, Reason: "boring"},
+			"missing original":    {Status: domain.QuestionDuplicate},
+			"duplicate of itself": {Status: domain.QuestionDuplicate, DuplicateOfID: duplicateOf(1)},
+			"unknown original":    {Status: domain.QuestionDuplicate, DuplicateOfID: duplicateOf(99)},
+		} {
+			_, err := service.SetStatus(1, req, author, domain.RoleUser, domain.RequestMeta{})
+			assert.ErrorIs(t, err, domain.ErrInvalidInput, name)
+		}
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		service, _, _ := newService(t)
+
+		question, err := service.SetStatus(2, &domain.UpdateQuestionStatusRequest{Status: domain.QuestionDuplicate, DuplicateOfID: duplicateOf(1)},
+			"moderator", domain.RoleModerator, domain.RequestMeta{})
+
+		require.NoError(t, err)
+		require.NotNil(t, question.DuplicateOfID)
+		assert.Equal(t, uint(1), *question.DuplicateOfID)
+	})
+
+	t.Run("permissions", func(t *testing.T) {
+		service, _, _ := newService(t)
+		closed := &domain.UpdateQuestionStatusRequest{Status: domain.QuestionClosed, Reason: domain.CloseOffTopic}
+
+		_, err := service.SetStatus(1, closed, "intruder", domain.RoleUser, domain.RequestMeta{})
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		_, err = service.SetStatus(2, closed, "intruder", domain.RoleUser, domain.RequestMeta{})
+		assert.ErrorIs(t, err, domain.ErrForbidden, "anonymous questions are left to moderators")
+		_, err = service.SetStatus(1, &domain.UpdateQuestionStatusRequest{Status: domain.QuestionLocked}, author, domain.RoleUser, domain.RequestMeta{})
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+
+		_, err = service.SetStatus(1, closed, "moderator", domain.RoleModerator, domain.RequestMeta{})
+		require.NoError(t, err)
+		_, err = service.SetStatus(1, &domain.UpdateQuestionStatusRequest{Status: domain.QuestionOpen}, author, domain.RoleUser, domain.RequestMeta{})
+		assert.ErrorIs(t, err, domain.ErrForbidden, "authors cannot reopen a question a moderator closed")
+	})
+
+	t.Run("closed questions refuse answers", func(t *testing.T) {
+		service, repo, _ := newService(t)
+		_, err := service.SetStatus(1, &domain.UpdateQuestionStatusRequest{Status: domain.QuestionClosed, Reason: domain.CloseUnclear},
+			author, domain.RoleUser, domain.RequestMeta{})
+		require.NoError(t, err)
+
+		answers := NewAnswerService(&fakeAnswerRepository{}, repo, newFakeUserRepository(), PostDeps{}, &config.Default().Validation)
+		_, err = answers.Create(1, &domain.CreateAnswerRequest{UserID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", Text: "Paris"})
+		assert.ErrorIs(t, err, domain.ErrQuestionClosed)
+
+		open, err := service.GetAll(domain.QuestionOpen)
+		require.NoError(t, err)
+		assert.Len(t, open, 1)
+		closed, err := service.GetAll(domain.QuestionClosed)
+		require.NoError(t, err)
+		assert.Len(t, closed, 1)
+
+		_, err = service.GetAll("archived")
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+}
+
+func TestQuestionService_Similar(t *testing.T) {
+	repo := &fakeQuestionRepository{}
+	for _, text := range []string{
+		"What is the capital of France?",
+		"How do I reverse a slice in Go?",
+		"What is the capital city of France?",
+	} {
+		require.NoError(t, repo.Create(&domain.Question{Text: text}))
+	}
+	service := NewQuestionService(repo, newFakeUserRepository(), PostDeps{}, &config.Default().Validation)
+
+	t.Run("disabled until configured", func(t *testing.T) {
+		similar, err := service.FindSimilar("What is the capital of France")
+		require.NoError(t, err)
+		assert.Empty(t, similar)
+	})
+
+	service.SetSimilarity(config.Default().Similarity)
+
+	t.Run("search", func(t *testing.T) {
+		similar, err := service.FindSimilar("what's the capital of france")
+		require.NoError(t, err)
+		require.Len(t, similar, 2)
+		assert.Equal(t, uint(1), similar[0].ID)
+		assert.Greater(t, similar[0].Score, similar[1].Score)
+
+		_, err = service.FindSimilar("  ")
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+
+	t.Run("create refuses near-duplicates", func(t *testing.T) {
+		_, err := service.Create(&domain.CreateQuestionRequest{Text: "What is the capital of France??"})
+
+		var similarErr *domain.SimilarQuestionsError
+		require.ErrorAs(t, err, &similarErr)
+		assert.ErrorIs(t, err, domain.ErrSimilarQuestions)
+		assert.Len(t, similarErr.Questions, 2)
+		assert.Len(t, repo.questions, 3)
+	})
+
+	t.Run("create with force", func(t *testing.T) {
+		question, err := service.Create(&domain.CreateQuestionRequest{Text: "What is the capital of France??", Force: true})
+
+		require.NoError(t, err)
+		assert.Equal(t, uint(4), question.ID)
+	})
+
+	t.Run("related", func(t *testing.T) {
+		question, err := service.GetByID(1)
+		require.NoError(t, err)
+		require.NoError(t, service.AttachRelated(question))
+
+		ids := make([]uint, len(question.Related))
+		for i, related := range question.Related {
+			ids[i] = related.ID
+		}
+		assert.ElementsMatch(t, []uint{3, 4}, ids)
+	})
+}
@@ -0,0 +1,456 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/markdown"
+	"hitalent-test/internal/repository"
+	"hitalent-test/internal/stackexchange"
+)
+
+const (
+	// defaultStackExchangeSource names the StackExchange imports that do not
+	// give a source.
+	defaultStackExchangeSource = "stackexchange"
+	// maxDisplayNameLength is the size of the display_name column.
+	maxDisplayNameLength = 64
+)
+
+// StackExchangeImportService imports the questions and answers of a
+// StackExchange data dump, with a placeholder user for each of the dump's
+// users. Scores and comments have no counterpart here and are not
+// imported.
+type StackExchangeImportService struct {
+	repo      repository.TransferRepository
+	userRepo  repository.UserRepository
+	audit     Auditor
+	logger    *slog.Logger
+	batchSize int
+	now       func() time.Time
+}
+
+func NewStackExchangeImportService(
+	repo repository.TransferRepository,
+	userRepo repository.UserRepository,
+	audit Auditor,
+	logger *slog.Logger,
+) *StackExchangeImportService {
+	return &StackExchangeImportService{
+		repo:      repo,
+		userRepo:  userRepo,
+		audit:     audit,
+		logger:    logger,
+		batchSize: transferBatchSize,
+		now:       time.Now,
+	}
+}
+
+// Import reads Users.xml and Posts.xml from dump. Like bulk imports, posts
+// are matched to those of earlier imports from the same source, so
+// importing a dump again updates it. A checkpoint is saved after each
+// batch; when an import is interrupted, for instance by cancelling ctx,
+// the next import from the same source resumes from it.
+func (s *StackExchangeImportService) Import(ctx context.Context, dump fs.FS, source string, meta domain.RequestMeta) (*domain.ImportReport, error) {
+	if source == "" {
+		source = defaultStackExchangeSource
+	}
+	if !importSourcePattern.MatchString(source) {
+		return nil, fmt.Errorf("%w: source must be lowercase letters, digits, dots, dashes and underscores", domain.ErrInvalidInput)
+	}
+
+	checkpoint, err := s.repo.Checkpoint(source)
+	if errors.Is(err, domain.ErrCheckpointNotFound) {
+		checkpoint = &domain.ImportCheckpoint{Source: source, Phase: domain.ImportPhaseUsers}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load import checkpoint: %w", err)
+	} else {
+		s.logger.Info("resuming stackexchange import",
+			slog.String("source", source),
+			slog.String("phase", checkpoint.Phase),
+			slog.Int64("rows_done", checkpoint.RowsDone),
+		)
+	}
+
+	imp := &stackExchangeImporter{
+		s:          s,
+		ctx:        ctx,
+		dump:       dump,
+		checkpoint: checkpoint,
+		report:     &domain.ImportReport{Errors: []domain.ImportIssue{}, Warnings: []domain.ImportIssue{}},
+		authors:    make(map[string]string),
+	}
+	phases := []struct {
+		name string
+		run  func() error
+	}{
+		{domain.ImportPhaseUsers, imp.importUsers},
+		{domain.ImportPhasePosts, imp.importPosts},
+		{domain.ImportPhaseReferences, imp.linkAcceptedAnswers},
+	}
+	started := false
+	for _, phase := range phases {
+		if !started && phase.name != checkpoint.Phase {
+			continue
+		}
+		if started {
+			checkpoint.Phase, checkpoint.RowsDone = phase.name, 0
+		}
+		started = true
+		if err := phase.run(); err != nil {
+			return nil, err
+		}
+	}
+	if !started {
+		return nil, fmt.Errorf("unknown import phase %q", checkpoint.Phase)
+	}
+
+	if err := s.repo.DeleteCheckpoint(source); err != nil {
+		return nil, fmt.Errorf("failed to delete import checkpoint: %w", err)
+	}
+	s.audit.Record(meta, domain.AuditDataImported, domain.AuditTargetImport, source, nil, map[string]int{
+		"users_created":     imp.report.UsersCreated,
+		"questions_created": imp.report.QuestionsCreated,
+		"questions_updated": imp.report.QuestionsUpdated,
+		"answers_created":   imp.report.AnswersCreated,
+		"answers_updated":   imp.report.AnswersUpdated,
+		"skipped":           imp.report.Skipped,
+	})
+	return imp.report, nil
+}
+
+// stackExchangeImporter holds the state of one import.
+type stackExchangeImporter struct {
+	s          *StackExchangeImportService
+	ctx        context.Context
+	dump       fs.FS
+	checkpoint *domain.ImportCheckpoint
+	report     *domain.ImportReport
+	// authors maps the user IDs of the dump to the IDs of their
+	// placeholders, or of the deleted user for unknown ones.
+	authors map[string]string
+}
+
+type dumpRow[T any] struct {
+	line int
+	row  *T
+}
+
+// eachBatch reads the rows of a dump file in batches, skipping those that
+// the checkpoint counts as saved, and advances the checkpoint after each
+// batch is saved. A batch saved again after a crash is only updated.
+func eachBatch[T any](imp *stackExchangeImporter, name string, save func([]dumpRow[T]) error) error {
+	f, err := imp.dump.Open(name)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer f.Close()
+
+	reader := stackexchange.NewReader[T](f)
+	var rows int64
+	var batch []dumpRow[T]
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := save(batch); err != nil {
+			return err
+		}
+		batch = nil
+		imp.checkpoint.RowsDone = rows
+		imp.checkpoint.UpdatedAt = imp.s.now()
+		if err := imp.s.repo.SaveCheckpoint(imp.checkpoint); err != nil {
+			return fmt.Errorf("failed to save import checkpoint: %w", err)
+		}
+		imp.s.logger.Info("stackexchange import progress",
+			slog.String("source", imp.checkpoint.Source),
+			slog.String("phase", imp.checkpoint.Phase),
+			slog.Int64("rows_done", rows),
+		)
+		return nil
+	}
+
+	for {
+		if err := imp.ctx.Err(); err != nil {
+			return err
+		}
+		row, line, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		rows++
+		if rows <= imp.checkpoint.RowsDone {
+			continue
+		}
+		batch = append(batch, dumpRow[T]{line: line, row: row})
+		if len(batch) >= imp.s.batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+func (imp *stackExchangeImporter) fail(line, id int, message string) {
+	imp.report.Skipped++
+	imp.report.Errors = append(imp.report.Errors, domain.ImportIssue{Line: line, ID: uint(max(id, 0)), Message: message})
+}
+
+func (imp *stackExchangeImporter) warn(line, id int, message string) {
+	imp.report.Warnings = append(imp.report.Warnings, domain.ImportIssue{Line: line, ID: uint(max(id, 0)), Message: message})
+}
+
+func (imp *stackExchangeImporter) externalID(id int) string {
+	return imp.checkpoint.Source + ":" + strconv.Itoa(id)
+}
+
+// placeholderEmail identifies the placeholder of a dump user. The .invalid
+// domain never receives mail.
+func (imp *stackExchangeImporter) placeholderEmail(userID string) string {
+	return imp.checkpoint.Source + "-" + userID + "@users.invalid"
+}
+
+// importUsers creates a placeholder user for each user of the dump. The
+// placeholders cannot log in.
+func (imp *stackExchangeImporter) importUsers() error {
+	return eachBatch(imp, stackexchange.UsersFile, func(rows []dumpRow[stackexchange.User]) error {
+		for _, r := range rows {
+			userID := strconv.Itoa(r.row.ID)
+			email := imp.placeholderEmail(userID)
+			existing, err := imp.s.userRepo.GetByEmail(email)
+			if err == nil {
+				imp.authors[userID] = existing.ID
+				continue
+			}
+			if !errors.Is(err, domain.ErrUserNotFound) {
+				return fmt.Errorf("failed to look up user: %w", err)
+			}
+
+			user := &domain.User{
+				ID:           uuid.New().String(),
+				Email:        email,
+				PasswordHash: "!",
+				Role:         domain.RoleUser,
+				DisplayName:  truncateRunes(r.row.DisplayName, maxDisplayNameLength),
+				CreatedAt:    r.row.CreationDate.Time,
+			}
+			if err := imp.s.userRepo.Create(user); err != nil {
+				return fmt.Errorf("failed to create user: %w", err)
+			}
+			imp.authors[userID] = user.ID
+			imp.report.UsersCreated++
+		}
+		return nil
+	})
+}
+
+// author returns the placeholder of a dump user. Posts of deleted users,
+// and of users missing from Users.xml, go to the deleted user.
+func (imp *stackExchangeImporter) author(userID string, line, postID int) (string, error) {
+	if userID == "" {
+		return domain.DeletedUserID, nil
+	}
+	if id, ok := imp.authors[userID]; ok {
+		return id, nil
+	}
+	user, err := imp.s.userRepo.GetByEmail(imp.placeholderEmail(userID))
+	switch {
+	case err == nil:
+		imp.authors[userID] = user.ID
+	case errors.Is(err, domain.ErrUserNotFound):
+		imp.warn(line, postID, fmt.Sprintf("%s: user %s is not in %s; their posts are attributed to the deleted user",
+			stackexchange.PostsFile, userID, stackexchange.UsersFile))
+		imp.authors[userID] = domain.DeletedUserID
+	default:
+		return "", fmt.Errorf("failed to look up user: %w", err)
+	}
+	return imp.authors[userID], nil
+}
+
+// importPosts creates or updates the questions and answers of Posts.xml.
+// Answers to questions of earlier batches are matched to them by the
+// questions' external IDs.
+func (imp *stackExchangeImporter) importPosts() error {
+	return eachBatch(imp, stackexchange.PostsFile, func(rows []dumpRow[stackexchange.Post]) error {
+		var questions []*domain.Question
+		inBatch := make(map[int]*domain.Question)
+		// answers are those not in the batch of their question.
+		var answers []looseAnswer
+		var questionKeys, answerKeys []string
+
+		for _, r := range rows {
+			post := r.row
+			if post.PostTypeID != stackexchange.PostQuestion && post.PostTypeID != stackexchange.PostAnswer {
+				continue
+			}
+			if post.Body == "" && post.Title == "" {
+				imp.fail(r.line, post.ID, fmt.Sprintf("%s: post %d has no text", stackexchange.PostsFile, post.ID))
+				continue
+			}
+			authorID, err := imp.author(post.OwnerUserID, r.line, post.ID)
+			if err != nil {
+				return err
+			}
+			text := post.Markdown()
+			externalID := imp.externalID(post.ID)
+
+			if post.PostTypeID == stackexchange.PostQuestion {
+				question := &domain.Question{
+					UserID:         &authorID,
+					Text:           text,
+					TextHTML:       markdown.Render(text, nil),
+					QuestionStatus: domain.QuestionStatus{Status: domain.QuestionOpen},
+					CreatedAt:      post.CreationDate.Time,
+					ExternalID:     &externalID,
+				}
+				if !post.ClosedDate.IsZero() {
+					question.QuestionStatus = domain.QuestionStatus{Status: domain.QuestionClosed, CloseReason: domain.CloseOther}
+				}
+				questions = append(questions, question)
+				inBatch[post.ID] = question
+				questionKeys = append(questionKeys, externalID)
+				continue
+			}
+
+			answer := domain.Answer{
+				UserID:     authorID,
+				Text:       text,
+				TextHTML:   markdown.Render(text, nil),
+				CreatedAt:  post.CreationDate.Time,
+				ExternalID: &externalID,
+			}
+			answerKeys = append(answerKeys, externalID)
+			if question, ok := inBatch[post.ParentID]; ok {
+				question.Answers = append(question.Answers, answer)
+				continue
+			}
+			answers = append(answers, looseAnswer{line: r.line, postID: post.ID, parentID: post.ParentID, answer: &answer})
+			questionKeys = append(questionKeys, imp.externalID(post.ParentID))
+		}
+
+		existingQuestions, err := imp.s.repo.ExternalIDs(domain.ContentQuestion, questionKeys)
+		if err != nil {
+			return fmt.Errorf("failed to match imported questions: %w", err)
+		}
+		existingAnswers, err := imp.s.repo.ExternalIDs(domain.ContentAnswer, answerKeys)
+		if err != nil {
+			return fmt.Errorf("failed to match imported answers: %w", err)
+		}
+		countAnswer := func(a *domain.Answer) {
+			a.ID = existingAnswers[*a.ExternalID]
+			if a.ID == 0 {
+				imp.report.AnswersCreated++
+			} else {
+				imp.report.AnswersUpdated++
+			}
+		}
+
+		for _, q := range questions {
+			q.ID = existingQuestions[*q.ExternalID]
+			if q.ID == 0 {
+				imp.report.QuestionsCreated++
+			} else {
+				imp.report.QuestionsUpdated++
+			}
+			for i := range q.Answers {
+				countAnswer(&q.Answers[i])
+			}
+		}
+		var loose []*domain.Answer
+		for _, a := range answers {
+			a.answer.QuestionID = existingQuestions[imp.externalID(a.parentID)]
+			if a.answer.QuestionID == 0 {
+				imp.fail(a.line, a.postID, fmt.Sprintf("%s: answer %d belongs to question %d, which was not imported",
+					stackexchange.PostsFile, a.postID, a.parentID))
+				continue
+			}
+			countAnswer(a.answer)
+			loose = append(loose, a.answer)
+		}
+
+		if len(questions) == 0 && len(loose) == 0 {
+			return nil
+		}
+		if err := imp.s.repo.SaveBatch(questions, loose); err != nil {
+			return fmt.Errorf("failed to save imported posts: %w", err)
+		}
+		return nil
+	})
+}
+
+type looseAnswer struct {
+	line     int
+	postID   int
+	parentID int
+	answer   *domain.Answer
+}
+
+// linkAcceptedAnswers sets the accepted answers, which usually come after
+// their question in Posts.xml, in a second pass over it.
+func (imp *stackExchangeImporter) linkAcceptedAnswers() error {
+	return eachBatch(imp, stackexchange.PostsFile, func(rows []dumpRow[stackexchange.Post]) error {
+		var accepted []dumpRow[stackexchange.Post]
+		var questionKeys, answerKeys []string
+		for _, r := range rows {
+			if r.row.PostTypeID == stackexchange.PostQuestion && r.row.AcceptedAnswerID != 0 {
+				accepted = append(accepted, r)
+				questionKeys = append(questionKeys, imp.externalID(r.row.ID))
+				answerKeys = append(answerKeys, imp.externalID(r.row.AcceptedAnswerID))
+			}
+		}
+		if len(accepted) == 0 {
+			return nil
+		}
+
+		questionIDs, err := imp.s.repo.ExternalIDs(domain.ContentQuestion, questionKeys)
+		if err != nil {
+			return fmt.Errorf("failed to match imported questions: %w", err)
+		}
+		answerIDs, err := imp.s.repo.ExternalIDs(domain.ContentAnswer, answerKeys)
+		if err != nil {
+			return fmt.Errorf("failed to match imported answers: %w", err)
+		}
+
+		var refs []domain.ImportReference
+		for _, r := range accepted {
+			questionID, ok := questionIDs[imp.externalID(r.row.ID)]
+			if !ok {
+				continue
+			}
+			answerID, ok := answerIDs[imp.externalID(r.row.AcceptedAnswerID)]
+			if !ok {
+				imp.warn(r.line, r.row.ID, fmt.Sprintf("%s: accepted answer %d was not imported", stackexchange.PostsFile, r.row.AcceptedAnswerID))
+				continue
+			}
+			refs = append(refs, domain.ImportReference{QuestionID: questionID, AcceptedAnswerID: &answerID})
+		}
+		if len(refs) == 0 {
+			return nil
+		}
+		if err := imp.s.repo.SetReferences(refs); err != nil {
+			return fmt.Errorf("failed to link accepted answers: %w", err)
+		}
+		return nil
+	})
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"testing/fstest"
+
+	"hitalent-test/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stackExchangeDump() fstest.MapFS {
+	return fstest.MapFS{
+		"Users.xml": {Data: []byte(`<?xml version="1.0" encoding="utf-8"?>
+<users>
+  <row Id="-1" DisplayName="Community" CreationDate="2010-07-28T16:38:27.683" />
+  <row Id="5" DisplayName="Alice" CreationDate="2010-07-28T18:00:00.000" />
+</users>`)},
+		"Posts.xml": {Data: []byte(`<?xml version="1.0" encoding="utf-8"?>
+<posts>
+  <row Id="1" PostTypeId="1" AcceptedAnswerId="4" CreationDate="2010-07-28T19:04:21.300" Title="First" Body="&lt;p&gt;Why?&lt;/p&gt;" OwnerUserId="5" />
+  <row Id="2" PostTypeId="2" ParentId="1" CreationDate="2010-07-28T19:15:25.433" Body="&lt;p&gt;Because.&lt;/p&gt;" OwnerUserId="-1" />
+  <row Id="3" PostTypeId="1" CreationDate="2010-07-29T10:00:00.000" ClosedDate="2010-08-01T00:00:00.000" Title="Second" Body="&lt;p&gt;How?&lt;/p&gt;" OwnerUserId="9" />
+  <row Id="4" PostTypeId="2" ParentId="1" CreationDate="2010-07-30T10:00:00.000" Body="&lt;p&gt;Like this.&lt;/p&gt;" />
+  <row Id="5" PostTypeId="4" CreationDate="2010-07-30T10:00:00.000" Body="Tag wiki" />
+  <row Id="6" PostTypeId="2" ParentId="99" CreationDate="2010-07-30T10:00:00.000" Body="&lt;p&gt;Orphan&lt;/p&gt;" OwnerUserId="5" />
+</posts>`)},
+	}
+}
+
+func newStackExchangeTestService(repo *fakeTransferRepository, users *fakeUserRepository) *StackExchangeImportService {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	svc := NewStackExchangeImportService(repo, users, NewAuditService(&fakeAuditRepository{}, logger), logger)
+	svc.batchSize = 2
+	return svc
+}
+
+func TestStackExchangeImport(t *testing.T) {
+	repo := &fakeTransferRepository{}
+	users := newFakeUserRepository()
+	svc := newStackExchangeTestService(repo, users)
+
+	report, err := svc.Import(context.Background(), stackExchangeDump(), "", domain.SystemMeta("test"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.UsersCreated)
+	assert.Equal(t, 2, report.QuestionsCreated)
+	assert.Equal(t, 2, report.AnswersCreated)
+	assert.Equal(t, 1, report.Skipped)
+	require.Len(t, report.Errors, 1)
+	assert.Equal(t, uint(6), report.Errors[0].ID)
+	require.Len(t, report.Warnings, 1)
+	assert.Contains(t, report.Warnings[0].Message, "user 9")
+
+	alice, err := users.GetByEmail("stackexchange-5@users.invalid")
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", alice.DisplayName)
+	assert.Equal(t, 2010, alice.CreatedAt.Year())
+
+	require.Len(t, repo.questions, 2)
+	first, second := repo.questions[0], repo.questions[1]
+	assert.Equal(t, alice.ID, *first.UserID)
+	assert.Equal(t, "# First\n\nWhy?", first.Text)
+	assert.Contains(t, first.TextHTML, "<h1>First</h1>")
+	assert.Equal(t, 28, first.CreatedAt.Day())
+	require.Len(t, first.Answers, 2)
+	assert.Equal(t, domain.DeletedUserID, first.Answers[1].UserID)
+	require.NotNil(t, first.AcceptedAnswerID)
+	assert.Equal(t, first.Answers[1].ID, *first.AcceptedAnswerID)
+	assert.Equal(t, domain.DeletedUserID, *second.UserID)
+	assert.Equal(t, domain.QuestionClosed, second.Status)
+	assert.Empty(t, repo.checkpoints)
+
+	report, err = svc.Import(context.Background(), stackExchangeDump(), "", domain.SystemMeta("test"))
+	require.NoError(t, err)
+	assert.Zero(t, report.UsersCreated)
+	assert.Zero(t, report.QuestionsCreated)
+	assert.Equal(t, 2, report.QuestionsUpdated)
+	assert.Equal(t, 2, report.AnswersUpdated)
+	assert.Len(t, repo.questions, 2)
+}
+
+// failingTransferRepository fails to save the second batch, as if the
+// import were interrupted.
+type failingTransferRepository struct {
+	*fakeTransferRepository
+	saved int
+}
+
+func (r *failingTransferRepository) SaveBatch(questions []*domain.Question, answers []*domain.Answer) error {
+	r.saved++
+	if r.saved == 2 {
+		return errors.New("connection lost")
+	}
+	return r.fakeTransferRepository.SaveBatch(questions, answers)
+}
+
+func TestStackExchangeImport_ResumesFromCheckpoint(t *testing.T) {
+	repo := &fakeTransferRepository{}
+	users := newFakeUserRepository()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	failing := NewStackExchangeImportService(&failingTransferRepository{fakeTransferRepository: repo}, users,
+		NewAuditService(&fakeAuditRepository{}, logger), logger)
+	failing.batchSize = 2
+
+	// The first batch of posts holds question 1 and an answer; saving the
+	// second, with question 3 and another answer to question 1, fails.
+	_, err := failing.Import(context.Background(), stackExchangeDump(), "", domain.SystemMeta("test"))
+	require.Error(t, err)
+	checkpoint := repo.checkpoints["stackexchange"]
+	assert.Equal(t, domain.ImportPhasePosts, checkpoint.Phase)
+	assert.Equal(t, int64(2), checkpoint.RowsDone)
+
+	svc := newStackExchangeTestService(repo, users)
+	report, err := svc.Import(context.Background(), stackExchangeDump(), "", domain.SystemMeta("test"))
+	require.NoError(t, err)
+	assert.Zero(t, report.UsersCreated)
+	assert.Equal(t, 1, report.QuestionsCreated)
+	assert.Equal(t, 1, report.AnswersCreated)
+	require.Len(t, repo.questions, 2)
+	assert.Len(t, repo.questions[0].Answers, 2)
+	assert.NotNil(t, repo.questions[0].AcceptedAnswerID)
+	assert.Empty(t, repo.checkpoints)
+}
+
+func TestStackExchangeImport_StopsWhenCancelled(t *testing.T) {
+	repo := &fakeTransferRepository{}
+	svc := newStackExchangeTestService(repo, newFakeUserRepository())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := svc.Import(ctx, stackExchangeDump(), "", domain.SystemMeta("test"))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, repo.questions)
+}
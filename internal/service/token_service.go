@@ -1,8 +1,10 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"hitalent-test/internal/config"
@@ -13,15 +15,36 @@ import (
 type TokenClaims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
+	Role   string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// ActionClaims identify a single-use token mailed to a user, such as an email
+// verification link. The registered ID is the token's unique identifier.
+type ActionClaims struct {
+	Purpose string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+// OIDCFlowClaims carry the secrets of an OpenID Connect sign-in between the
+// redirect to the provider and the callback. The token is kept in a cookie
+// and never sent to the provider.
+type OIDCFlowClaims struct {
+	Provider string `json:"provider"`
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
 	jwt.RegisteredClaims
 }
 
 type TokenService struct {
-	cfg *config.JWTConfig
+	cfg                 *config.JWTConfig
+	verificationSecrets atomic.Pointer[[]string]
 }
 
 type RefreshTokenInfo struct {
 	UserID    string
+	IssuedAt  time.Time
 	ExpiresAt time.Time
 }
 
@@ -31,7 +54,16 @@ type RefreshTokenStore struct {
 }
 
 func NewTokenService(cfg *config.JWTConfig) *TokenService {
-	return &TokenService{cfg: cfg}
+	s := &TokenService{cfg: cfg}
+	s.SetVerificationSecrets(cfg.VerificationSecrets)
+	return s
+}
+
+// SetVerificationSecrets replaces the extra secrets accepted when verifying
+// tokens. Tokens are always signed with the primary secret.
+func (s *TokenService) SetVerificationSecrets(secrets []string) {
+	secrets = append([]string(nil), secrets...)
+	s.verificationSecrets.Store(&secrets)
 }
 
 func NewRefreshTokenStore() *RefreshTokenStore {
@@ -40,10 +72,11 @@ func NewRefreshTokenStore() *RefreshTokenStore {
 	}
 }
 
-func (s *TokenService) GenerateAccessToken(userID, email string) (string, error) {
+func (s *TokenService) GenerateAccessToken(userID, email, role string) (string, error) {
 	claims := TokenClaims{
 		UserID: userID,
 		Email:  email,
+		Role:   role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.cfg.AccessTokenExpiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -67,24 +100,108 @@ func (s *TokenService) GenerateRefreshToken(userID string) (string, error) {
 
 func (s *TokenService) VerifyToken(tokenString string) (*TokenClaims, error) {
 	claims := &TokenClaims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(s.cfg.Secret), nil
-	})
+	if err := s.parse(tokenString, claims); err != nil {
+		return nil, err
+	}
 
-	if err != nil {
+	if claims.UserID == "" {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	return claims, nil
+}
+
+func (s *TokenService) GenerateActionToken(userID, purpose, tokenID string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := ActionClaims{
+		Purpose: purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			Subject:   userID,
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(s.cfg.Secret))
+}
+
+func (s *TokenService) VerifyActionToken(tokenString, purpose string) (*ActionClaims, error) {
+	claims := &ActionClaims{}
+	if err := s.parse(tokenString, claims); err != nil {
 		return nil, err
 	}
 
-	if !token.Valid {
+	if claims.Purpose != purpose || claims.ID == "" || claims.Subject == "" {
 		return nil, fmt.Errorf("invalid token")
 	}
 
 	return claims, nil
 }
 
+func (s *TokenService) GenerateOIDCFlowToken(provider, state, nonce, verifier string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := OIDCFlowClaims{
+		Provider: provider,
+		State:    state,
+		Nonce:    nonce,
+		Verifier: verifier,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(s.cfg.Secret))
+}
+
+func (s *TokenService) VerifyOIDCFlowToken(tokenString, provider string) (*OIDCFlowClaims, error) {
+	claims := &OIDCFlowClaims{}
+	if err := s.parse(tokenString, claims); err != nil {
+		return nil, err
+	}
+
+	if claims.Provider != provider || claims.State == "" || claims.Nonce == "" || claims.Verifier == "" {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	return claims, nil
+}
+
+// parse verifies the signature against the primary secret and then every
+// verification secret, and decodes the claims.
+func (s *TokenService) parse(tokenString string, claims jwt.Claims) error {
+	secrets := append([]string{s.cfg.Secret}, *s.verificationSecrets.Load()...)
+
+	var (
+		token *jwt.Token
+		err   error
+	)
+	for _, secret := range secrets {
+		token, err = jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			return []byte(secret), nil
+		})
+		if !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+			break
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !token.Valid {
+		return fmt.Errorf("invalid token")
+	}
+
+	return nil
+}
+
 func (store *RefreshTokenStore) Save(token string, info *RefreshTokenInfo) {
 	store.mu.Lock()
 	defer store.mu.Unlock()
@@ -111,6 +228,33 @@ func (store *RefreshTokenStore) Delete(token string) {
 	delete(store.tokens, token)
 }
 
+// DeleteByUser revokes every refresh token issued to userID.
+func (store *RefreshTokenStore) DeleteByUser(userID string) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for token, info := range store.tokens {
+		if info.UserID == userID {
+			delete(store.tokens, token)
+		}
+	}
+}
+
+// ListByUser returns the unexpired refresh tokens issued to userID.
+func (store *RefreshTokenStore) ListByUser(userID string) []RefreshTokenInfo {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	now := time.Now()
+	var infos []RefreshTokenInfo
+	for _, info := range store.tokens {
+		if info.UserID == userID && info.ExpiresAt.After(now) {
+			infos = append(infos, *info)
+		}
+	}
+	return infos
+}
+
 func (store *RefreshTokenStore) CleanupExpired() {
 	store.mu.Lock()
 	defer store.mu.Unlock()
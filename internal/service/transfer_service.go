@@ -0,0 +1,517 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/markdown"
+	"hitalent-test/internal/repository"
+	"hitalent-test/internal/transfer"
+)
+
+const (
+	// transferBatchSize is how many questions an export reads per query and
+	// an import writes per transaction.
+	transferBatchSize = 100
+	// defaultImportSource names the imports that do not give a source.
+	defaultImportSource = "import"
+)
+
+var importSourcePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,63}$`)
+
+// TransferService exports all questions with their answers and imports
+// them back, into this instance or another one.
+type TransferService struct {
+	repo      repository.TransferRepository
+	userRepo  repository.UserRepository
+	audit     Auditor
+	batchSize int
+	now       func() time.Time
+}
+
+func NewTransferService(repo repository.TransferRepository, userRepo repository.UserRepository, audit Auditor) *TransferService {
+	return &TransferService{
+		repo:      repo,
+		userRepo:  userRepo,
+		audit:     audit,
+		batchSize: transferBatchSize,
+		now:       time.Now,
+	}
+}
+
+// Export writes every question, hidden ones included, in the given format.
+// Output is written batch by batch, so an error may come after part of it.
+func (s *TransferService) Export(w io.Writer, format string) error {
+	enc, err := transfer.NewEncoder(w, format)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.Each(s.batchSize, func(questions []domain.Question) error {
+		emails, err := s.authorEmails(questions)
+		if err != nil {
+			return err
+		}
+		for i := range questions {
+			if err := enc.Encode(toTransferQuestion(&questions[i], emails)); err != nil {
+				return err
+			}
+		}
+		return enc.Flush()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export questions: %w", err)
+	}
+	return enc.Flush()
+}
+
+// authorEmails maps the IDs of the authors of questions and their answers
+// to their emails. Erased authors are left out.
+func (s *TransferService) authorEmails(questions []domain.Question) (map[string]string, error) {
+	var ids []string
+	for _, q := range questions {
+		if q.UserID != nil {
+			ids = append(ids, *q.UserID)
+		}
+		for _, a := range q.Answers {
+			ids = append(ids, a.UserID)
+		}
+	}
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	users, err := s.userRepo.GetByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	emails := make(map[string]string, len(users))
+	for _, u := range users {
+		if u.ID != domain.DeletedUserID {
+			emails[u.ID] = u.Email
+		}
+	}
+	return emails, nil
+}
+
+func toTransferQuestion(q *domain.Question, emails map[string]string) *domain.TransferQuestion {
+	record := &domain.TransferQuestion{
+		ID:               q.ID,
+		Text:             q.Text,
+		Status:           q.Status,
+		CloseReason:      q.CloseReason,
+		DuplicateOfID:    q.DuplicateOfID,
+		AcceptedAnswerID: q.AcceptedAnswerID,
+		Hidden:           q.HiddenAt != nil,
+		CreatedAt:        q.CreatedAt,
+		Answers:          make([]domain.TransferAnswer, 0, len(q.Answers)),
+	}
+	if record.Status == "" {
+		record.Status = domain.QuestionOpen
+	}
+	if q.UserID != nil {
+		record.AuthorEmail = emails[*q.UserID]
+	}
+	for _, a := range q.Answers {
+		record.Answers = append(record.Answers, domain.TransferAnswer{
+			ID:          a.ID,
+			AuthorEmail: emails[a.UserID],
+			Text:        a.Text,
+			Hidden:      a.HiddenAt != nil,
+			CreatedAt:   a.CreatedAt,
+		})
+	}
+	return record
+}
+
+// Import reads questions with their answers and creates or updates them.
+// Records are matched to posts created by earlier imports from the same
+// source, source IDs are mapped to the new IDs, and authors are looked up
+// by email; unknown authors are replaced by the deleted user placeholder.
+// Invalid records are skipped and reported.
+//
+// Questions are written in batches, each in its own transaction. An error
+// other than a bad record stops the import with the earlier batches
+// saved; since records are matched, running the import again completes it.
+func (s *TransferService) Import(r io.Reader, opts domain.ImportOptions, meta domain.RequestMeta) (*domain.ImportReport, error) {
+	if opts.Source == "" {
+		opts.Source = defaultImportSource
+	}
+	if !importSourcePattern.MatchString(opts.Source) {
+		return nil, fmt.Errorf("%w: source must be lowercase letters, digits, dots, dashes and underscores", domain.ErrInvalidInput)
+	}
+	dec, err := transfer.NewDecoder(r, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	imp := &importer{
+		s:             s,
+		opts:          opts,
+		report:        &domain.ImportReport{DryRun: opts.DryRun, Errors: []domain.ImportIssue{}, Warnings: []domain.ImportIssue{}},
+		authors:       make(map[string]*string),
+		seenQuestions: make(map[uint]bool),
+		seenAnswers:   make(map[uint]bool),
+		questionIDs:   make(map[uint]uint),
+	}
+	for {
+		record, line, err := dec.Decode()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var recordErr *transfer.RecordError
+		if errors.As(err, &recordErr) {
+			imp.fail(recordErr.Line, 0, recordErr.Err.Error())
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read import: %w", err)
+		}
+
+		if err := imp.add(record, line); err != nil {
+			return nil, err
+		}
+	}
+	if err := imp.flush(); err != nil {
+		return nil, err
+	}
+	if err := imp.resolveReferences(); err != nil {
+		return nil, err
+	}
+
+	if !opts.DryRun {
+		s.audit.Record(meta, domain.AuditDataImported, domain.AuditTargetImport, opts.Source, nil, map[string]int{
+			"questions_created": imp.report.QuestionsCreated,
+			"questions_updated": imp.report.QuestionsUpdated,
+			"answers_created":   imp.report.AnswersCreated,
+			"answers_updated":   imp.report.AnswersUpdated,
+			"skipped":           imp.report.Skipped,
+		})
+	}
+	return imp.report, nil
+}
+
+// importer holds the state of one import.
+type importer struct {
+	s      *TransferService
+	opts   domain.ImportOptions
+	report *domain.ImportReport
+	// authors maps emails to user IDs, or to nil for unknown emails.
+	authors       map[string]*string
+	seenQuestions map[uint]bool
+	seenAnswers   map[uint]bool
+	// questionIDs maps the source IDs of saved questions to their IDs; in a
+	// dry run, new questions map to zero.
+	questionIDs map[uint]uint
+	batch       []pendingQuestion
+	refs        []pendingReference
+}
+
+type pendingQuestion struct {
+	line     int
+	record   *domain.TransferQuestion
+	question *domain.Question
+}
+
+// pendingReference is an ImportReference whose duplicate is still known
+// by its source ID.
+type pendingReference struct {
+	line          int
+	sourceID      uint
+	ref           domain.ImportReference
+	duplicateOfID *uint
+}
+
+func (imp *importer) fail(line int, id uint, message string) {
+	imp.report.Skipped++
+	imp.report.Errors = append(imp.report.Errors, domain.ImportIssue{Line: line, ID: id, Message: message})
+}
+
+func (imp *importer) warn(line int, id uint, message string) {
+	imp.report.Warnings = append(imp.report.Warnings, domain.ImportIssue{Line: line, ID: id, Message: message})
+}
+
+// externalID identifies a post of this import's source.
+func (imp *importer) externalID(id uint) string {
+	return imp.opts.Source + ":" + strconv.FormatUint(uint64(id), 10)
+}
+
+// add validates a record and queues it for the next batch.
+func (imp *importer) add(record *domain.TransferQuestion, line int) error {
+	if err := imp.lookUpAuthors(record, line); err != nil {
+		return err
+	}
+	question, err := imp.convert(record, line)
+	if err != nil {
+		imp.fail(line, record.ID, err.Error())
+		return nil
+	}
+	imp.seenQuestions[record.ID] = true
+	for _, a := range record.Answers {
+		imp.seenAnswers[a.ID] = true
+	}
+
+	imp.batch = append(imp.batch, pendingQuestion{line: line, record: record, question: question})
+	if len(imp.batch) >= imp.s.batchSize {
+		return imp.flush()
+	}
+	return nil
+}
+
+// convert turns a record into a question with answers, or explains why it
+// cannot be imported.
+func (imp *importer) convert(record *domain.TransferQuestion, line int) (*domain.Question, error) {
+	if record.ID == 0 {
+		return nil, errors.New("id is required")
+	}
+	if imp.seenQuestions[record.ID] {
+		return nil, fmt.Errorf("question %d appears more than once", record.ID)
+	}
+	if strings.TrimSpace(record.Text) == "" {
+		return nil, errors.New("text is required")
+	}
+
+	status := record.Status
+	if status == "" {
+		status = domain.QuestionOpen
+	}
+	if !slices.Contains(domain.QuestionStatuses, status) {
+		return nil, fmt.Errorf("unknown status %q", status)
+	}
+	var closeReason string
+	if status == domain.QuestionClosed {
+		closeReason = record.CloseReason
+		if closeReason == "" {
+			closeReason = domain.CloseOther
+		}
+		if !slices.Contains(domain.CloseReasons, closeReason) {
+			return nil, fmt.Errorf("unknown close reason %q", closeReason)
+		}
+	}
+	if status == domain.QuestionDuplicate {
+		if record.DuplicateOfID == nil {
+			return nil, errors.New("duplicate_of_id is required for duplicates")
+		}
+		if *record.DuplicateOfID == record.ID {
+			return nil, errors.New("a question cannot be a duplicate of itself")
+		}
+	}
+
+	answerIDs := make(map[uint]bool, len(record.Answers))
+	for _, a := range record.Answers {
+		if a.ID == 0 {
+			return nil, errors.New("answer id is required")
+		}
+		if answerIDs[a.ID] || imp.seenAnswers[a.ID] {
+			return nil, fmt.Errorf("answer %d appears more than once", a.ID)
+		}
+		answerIDs[a.ID] = true
+		if strings.TrimSpace(a.Text) == "" {
+			return nil, fmt.Errorf("answer %d has no text", a.ID)
+		}
+	}
+	if record.AcceptedAnswerID != nil && !answerIDs[*record.AcceptedAnswerID] {
+		imp.warn(line, record.ID, fmt.Sprintf("accepted answer %d is not among the question's answers and is ignored", *record.AcceptedAnswerID))
+		record.AcceptedAnswerID = nil
+	}
+
+	now := imp.s.now()
+	externalID := imp.externalID(record.ID)
+	question := &domain.Question{
+		UserID:         imp.author(record.AuthorEmail),
+		Text:           record.Text,
+		TextHTML:       markdown.Render(record.Text, nil),
+		QuestionStatus: domain.QuestionStatus{Status: status, CloseReason: closeReason},
+		CreatedAt:      record.CreatedAt,
+		ExternalID:     &externalID,
+		Answers:        make([]domain.Answer, 0, len(record.Answers)),
+	}
+	if record.Hidden {
+		question.HiddenAt = &now
+	}
+	for _, a := range record.Answers {
+		answerExternalID := imp.externalID(a.ID)
+		answer := domain.Answer{
+			UserID:     domain.DeletedUserID,
+			Text:       a.Text,
+			TextHTML:   markdown.Render(a.Text, nil),
+			CreatedAt:  a.CreatedAt,
+			ExternalID: &answerExternalID,
+		}
+		if id := imp.author(a.AuthorEmail); id != nil {
+			answer.UserID = *id
+		}
+		if a.Hidden {
+			answer.HiddenAt = &now
+		}
+		question.Answers = append(question.Answers, answer)
+	}
+	return question, nil
+}
+
+// lookUpAuthors finds the users with the emails of a record's authors.
+// Each unknown email is reported once.
+func (imp *importer) lookUpAuthors(record *domain.TransferQuestion, line int) error {
+	emails := []string{record.AuthorEmail}
+	for _, a := range record.Answers {
+		emails = append(emails, a.AuthorEmail)
+	}
+	for _, email := range emails {
+		email = normalizeEmail(email)
+		if _, ok := imp.authors[email]; ok || email == "" {
+			continue
+		}
+		user, err := imp.s.userRepo.GetByEmail(email)
+		switch {
+		case err == nil:
+			imp.authors[email] = &user.ID
+		case errors.Is(err, domain.ErrUserNotFound):
+			imp.warn(line, record.ID, fmt.Sprintf("unknown author %s; their posts are attributed to the deleted user", email))
+			imp.authors[email] = nil
+		default:
+			return fmt.Errorf("failed to look up author: %w", err)
+		}
+	}
+	return nil
+}
+
+// author returns the ID of the user with the given email: nil for no
+// email, or the deleted user placeholder for an unknown one.
+func (imp *importer) author(email string) *string {
+	email = normalizeEmail(email)
+	if email == "" {
+		return nil
+	}
+	if userID := imp.authors[email]; userID != nil {
+		return userID
+	}
+	deleted := domain.DeletedUserID
+	return &deleted
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// flush matches the queued questions to earlier imports and saves them.
+func (imp *importer) flush() error {
+	if len(imp.batch) == 0 {
+		return nil
+	}
+	batch := imp.batch
+	imp.batch = nil
+
+	var questionKeys, answerKeys []string
+	for _, p := range batch {
+		questionKeys = append(questionKeys, *p.question.ExternalID)
+		for _, a := range p.question.Answers {
+			answerKeys = append(answerKeys, *a.ExternalID)
+		}
+	}
+	existingQuestions, err := imp.s.repo.ExternalIDs(domain.ContentQuestion, questionKeys)
+	if err != nil {
+		return fmt.Errorf("failed to match imported questions: %w", err)
+	}
+	existingAnswers, err := imp.s.repo.ExternalIDs(domain.ContentAnswer, answerKeys)
+	if err != nil {
+		return fmt.Errorf("failed to match imported answers: %w", err)
+	}
+
+	questions := make([]*domain.Question, len(batch))
+	for i, p := range batch {
+		q := p.question
+		q.ID = existingQuestions[*q.ExternalID]
+		if q.ID == 0 {
+			imp.report.QuestionsCreated++
+		} else {
+			imp.report.QuestionsUpdated++
+		}
+		for j := range q.Answers {
+			a := &q.Answers[j]
+			a.ID = existingAnswers[*a.ExternalID]
+			if a.ID == 0 {
+				imp.report.AnswersCreated++
+			} else {
+				imp.report.AnswersUpdated++
+			}
+		}
+		questions[i] = q
+	}
+
+	if !imp.opts.DryRun {
+		if err := imp.s.repo.SaveBatch(questions, nil); err != nil {
+			return fmt.Errorf("failed to save imported questions: %w", err)
+		}
+	}
+
+	for _, p := range batch {
+		imp.questionIDs[p.record.ID] = p.question.ID
+		ref := pendingReference{
+			line:     p.line,
+			sourceID: p.record.ID,
+			ref:      domain.ImportReference{QuestionID: p.question.ID},
+		}
+		if p.record.AcceptedAnswerID != nil {
+			i := slices.IndexFunc(p.record.Answers, func(a domain.TransferAnswer) bool { return a.ID == *p.record.AcceptedAnswerID })
+			ref.ref.AcceptedAnswerID = &p.question.Answers[i].ID
+		}
+		if p.question.Status == domain.QuestionDuplicate {
+			ref.duplicateOfID = p.record.DuplicateOfID
+		}
+		imp.refs = append(imp.refs, ref)
+	}
+	return nil
+}
+
+// resolveReferences maps the duplicates of imported questions to their
+// IDs, looking for those not in the file among earlier imports, and sets
+// the accepted answers and duplicates.
+func (imp *importer) resolveReferences() error {
+	var keys []string
+	for _, r := range imp.refs {
+		if r.duplicateOfID != nil {
+			if _, ok := imp.questionIDs[*r.duplicateOfID]; !ok {
+				keys = append(keys, imp.externalID(*r.duplicateOfID))
+			}
+		}
+	}
+	earlier, err := imp.s.repo.ExternalIDs(domain.ContentQuestion, keys)
+	if err != nil {
+		return fmt.Errorf("failed to match duplicates: %w", err)
+	}
+
+	refs := make([]domain.ImportReference, 0, len(imp.refs))
+	for _, r := range imp.refs {
+		if r.duplicateOfID != nil {
+			id, ok := imp.questionIDs[*r.duplicateOfID]
+			if !ok {
+				id, ok = earlier[imp.externalID(*r.duplicateOfID)]
+			}
+			if ok {
+				r.ref.DuplicateOfID = &id
+			} else {
+				imp.warn(r.line, r.sourceID, fmt.Sprintf("duplicate_of_id %d is neither in the file nor imported before and is ignored", *r.duplicateOfID))
+			}
+		}
+		refs = append(refs, r.ref)
+	}
+	imp.refs = nil
+
+	if imp.opts.DryRun {
+		return nil
+	}
+	for len(refs) > 0 {
+		n := min(len(refs), imp.s.batchSize)
+		if err := imp.s.repo.SetReferences(refs[:n]); err != nil {
+			return fmt.Errorf("failed to link imported questions: %w", err)
+		}
+		refs = refs[n:]
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"hitalent-test/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const transferTestAuthorID = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+
+type fakeTransferRepository struct {
+	mu          sync.Mutex
+	questions   []*domain.Question
+	nextID      uint
+	batches     int
+	checkpoints map[string]domain.ImportCheckpoint
+}
+
+func (r *fakeTransferRepository) Each(batchSize int, fn func([]domain.Question) error) error {
+	r.mu.Lock()
+	questions := make([]domain.Question, 0, len(r.questions))
+	for _, q := range r.questions {
+		questions = append(questions, *q)
+	}
+	r.mu.Unlock()
+
+	for len(questions) > 0 {
+		n := min(len(questions), batchSize)
+		if err := fn(questions[:n]); err != nil {
+			return err
+		}
+		questions = questions[n:]
+	}
+	return nil
+}
+
+func (r *fakeTransferRepository) ExternalIDs(contentType string, externalIDs []string) (map[string]uint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make(map[string]uint)
+	for _, q := range r.questions {
+		if contentType == domain.ContentQuestion && q.ExternalID != nil && slices.Contains(externalIDs, *q.ExternalID) {
+			ids[*q.ExternalID] = q.ID
+		}
+		for _, a := range q.Answers {
+			if contentType == domain.ContentAnswer && a.ExternalID != nil && slices.Contains(externalIDs, *a.ExternalID) {
+				ids[*a.ExternalID] = a.ID
+			}
+		}
+	}
+	return ids, nil
+}
+
+func (r *fakeTransferRepository) SaveBatch(questions []*domain.Question, answers []*domain.Answer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches++
+	for _, q := range questions {
+		if q.ID == 0 {
+			r.nextID++
+			q.ID = r.nextID
+		}
+		i := slices.IndexFunc(r.questions, func(s *domain.Question) bool { return s.ID == q.ID })
+		stored := *q
+		stored.Answers = nil
+		if i >= 0 {
+			stored.Answers = r.questions[i].Answers
+			r.questions[i] = &stored
+		} else {
+			r.questions = append(r.questions, &stored)
+		}
+		for j := range q.Answers {
+			q.Answers[j].QuestionID = q.ID
+			r.saveAnswer(&q.Answers[j])
+		}
+	}
+	for _, a := range answers {
+		if !r.saveAnswer(a) {
+			return fmt.Errorf("question %d not found", a.QuestionID)
+		}
+	}
+	return nil
+}
+
+func (r *fakeTransferRepository) saveAnswer(a *domain.Answer) bool {
+	i := slices.IndexFunc(r.questions, func(q *domain.Question) bool { return q.ID == a.QuestionID })
+	if i < 0 {
+		return false
+	}
+	q := r.questions[i]
+	if a.ID == 0 {
+		r.nextID++
+		a.ID = 1000 + r.nextID
+	}
+	if j := slices.IndexFunc(q.Answers, func(s domain.Answer) bool { return s.ID == a.ID }); j >= 0 {
+		q.Answers[j] = *a
+	} else {
+		q.Answers = append(q.Answers, *a)
+	}
+	return true
+}
+
+func (r *fakeTransferRepository) SetReferences(refs []domain.ImportReference) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, ref := range refs {
+		for _, q := range r.questions {
+			if q.ID == ref.QuestionID {
+				q.AcceptedAnswerID = ref.AcceptedAnswerID
+				q.DuplicateOfID = ref.DuplicateOfID
+			}
+		}
+	}
+	return nil
+}
+
+func (r *fakeTransferRepository) Checkpoint(source string) (*domain.ImportCheckpoint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	checkpoint, ok := r.checkpoints[source]
+	if !ok {
+		return nil, domain.ErrCheckpointNotFound
+	}
+	return &checkpoint, nil
+}
+
+func (r *fakeTransferRepository) SaveCheckpoint(checkpoint *domain.ImportCheckpoint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.checkpoints == nil {
+		r.checkpoints = make(map[string]domain.ImportCheckpoint)
+	}
+	r.checkpoints[checkpoint.Source] = *checkpoint
+	return nil
+}
+
+func (r *fakeTransferRepository) DeleteCheckpoint(source string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.checkpoints, source)
+	return nil
+}
+
+func newTransferTestService() (*TransferService, *fakeTransferRepository, *fakeAuditRepository) {
+	repo := &fakeTransferRepository{}
+	auditRepo := &fakeAuditRepository{}
+	users := newFakeUserRepository(
+		&domain.User{ID: transferTestAuthorID, Email: "alice@example.com"},
+		&domain.User{ID: domain.DeletedUserID, Email: "deleted@invalid"},
+	)
+	svc := NewTransferService(repo, users, NewAuditService(auditRepo, slog.New(slog.NewTextHandler(io.Discard, nil))))
+	svc.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+	return svc, repo, auditRepo
+}
+
+const transferTestFile = `{"id":10,"author_email":"Alice@Example.com","text":"How do I import?","status":"open","accepted_answer_id":21,"answers":[{"id":20,"author_email":"bob@example.com","text":"Like this."},{"id":21,"author_email":"alice@example.com","text":"Or like this."}]}
+{"id":11,"text":"Same question","status":"duplicate","duplicate_of_id":10,"hidden":true}
+`
+
+func TestTransferService_ImportCreatesThenUpdates(t *testing.T) {
+	svc, repo, auditRepo := newTransferTestService()
+
+	report, err := svc.Import(strings.NewReader(transferTestFile), domain.ImportOptions{Format: domain.TransferJSONL}, domain.SystemMeta("test"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.QuestionsCreated)
+	assert.Equal(t, 2, report.AnswersCreated)
+	assert.Empty(t, report.Errors)
+	require.Len(t, report.Warnings, 1)
+	assert.Contains(t, report.Warnings[0].Message, "bob@example.com")
+
+	require.Len(t, repo.questions, 2)
+	first, second := repo.questions[0], repo.questions[1]
+	assert.Equal(t, transferTestAuthorID, *first.UserID)
+	assert.Equal(t, "import:10", *first.ExternalID)
+	assert.Equal(t, domain.DeletedUserID, first.Answers[0].UserID)
+	assert.Equal(t, transferTestAuthorID, first.Answers[1].UserID)
+	require.NotNil(t, first.AcceptedAnswerID)
+	assert.Equal(t, first.Answers[1].ID, *first.AcceptedAnswerID)
+	assert.Nil(t, second.UserID)
+	assert.NotNil(t, second.HiddenAt)
+	require.NotNil(t, second.DuplicateOfID)
+	assert.Equal(t, first.ID, *second.DuplicateOfID)
+	require.Len(t, auditRepo.entries, 1)
+	assert.Equal(t, domain.AuditDataImported, auditRepo.entries[0].Action)
+
+	updated := strings.Replace(transferTestFile, "How do I import?", "How do I import data?", 1)
+	report, err = svc.Import(strings.NewReader(updated), domain.ImportOptions{Format: domain.TransferJSONL}, domain.SystemMeta("test"))
+	require.NoError(t, err)
+	assert.Equal(t, 0, report.QuestionsCreated)
+	assert.Equal(t, 2, report.QuestionsUpdated)
+	assert.Equal(t, 2, report.AnswersUpdated)
+	require.Len(t, repo.questions, 2)
+	assert.Equal(t, "How do I import data?", repo.questions[0].Text)
+
+	// The same IDs from another source are other posts.
+	report, err = svc.Import(strings.NewReader(transferTestFile), domain.ImportOptions{Format: domain.TransferJSONL, Source: "legacy"}, domain.SystemMeta("test"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.QuestionsCreated)
+	assert.Len(t, repo.questions, 4)
+}
+
+func TestTransferService_DryRunReportsWithoutSaving(t *testing.T) {
+	svc, repo, auditRepo := newTransferTestService()
+
+	file := transferTestFile +
+		`{"id":12,"text":""}` + "\n" +
+		`{"id":13,"text":"Closed","status":"closed","close_reason":"boring"}` + "\n" +
+		`not json` + "\n" +
+		`{"id":10,"text":"Again"}` + "\n" +
+		`{"id":14,"text":"Orphan","status":"duplicate","duplicate_of_id":99}` + "\n"
+	report, err := svc.Import(strings.NewReader(file), domain.ImportOptions{Format: domain.TransferJSONL, DryRun: true}, domain.SystemMeta("test"))
+	require.NoError(t, err)
+
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 3, report.QuestionsCreated)
+	assert.Equal(t, 4, report.Skipped)
+	lines := make([]int, len(report.Errors))
+	for i, issue := range report.Errors {
+		lines[i] = issue.Line
+	}
+	assert.Equal(t, []int{3, 4, 5, 6}, lines)
+	assert.Contains(t, report.Warnings[len(report.Warnings)-1].Message, "duplicate_of_id 99")
+
+	assert.Empty(t, repo.questions)
+	assert.Zero(t, repo.batches)
+	assert.Empty(t, auditRepo.entries)
+}
+
+func TestTransferService_ImportWritesInBatches(t *testing.T) {
+	svc, repo, _ := newTransferTestService()
+	svc.batchSize = 2
+
+	var file strings.Builder
+	for id := 1; id <= 5; id++ {
+		file.WriteString(`{"id":` + strconv.Itoa(id) + `,"text":"Question"}` + "\n")
+	}
+	report, err := svc.Import(strings.NewReader(file.String()), domain.ImportOptions{Format: domain.TransferJSONL}, domain.SystemMeta("test"))
+	require.NoError(t, err)
+	assert.Equal(t, 5, report.QuestionsCreated)
+	assert.Equal(t, 3, repo.batches)
+}
+
+func TestTransferService_ImportRejectsBadOptions(t *testing.T) {
+	svc, _, _ := newTransferTestService()
+
+	_, err := svc.Import(strings.NewReader(""), domain.ImportOptions{Format: "xml"}, domain.SystemMeta("test"))
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	_, err = svc.Import(strings.NewReader(""), domain.ImportOptions{Format: domain.TransferJSONL, Source: "Old Site"}, domain.SystemMeta("test"))
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+}
+
+func TestTransferService_ExportRoundTrip(t *testing.T) {
+	for _, format := range domain.TransferFormats {
+		t.Run(format, func(t *testing.T) {
+			svc, repo, _ := newTransferTestService()
+			_, err := svc.Import(strings.NewReader(transferTestFile), domain.ImportOptions{Format: domain.TransferJSONL}, domain.SystemMeta("test"))
+			require.NoError(t, err)
+
+			var out bytes.Buffer
+			require.NoError(t, svc.Export(&out, format))
+
+			target, targetRepo, _ := newTransferTestService()
+			report, err := target.Import(&out, domain.ImportOptions{Format: format}, domain.SystemMeta("test"))
+			require.NoError(t, err)
+			assert.Empty(t, report.Errors)
+			assert.Equal(t, 2, report.QuestionsCreated)
+			assert.Equal(t, 2, report.AnswersCreated)
+
+			require.Len(t, targetRepo.questions, 2)
+			assert.Equal(t, repo.questions[0].Text, targetRepo.questions[0].Text)
+			assert.Equal(t, transferTestAuthorID, *targetRepo.questions[0].UserID)
+			// The erased author's answer stays with the placeholder.
+			assert.Equal(t, domain.DeletedUserID, targetRepo.questions[0].Answers[0].UserID)
+			assert.NotNil(t, targetRepo.questions[0].AcceptedAnswerID)
+			assert.NotNil(t, targetRepo.questions[1].DuplicateOfID)
+			assert.NotNil(t, targetRepo.questions[1].HiddenAt)
+		})
+	}
+}
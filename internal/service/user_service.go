@@ -0,0 +1,138 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/repository"
+)
+
+const maxAvatarURLLength = 2048
+
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z]{2})?$`)
+
+type UserService struct {
+	userRepo repository.UserRepository
+	limits   *config.ValidationConfig
+}
+
+func NewUserService(userRepo repository.UserRepository, limits *config.ValidationConfig) *UserService {
+	return &UserService{
+		userRepo: userRepo,
+		limits:   limits,
+	}
+}
+
+func (s *UserService) GetProfile(userID string) (*domain.UserProfile, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.userRepo.Stats(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user stats: %w", err)
+	}
+
+	return &domain.UserProfile{User: user, Stats: *stats}, nil
+}
+
+func (s *UserService) UpdateProfile(userID string, req *domain.UpdateProfileRequest) (*domain.UserProfile, error) {
+	if err := s.validateUpdateRequest(req); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.DisplayName != nil {
+		user.DisplayName = strings.TrimSpace(*req.DisplayName)
+	}
+	if req.Bio != nil {
+		user.Bio = strings.TrimSpace(*req.Bio)
+	}
+	if req.AvatarURL != nil {
+		user.AvatarURL = strings.TrimSpace(*req.AvatarURL)
+	}
+	if req.Settings != nil {
+		user.Settings = *req.Settings
+	}
+
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return s.GetProfile(user.ID)
+}
+
+// GetPublic returns the public view of a user, without email or settings.
+func (s *UserService) GetPublic(id string) (*domain.PublicUser, error) {
+	profile, err := s.GetProfile(id)
+	if err != nil {
+		return nil, err
+	}
+	return profile.User.Public(profile.Stats), nil
+}
+
+func (s *UserService) validateUpdateRequest(req *domain.UpdateProfileRequest) error {
+	if req.DisplayName != nil &&
+		utf8.RuneCountInString(strings.TrimSpace(*req.DisplayName)) > s.limits.DisplayNameMaxLength {
+		return fmt.Errorf("%w: display_name must not exceed %d characters", domain.ErrInvalidInput, s.limits.DisplayNameMaxLength)
+	}
+
+	if req.Bio != nil && utf8.RuneCountInString(strings.TrimSpace(*req.Bio)) > s.limits.BioMaxLength {
+		return fmt.Errorf("%w: bio must not exceed %d characters", domain.ErrInvalidInput, s.limits.BioMaxLength)
+	}
+
+	if req.AvatarURL != nil {
+		if avatar := strings.TrimSpace(*req.AvatarURL); avatar != "" {
+			u, err := url.Parse(avatar)
+			if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || len(avatar) > maxAvatarURLLength {
+				return fmt.Errorf("%w: avatar_url must be an absolute http(s) URL", domain.ErrInvalidInput)
+			}
+		}
+	}
+
+	if req.Settings != nil {
+		if tz := req.Settings.Timezone; tz != "" {
+			if _, err := time.LoadLocation(tz); err != nil {
+				return fmt.Errorf("%w: unknown timezone %q", domain.ErrInvalidInput, tz)
+			}
+		}
+		if locale := req.Settings.Locale; locale != "" && !localePattern.MatchString(locale) {
+			return fmt.Errorf("%w: locale must look like \"en\" or \"ru-RU\"", domain.ErrInvalidInput)
+		}
+		if f := req.Settings.EmailFrequency; f != "" && !slices.Contains(domain.EmailFrequencies, f) {
+			return fmt.Errorf("%w: email_frequency must be one of %s", domain.ErrInvalidInput, strings.Join(domain.EmailFrequencies, ", "))
+		}
+	}
+
+	return nil
+}
+
+// authorSummaries looks up the given users with a single query. Unknown IDs
+// are left out of the result.
+func authorSummaries(userRepo repository.UserRepository, ids []string) (map[string]*domain.UserSummary, error) {
+	summaries := make(map[string]*domain.UserSummary, len(ids))
+	if len(ids) == 0 {
+		return summaries, nil
+	}
+
+	users, err := userRepo.GetByIDs(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get authors: %w", err)
+	}
+	for i := range users {
+		summaries[users[i].ID] = users[i].Summary()
+	}
+	return summaries, nil
+}
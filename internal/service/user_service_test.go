@@ -0,0 +1,73 @@
+package service
+
+import (
+	"testing"
+
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestUserService_UpdateProfile(t *testing.T) {
+	users := newFakeUserRepository(&domain.User{ID: "user-1", Email: "alice@example.com", Role: domain.RoleUser})
+	service := NewUserService(users, &config.Default().Validation)
+
+	profile, err := service.UpdateProfile("user-1", &domain.UpdateProfileRequest{
+		DisplayName: strPtr("  Alice  "),
+		AvatarURL:   strPtr("https://example.com/alice.png"),
+		Settings:    &domain.UserSettings{Timezone: "Europe/Moscow", Locale: "ru-RU"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", profile.DisplayName)
+	assert.Equal(t, "Europe/Moscow", profile.Settings.Timezone)
+
+	// Fields left out of the request are not touched.
+	profile, err = service.UpdateProfile("user-1", &domain.UpdateProfileRequest{Bio: strPtr("Gopher")})
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", profile.DisplayName)
+	assert.Equal(t, "Gopher", profile.Bio)
+}
+
+func TestUserService_UpdateProfile_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		req  domain.UpdateProfileRequest
+	}{
+		{"long display name", domain.UpdateProfileRequest{DisplayName: strPtr(string(make([]rune, 65)))}},
+		{"avatar scheme", domain.UpdateProfileRequest{AvatarURL: strPtr("javascript:alert(1)")}},
+		{"relative avatar", domain.UpdateProfileRequest{AvatarURL: strPtr("/avatar.png")}},
+		{"timezone", domain.UpdateProfileRequest{Settings: &domain.UserSettings{Timezone: "Mars/Olympus"}}},
+		{"locale", domain.UpdateProfileRequest{Settings: &domain.UserSettings{Locale: "english"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := newFakeUserRepository(&domain.User{ID: "user-1", Email: "alice@example.com"})
+			service := NewUserService(users, &config.Default().Validation)
+
+			_, err := service.UpdateProfile("user-1", &tt.req)
+
+			assert.ErrorIs(t, err, domain.ErrInvalidInput)
+		})
+	}
+}
+
+func TestUserService_GetPublic_HidesPrivateFields(t *testing.T) {
+	users := newFakeUserRepository(&domain.User{
+		ID:       "user-1",
+		Email:    "alice@example.com",
+		Settings: domain.UserSettings{Timezone: "UTC"},
+	})
+	service := NewUserService(users, &config.Default().Validation)
+
+	public, err := service.GetPublic("user-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", public.ID)
+	assert.NotContains(t, public.DisplayName, "alice@example.com")
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/digest"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/mailer"
+	"hitalent-test/internal/repository"
+)
+
+// QuestionWatcher makes authors follow their questions and tells watchers
+// about new answers.
+type QuestionWatcher interface {
+	Follow(userID string, questionID uint)
+	AnswerPosted(question *domain.Question, answer *domain.Answer)
+}
+
+// NopQuestionWatcher is a QuestionWatcher that does nothing.
+type NopQuestionWatcher struct{}
+
+func (NopQuestionWatcher) Follow(string, uint) {}
+
+func (NopQuestionWatcher) AnswerPosted(*domain.Question, *domain.Answer) {}
+
+// WatchService keeps track of who follows which questions and mails new
+// answers to the watchers who want them instantly.
+type WatchService struct {
+	repo         repository.WatchRepository
+	questionRepo repository.QuestionRepository
+	userRepo     repository.UserRepository
+	mailer       mailer.Mailer
+	mailCfg      *config.MailConfig
+	logger       *slog.Logger
+}
+
+func NewWatchService(
+	repo repository.WatchRepository,
+	questionRepo repository.QuestionRepository,
+	userRepo repository.UserRepository,
+	mailer mailer.Mailer,
+	mailCfg *config.MailConfig,
+	logger *slog.Logger,
+) *WatchService {
+	return &WatchService{
+		repo:         repo,
+		questionRepo: questionRepo,
+		userRepo:     userRepo,
+		mailer:       mailer,
+		mailCfg:      mailCfg,
+		logger:       logger,
+	}
+}
+
+// Watch makes the user follow a visible question. Watching a question
+// twice is not an error.
+func (s *WatchService) Watch(userID string, questionID uint) error {
+	question, err := s.questionRepo.GetByID(questionID)
+	if err != nil {
+		return err
+	}
+	if question.HiddenAt != nil {
+		return domain.ErrQuestionNotFound
+	}
+
+	if err := s.repo.Watch(userID, questionID); err != nil {
+		return fmt.Errorf("failed to watch question: %w", err)
+	}
+	return nil
+}
+
+// Unwatch stops following a question. Unwatching a question the user does
+// not follow is not an error.
+func (s *WatchService) Unwatch(userID string, questionID uint) error {
+	if _, err := s.repo.Unwatch(userID, questionID); err != nil {
+		return fmt.Errorf("failed to unwatch question: %w", err)
+	}
+	return nil
+}
+
+// Follow makes an author watch the question they asked or answered.
+// Failures are logged: the post itself has already been saved.
+func (s *WatchService) Follow(userID string, questionID uint) {
+	if userID == "" || userID == domain.DeletedUserID {
+		return
+	}
+	if err := s.repo.Watch(userID, questionID); err != nil {
+		s.logger.Error("failed to watch question",
+			slog.String("user_id", userID),
+			slog.Uint64("question_id", uint64(questionID)),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// AnswerPosted mails a new answer to the question's watchers who want
+// email instantly, except its author. Failures are logged.
+func (s *WatchService) AnswerPosted(question *domain.Question, answer *domain.Answer) {
+	watchers, err := s.repo.Watchers(question.ID)
+	if err == nil {
+		watchers = slices.DeleteFunc(watchers, func(u domain.User) bool {
+			return u.ID == answer.UserID || u.Settings.Frequency() != domain.EmailInstant
+		})
+	}
+	var names map[string]string
+	if err == nil && len(watchers) > 0 {
+		names, err = authorNames(s.userRepo, []string{answer.UserID})
+	}
+	if err != nil {
+		s.logger.Error("failed to find watchers to notify",
+			slog.Uint64("question_id", uint64(question.ID)),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+
+	for i := range watchers {
+		user := &watchers[i]
+		q := *question
+		q.Answers = []domain.Answer{*answer}
+		email := &digest.AnswerEmail{
+			Name:        user.PublicName(),
+			Question:    digest.NewQuestion(&q, s.mailCfg.LinkBaseURL, names, user.Settings.Location()),
+			SettingsURL: settingsURL(s.mailCfg),
+		}
+		email.Answer = email.Question.Answers[0]
+
+		msg, err := digest.RenderAnswer(email)
+		if err == nil {
+			msg.To = user.Email
+			err = s.mailer.Send(msg)
+		}
+		if err != nil {
+			s.logger.Error("failed to mail new answer to watcher",
+				slog.String("user_id", user.ID),
+				slog.Uint64("question_id", uint64(question.ID)),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
+
+// authorNames maps user IDs to the names shown in emails.
+func authorNames(userRepo repository.UserRepository, ids []string) (map[string]string, error) {
+	slices.Sort(ids)
+	summaries, err := authorSummaries(userRepo, slices.Compact(ids))
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(summaries))
+	for id, summary := range summaries {
+		names[id] = summary.DisplayName
+	}
+	return names, nil
+}
+
+func settingsURL(mailCfg *config.MailConfig) string {
+	return strings.TrimRight(mailCfg.LinkBaseURL, "/") + "/settings/notifications"
+}
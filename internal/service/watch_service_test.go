@@ -0,0 +1,233 @@
+package service
+
+import (
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"hitalent-test/internal/config"
+	"hitalent-test/internal/domain"
+	"hitalent-test/internal/mailer/mailertest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	watchTestAuthorID   = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	watchTestAnswererID = "11111111-1111-4111-8111-111111111111"
+	watchTestWatcherID  = "22222222-2222-4222-8222-222222222222"
+)
+
+// fakeWatchRepository reads questions, answers and users from the other
+// fakes, as the real repository joins their tables.
+type fakeWatchRepository struct {
+	questions  *fakeQuestionRepository
+	answers    *fakeAnswerRepository
+	users      *fakeUserRepository
+	watches    map[string][]uint
+	digestSent map[string]time.Time
+}
+
+func newFakeWatchRepository(questions *fakeQuestionRepository, answers *fakeAnswerRepository, users *fakeUserRepository) *fakeWatchRepository {
+	return &fakeWatchRepository{
+		questions:  questions,
+		answers:    answers,
+		users:      users,
+		watches:    make(map[string][]uint),
+		digestSent: make(map[string]time.Time),
+	}
+}
+
+func (r *fakeWatchRepository) Watch(userID string, questionID uint) error {
+	if !slices.Contains(r.watches[userID], questionID) {
+		r.watches[userID] = append(r.watches[userID], questionID)
+	}
+	return nil
+}
+
+func (r *fakeWatchRepository) Unwatch(userID string, questionID uint) (bool, error) {
+	n := len(r.watches[userID])
+	r.watches[userID] = slices.DeleteFunc(r.watches[userID], func(id uint) bool { return id == questionID })
+	return len(r.watches[userID]) < n, nil
+}
+
+func (r *fakeWatchRepository) IsWatching(userID string, questionID uint) (bool, error) {
+	return slices.Contains(r.watches[userID], questionID), nil
+}
+
+func (r *fakeWatchRepository) Watchers(questionID uint) ([]domain.User, error) {
+	var users []domain.User
+	for userID, ids := range r.watches {
+		if slices.Contains(ids, questionID) {
+			if u, err := r.users.GetByID(userID); err == nil {
+				users = append(users, *u)
+			}
+		}
+	}
+	return users, nil
+}
+
+func (r *fakeWatchRepository) Subscribers() ([]domain.User, error) {
+	var users []domain.User
+	for userID, ids := range r.watches {
+		if u, err := r.users.GetByID(userID); err == nil && len(ids) > 0 {
+			if sent, ok := r.digestSent[userID]; ok {
+				u.DigestSentAt = &sent
+			}
+			users = append(users, *u)
+		}
+	}
+	return users, nil
+}
+
+func (r *fakeWatchRepository) Activity(userID string, since time.Time) ([]domain.Question, error) {
+	var questions []domain.Question
+	for _, id := range r.watches[userID] {
+		q, err := r.questions.GetByID(id)
+		if err != nil || q.HiddenAt != nil {
+			continue
+		}
+		q.Answers = nil
+		for _, a := range r.answers.answers {
+			if a.QuestionID == id && a.CreatedAt.After(since) && a.UserID != userID && a.HiddenAt == nil {
+				q.Answers = append(q.Answers, *a)
+			}
+		}
+		statusChanged := q.StatusChangedAt != nil && q.StatusChangedAt.After(since) &&
+			(q.StatusChangedBy == nil || *q.StatusChangedBy != userID)
+		if len(q.Answers) > 0 || statusChanged {
+			questions = append(questions, *q)
+		}
+	}
+	return questions, nil
+}
+
+func (r *fakeWatchRepository) MarkDigestSent(userID string, at time.Time) error {
+	r.digestSent[userID] = at
+	return nil
+}
+
+type watchTestEnv struct {
+	questions *fakeQuestionRepository
+	answers   *fakeAnswerRepository
+	watches   *fakeWatchRepository
+	mail      *mailertest.Recorder
+	svc       *WatchService
+	logger    *slog.Logger
+}
+
+func newWatchTestEnv(t *testing.T) *watchTestEnv {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	users := newFakeUserRepository(
+		&domain.User{ID: watchTestAuthorID, Email: "author@example.com", DisplayName: "Alice",
+			Settings: domain.UserSettings{EmailFrequency: domain.EmailInstant}},
+		&domain.User{ID: watchTestAnswererID, Email: "answerer@example.com", DisplayName: "Bob",
+			Settings: domain.UserSettings{EmailFrequency: domain.EmailInstant}},
+		&domain.User{ID: watchTestWatcherID, Email: "watcher@example.com", DisplayName: "Carol",
+			Settings: domain.UserSettings{Timezone: "Europe/Moscow"}},
+	)
+	env := &watchTestEnv{
+		questions: &fakeQuestionRepository{},
+		answers:   &fakeAnswerRepository{},
+		mail:      mailertest.NewRecorder(),
+		logger:    logger,
+	}
+	env.watches = newFakeWatchRepository(env.questions, env.answers, users)
+	env.svc = NewWatchService(env.watches, env.questions, users, env.mail, &config.Default().Mail, logger)
+	return env
+}
+
+func TestWatchService_WatchAndUnwatch(t *testing.T) {
+	env := newWatchTestEnv(t)
+	hiddenAt := time.Now()
+	require.NoError(t, env.questions.Create(&domain.Question{Text: "What is the capital of France?"}))
+	require.NoError(t, env.questions.Create(&domain.Question{Text: "Hidden", HiddenAt: &hiddenAt}))
+
+	require.NoError(t, env.svc.Watch(watchTestWatcherID, 1))
+	require.NoError(t, env.svc.Watch(watchTestWatcherID, 1))
+	assert.Equal(t, []uint{1}, env.watches.watches[watchTestWatcherID])
+
+	assert.ErrorIs(t, env.svc.Watch(watchTestWatcherID, 2), domain.ErrQuestionNotFound)
+	assert.ErrorIs(t, env.svc.Watch(watchTestWatcherID, 99), domain.ErrQuestionNotFound)
+
+	require.NoError(t, env.svc.Unwatch(watchTestWatcherID, 1))
+	require.NoError(t, env.svc.Unwatch(watchTestWatcherID, 1))
+	assert.Empty(t, env.watches.watches[watchTestWatcherID])
+}
+
+func TestWatchService_AuthorsFollowAndInstantEmails(t *testing.T) {
+	env := newWatchTestEnv(t)
+	users := env.watches.users
+	questions := NewQuestionService(env.questions, users, PostDeps{Watches: env.svc}, &config.Default().Validation)
+	answers := NewAnswerService(env.answers, env.questions, users, PostDeps{Watches: env.svc}, &config.Default().Validation)
+
+	question, err := questions.Create(&domain.CreateQuestionRequest{UserID: watchTestAuthorID, Text: "What is the capital of France?"})
+	require.NoError(t, err)
+	require.NoError(t, env.svc.Watch(watchTestWatcherID, question.ID))
+
+	_, err = answers.Create(question.ID, &domain.CreateAnswerRequest{UserID: watchTestAnswererID, Text: "Paris"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []uint{question.ID}, env.watches.watches[watchTestAuthorID])
+	assert.Equal(t, []uint{question.ID}, env.watches.watches[watchTestAnswererID])
+
+	messages := env.mail.Messages()
+	require.Len(t, messages, 1, "the answerer and daily subscribers get no instant email")
+	assert.Equal(t, "author@example.com", messages[0].To)
+	assert.Contains(t, messages[0].Text, "Bob answered a question you watch")
+	assert.Contains(t, messages[0].Text, "Paris")
+}
+
+func TestDigestService_SendDue(t *testing.T) {
+	env := newWatchTestEnv(t)
+	author := watchTestAuthorID
+	require.NoError(t, env.questions.Create(&domain.Question{UserID: &author, Text: "What is the capital of France?"}))
+	require.NoError(t, env.svc.Watch(watchTestWatcherID, 1))
+	require.NoError(t, env.svc.Watch(watchTestAuthorID, 1))
+
+	// 09:30 in Moscow, after the 08:00 slot.
+	now := time.Date(2025, 1, 15, 6, 30, 0, 0, time.UTC)
+	require.NoError(t, env.answers.Create(&domain.Answer{QuestionID: 1, UserID: watchTestAnswererID, Text: "Paris",
+		CreatedAt: now.Add(-2 * time.Hour)}))
+	require.NoError(t, env.answers.Create(&domain.Answer{QuestionID: 1, UserID: watchTestAnswererID, Text: "Long ago",
+		CreatedAt: now.Add(-72 * time.Hour)}))
+
+	svc := NewDigestService(env.watches, env.watches.users, env.mail, &config.Default().Mail, config.Default().Digest, env.logger)
+	svc.now = func() time.Time { return now }
+
+	sent, err := svc.SendDue()
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent, "the author gets instant emails instead")
+
+	messages := env.mail.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "watcher@example.com", messages[0].To)
+	assert.Equal(t, "Your daily digest: activity on 1 watched question", messages[0].Subject)
+	assert.Contains(t, messages[0].Text, "Hello Carol")
+	assert.Contains(t, messages[0].Text, "Bob answered on 15 Jan 2025 07:30:")
+	assert.NotContains(t, messages[0].Text, "Long ago")
+	assert.Equal(t, now, env.watches.digestSent[watchTestWatcherID])
+
+	sent, err = svc.SendDue()
+	require.NoError(t, err)
+	assert.Zero(t, sent, "nothing is due until the next slot")
+
+	svc.now = func() time.Time { return now.Add(24 * time.Hour) }
+	sent, err = svc.SendDue()
+	require.NoError(t, err)
+	assert.Zero(t, sent, "no activity, no email")
+	assert.Equal(t, now.Add(24*time.Hour), env.watches.digestSent[watchTestWatcherID])
+
+	cfg := config.Default().Digest
+	cfg.Enabled = false
+	svc.SetConfig(cfg)
+	svc.now = func() time.Time { return now.Add(48 * time.Hour) }
+	sent, err = svc.SendDue()
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+	assert.Len(t, env.mail.Messages(), 1)
+}
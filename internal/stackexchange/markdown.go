@@ -0,0 +1,183 @@
+package stackexchange
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var whitespace = regexp.MustCompile(`\s+`)
+
+// markdownEscaper escapes the characters of plain text that Markdown would
+// take for markup.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", `\<`,
+)
+
+// ToMarkdown converts the HTML body of a post to Markdown. Dump bodies use
+// the small set of tags that StackExchange renders from Markdown; other
+// tags are dropped and their text kept, except for scripts and styles.
+func ToMarkdown(body string) string {
+	nodes, err := html.ParseFragment(strings.NewReader(body), &html.Node{Type: html.ElementNode, DataAtom: atom.Body, Data: "body"})
+	if err != nil {
+		return body
+	}
+	var c converter
+	var b strings.Builder
+	for _, n := range nodes {
+		b.WriteString(c.node(n))
+	}
+	return tidy(b.String())
+}
+
+type converter struct {
+	// code is set inside code and pre elements, whose text is kept as is.
+	code bool
+}
+
+func (c *converter) children(n *html.Node) string {
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(c.node(child))
+	}
+	return b.String()
+}
+
+func (c *converter) node(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		if c.code {
+			return n.Data
+		}
+		return markdownEscaper.Replace(whitespace.ReplaceAllString(n.Data, " "))
+	case html.ElementNode:
+	default:
+		return c.children(n)
+	}
+
+	switch n.DataAtom {
+	case atom.P, atom.Div:
+		return block(c.children(n))
+	case atom.Br:
+		return "  \n"
+	case atom.Hr:
+		return block("---")
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(n.Data[1] - '0')
+		return block(strings.Repeat("#", level) + " " + strings.TrimSpace(c.children(n)))
+	case atom.Strong, atom.B:
+		return "**" + c.children(n) + "**"
+	case atom.Em, atom.I:
+		return "*" + c.children(n) + "*"
+	case atom.Code:
+		if c.code {
+			return c.children(n)
+		}
+		c.code = true
+		text := c.children(n)
+		c.code = false
+		if strings.Contains(text, "`") {
+			return "`` " + text + " ``"
+		}
+		return "`" + text + "`"
+	case atom.Pre:
+		c.code = true
+		text := c.children(n)
+		c.code = false
+		return block("```\n" + strings.TrimRight(text, "\n") + "\n```")
+	case atom.A:
+		text := c.children(n)
+		href := attr(n, "href")
+		if href == "" {
+			return text
+		}
+		return "[" + text + "](" + href + ")"
+	case atom.Img:
+		return "![" + markdownEscaper.Replace(attr(n, "alt")) + "](" + attr(n, "src") + ")"
+	case atom.Blockquote:
+		return block(prefixLines(strings.TrimSpace(tidy(c.children(n))), "> ", "> "))
+	case atom.Ul, atom.Ol:
+		return block(c.list(n))
+	case atom.Script, atom.Style:
+		return ""
+	}
+	return c.children(n)
+}
+
+// list renders the items of a list, indenting their continuation lines
+// under the marker.
+func (c *converter) list(n *html.Node) string {
+	var items []string
+	number := 1
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || child.DataAtom != atom.Li {
+			continue
+		}
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+		text := strings.TrimSpace(tidy(c.children(child)))
+		items = append(items, prefixLines(text, marker, strings.Repeat(" ", len(marker))))
+	}
+	return strings.Join(items, "\n")
+}
+
+func block(text string) string {
+	return "\n\n" + strings.TrimSpace(text) + "\n\n"
+}
+
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// prefixLines puts first before the first line and rest before the others,
+// without trailing spaces on blank lines.
+func prefixLines(text, first, rest string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		prefix := rest
+		if i == 0 {
+			prefix = first
+		}
+		if line == "" {
+			prefix = strings.TrimRight(prefix, " ")
+		}
+		lines[i] = prefix + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// tidy trims trailing spaces other than hard breaks and collapses runs of
+// blank lines, except inside fenced code blocks.
+func tidy(text string) string {
+	var out []string
+	fenced := false
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			fenced = !fenced
+		}
+		if !fenced && !strings.HasPrefix(strings.TrimSpace(line), "```") {
+			if !strings.HasSuffix(line, "  ") {
+				line = strings.TrimRight(line, " \t")
+			}
+			if strings.TrimSpace(line) == "" {
+				if len(out) == 0 || out[len(out)-1] == "" {
+					continue
+				}
+				line = ""
+			}
+		}
+		out = append(out, line)
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
@@ -0,0 +1,98 @@
+// Package stackexchange reads the XML files of StackExchange data dumps.
+// Each file holds a single element with one <row> per record, whose fields
+// are attributes; rows are read one at a time so that dumps of any size
+// can be imported.
+package stackexchange
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Names of the dump files.
+const (
+	UsersFile = "Users.xml"
+	PostsFile = "Posts.xml"
+)
+
+// Post types of Posts.xml; the other types, such as tag wikis, have no
+// counterpart here.
+const (
+	PostQuestion = 1
+	PostAnswer   = 2
+)
+
+type User struct {
+	ID           int    `xml:"Id,attr"`
+	DisplayName  string `xml:"DisplayName,attr"`
+	CreationDate Time   `xml:"CreationDate,attr"`
+}
+
+type Post struct {
+	ID               int    `xml:"Id,attr"`
+	PostTypeID       int    `xml:"PostTypeId,attr"`
+	ParentID         int    `xml:"ParentId,attr"`
+	AcceptedAnswerID int    `xml:"AcceptedAnswerId,attr"`
+	CreationDate     Time   `xml:"CreationDate,attr"`
+	ClosedDate       Time   `xml:"ClosedDate,attr"`
+	Title            string `xml:"Title,attr"`
+	// Body is HTML.
+	Body string `xml:"Body,attr"`
+	// OwnerUserID is empty for posts of deleted users.
+	OwnerUserID string `xml:"OwnerUserId,attr"`
+}
+
+// Markdown returns the text of the post, headed by the title for questions.
+func (p *Post) Markdown() string {
+	body := ToMarkdown(p.Body)
+	if p.Title == "" {
+		return body
+	}
+	return "# " + markdownEscaper.Replace(p.Title) + "\n\n" + body
+}
+
+// Time is a dump timestamp, which is in UTC without a zone.
+type Time struct {
+	time.Time
+}
+
+func (t *Time) UnmarshalXMLAttr(attr xml.Attr) error {
+	parsed, err := time.Parse("2006-01-02T15:04:05.999999999", attr.Value)
+	if err != nil {
+		return fmt.Errorf("%s: %q is not a dump timestamp", attr.Name.Local, attr.Value)
+	}
+	t.Time = parsed.UTC()
+	return nil
+}
+
+// Reader streams the rows of a dump file.
+type Reader[T any] struct {
+	dec *xml.Decoder
+}
+
+func NewReader[T any](r io.Reader) *Reader[T] {
+	return &Reader[T]{dec: xml.NewDecoder(r)}
+}
+
+// Next returns the next row and the line it is on, or io.EOF after the
+// last row.
+func (r *Reader[T]) Next() (*T, int, error) {
+	for {
+		tok, err := r.dec.Token()
+		if err != nil {
+			return nil, 0, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+		line, _ := r.dec.InputPos()
+		var row T
+		if err := r.dec.DecodeElement(&row, &start); err != nil {
+			return nil, line, fmt.Errorf("line %d: %w", line, err)
+		}
+		return &row, line, nil
+	}
+}
@@ -0,0 +1,66 @@
+package stackexchange
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const posts = `<?xml version="1.0" encoding="utf-8"?>
+<posts>
+  <row Id="1" PostTypeId="1" AcceptedAnswerId="2" CreationDate="2010-07-28T19:04:21.300" Title="How to &lt;grep&gt;?" Body="&lt;p&gt;Text&lt;/p&gt;" OwnerUserId="5" />
+  <row Id="2" PostTypeId="2" ParentId="1" CreationDate="2010-07-28T19:15:25.433" Body="&lt;p&gt;Answer&lt;/p&gt;" />
+</posts>`
+
+func TestReader(t *testing.T) {
+	r := NewReader[Post](strings.NewReader(posts))
+
+	post, line, err := r.Next()
+	require.NoError(t, err)
+	assert.Equal(t, 3, line)
+	assert.Equal(t, 1, post.ID)
+	assert.Equal(t, PostQuestion, post.PostTypeID)
+	assert.Equal(t, 2, post.AcceptedAnswerID)
+	assert.Equal(t, "5", post.OwnerUserID)
+	assert.Equal(t, time.Date(2010, 7, 28, 19, 4, 21, 300e6, time.UTC), post.CreationDate.Time)
+	assert.True(t, post.ClosedDate.IsZero())
+	assert.Equal(t, "# How to \\<grep>?\n\nText", post.Markdown())
+
+	post, line, err = r.Next()
+	require.NoError(t, err)
+	assert.Equal(t, 4, line)
+	assert.Equal(t, 1, post.ParentID)
+	assert.Empty(t, post.OwnerUserID)
+	assert.Equal(t, "Answer", post.Markdown())
+
+	_, _, err = r.Next()
+	assert.True(t, errors.Is(err, io.EOF))
+}
+
+func TestReader_BadTimestamp(t *testing.T) {
+	r := NewReader[User](strings.NewReader(`<users><row Id="1" CreationDate="yesterday" /></users>`))
+	_, _, err := r.Next()
+	assert.ErrorContains(t, err, "CreationDate")
+}
+
+func TestToMarkdown(t *testing.T) {
+	body := "<p>Use <code>a*b</code> in <strong>Go</strong>, not x_y [z].</p>\n\n" +
+		"<pre><code>if a &lt; b {\n\n    return\n}\n</code></pre>\n\n" +
+		"<ul>\n<li>see <a href=\"https://go.dev\">docs</a></li>\n<li><p>two</p><p>more</p></li>\n</ul>\n" +
+		"<blockquote><p>quoted\ntext</p></blockquote>" +
+		"<ol><li>first</li><li>second<br>line</li></ol>" +
+		"<h2>Notes</h2><img src=\"https://i.example/a.png\" alt=\"diagram\"><script>alert(1)</script>"
+
+	assert.Equal(t, "Use `a*b` in **Go**, not x\\_y \\[z\\].\n\n"+
+		"```\nif a < b {\n\n    return\n}\n```\n\n"+
+		"- see [docs](https://go.dev)\n- two\n\n  more\n\n"+
+		"> quoted text\n\n"+
+		"1. first\n2. second  \n   line\n\n"+
+		"## Notes\n\n![diagram](https://i.example/a.png)",
+		ToMarkdown(body))
+}
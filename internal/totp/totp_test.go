@@ -0,0 +1,75 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA-1 seed of the RFC 6238 test vectors, base32-encoded.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode_RFC6238Vectors(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		code, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, tt.code, code, "time %d", tt.unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	previous, err := Code(rfcSecret, Step(now)-1)
+	require.NoError(t, err)
+
+	step, ok := Validate(rfcSecret, "005924", now, 1)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	step, ok = Validate(rfcSecret, previous, now, 1)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now)-1, step)
+
+	_, ok = Validate(rfcSecret, previous, now, 0)
+	assert.False(t, ok)
+
+	_, ok = Validate(rfcSecret, "000000", now, 1)
+	assert.False(t, ok)
+
+	_, ok = Validate(rfcSecret, "12345", now, 1)
+	assert.False(t, ok)
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	_, err = Code(secret, 1)
+	assert.NoError(t, err)
+}
+
+func TestURI(t *testing.T) {
+	u, err := url.Parse(URI("Q&A Service", "user@example.com", rfcSecret))
+	require.NoError(t, err)
+
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/Q&A Service:user@example.com", u.Path)
+	assert.Equal(t, rfcSecret, u.Query().Get("secret"))
+	assert.Equal(t, "Q&A Service", u.Query().Get("issuer"))
+}
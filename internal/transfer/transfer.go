@@ -0,0 +1,414 @@
+// Package transfer reads and writes the files of bulk exports and imports
+// of questions with their answers. JSONL files hold one question per line
+// with the answers nested; CSV files have a header row and then a row per
+// question, each followed by the rows of its answers.
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"hitalent-test/internal/domain"
+)
+
+// Row types of CSV files.
+const (
+	rowQuestion = "question"
+	rowAnswer   = "answer"
+)
+
+// columns are the CSV columns in the order they are written. Files being
+// read may order them differently and leave out the optional ones.
+var columns = []string{
+	"type", "id", "question_id", "author_email", "text", "status", "close_reason",
+	"duplicate_of_id", "accepted_answer_id", "hidden", "created_at",
+}
+
+var requiredColumns = []string{"type", "id", "text"}
+
+// RecordError reports a malformed record. The reader can go on with the
+// next one.
+type RecordError struct {
+	Line int
+	Err  error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// Encoder writes questions in one of domain.TransferFormats.
+type Encoder interface {
+	Encode(question *domain.TransferQuestion) error
+	// Flush writes out buffered data; it must be called after the last
+	// question.
+	Flush() error
+}
+
+// Decoder reads questions in one of domain.TransferFormats.
+type Decoder interface {
+	// Decode returns the next question and the line it starts on. It
+	// returns io.EOF after the last question and a *RecordError for a
+	// record that cannot be read; other errors end the file.
+	Decode() (*domain.TransferQuestion, int, error)
+}
+
+func NewEncoder(w io.Writer, format string) (Encoder, error) {
+	switch format {
+	case domain.TransferJSONL:
+		bw := bufio.NewWriter(w)
+		return &jsonlEncoder{w: bw, enc: json.NewEncoder(bw)}, nil
+	case domain.TransferCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(columns); err != nil {
+			return nil, err
+		}
+		return &csvEncoder{w: cw}, nil
+	}
+	return nil, fmt.Errorf("%w: unknown format %q", domain.ErrInvalidInput, format)
+}
+
+// NewDecoder returns a decoder for r. For CSV it reads the header row, so
+// a file without the required columns is refused here.
+func NewDecoder(r io.Reader, format string) (Decoder, error) {
+	switch format {
+	case domain.TransferJSONL:
+		return &jsonlDecoder{r: bufio.NewReader(r)}, nil
+	case domain.TransferCSV:
+		return newCSVDecoder(r)
+	}
+	return nil, fmt.Errorf("%w: unknown format %q", domain.ErrInvalidInput, format)
+}
+
+type jsonlEncoder struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (e *jsonlEncoder) Encode(question *domain.TransferQuestion) error {
+	if question.Answers == nil {
+		question.Answers = []domain.TransferAnswer{}
+	}
+	return e.enc.Encode(question)
+}
+
+func (e *jsonlEncoder) Flush() error {
+	return e.w.Flush()
+}
+
+type jsonlDecoder struct {
+	r    *bufio.Reader
+	line int
+}
+
+func (d *jsonlDecoder) Decode() (*domain.TransferQuestion, int, error) {
+	for {
+		data, err := d.r.ReadBytes('\n')
+		if len(data) == 0 && err != nil {
+			return nil, d.line, err
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, d.line, err
+		}
+		d.line++
+
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+		var question domain.TransferQuestion
+		if err := json.Unmarshal(data, &question); err != nil {
+			return nil, d.line, &RecordError{Line: d.line, Err: err}
+		}
+		return &question, d.line, nil
+	}
+}
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvEncoder) Encode(question *domain.TransferQuestion) error {
+	row := []string{
+		rowQuestion,
+		formatID(question.ID),
+		"",
+		question.AuthorEmail,
+		question.Text,
+		question.Status,
+		question.CloseReason,
+		formatOptionalID(question.DuplicateOfID),
+		formatOptionalID(question.AcceptedAnswerID),
+		formatBool(question.Hidden),
+		formatTime(question.CreatedAt),
+	}
+	if err := e.w.Write(row); err != nil {
+		return err
+	}
+	for _, answer := range question.Answers {
+		row := []string{
+			rowAnswer,
+			formatID(answer.ID),
+			formatID(question.ID),
+			answer.AuthorEmail,
+			answer.Text,
+			"", "", "", "",
+			formatBool(answer.Hidden),
+			formatTime(answer.CreatedAt),
+		}
+		if err := e.w.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *csvEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type csvDecoder struct {
+	r       *csv.Reader
+	columns map[string]int
+	// pending is a question row read while looking for the end of the
+	// previous question.
+	pending []string
+	line    int
+}
+
+func newCSVDecoder(r io.Reader) (*csvDecoder, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot read the CSV header: %v", domain.ErrInvalidInput, err)
+	}
+
+	d := &csvDecoder{r: cr, columns: make(map[string]int, len(header))}
+	for i, name := range header {
+		d.columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range requiredColumns {
+		if _, ok := d.columns[name]; !ok {
+			return nil, fmt.Errorf("%w: the CSV header has no %q column", domain.ErrInvalidInput, name)
+		}
+	}
+	return d, nil
+}
+
+// field returns the value of a column, or "" if the row or the file lacks
+// it.
+func (d *csvDecoder) field(row []string, name string) string {
+	i, ok := d.columns[name]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return row[i]
+}
+
+// next returns the next row, or the pending one.
+func (d *csvDecoder) next() ([]string, int, error) {
+	if d.pending != nil {
+		row := d.pending
+		d.pending = nil
+		return row, d.line, nil
+	}
+	row, err := d.r.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, parseErr.StartLine, &RecordError{Line: parseErr.StartLine, Err: parseErr.Err}
+		}
+		return nil, 0, err
+	}
+	d.line, _ = d.r.FieldPos(0)
+	return row, d.line, nil
+}
+
+func (d *csvDecoder) Decode() (*domain.TransferQuestion, int, error) {
+	row, line, err := d.next()
+	if err != nil {
+		return nil, line, err
+	}
+	if typ := d.field(row, "type"); typ != rowQuestion {
+		if typ == rowAnswer {
+			return nil, line, &RecordError{Line: line, Err: errors.New("answer row does not follow its question")}
+		}
+		return nil, line, &RecordError{Line: line, Err: fmt.Errorf("unknown row type %q", typ)}
+	}
+
+	question, recordErr := d.question(row)
+	for {
+		answerRow, answerLine, err := d.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var rowErr *RecordError
+		if errors.As(err, &rowErr) {
+			// A broken row may have been an answer: drop the question too
+			// rather than import it incomplete.
+			recordErr = firstErr(recordErr, err)
+			continue
+		}
+		if err != nil {
+			return nil, line, err
+		}
+		if d.field(answerRow, "type") != rowAnswer {
+			d.pending = answerRow
+			d.line = answerLine
+			break
+		}
+		if recordErr != nil {
+			continue
+		}
+		answer, err := d.answer(answerRow, question.ID)
+		if err != nil {
+			recordErr = fmt.Errorf("line %d: %w", answerLine, err)
+			continue
+		}
+		question.Answers = append(question.Answers, *answer)
+	}
+
+	if recordErr != nil {
+		return nil, line, &RecordError{Line: line, Err: recordErr}
+	}
+	return question, line, nil
+}
+
+// firstErr keeps the first of two errors.
+func firstErr(first, second error) error {
+	if first != nil {
+		return first
+	}
+	return second
+}
+
+func (d *csvDecoder) question(row []string) (*domain.TransferQuestion, error) {
+	var err error
+	question := &domain.TransferQuestion{
+		AuthorEmail: d.field(row, "author_email"),
+		Text:        d.field(row, "text"),
+		Status:      d.field(row, "status"),
+		CloseReason: d.field(row, "close_reason"),
+		Answers:     []domain.TransferAnswer{},
+	}
+	if question.ID, err = parseID("id", d.field(row, "id")); err != nil {
+		return question, err
+	}
+	if question.DuplicateOfID, err = parseOptionalID("duplicate_of_id", d.field(row, "duplicate_of_id")); err != nil {
+		return question, err
+	}
+	if question.AcceptedAnswerID, err = parseOptionalID("accepted_answer_id", d.field(row, "accepted_answer_id")); err != nil {
+		return question, err
+	}
+	if question.Hidden, err = parseBool(d.field(row, "hidden")); err != nil {
+		return question, err
+	}
+	if question.CreatedAt, err = parseTime(d.field(row, "created_at")); err != nil {
+		return question, err
+	}
+	return question, nil
+}
+
+func (d *csvDecoder) answer(row []string, questionID uint) (*domain.TransferAnswer, error) {
+	var err error
+	answer := &domain.TransferAnswer{
+		AuthorEmail: d.field(row, "author_email"),
+		Text:        d.field(row, "text"),
+	}
+	if answer.ID, err = parseID("id", d.field(row, "id")); err != nil {
+		return nil, err
+	}
+	if v := d.field(row, "question_id"); v != "" {
+		id, err := parseID("question_id", v)
+		if err != nil {
+			return nil, err
+		}
+		if id != questionID {
+			return nil, fmt.Errorf("answer %d belongs to question %d, not %d", answer.ID, id, questionID)
+		}
+	}
+	if answer.Hidden, err = parseBool(d.field(row, "hidden")); err != nil {
+		return nil, err
+	}
+	if answer.CreatedAt, err = parseTime(d.field(row, "created_at")); err != nil {
+		return nil, err
+	}
+	return answer, nil
+}
+
+func formatID(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+func formatOptionalID(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return formatID(*id)
+}
+
+func formatBool(b bool) string {
+	if b {
+		return "true"
+	}
+	return ""
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func parseID(column, v string) (uint, error) {
+	id, err := strconv.ParseUint(strings.TrimSpace(v), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %q is not an ID", column, v)
+	}
+	return uint(id), nil
+}
+
+func parseOptionalID(column, v string) (*uint, error) {
+	if strings.TrimSpace(v) == "" {
+		return nil, nil
+	}
+	id, err := parseID(column, v)
+	return &id, err
+}
+
+func parseBool(v string) (bool, error) {
+	if strings.TrimSpace(v) == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(v))
+	if err != nil {
+		return false, fmt.Errorf("hidden: %q is not a boolean", v)
+	}
+	return b, nil
+}
+
+func parseTime(v string) (time.Time, error) {
+	if strings.TrimSpace(v) == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(v))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("created_at: %q is not an RFC 3339 time", v)
+	}
+	return t, nil
+}
@@ -0,0 +1,139 @@
+package transfer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"hitalent-test/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleQuestions() []*domain.TransferQuestion {
+	accepted, duplicateOf := uint(3), uint(1)
+	created := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	return []*domain.TransferQuestion{
+		{
+			ID:               1,
+			AuthorEmail:      "alice@example.com",
+			Text:             "Multi-line,\n\"quoted\" text",
+			Status:           domain.QuestionOpen,
+			AcceptedAnswerID: &accepted,
+			CreatedAt:        created,
+			Answers: []domain.TransferAnswer{
+				{ID: 2, AuthorEmail: "bob@example.com", Text: "First", CreatedAt: created},
+				{ID: 3, Text: "Second", Hidden: true, CreatedAt: created},
+			},
+		},
+		{
+			ID:            4,
+			Text:          "Again",
+			Status:        domain.QuestionDuplicate,
+			DuplicateOfID: &duplicateOf,
+			Hidden:        true,
+			CreatedAt:     created,
+			Answers:       []domain.TransferAnswer{},
+		},
+	}
+}
+
+func decodeAll(t *testing.T, dec Decoder) ([]*domain.TransferQuestion, []error) {
+	t.Helper()
+	var questions []*domain.TransferQuestion
+	var recordErrs []error
+	for {
+		q, _, err := dec.Decode()
+		if errors.Is(err, io.EOF) {
+			return questions, recordErrs
+		}
+		var recordErr *RecordError
+		if errors.As(err, &recordErr) {
+			recordErrs = append(recordErrs, err)
+			continue
+		}
+		require.NoError(t, err)
+		questions = append(questions, q)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range domain.TransferFormats {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			enc, err := NewEncoder(&buf, format)
+			require.NoError(t, err)
+			for _, q := range sampleQuestions() {
+				require.NoError(t, enc.Encode(q))
+			}
+			require.NoError(t, enc.Flush())
+
+			dec, err := NewDecoder(&buf, format)
+			require.NoError(t, err)
+			questions, recordErrs := decodeAll(t, dec)
+			assert.Empty(t, recordErrs)
+			assert.Equal(t, sampleQuestions(), questions)
+		})
+	}
+}
+
+func TestCSVDecoder_SkipsBadRecords(t *testing.T) {
+	file := "type,id,question_id,text\n" +
+		"answer,9,1,Orphan\n" +
+		"question,1,,Fine\n" +
+		"answer,2,1,Yes\n" +
+		"question,x,,Bad ID\n" +
+		"answer,3,,Dropped with its question\n" +
+		"question,5,,Wrong answer\n" +
+		"answer,6,7,Belongs elsewhere\n" +
+		"comment,8,,Unknown\n" +
+		"question,10,,Last\n"
+	dec, err := NewDecoder(strings.NewReader(file), domain.TransferCSV)
+	require.NoError(t, err)
+
+	questions, recordErrs := decodeAll(t, dec)
+	require.Len(t, questions, 2)
+	assert.Equal(t, uint(1), questions[0].ID)
+	assert.Len(t, questions[0].Answers, 1)
+	assert.Equal(t, uint(10), questions[1].ID)
+
+	var lines []int
+	for _, err := range recordErrs {
+		var recordErr *RecordError
+		require.ErrorAs(t, err, &recordErr)
+		lines = append(lines, recordErr.Line)
+	}
+	assert.Equal(t, []int{2, 5, 7, 9}, lines)
+}
+
+func TestNewDecoder_Refuses(t *testing.T) {
+	_, err := NewDecoder(strings.NewReader("type,id\n"), domain.TransferCSV)
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	_, err = NewDecoder(strings.NewReader(""), domain.TransferCSV)
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	_, err = NewDecoder(strings.NewReader(""), "xml")
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+}
+
+func TestJSONLDecoder_ReportsLines(t *testing.T) {
+	dec, err := NewDecoder(strings.NewReader("{\"id\":1,\"text\":\"a\"}\n\n{broken\n{\"id\":2,\"text\":\"b\"}"), domain.TransferJSONL)
+	require.NoError(t, err)
+
+	_, line, err := dec.Decode()
+	require.NoError(t, err)
+	assert.Equal(t, 1, line)
+	_, line, err = dec.Decode()
+	var recordErr *RecordError
+	require.ErrorAs(t, err, &recordErr)
+	assert.Equal(t, 3, line)
+	q, line, err := dec.Decode()
+	require.NoError(t, err)
+	assert.Equal(t, 4, line)
+	assert.Equal(t, uint(2), q.ID)
+	_, _, err = dec.Decode()
+	assert.ErrorIs(t, err, io.EOF)
+}
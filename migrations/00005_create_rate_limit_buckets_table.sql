@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    key VARCHAR(255) PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_rate_limit_buckets_updated_at ON rate_limit_buckets(updated_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_rate_limit_buckets_updated_at;
+DROP TABLE IF EXISTS rate_limit_buckets;
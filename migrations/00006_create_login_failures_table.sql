@@ -0,0 +1,30 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS login_failures (
+    key VARCHAR(320) PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS lockout_events (
+    id SERIAL PRIMARY KEY,
+    action VARCHAR(16) NOT NULL,
+    key VARCHAR(320) NOT NULL,
+    user_id UUID,
+    actor_id UUID,
+    ip VARCHAR(64),
+    failures INTEGER NOT NULL DEFAULT 0,
+    locked_until TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_login_failures_last_failure_at ON login_failures(last_failure_at);
+CREATE INDEX idx_lockout_events_key ON lockout_events(key);
+CREATE INDEX idx_lockout_events_created_at ON lockout_events(created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_lockout_events_created_at;
+DROP INDEX IF EXISTS idx_lockout_events_key;
+DROP INDEX IF EXISTS idx_login_failures_last_failure_at;
+DROP TABLE IF EXISTS lockout_events;
+DROP TABLE IF EXISTS login_failures;
@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS user_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    purpose VARCHAR(32) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_user
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_user_tokens_user_id_purpose ON user_tokens(user_id, purpose);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_user_tokens_user_id_purpose;
+DROP TABLE IF EXISTS user_tokens;
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified;
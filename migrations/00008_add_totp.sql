@@ -0,0 +1,27 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN totp_secret VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN totp_enabled_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_user
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX idx_recovery_codes_user_id_code_hash ON recovery_codes(user_id, code_hash);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_recovery_codes_user_id_code_hash;
+DROP TABLE IF EXISTS recovery_codes;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_last_step;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_enabled_at;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_enabled;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_secret;
@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS personal_access_tokens (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    scopes JSONB NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_user
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_personal_access_tokens_user_id ON personal_access_tokens(user_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_personal_access_tokens_user_id;
+DROP TABLE IF EXISTS personal_access_tokens;
@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS user_identities (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    provider VARCHAR(64) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255),
+    last_login_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_user
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX idx_user_identities_provider_subject ON user_identities(provider, subject);
+CREATE INDEX idx_user_identities_user_id ON user_identities(user_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_user_identities_user_id;
+DROP INDEX IF EXISTS idx_user_identities_provider_subject;
+DROP TABLE IF EXISTS user_identities;
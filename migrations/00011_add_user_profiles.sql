@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN display_name VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN bio TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN avatar_url VARCHAR(2048) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN settings JSONB NOT NULL DEFAULT '{}';
+
+ALTER TABLE questions ADD COLUMN user_id UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE questions ADD COLUMN accepted_answer_id INTEGER REFERENCES answers(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_questions_user_id ON questions(user_id);
+CREATE INDEX idx_questions_accepted_answer_id ON questions(accepted_answer_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_questions_accepted_answer_id;
+DROP INDEX IF EXISTS idx_questions_user_id;
+ALTER TABLE questions DROP COLUMN IF EXISTS accepted_answer_id;
+ALTER TABLE questions DROP COLUMN IF EXISTS user_id;
+ALTER TABLE users DROP COLUMN IF EXISTS settings;
+ALTER TABLE users DROP COLUMN IF EXISTS avatar_url;
+ALTER TABLE users DROP COLUMN IF EXISTS bio;
+ALTER TABLE users DROP COLUMN IF EXISTS display_name;
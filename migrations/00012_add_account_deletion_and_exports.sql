@@ -0,0 +1,36 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN deletion_scheduled_at TIMESTAMP;
+
+CREATE INDEX idx_users_deletion_scheduled_at ON users(deletion_scheduled_at)
+    WHERE deletion_scheduled_at IS NOT NULL;
+
+-- Content of erased accounts is reassigned to this placeholder under the
+-- "anonymize" policy. Its password hash is not a bcrypt hash, so nobody can
+-- sign in as it.
+INSERT INTO users (id, email, password_hash, role, display_name, email_verified)
+VALUES ('00000000-0000-0000-0000-000000000000', 'deleted-user@invalid', '!', 'user', 'Deleted user', FALSE)
+ON CONFLICT (id) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS data_exports (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    format VARCHAR(8) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    data BYTEA,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP,
+    CONSTRAINT fk_user
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_data_exports_user_id ON data_exports(user_id, format, created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_data_exports_user_id;
+DROP TABLE IF EXISTS data_exports;
+DELETE FROM users WHERE id = '00000000-0000-0000-0000-000000000000';
+DROP INDEX IF EXISTS idx_users_deletion_scheduled_at;
+ALTER TABLE users DROP COLUMN IF EXISTS deletion_scheduled_at;
@@ -0,0 +1,33 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS flags (
+    id SERIAL PRIMARY KEY,
+    target_type VARCHAR(16) NOT NULL,
+    target_id INTEGER NOT NULL,
+    reporter_id UUID NOT NULL,
+    reason VARCHAR(32) NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    resolved_by UUID,
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_reporter
+        FOREIGN KEY (reporter_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE,
+    CONSTRAINT uq_flags_reporter UNIQUE (target_type, target_id, reporter_id)
+);
+
+CREATE INDEX idx_flags_open ON flags(target_type, target_id) WHERE status = 'open';
+
+-- Content is hidden automatically once it collects enough flags, until a
+-- moderator decides. Locked questions accept no new answers.
+ALTER TABLE questions ADD COLUMN hidden_at TIMESTAMP;
+ALTER TABLE questions ADD COLUMN locked_at TIMESTAMP;
+ALTER TABLE answers ADD COLUMN hidden_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE answers DROP COLUMN IF EXISTS hidden_at;
+ALTER TABLE questions DROP COLUMN IF EXISTS locked_at;
+ALTER TABLE questions DROP COLUMN IF EXISTS hidden_at;
+DROP INDEX IF EXISTS idx_flags_open;
+DROP TABLE IF EXISTS flags;
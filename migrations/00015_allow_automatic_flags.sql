@@ -0,0 +1,7 @@
+-- +goose Up
+-- Flags raised by the content filter have no reporter.
+ALTER TABLE flags ALTER COLUMN reporter_id DROP NOT NULL;
+
+-- +goose Down
+DELETE FROM flags WHERE reporter_id IS NULL;
+ALTER TABLE flags ALTER COLUMN reporter_id SET NOT NULL;
@@ -0,0 +1,22 @@
+-- +goose Up
+ALTER TABLE questions ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'open';
+ALTER TABLE questions ADD COLUMN close_reason VARCHAR(32) NOT NULL DEFAULT '';
+ALTER TABLE questions ADD COLUMN duplicate_of_id INTEGER REFERENCES questions(id) ON DELETE SET NULL;
+ALTER TABLE questions ADD COLUMN status_changed_by UUID;
+ALTER TABLE questions ADD COLUMN status_changed_at TIMESTAMP;
+
+-- Locking by moderators is now a status.
+UPDATE questions SET status = 'locked', status_changed_at = locked_at WHERE locked_at IS NOT NULL;
+ALTER TABLE questions DROP COLUMN locked_at;
+
+CREATE INDEX idx_questions_status ON questions(status);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_questions_status;
+ALTER TABLE questions ADD COLUMN locked_at TIMESTAMP;
+UPDATE questions SET locked_at = COALESCE(status_changed_at, NOW()) WHERE status = 'locked';
+ALTER TABLE questions DROP COLUMN IF EXISTS status_changed_at;
+ALTER TABLE questions DROP COLUMN IF EXISTS status_changed_by;
+ALTER TABLE questions DROP COLUMN IF EXISTS duplicate_of_id;
+ALTER TABLE questions DROP COLUMN IF EXISTS close_reason;
+ALTER TABLE questions DROP COLUMN IF EXISTS status;
@@ -0,0 +1,6 @@
+-- +goose Up
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX idx_questions_text_trgm ON questions USING GIN (text gin_trgm_ops);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_questions_text_trgm;
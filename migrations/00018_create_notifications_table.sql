@@ -0,0 +1,40 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    actor_id UUID,
+    question_id INTEGER,
+    answer_id INTEGER,
+    message TEXT NOT NULL,
+    read_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_user
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_actor
+        FOREIGN KEY (actor_id)
+        REFERENCES users(id)
+        ON DELETE SET NULL
+);
+
+CREATE INDEX idx_notifications_user ON notifications(user_id, id DESC);
+CREATE INDEX idx_notifications_unread ON notifications(user_id) WHERE read_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id UUID NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    PRIMARY KEY (user_id, type),
+    CONSTRAINT fk_user
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS notification_preferences;
+DROP INDEX IF EXISTS idx_notifications_unread;
+DROP INDEX IF EXISTS idx_notifications_user;
+DROP TABLE IF EXISTS notifications;
@@ -0,0 +1,36 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS question_watches (
+    user_id UUID NOT NULL,
+    question_id INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, question_id),
+    CONSTRAINT fk_user
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_question
+        FOREIGN KEY (question_id)
+        REFERENCES questions(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_question_watches_question ON question_watches(question_id);
+
+-- Authors follow their questions and the questions they answered; the
+-- placeholder that keeps erased users' content follows nothing.
+INSERT INTO question_watches (user_id, question_id, created_at)
+SELECT user_id, id, created_at FROM questions
+WHERE user_id IS NOT NULL AND user_id <> '00000000-0000-0000-0000-000000000000'
+ON CONFLICT DO NOTHING;
+INSERT INTO question_watches (user_id, question_id, created_at)
+SELECT user_id, question_id, MIN(created_at) FROM answers
+WHERE user_id <> '00000000-0000-0000-0000-000000000000'
+GROUP BY user_id, question_id
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE users ADD COLUMN digest_sent_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS digest_sent_at;
+DROP INDEX IF EXISTS idx_question_watches_question;
+DROP TABLE IF EXISTS question_watches;
@@ -0,0 +1,39 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS mentions (
+    id SERIAL PRIMARY KEY,
+    question_id INTEGER,
+    answer_id INTEGER,
+    user_id UUID NOT NULL,
+    handle VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_question
+        FOREIGN KEY (question_id)
+        REFERENCES questions(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_answer
+        FOREIGN KEY (answer_id)
+        REFERENCES answers(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_user
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE,
+    CONSTRAINT chk_mentions_target
+        CHECK ((question_id IS NULL) <> (answer_id IS NULL))
+);
+
+CREATE UNIQUE INDEX idx_mentions_question ON mentions(question_id, user_id) WHERE question_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_mentions_answer ON mentions(answer_id, user_id) WHERE answer_id IS NOT NULL;
+CREATE INDEX idx_mentions_user ON mentions(user_id);
+
+-- Handles are resolved against display names and email local parts.
+CREATE INDEX idx_users_display_name_lower ON users(lower(display_name));
+CREATE INDEX idx_users_email_local_part ON users(lower(split_part(email, '@', 1)));
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_users_email_local_part;
+DROP INDEX IF EXISTS idx_users_display_name_lower;
+DROP INDEX IF EXISTS idx_mentions_user;
+DROP INDEX IF EXISTS idx_mentions_answer;
+DROP INDEX IF EXISTS idx_mentions_question;
+DROP TABLE IF EXISTS mentions;
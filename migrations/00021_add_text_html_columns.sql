@@ -0,0 +1,9 @@
+-- +goose Up
+-- Posts stored before this migration keep an empty text_html and are
+-- rendered when read.
+ALTER TABLE questions ADD COLUMN text_html TEXT NOT NULL DEFAULT '';
+ALTER TABLE answers ADD COLUMN text_html TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE answers DROP COLUMN IF EXISTS text_html;
+ALTER TABLE questions DROP COLUMN IF EXISTS text_html;
@@ -0,0 +1,39 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS attachments (
+    id SERIAL PRIMARY KEY,
+    question_id INTEGER,
+    answer_id INTEGER,
+    user_id UUID,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL,
+    checksum CHAR(64) NOT NULL,
+    width INTEGER,
+    height INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_question
+        FOREIGN KEY (question_id)
+        REFERENCES questions(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_answer
+        FOREIGN KEY (answer_id)
+        REFERENCES answers(id)
+        ON DELETE CASCADE,
+    -- Attachments stay with their post when the uploader is erased.
+    CONSTRAINT fk_user
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE SET NULL,
+    CONSTRAINT chk_attachments_target
+        CHECK ((question_id IS NULL) <> (answer_id IS NULL))
+);
+
+CREATE INDEX idx_attachments_question ON attachments(question_id) WHERE question_id IS NOT NULL;
+CREATE INDEX idx_attachments_answer ON attachments(answer_id) WHERE answer_id IS NOT NULL;
+CREATE INDEX idx_attachments_checksum ON attachments(checksum);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_attachments_checksum;
+DROP INDEX IF EXISTS idx_attachments_answer;
+DROP INDEX IF EXISTS idx_attachments_question;
+DROP TABLE IF EXISTS attachments;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Bulk imports record where each post came from as "<source>:<id>".
+ALTER TABLE questions ADD COLUMN external_id VARCHAR(255);
+ALTER TABLE answers ADD COLUMN external_id VARCHAR(255);
+CREATE UNIQUE INDEX idx_questions_external_id ON questions(external_id) WHERE external_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_answers_external_id ON answers(external_id) WHERE external_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_answers_external_id;
+DROP INDEX IF EXISTS idx_questions_external_id;
+ALTER TABLE answers DROP COLUMN IF EXISTS external_id;
+ALTER TABLE questions DROP COLUMN IF EXISTS external_id;
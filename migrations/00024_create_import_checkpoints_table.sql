@@ -0,0 +1,12 @@
+-- +goose Up
+-- Long-running imports record how far they got, so that an interrupted
+-- import resumes instead of starting over.
+CREATE TABLE IF NOT EXISTS import_checkpoints (
+    source VARCHAR(64) PRIMARY KEY,
+    phase VARCHAR(32) NOT NULL,
+    rows_done BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS import_checkpoints;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- A user flags an item once while the flag is open; after a moderator
+-- resolves it, the item may be flagged again.
+ALTER TABLE flags DROP CONSTRAINT IF EXISTS uq_flags_reporter;
+CREATE UNIQUE INDEX uq_flags_reporter_open ON flags(target_type, target_id, reporter_id) WHERE status = 'open';
+
+-- +goose Down
+DROP INDEX IF EXISTS uq_flags_reporter_open;
+DELETE FROM flags a USING flags b
+    WHERE a.id > b.id AND a.target_type = b.target_type AND a.target_id = b.target_id AND a.reporter_id = b.reporter_id;
+ALTER TABLE flags ADD CONSTRAINT uq_flags_reporter UNIQUE (target_type, target_id, reporter_id);
@@ -0,0 +1,27 @@
+-- +goose Up
+-- Attachments disappear through ON DELETE CASCADE when their post or its
+-- author is deleted. The trigger notes the checksum of each removed row; the
+-- cleanup job deletes the blob once no attachment refers to it any more.
+CREATE TABLE IF NOT EXISTS orphaned_blobs (
+    checksum CHAR(64) PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION attachments_note_orphaned_blob() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO orphaned_blobs (checksum) VALUES (OLD.checksum)
+    ON CONFLICT (checksum) DO NOTHING;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER attachments_note_orphaned_blob
+    AFTER DELETE ON attachments
+    FOR EACH ROW EXECUTE FUNCTION attachments_note_orphaned_blob();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS attachments_note_orphaned_blob ON attachments;
+DROP FUNCTION IF EXISTS attachments_note_orphaned_blob();
+DROP TABLE IF EXISTS orphaned_blobs;
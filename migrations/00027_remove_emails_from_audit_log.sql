@@ -0,0 +1,18 @@
+-- +goose Up
+-- Entries about users used to keep their email, which erasing an account
+-- could not remove from the append-only log. New entries keep only the
+-- user's ID; this clears the emails from the old ones, lifting the
+-- append-only triggers for the duration.
+ALTER TABLE audit_log DISABLE TRIGGER audit_log_append_only;
+
+UPDATE audit_log SET before = before - 'email'
+WHERE target_type = 'user' AND jsonb_typeof(before) = 'object' AND before ? 'email';
+
+UPDATE audit_log SET after = after - 'email'
+WHERE target_type = 'user' AND jsonb_typeof(after) = 'object' AND after ? 'email';
+
+ALTER TABLE audit_log ENABLE TRIGGER audit_log_append_only;
+
+-- +goose Down
+-- The emails are gone for good.
+SELECT 1;
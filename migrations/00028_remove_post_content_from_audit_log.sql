@@ -0,0 +1,25 @@
+-- +goose Up
+-- Entries about deleted questions and answers used to keep the whole post,
+-- with its text and its author's profile, which erasing the author's
+-- account could not remove from the append-only log. New entries keep only
+-- the post's metadata and a hash of its text; this rewrites the old ones to
+-- match, lifting the append-only triggers for the duration.
+ALTER TABLE audit_log DISABLE TRIGGER audit_log_append_only;
+
+UPDATE audit_log SET before = jsonb_strip_nulls(jsonb_build_object(
+    'id', before->'id',
+    'question_id', before->'question_id',
+    'status', before->'status',
+    'created_at', before->'created_at',
+    'answers', CASE WHEN target_type = 'question' THEN jsonb_array_length(COALESCE(before->'answers', '[]'::jsonb)) END,
+    'text_length', char_length(before->>'text'),
+    'text_sha256', encode(sha256(convert_to(before->>'text', 'UTF8')), 'hex')
+))
+WHERE action IN ('question.deleted', 'answer.deleted')
+  AND jsonb_typeof(before) = 'object' AND before ? 'text';
+
+ALTER TABLE audit_log ENABLE TRIGGER audit_log_append_only;
+
+-- +goose Down
+-- The post content is gone for good.
+SELECT 1;
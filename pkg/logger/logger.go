@@ -5,23 +5,11 @@ import (
 	"os"
 )
 
-func New(level string, format string) *slog.Logger {
-	var logLevel slog.Level
-	switch level {
-	case "debug":
-		logLevel = slog.LevelDebug
-	case "info":
-		logLevel = slog.LevelInfo
-	case "warn":
-		logLevel = slog.LevelWarn
-	case "error":
-		logLevel = slog.LevelError
-	default:
-		logLevel = slog.LevelInfo
-	}
-
+// New builds the application logger. The level is read through level on
+// every record, so adjusting it takes effect immediately.
+func New(level *slog.LevelVar, format string) *slog.Logger {
 	opts := &slog.HandlerOptions{
-		Level:     logLevel,
+		Level:     level,
 		AddSource: true,
 	}
 
@@ -34,3 +22,18 @@ func New(level string, format string) *slog.Logger {
 
 	return slog.New(handler)
 }
+
+func ParseLevel(level string) slog.Level {
+	switch level {
+	case "debug":
+		return slog.LevelDebug
+	case "info":
+		return slog.LevelInfo
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}